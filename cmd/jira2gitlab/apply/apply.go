@@ -0,0 +1,99 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type Options struct {
+	*utils.IOStreams
+
+	Plan          string
+	AllowNonEmpty bool
+}
+
+func NewOptions(ioStreams *utils.IOStreams) *Options {
+	return &Options{
+		IOStreams: ioStreams,
+	}
+}
+
+func NewCmdApply(ioStreams *utils.IOStreams) *cobra.Command {
+	o := NewOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:   "apply PLAN",
+		Short: "Run a migration plan",
+		Long:  "Run the migration plan of run --plan after it is reviewed: only the items with the create action are migrated, with the title and labels of the plan",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.complete(cmd, args))
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.AllowNonEmpty, "allow-non-empty", false, "Migrate into a GitLab project or group that already has issues or epics")
+
+	return cmd
+}
+
+func (o *Options) complete(cmd *cobra.Command, args []string) error {
+	o.Plan = args[0]
+	return nil
+}
+
+func (o *Options) run() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return utils.WithExitCode(utils.ExitConfig, errors.Wrap(err, "Error getting config"))
+	}
+
+	plan, err := j2g.ReadPlan(o.Plan)
+	if err != nil {
+		return utils.WithExitCode(utils.ExitConfig, err)
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+	gl := j2g.NewGitLabWriter(config.GetGitLabClient(cfg))
+
+	defer stats.Default().Print(o.Out)
+
+	cfg.AllowNonEmpty = o.AllowNonEmpty
+
+	ctx, cancel := utils.WithGracefulShutdown(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	fmt.Fprintf(o.Out, "Applying %s: %d to create\n", o.Plan, plan.Count(j2g.PlanActionCreate))
+	err = j2g.ApplyPlan(ctx, gl, jr, plan)
+	if errors.Is(err, j2g.ErrInterrupted) {
+		fmt.Fprintf(o.Out, "\nMigration interrupted. The progress is saved to %s\n", cfg.StateFile)
+		fmt.Fprintf(o.Out, "Resume with: apply %s\n", o.Plan)
+	}
+	if err != nil {
+		if errors.Is(err, j2g.ErrInterrupted) || errors.Is(err, j2g.ErrQuarantined) || stats.Default().Items(stats.ItemCreated) > 0 {
+			return utils.WithExitCode(utils.ExitPartial, err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/bench"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type Options struct {
+	*utils.IOStreams
+
+	bench.Options
+}
+
+func NewOptions(ioStreams *utils.IOStreams) *Options {
+	return &Options{
+		IOStreams: ioStreams,
+	}
+}
+
+func NewCmdBench(ioStreams *utils.IOStreams) *cobra.Command {
+	o := NewOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:   "bench [options]",
+		Short: "Measure the throughput of the migration with fake Jira and GitLab servers",
+		Long:  "Migrate generated issues from a fake Jira to a fake GitLab behind a local HTTP server with a latency, e.g. to validate a concurrency change. No config file is needed and nothing is sent to Jira or GitLab.",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(utils.WithExitCode(utils.ExitConfig, o.validate()))
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().IntVar(&o.Issues, "issues", 1000, "Number of Jira issues")
+	cmd.Flags().IntVar(&o.Comments, "comments", 5, "Number of comments of each issue")
+	cmd.Flags().IntVar(&o.Attachments, "attachments", 1, "Number of attachments of each issue")
+	cmd.Flags().DurationVar(&o.Latency, "latency", 20*time.Millisecond, "Latency of every request to the fake servers")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 20, "Requests in flight (concurrency.max)")
+
+	return cmd
+}
+
+func (o *Options) validate() error {
+	if o.Issues <= 0 {
+		return errors.New("--issues must be positive")
+	}
+	if o.Comments < 0 || o.Attachments < 0 || o.Latency < 0 {
+		return errors.New("--comments, --attachments and --latency can't be negative")
+	}
+	if o.Concurrency <= 0 {
+		return errors.New("--concurrency must be positive")
+	}
+	return nil
+}
+
+func (o *Options) run() error {
+	//* The log of each issue would measure the terminal
+	if !viper.GetBool("DEBUG") {
+		log.SetLevel(log.WarnLevel)
+	}
+	defer stats.Default().Print(o.Out)
+
+	result, err := bench.Run(context.Background(), &o.Options)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.Out, "Migrated %d issues, %d comments and %d attachments in %s\n", result.Issues, result.Notes, result.Uploads, result.Duration.Round(time.Millisecond))
+	fmt.Fprintf(o.Out, "Throughput: %.1f issues/s, %d requests (%.1f/s), up to %d in flight\n",
+		result.IssuesPerSecond(), result.Requests, float64(result.Requests)/result.Duration.Seconds(), result.MaxInFlight)
+	return nil
+}
@@ -27,6 +27,7 @@ func NewCmdConfig(ioStreams *utils.IOStreams) *cobra.Command {
 	cmd.AddCommand(
 		newCmd.NewCmdNew(ioStreams),
 		newCmdConfigLint(ioStreams),
+		newCmdConfigEncrypt(ioStreams),
 	)
 
 	return cmd
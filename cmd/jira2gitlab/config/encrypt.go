@@ -0,0 +1,84 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/secret"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type encryptOptions struct {
+	*utils.IOStreams
+
+	StateFile string
+}
+
+func newCmdConfigEncrypt(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &encryptOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "encrypt [options]",
+		Short: "Encrypt a token of the config or the state file",
+		Long: fmt.Sprintf(`Encrypt the token read from stdin with AES-GCM and the passphrase of %s.
+The printed enc: value replaces the token in config.yaml (jira.token, gitlab.token, gitlab.tokens).
+With --state, the state file is encrypted in place. The passphrase is required to read them.`, secret.PassphraseEnv),
+		Example: fmt.Sprintf("  echo -n $GITLAB_TOKEN | %s=... jira2gitlab config encrypt", secret.PassphraseEnv),
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.StateFile, "state", "", "state file to encrypt in place")
+
+	return cmd
+}
+
+func (o *encryptOptions) run() error {
+	passphrase := secret.Passphrase()
+	if passphrase == "" {
+		return secret.ErrNoPassphrase
+	}
+
+	//* State file, saved again with the passphrase
+	if o.StateFile != "" {
+		s, err := state.Load(o.StateFile)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", o.StateFile))
+		}
+		return s.Save(o.StateFile)
+	}
+
+	line, err := bufio.NewReader(o.In).ReadString('\n')
+	if err != nil && line == "" {
+		return errors.Wrap(err, "Error reading token from stdin")
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return errors.New("Token is empty")
+	}
+
+	value, err := secret.EncryptString(token, passphrase)
+	if err != nil {
+		return errors.Wrap(err, "Error encrypting token")
+	}
+	fmt.Fprintln(o.Out, value)
+	return nil
+}
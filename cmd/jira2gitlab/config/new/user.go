@@ -13,6 +13,7 @@ package new
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -54,9 +55,14 @@ func runConfigNewUser(io *utils.IOStreams) error {
 		errors.Wrap(err, "Error unmarshalling config")
 	}
 
-	jr := config.GetJiraClient(cfg)
+	ctx := context.Background()
+
+	reader, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
 
-	jiraEpics, jiraIssues, err := j2g.GetJiraIssues(jr, cfg.Jira.Name, cfg.Jira.Jql)
+	jiraEpics, jiraIssues, err := j2g.GetJiraIssues(ctx, reader, cfg)
 	if err != nil {
 		return errors.Wrap(err, "Error getting Jira issues")
 	}
@@ -93,10 +99,21 @@ func runConfigNewUser(io *utils.IOStreams) error {
 		return errors.Wrap(err, "Error writing to file")
 	}
 
+	//* The names of a backup can't be looked up in Jira
+	if cfg.Jira.Backup != "" {
+		for _, username := range usernames {
+			if _, err = file.WriteString(username + ",\n"); err != nil {
+				return errors.Wrap(err, "Error writing to file")
+			}
+		}
+		return nil
+	}
+
+	jr := config.GetJiraClient(cfg)
 	for _, username := range usernames {
 		options := &jirax.UserQueryOptions{Username: username}
 
-		user, _, err := jirax.GetUser(jr, options)
+		user, _, err := jirax.GetUser(ctx, jr, options)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("Error getting user %s", username))
 		}
@@ -0,0 +1,31 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package fields
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+func NewCmdFields(ioStreams *utils.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fields SUBCOMMAND [options]",
+		Short: "Inspect the Jira fields",
+		Long:  "Inspect the Jira fields to find the custom field IDs of the config",
+	}
+
+	cmd.AddCommand(
+		newCmdFieldsList(ioStreams),
+	)
+
+	return cmd
+}
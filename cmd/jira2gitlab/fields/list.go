@@ -0,0 +1,91 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package fields
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+// maxSampleLength is the number of characters of a sample value in the list
+const maxSampleLength = 40
+
+type listOptions struct {
+	*utils.IOStreams
+
+	Custom  bool
+	Samples int
+}
+
+func newCmdFieldsList(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &listOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "list [options]",
+		Short:   "List the Jira fields with a sample value of the project",
+		Long:    "List the ID, name, type and a sample value of the project issues of every Jira field, e.g. to configure jira.custom_field",
+		Example: "  jira2gitlab fields list --custom",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.Custom, "custom", false, "list the custom fields only")
+	cmd.Flags().IntVar(&o.Samples, "samples", 50, "number of issues of the project to find the sample values in")
+
+	return cmd
+}
+
+func (o *listOptions) run() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+
+	fields, err := j2g.ListFields(context.Background(), jr, cfg, o.Samples)
+	if err != nil {
+		return errors.Wrap(err, "Error listing Jira fields")
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tSAMPLE")
+	for _, field := range fields {
+		if o.Custom && !field.Custom {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", field.ID, field.Name, field.Type, truncate(field.Sample, maxSampleLength))
+	}
+	return w.Flush()
+}
+
+// truncate shortens the text to max characters with an ellipsis
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max-1]) + "…"
+}
@@ -0,0 +1,31 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlab
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+func NewCmdGitLab(ioStreams *utils.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gitlab SUBCOMMAND [options]",
+		Short: "Discover the GitLab groups",
+		Long:  "List the GitLab groups of the token to find the paths and IDs of the config",
+	}
+
+	cmd.AddCommand(
+		newCmdGroups(ioStreams),
+	)
+
+	return cmd
+}
@@ -0,0 +1,116 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlab
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type groupsOptions struct {
+	*utils.IOStreams
+
+	Search   string
+	Projects bool
+}
+
+func newCmdGroups(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &groupsOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "groups [options]",
+		Short:   "List the GitLab groups",
+		Long:    "List the ID, full path and name of the GitLab groups the token is a member of, the full path is gitlab.epic of the config",
+		Example: "  jira2gitlab gitlab groups --search devops --projects",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Search, "search", "", "list the groups matching the name or path")
+	cmd.Flags().BoolVar(&o.Projects, "projects", false, "list the projects of the groups too, the full path is gitlab.issue of the config")
+
+	return cmd
+}
+
+func (o *groupsOptions) run() error {
+	cfg, err := config.GetConnectionConfig("GitLab.Host", "GitLab.Token")
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+	gl := config.GetGitLabClient(cfg)
+
+	opt := &gitlab.ListGroupsOptions{
+		ListOptions:    gitlab.ListOptions{PerPage: 100},
+		MinAccessLevel: gitlab.AccessLevel(gitlab.ReporterPermissions),
+		OrderBy:        gitlab.String("path"),
+	}
+	if o.Search != "" {
+		opt.Search = gitlab.String(o.Search)
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFULL PATH\tNAME")
+	for {
+		groups, res, err := gl.Groups.ListGroups(opt)
+		if err != nil {
+			return errors.Wrap(err, "Error listing GitLab groups")
+		}
+
+		for _, group := range groups {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", group.ID, group.FullPath, group.Name)
+			if o.Projects {
+				if err := o.listProjects(w, gl, group); err != nil {
+					return err
+				}
+			}
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+	return w.Flush()
+}
+
+// listProjects writes the projects of the group below the group
+func (o *groupsOptions) listProjects(w *tabwriter.Writer, gl *gitlab.Client, group *gitlab.Group) error {
+	opt := &gitlab.ListGroupProjectsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		OrderBy:     gitlab.String("path"),
+		Simple:      gitlab.Bool(true),
+	}
+	for {
+		projects, res, err := gl.Groups.ListGroupProjects(group.ID, opt)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error listing GitLab projects: %s", group.FullPath))
+		}
+
+		for _, project := range projects {
+			fmt.Fprintf(w, "%d\t  %s\t%s\n", project.ID, project.PathWithNamespace, project.Name)
+		}
+
+		if res.NextPage == 0 {
+			return nil
+		}
+		opt.Page = res.NextPage
+	}
+}
@@ -0,0 +1,315 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package initialize
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/secret"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+type Options struct {
+	*utils.IOStreams
+
+	Output string
+	Force  bool
+
+	reader *bufio.Reader
+}
+
+func NewOptions(ioStreams *utils.IOStreams) *Options {
+	return &Options{
+		IOStreams: ioStreams,
+	}
+}
+
+func NewCmdInit(ioStreams *utils.IOStreams) *cobra.Command {
+	o := NewOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:   "init [options]",
+		Short: "Create the config file interactively",
+		Long: fmt.Sprintf(`Prompt for the Jira and GitLab hosts and tokens, list the available projects of both and
+write a validated config file. The tokens are encrypted if %s is set.`, secret.PassphraseEnv),
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "config.yaml", "config file to write")
+	cmd.Flags().BoolVar(&o.Force, "force", false, "Overwrite the config file if it exists")
+
+	return cmd
+}
+
+func (o *Options) run() error {
+	o.reader = bufio.NewReader(o.In)
+	ctx := context.Background()
+
+	if _, err := os.Stat(o.Output); err == nil && !o.Force {
+		answer, err := o.prompt(fmt.Sprintf("%s already exists. Overwrite it? (y/n)", o.Output), "n")
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(answer) != "y" {
+			return nil
+		}
+	}
+
+	cfg := &config.Config{}
+
+	//* Jira
+	jiraProjects, err := o.promptJira(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	cfg.Jira.Name, err = o.choose("Jira project", jiraProjects)
+	if err != nil {
+		return err
+	}
+
+	//* GitLab
+	if err := o.promptGitLab(cfg); err != nil {
+		return err
+	}
+
+	if err := config.Validate(cfg); err != nil {
+		return err
+	}
+
+	if passphrase := secret.Passphrase(); passphrase != "" {
+		for _, value := range []*string{&cfg.Jira.Token, &cfg.GitLab.Token} {
+			if *value, err = secret.EncryptString(*value, passphrase); err != nil {
+				return errors.Wrap(err, "Error encrypting token")
+			}
+		}
+	}
+
+	data, err := formatConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(o.Output, data, 0600); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing config file: %s", o.Output))
+	}
+	fmt.Fprintf(o.Out, "\nConfig is written to %s\n", o.Output)
+	fmt.Fprintln(o.Out, "Next: list the Jira users with 'jira2gitlab config new user' and map them to GitLab users in user.csv")
+	return nil
+}
+
+// promptJira asks for the host and the token of Jira and returns the projects of the token
+func (o *Options) promptJira(ctx context.Context, cfg *config.Config) ([]choice, error) {
+	var err error
+	if cfg.Jira.Host, err = o.prompt("Jira host (e.g. https://jira.example.com)", ""); err != nil {
+		return nil, err
+	}
+	if cfg.Jira.Token, err = o.promptSecret("Jira personal access token"); err != nil {
+		return nil, err
+	}
+
+	tp := jira.BearerAuthTransport{Token: cfg.Jira.Token}
+	jr, err := jira.NewClient(cfg.Jira.Host, tp.Client())
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating Jira client")
+	}
+
+	projects, _, err := jr.Project.GetAll(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error listing Jira projects: %s", cfg.Jira.Host))
+	}
+	if len(*projects) == 0 {
+		return nil, errors.New("The Jira token can't browse any project")
+	}
+
+	var choices []choice
+	for _, project := range *projects {
+		choices = append(choices, choice{project.Key, project.Name})
+	}
+	return choices, nil
+}
+
+// promptGitLab asks for the host and the token of GitLab, the project of the issues and the group of the epics
+func (o *Options) promptGitLab(cfg *config.Config) error {
+	var err error
+	if cfg.GitLab.Host, err = o.prompt("GitLab host", "https://gitlab.com"); err != nil {
+		return err
+	}
+	if cfg.GitLab.Token, err = o.promptSecret("GitLab personal access token (api scope)"); err != nil {
+		return err
+	}
+
+	gl, err := gitlab.NewClient(cfg.GitLab.Token, gitlab.WithBaseURL(cfg.GitLab.Host))
+	if err != nil {
+		return errors.Wrap(err, "Error creating GitLab client")
+	}
+
+	search, err := o.prompt("Search GitLab projects", cfg.Jira.Name)
+	if err != nil {
+		return err
+	}
+	projects, _, err := gl.Projects.ListProjects(&gitlab.ListProjectsOptions{
+		ListOptions:      gitlab.ListOptions{PerPage: 50},
+		Membership:       gitlab.Bool(true),
+		MinAccessLevel:   gitlab.AccessLevel(gitlab.ReporterPermissions),
+		Search:           gitlab.String(search),
+		SearchNamespaces: gitlab.Bool(true),
+		Simple:           gitlab.Bool(true),
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error listing GitLab projects: %s", cfg.GitLab.Host))
+	}
+	if len(projects) == 0 {
+		return errors.Errorf("No GitLab project of the token matches %q", search)
+	}
+
+	var choices []choice
+	namespaces := make(map[string]string)
+	for _, project := range projects {
+		choices = append(choices, choice{project.PathWithNamespace, project.NameWithNamespace})
+		namespaces[project.PathWithNamespace] = project.Namespace.FullPath
+	}
+	if cfg.GitLab.Issue, err = o.choose("GitLab project of the issues", choices); err != nil {
+		return err
+	}
+
+	//* Epics belong to a group, the namespace of the project by default
+	if cfg.GitLab.Epic, err = o.prompt("GitLab group of the epics", namespaces[cfg.GitLab.Issue]); err != nil {
+		return err
+	}
+	if _, _, err := gl.Groups.GetGroup(cfg.GitLab.Epic, &gitlab.GetGroupOptions{}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error getting GitLab group: %s", cfg.GitLab.Epic))
+	}
+	return nil
+}
+
+// choice is a project listed for the selection
+type choice struct {
+	Value string
+	Name  string
+}
+
+// choose lists the choices and returns the value of the number or the value entered
+func (o *Options) choose(label string, choices []choice) (string, error) {
+	fmt.Fprintf(o.Out, "\n%s:\n", label)
+	for i, c := range choices {
+		fmt.Fprintf(o.Out, "  %2d) %s (%s)\n", i+1, c.Value, c.Name)
+	}
+
+	for {
+		answer, err := o.prompt(fmt.Sprintf("%s (number or key)", label), "")
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1].Value, nil
+		}
+		for _, c := range choices {
+			if strings.EqualFold(c.Value, answer) {
+				return c.Value, nil
+			}
+		}
+		fmt.Fprintf(o.Out, "Unknown %s: %s\n", label, answer)
+	}
+}
+
+// prompt reads a line, the default if it is empty. An answer is required without a default.
+func (o *Options) prompt(label string, def string) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(o.Out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(o.Out, "%s: ", label)
+		}
+
+		line, err := o.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", errors.Wrap(err, "Error reading answer")
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if answer != "" {
+			return answer, nil
+		}
+	}
+}
+
+// promptSecret reads a token without echo on a terminal, the input is read like prompt otherwise (e.g. a pipe)
+func (o *Options) promptSecret(label string) (string, error) {
+	in, ok := o.In.(*os.File)
+	if !ok || !term.IsTerminal(int(in.Fd())) {
+		return o.prompt(label, "")
+	}
+
+	for {
+		fmt.Fprintf(o.Out, "%s: ", label)
+		line, err := term.ReadPassword(int(in.Fd()))
+		fmt.Fprintln(o.Out)
+		if err != nil {
+			return "", errors.Wrap(err, "Error reading answer")
+		}
+		if answer := strings.TrimSpace(string(line)); answer != "" {
+			return answer, nil
+		}
+	}
+}
+
+// initConfig is the config file of the wizard, the other options are in internal/config/example/config.yaml
+type initConfig struct {
+	Jira struct {
+		Host  string `yaml:"host"`
+		Token string `yaml:"token"`
+		Name  string `yaml:"name"` // Jira project key
+	} `yaml:"jira"`
+	GitLab struct {
+		Host  string `yaml:"host"`
+		Token string `yaml:"token"`
+		Issue string `yaml:"issue"` // project of the issues
+		Epic  string `yaml:"epic"`  // group of the epics
+	} `yaml:"gitlab"`
+}
+
+// formatConfig returns the config file of the wizard
+func formatConfig(cfg *config.Config) ([]byte, error) {
+	var c initConfig
+	c.Jira.Host = cfg.Jira.Host
+	c.Jira.Token = cfg.Jira.Token
+	c.Jira.Name = cfg.Jira.Name
+	c.GitLab.Host = cfg.GitLab.Host
+	c.GitLab.Token = cfg.GitLab.Token
+	c.GitLab.Issue = cfg.GitLab.Issue
+	c.GitLab.Epic = cfg.GitLab.Epic
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# Written by jira2gitlab init, see internal/config/example/config.yaml for the other options")
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&c); err != nil {
+		return nil, errors.Wrap(err, "Error marshaling config")
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jira
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type boardsOptions struct {
+	*utils.IOStreams
+
+	Project string
+	Type    string
+}
+
+func newCmdBoards(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &boardsOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "boards [options]",
+		Short:   "List the Jira boards",
+		Long:    "List the ID, name, type and filter of the Jira boards, e.g. the scrum boards of the sprints",
+		Example: "  jira2gitlab jira boards --project TEST --type scrum",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Project, "project", "", "key of the Jira project, every board if it is empty")
+	cmd.Flags().StringVar(&o.Type, "type", "", "'scrum' or 'kanban', every type if it is empty")
+
+	return cmd
+}
+
+func (o *boardsOptions) run() error {
+	cfg, err := config.GetConnectionConfig("Jira.Host", "Jira.Token")
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+	jr := config.GetJiraClient(cfg)
+
+	boards, err := jirax.UnpaginateBoards(context.Background(), jr, o.Project, o.Type)
+	if err != nil {
+		return errors.Wrap(err, "Error listing Jira boards")
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tFILTER")
+	for _, board := range boards {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", board.ID, board.Name, board.Type, board.FilterID)
+	}
+	return w.Flush()
+}
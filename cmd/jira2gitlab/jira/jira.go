@@ -0,0 +1,32 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jira
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+func NewCmdJira(ioStreams *utils.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jira SUBCOMMAND [options]",
+		Short: "Discover the Jira projects and boards",
+		Long:  "List the Jira projects and boards of the token to find the keys and IDs of the config",
+	}
+
+	cmd.AddCommand(
+		newCmdProjects(ioStreams),
+		newCmdBoards(ioStreams),
+	)
+
+	return cmd
+}
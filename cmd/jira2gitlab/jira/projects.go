@@ -0,0 +1,65 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jira
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type projectsOptions struct {
+	*utils.IOStreams
+}
+
+func newCmdProjects(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &projectsOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "projects",
+		Short:   "List the Jira projects",
+		Long:    "List the key, name and type of the Jira projects the token can browse, the key is jira.name of the config",
+		Example: "  jira2gitlab jira projects",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	return cmd
+}
+
+func (o *projectsOptions) run() error {
+	cfg, err := config.GetConnectionConfig("Jira.Host", "Jira.Token")
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+	jr := config.GetJiraClient(cfg)
+
+	projects, _, err := jr.Project.GetAll(context.Background(), nil)
+	if err != nil {
+		return errors.Wrap(err, "Error listing Jira projects")
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tNAME\tTYPE\tCATEGORY")
+	for _, project := range *projects {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", project.Key, project.Name, project.ProjectTypeKey, project.ProjectCategory.Name)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,32 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package mapping
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+func NewCmdMapping(ioStreams *utils.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mapping SUBCOMMAND [options]",
+		Short: "Use the Jira to GitLab mapping of the state file",
+		Long:  "Use the Jira to GitLab mapping of the state file after the migration",
+	}
+
+	cmd.AddCommand(
+		newCmdMappingServe(ioStreams),
+		newCmdMappingRedirects(ioStreams),
+	)
+
+	return cmd
+}
@@ -0,0 +1,73 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package mapping
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/mapping"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type redirectsOptions struct {
+	*utils.IOStreams
+
+	StateFile string
+	Format    string
+	Output    string
+}
+
+func newCmdMappingRedirects(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &redirectsOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "redirects [options]",
+		Short:   "Generate the redirect rules from Jira to GitLab",
+		Long:    "Generate the nginx map or Caddy redirect rules from https://jira/browse/{key} to the GitLab URL",
+		Example: "  jira2gitlab mapping redirects --format nginx --output j2lab-redirects.conf",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.StateFile, "state", state.DefaultPath, "state file of the migration")
+	cmd.Flags().StringVar(&o.Format, "format", mapping.FormatNginx, "One of 'nginx' or 'caddy'")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "output file (default stdout)")
+
+	return cmd
+}
+
+func (o *redirectsOptions) run() error {
+	s, err := state.Load(o.StateFile)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", o.StateFile))
+	}
+
+	var w io.Writer = o.Out
+	if o.Output != "" {
+		file, err := os.Create(o.Output)
+		if err != nil {
+			return errors.Wrap(err, "Error creating file")
+		}
+		defer file.Close()
+		w = file
+	}
+
+	return mapping.WriteRedirects(w, s, o.Format)
+}
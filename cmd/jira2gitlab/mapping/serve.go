@@ -0,0 +1,61 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package mapping
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/mapping"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type serveOptions struct {
+	*utils.IOStreams
+
+	StateFile string
+	Addr      string
+}
+
+func newCmdMappingServe(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &serveOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "serve [options]",
+		Short:   "Serve the Jira to GitLab mapping as an HTTP API",
+		Long:    "Serve the Jira to GitLab mapping as an HTTP API (/jira/{key}, /gitlab?url={url})",
+		Example: "  jira2gitlab mapping serve --state state.json --addr :8080",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.StateFile, "state", state.DefaultPath, "state file of the migration")
+	cmd.Flags().StringVar(&o.Addr, "addr", ":8080", "address to listen on")
+
+	return cmd
+}
+
+func (o *serveOptions) run() error {
+	s, err := state.Load(o.StateFile)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", o.StateFile))
+	}
+
+	fmt.Fprintf(o.Out, "Serving %d items of %s on %s\n", len(s.Items), o.StateFile, o.Addr)
+	return http.ListenAndServe(o.Addr, mapping.NewHandler(s))
+}
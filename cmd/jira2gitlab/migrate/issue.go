@@ -0,0 +1,76 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type issueOptions struct {
+	*utils.IOStreams
+
+	IssueKey string
+	Force    bool
+}
+
+func newCmdMigrateIssue(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &issueOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "issue KEY [options]",
+		Short:   "Migrate one Jira issue",
+		Long:    "Migrate one Jira issue. With --force the GitLab issue migrated before is deleted and created again.",
+		Example: "  jira2gitlab migrate issue PROJ-123 --force",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			o.IssueKey = args[0]
+			utils.CheckErr(o.run())
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.Force, "force", false, "replace the GitLab issue if the Jira issue is already migrated")
+
+	return cmd
+}
+
+func (o *issueOptions) run() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+	gl := j2g.NewGitLabWriter(config.GetGitLabClient(cfg))
+
+	ctx, cancel := utils.WithGracefulShutdown(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	gitlabIssue, err := j2g.MigrateIssue(ctx, gl, jr, o.IssueKey, o.Force)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.Out, "%s is migrated to %s\n", o.IssueKey, gitlabIssue.WebURL)
+	return nil
+}
@@ -0,0 +1,31 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package migrate
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+func NewCmdMigrate(ioStreams *utils.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate SUBCOMMAND [options]",
+		Short: "Migrate a part of the Jira project",
+		Long:  "Migrate a part of the Jira project, e.g. one issue again after fixing a conversion bug",
+	}
+
+	cmd.AddCommand(
+		newCmdMigrateIssue(ioStreams),
+	)
+
+	return cmd
+}
@@ -0,0 +1,82 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package retry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type Options struct {
+	*utils.IOStreams
+}
+
+func NewOptions(ioStreams *utils.IOStreams) *Options {
+	return &Options{
+		IOStreams: ioStreams,
+	}
+}
+
+func NewCmdRetryFailed(ioStreams *utils.IOStreams) *cobra.Command {
+	o := NewOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:   "retry-failed",
+		Short: "Retry the quarantined items",
+		Long:  "Retry the epics and issues which failed in the previous runs (quarantine), e.g. after an expired token is renewed. The items of the state file are not migrated again.",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	return cmd
+}
+
+func (o *Options) run() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return utils.WithExitCode(utils.ExitConfig, errors.Wrap(err, "Error getting config"))
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+	gl := j2g.NewGitLabWriter(config.GetGitLabClient(cfg))
+
+	defer stats.Default().Print(o.Out)
+
+	ctx, cancel := utils.WithGracefulShutdown(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	items, err := j2g.RetryFailed(ctx, gl, jr)
+	for _, item := range items {
+		fmt.Fprintf(o.Out, "! %s (%s, %d attempts): %s\n", item.JiraKey, item.Type, item.Attempts, item.Error)
+	}
+	if err != nil {
+		if errors.Is(err, j2g.ErrQuarantined) || stats.Default().Items(stats.ItemCreated) > 0 {
+			return utils.WithExitCode(utils.ExitPartial, err)
+		}
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(o.Out, "No quarantined items are left.")
+	}
+	return nil
+}
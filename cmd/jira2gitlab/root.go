@@ -15,8 +15,19 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	applyCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/apply"
+	benchCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/bench"
 	configCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/config"
+	fieldsCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/fields"
+	gitlabCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/gitlab"
+	initCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/initialize"
+	jiraCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/jira"
+	mappingCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/mapping"
+	migrateCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/migrate"
+	retryCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/retry"
 	runCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/run"
+	siteCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/site"
+	verifyCmd "gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/verify"
 	"gitlab.com/infograb/team/devops/toy/j2lab/cmd/jira2gitlab/version"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
 )
@@ -39,9 +50,11 @@ func init() {
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config.yaml file")
 	rootCmd.PersistentFlags().StringP("user", "u", "", "user.csv file")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "debug mode")
+	rootCmd.PersistentFlags().StringP("profile", "p", "", "profile merged over the config (profiles.<name> or <name>.yaml next to the config file)")
 	viper.BindPFlag("CONFIG_FILE", rootCmd.PersistentFlags().Lookup("config"))
 	viper.BindPFlag("USER_FILE", rootCmd.PersistentFlags().Lookup("user"))
 	viper.BindPFlag("DEBUG", rootCmd.PersistentFlags().Lookup("debug"))
+	viper.BindPFlag("PROFILE", rootCmd.PersistentFlags().Lookup("profile"))
 
 	ioStreams := utils.NewStdIOStreams()
 	log.SetOutput(ioStreams.ErrOut)
@@ -60,7 +73,18 @@ func init() {
 	rootCmd.AddCommand(
 		version.NewCmdVersion(io),
 		runCmd.NewCmdRun(io),
+		applyCmd.NewCmdApply(io),
+		retryCmd.NewCmdRetryFailed(io),
+		siteCmd.NewCmdSite(io),
+		initCmd.NewCmdInit(io),
 		configCmd.NewCmdConfig(io),
+		mappingCmd.NewCmdMapping(io),
+		migrateCmd.NewCmdMigrate(io),
+		verifyCmd.NewCmdVerify(io),
+		fieldsCmd.NewCmdFields(io),
+		jiraCmd.NewCmdJira(io),
+		gitlabCmd.NewCmdGitLab(io),
+		benchCmd.NewCmdBench(io),
 	)
 }
 
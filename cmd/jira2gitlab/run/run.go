@@ -12,15 +12,47 @@
 package run
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/export"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
 )
 
 type Options struct {
 	*utils.IOStreams
+
+	Export        string
+	Only          string
+	AllowNonEmpty bool
+	Diff          bool
+	Sync          bool
+	Plan          string
+	Result        string
+}
+
+// Result is the outcome of the run written by --result, e.g. for a CI pipeline
+type Result struct {
+	Status    string `json:"status"` // success, partial or failed
+	ExitCode  int    `json:"exit_code"`
+	Created   int    `json:"created"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"`
+	StateFile string `json:"state_file,omitempty"`
+	Export    string `json:"export,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Hint      string `json:"hint,omitempty"` // how to fix the error
+
+	Warnings []string `json:"warnings,omitempty"` // Jira values corrected for GitLab, e.g. reversed dates
 }
 
 func NewOptions(ioStreams *utils.IOStreams) *Options {
@@ -37,11 +69,19 @@ func NewCmdRun(ioStreams *utils.IOStreams) *cobra.Command {
 		Long:  "Run the application",
 		Run: func(cmd *cobra.Command, args []string) {
 			utils.CheckErr(o.complete(cmd, args))
-			utils.CheckErr(o.validate())
+			utils.CheckErr(utils.WithExitCode(utils.ExitConfig, o.validate()))
 			utils.CheckErr(o.run())
 		},
 	}
 
+	cmd.Flags().StringVar(&o.Export, "export", "", "Write a GitLab project export archive (tar.gz) instead of calling the GitLab API")
+	cmd.Flags().StringVar(&o.Only, "only", "", "Run one phase of the migration: 'epics', 'issues' or 'links'. The state file carries the other phases")
+	cmd.Flags().BoolVar(&o.AllowNonEmpty, "allow-non-empty", false, "Migrate into a GitLab project or group that already has issues or epics")
+	cmd.Flags().BoolVar(&o.Diff, "diff", false, "Print the fields and comments a sync would change in GitLab without applying them")
+	cmd.Flags().BoolVar(&o.Sync, "sync", false, "Write the Jira fields changed since the last sync to the migrated GitLab issues (sync.conflict_policy)")
+	cmd.Flags().StringVar(&o.Plan, "plan", "", "Write the reviewable migration plan (YAML, or JSON for a .json file) instead of migrating, run it with apply")
+	cmd.Flags().StringVar(&o.Result, "result", "", "Write the result JSON (counts, state file, exit code) to the file, '-' for stdout")
+
 	return cmd
 }
 
@@ -50,16 +90,217 @@ func (o *Options) complete(cmd *cobra.Command, args []string) error {
 }
 
 func (o *Options) validate() error {
+	switch o.Only {
+	case "", config.PhaseEpics, config.PhaseIssues, config.PhaseLinks:
+	default:
+		return errors.Errorf("Unknown phase: %s (epics, issues or links)", o.Only)
+	}
+
+	if o.Only != "" && o.Export != "" {
+		return errors.New("--only can't be used with --export, the export doesn't have a state file")
+	}
+	if (o.Diff || o.Sync) && (o.Only != "" || o.Export != "") {
+		return errors.New("--diff and --sync can't be used with --only or --export")
+	}
+	if o.Diff && o.Sync {
+		return errors.New("--diff can't be used with --sync")
+	}
+	if o.Plan != "" && (o.Only != "" || o.Export != "" || o.Diff || o.Sync) {
+		return errors.New("--plan can't be used with --only, --export, --diff or --sync")
+	}
 	return nil
 }
 
-func (o *Options) run() error {
+func (o *Options) run() (err error) {
+	if o.Result != "" {
+		defer func() { err = o.writeResult(err) }()
+	}
+
 	cfg, err := config.GetConfig()
 	if err != nil {
-		return errors.Wrap(err, "Error getting config")
+		return utils.WithExitCode(utils.ExitConfig, errors.Wrap(err, "Error getting config"))
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+
+	if o.Diff {
+		return o.runDiff(jr, j2g.NewGitLabWriter(config.GetGitLabClient(cfg)))
+	}
+	if o.Sync {
+		return o.runSync(jr, j2g.NewGitLabWriter(config.GetGitLabClient(cfg)))
+	}
+	if o.Plan != "" {
+		return o.runPlan(jr, j2g.NewGitLabWriter(config.GetGitLabClient(cfg)))
+	}
+	//* The result JSON is alone on stdout
+	out := o.messages()
+	defer stats.Default().Print(out)
+
+	var gl j2g.GitLabWriter
+	var archive *export.Archive
+	if o.Export != "" {
+		archive = export.New(cfg.GitLab.Issue, cfg.GitLab.Epic)
+		gl = archive
+		export.Configure(cfg)
+	} else {
+		gl = j2g.NewGitLabWriter(config.GetGitLabClient(cfg))
+	}
+
+	cfg.Only = o.Only
+	cfg.AllowNonEmpty = o.AllowNonEmpty
+
+	//* Ctrl-C stops accepting new work, waits for the in-flight work and the state file is flushed
+	ctx, cancel := utils.WithGracefulShutdown(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	err = j2g.ConvertByProject(ctx, gl, jr)
+	if errors.Is(err, j2g.ErrInterrupted) && archive == nil {
+		fmt.Fprintf(out, "\nMigration interrupted. The progress is saved to %s\n", cfg.StateFile)
+		fmt.Fprintf(out, "Resume with: %s\n", strings.Join(os.Args, " "))
+	}
+	if err != nil {
+		//* Resumed with the state file, the quarantined items with retry-failed
+		if errors.Is(err, j2g.ErrInterrupted) || errors.Is(err, j2g.ErrQuarantined) || stats.Default().Items(stats.ItemCreated) > 0 {
+			return utils.WithExitCode(utils.ExitPartial, err)
+		}
+		return err
+	}
+
+	if archive != nil {
+		if err := archive.WriteFile(o.Export); err != nil {
+			return errors.Wrap(err, "Error writing GitLab project export")
+		}
+		fmt.Fprintf(out, "GitLab project export is written to %s\n", o.Export)
+	}
+
+	return nil
+}
+
+// runDiff prints the changes of a sync like a plan, nothing is written to GitLab
+func (o *Options) runDiff(jr j2g.JiraReader, gl j2g.GitLabWriter) error {
+	out := o.messages()
+	changes, err := j2g.Diff(context.Background(), gl, jr)
+	if err != nil {
+		return errors.Wrap(err, "Error comparing Jira with GitLab")
+	}
+
+	created, changed := 0, 0
+	for _, change := range changes {
+		if change.Action == j2g.ChangeCreate {
+			created++
+			fmt.Fprintf(out, "+ %s\n", change.JiraKey)
+			continue
+		}
+
+		changed++
+		fmt.Fprintf(out, "~ %s (%s)\n", change.JiraKey, change.WebURL)
+		for _, field := range change.Fields {
+			if field.Field == j2g.SyncFieldLabels {
+				fmt.Fprintf(out, "    labels: %s\n", field.To)
+				continue
+			}
+			fmt.Fprintf(out, "    %s: %q -> %q\n", field.Field, field.From, field.To)
+		}
+		for _, comment := range change.Comments {
+			fmt.Fprintf(out, "    + comment %s by %s\n", comment.ID, comment.Author.DisplayName)
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "No changes. GitLab is up to date with Jira.")
+		return nil
+	}
+	fmt.Fprintf(out, "\nPlan: %d to create, %d to change.\n", created, changed)
+	return nil
+}
+
+// runSync applies the changes of Jira and prints the conflicts which are not synced
+func (o *Options) runSync(jr j2g.JiraReader, gl j2g.GitLabWriter) error {
+	out := o.messages()
+	changes, conflicts, err := j2g.Sync(context.Background(), gl, jr)
+	if err != nil {
+		return errors.Wrap(err, "Error syncing Jira with GitLab")
+	}
+
+	for _, change := range changes {
+		fields := make([]string, 0, len(change.Fields))
+		for _, field := range change.Fields {
+			fields = append(fields, field.Field)
+		}
+		fmt.Fprintf(out, "~ %s (%s): %s\n", change.JiraKey, change.WebURL, strings.Join(fields, ", "))
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintf(out, "! %s (%s) %s: Jira %q, GitLab %q\n", conflict.JiraKey, conflict.WebURL, conflict.Field, conflict.Jira, conflict.GitLab)
+	}
+	fmt.Fprintf(out, "Synced %d Jira issues: %d conflicts\n", len(changes), len(conflicts))
+	return nil
+}
+
+// runPlan writes the plan of the migration, nothing is written to GitLab
+func (o *Options) runPlan(jr j2g.JiraReader, gl j2g.GitLabWriter) error {
+	out := o.messages()
+	plan, err := j2g.NewPlan(context.Background(), gl, jr)
+	if err != nil {
+		return errors.Wrap(err, "Error making migration plan")
+	}
+	if err := plan.WriteFile(o.Plan); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Plan: %d to create, %d to skip. Review %s and run it with: apply %s\n", plan.Count(j2g.PlanActionCreate), plan.Count(j2g.PlanActionSkip), o.Plan, o.Plan)
+	return nil
+}
+
+// messages is the writer of the human-readable output, stderr when the result JSON is alone on stdout
+func (o *Options) messages() io.Writer {
+	if o.Result == "-" {
+		return o.ErrOut
+	}
+	return o.Out
+}
+
+// writeResult writes the result JSON of the error of the run and returns the error
+func (o *Options) writeResult(runErr error) error {
+	result := &Result{
+		Status:   "success",
+		ExitCode: utils.ExitCode(runErr),
+		Created:  stats.Default().Items(stats.ItemCreated),
+		Skipped:  stats.Default().Items(stats.ItemSkipped),
+		Failed:   stats.Default().Items(stats.ItemFailed),
+		Export:   o.Export,
+		Warnings: stats.Default().Warnings(),
+	}
+	switch result.ExitCode {
+	case utils.ExitOK:
+	case utils.ExitPartial:
+		result.Status = "partial"
+	default:
+		result.Status = "failed"
+	}
+	if runErr != nil {
+		result.Error = runErr.Error()
+		result.Hint = utils.Hint(utils.ClassifyError(runErr))
+	}
+	if cfg, err := config.GetConfig(); err == nil {
+		result.StateFile = cfg.StateFile
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error marshalling result")
 	}
+	data = append(data, '\n')
 
-	gl := config.GetGitLabClient(cfg)
-	jr := config.GetJiraClient(cfg)
-	return j2g.ConvertByProject(gl, jr)
+	if o.Result == "-" {
+		_, err = o.Out.Write(data)
+	} else {
+		err = os.WriteFile(o.Result, data, 0644)
+	}
+	if err != nil && runErr == nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing result: %s", o.Result))
+	}
+	return runErr
 }
@@ -0,0 +1,70 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package site
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type listOptions struct {
+	*utils.IOStreams
+}
+
+func newCmdList(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &listOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the Jira projects of the site and their GitLab projects",
+		Long:    "List the Jira projects of the site migration and the paths of their GitLab projects, nothing is created",
+		Example: "  jira2gitlab site list",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	return cmd
+}
+
+func (o *listOptions) run() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return utils.WithExitCode(utils.ExitConfig, errors.Wrap(err, "Error getting config"))
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+
+	projects, err := j2g.PlanSite(context.Background(), jr)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tCATEGORY\tGITLAB PROJECT\tSTATE FILE")
+	for _, project := range projects {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", project.Key, project.Category, project.Path, project.StateFile)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,77 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package site
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+type runOptions struct {
+	*utils.IOStreams
+}
+
+func newCmdRun(ioStreams *utils.IOStreams) *cobra.Command {
+	o := &runOptions{
+		IOStreams: ioStreams,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "run",
+		Short:   "Migrate the Jira projects of the site",
+		Long:    "Create the GitLab groups and projects of the site and migrate each Jira project. A run is resumed with the state files of site.state_dir.",
+		Example: "  jira2gitlab site run",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	return cmd
+}
+
+func (o *runOptions) run() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return utils.WithExitCode(utils.ExitConfig, errors.Wrap(err, "Error getting config"))
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+	gl := j2g.NewGitLabWriter(config.GetGitLabClient(cfg))
+
+	defer stats.Default().Print(o.Out)
+
+	ctx, cancel := utils.WithGracefulShutdown(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	projects, err := j2g.MigrateSite(ctx, gl, jr)
+	if err != nil {
+		if errors.Is(err, j2g.ErrInterrupted) || errors.Is(err, j2g.ErrQuarantined) || stats.Default().Items(stats.ItemCreated) > 0 {
+			return utils.WithExitCode(utils.ExitPartial, err)
+		}
+		return err
+	}
+
+	for _, project := range projects {
+		fmt.Fprintf(o.Out, "%s is migrated to %s\n", project.Key, project.Path)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package site
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+func NewCmdSite(ioStreams *utils.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "site SUBCOMMAND [options]",
+		Short: "Migrate every Jira project to the GitLab group tree",
+		Long:  "Migrate the Jira projects of the site (site.category) to GitLab projects under gitlab.epic, laid out by site.project_path",
+	}
+
+	cmd.AddCommand(
+		newCmdList(ioStreams),
+		newCmdRun(ioStreams),
+	)
+
+	return cmd
+}
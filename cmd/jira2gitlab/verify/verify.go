@@ -0,0 +1,77 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package verify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+var errVerifyFailed = errors.New("Verification failed")
+
+type Options struct {
+	*utils.IOStreams
+}
+
+func NewOptions(ioStreams *utils.IOStreams) *Options {
+	return &Options{
+		IOStreams: ioStreams,
+	}
+}
+
+func NewCmdVerify(ioStreams *utils.IOStreams) *cobra.Command {
+	o := NewOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:   "verify [options]",
+		Short: "Compare Jira and GitLab after the migration",
+		Long:  "Re-read Jira and GitLab and report the missing issues, comments, attachments and labels. The exit code is 5 if there is a difference.",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.CheckErr(o.run())
+		},
+	}
+
+	return cmd
+}
+
+func (o *Options) run() error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+
+	jr, err := j2g.OpenJiraReader(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening Jira")
+	}
+	gl := j2g.NewGitLabWriter(config.GetGitLabClient(cfg))
+
+	verified, mismatches, err := j2g.Verify(context.Background(), gl, jr)
+	if err != nil {
+		return errors.Wrap(err, "Error verifying the migration")
+	}
+
+	for _, mismatch := range mismatches {
+		fmt.Fprintf(o.Out, "%s\t%s\t%s\n", mismatch.JiraKey, mismatch.Kind, mismatch.Detail)
+	}
+	fmt.Fprintf(o.Out, "Verified %d Jira issues: %d differences\n", verified, len(mismatches))
+
+	if len(mismatches) > 0 {
+		return utils.WithExitCode(utils.ExitMismatch, errVerifyFailed)
+	}
+	return nil
+}
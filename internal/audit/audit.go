@@ -0,0 +1,187 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry is a line of the audit log, a write request to GitLab
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Endpoint   string    `json:"endpoint"` // path of the request, graphql:<mutation> for GraphQL
+	Status     int       `json:"status,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Fields     []string  `json:"fields,omitempty"` // fields of the payload, not their values
+	Title      string    `json:"title,omitempty"`
+	ResponseID int       `json:"response_id,omitempty"`
+}
+
+// Logger appends the entries as JSON lines, the file is never truncated
+type Logger struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func NewLogger(path string) (*Logger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error opening audit log")
+	}
+	return &Logger{file: file}, nil
+}
+
+func (l *Logger) Write(entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "Error encoding audit entry")
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "Error writing audit log")
+	}
+	return nil
+}
+
+func (l *Logger) Close() error {
+	return l.file.Close()
+}
+
+var mutationPattern = regexp.MustCompile(`^\s*mutation[^{]*\{\s*(\w+)`)
+
+type transport struct {
+	logger *Logger
+	base   http.RoundTripper
+}
+
+// NewTransport returns a RoundTripper logging the POST, PUT, PATCH and DELETE requests and the GraphQL mutations
+func NewTransport(logger *Logger, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{logger: logger, base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions {
+		return t.base.RoundTrip(req)
+	}
+
+	//* The body of an upload is streamed to the server, it is not read into memory
+	var payload []byte
+	multipart := strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/")
+	if !multipart && req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		payload = body
+		//* A RoundTripper must not modify the request of the caller
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	entry := &Entry{Time: time.Now(), Method: req.Method, Endpoint: req.URL.Path}
+	summarize(entry, req, payload)
+	if entry.Endpoint == "" {
+		//* A GraphQL query reads
+		return t.base.RoundTrip(req)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Status = resp.StatusCode
+		entry.ResponseID = responseID(resp)
+	}
+
+	if err := t.logger.Write(entry); err != nil {
+		log.Warnf("Error writing audit log: %s", err)
+	}
+	return resp, err
+}
+
+// summarize sets the fields and the title of the payload, the GraphQL endpoint is the mutation (empty for a query)
+func summarize(entry *Entry, req *http.Request, payload []byte) {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		entry.Fields = []string{"file"}
+		return
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return
+	}
+
+	if strings.HasSuffix(req.URL.Path, "/graphql") {
+		query, _ := body["query"].(string)
+		match := mutationPattern.FindStringSubmatch(query)
+		if match == nil {
+			entry.Endpoint = ""
+			return
+		}
+		entry.Endpoint = "graphql:" + match[1]
+		variables, _ := body["variables"].(map[string]interface{})
+		if input, ok := variables["input"].(map[string]interface{}); ok {
+			body = input
+		} else {
+			body = variables
+		}
+	}
+
+	for field := range body {
+		entry.Fields = append(entry.Fields, field)
+	}
+	sort.Strings(entry.Fields)
+	entry.Title, _ = body["title"].(string)
+}
+
+// responseID returns the id of the created or updated object, the body is kept for the client
+func responseID(resp *http.Response) int {
+	if resp.Body == nil || !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return 0
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+
+	var object struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return 0
+	}
+	return object.ID
+}
@@ -0,0 +1,144 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// uploadBody fails the test if the audit transport reads the body instead of the base transport
+type uploadBody struct {
+	t    *testing.T
+	sent bool
+	io.Reader
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	if !b.sent {
+		b.t.Error("Upload body read by the audit transport")
+	}
+	return b.Reader.Read(p)
+}
+
+func (b *uploadBody) Close() error {
+	return nil
+}
+
+func readEntries(t *testing.T, path string) []Entry {
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestTransport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(path)
+	assert.NoError(t, err)
+
+	upload := &uploadBody{t: t, Reader: strings.NewReader("--boundary\r\nfile\r\n--boundary--\r\n")}
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Body == upload {
+			upload.sent = true
+		}
+		if req.Body != nil {
+			io.Copy(io.Discard, req.Body)
+		}
+		header := http.Header{"Content-Type": []string{"application/json"}}
+		return &http.Response{StatusCode: http.StatusCreated, Header: header, Body: io.NopCloser(strings.NewReader(`{"id":42}`))}, nil
+	})
+	client := &http.Client{Transport: NewTransport(logger, base)}
+
+	resp, err := client.Post("https://gitlab.example.com/api/v4/projects/2/issues", "application/json", strings.NewReader(`{"title":"Issue","description":"secret"}`))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"id":42}`, string(body))
+
+	req, _ := http.NewRequest(http.MethodPost, "https://gitlab.example.com/api/v4/projects/2/uploads", upload)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=boundary")
+	_, err = client.Do(req)
+	assert.NoError(t, err)
+
+	_, err = client.Post("https://gitlab.example.com/api/graphql", "application/json", strings.NewReader(`{"query":"query { project { id } }"}`))
+	assert.NoError(t, err)
+	_, err = client.Post("https://gitlab.example.com/api/graphql", "application/json", strings.NewReader(`{"query":"mutation createIteration($input: X!) { iterationCreate(input: $input) { errors } }","variables":{"input":{"title":"Sprint 1","startDate":"2023-01-01"}}}`))
+	assert.NoError(t, err)
+	_, err = client.Get("https://gitlab.example.com/api/v4/projects/2")
+	assert.NoError(t, err)
+	assert.NoError(t, logger.Close())
+
+	//* The GraphQL query and the GET are not logged
+	entries := readEntries(t, path)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "/api/v4/projects/2/issues", entries[0].Endpoint)
+	assert.Equal(t, http.StatusCreated, entries[0].Status)
+	assert.Equal(t, []string{"description", "title"}, entries[0].Fields)
+	assert.Equal(t, "Issue", entries[0].Title)
+	assert.Equal(t, 42, entries[0].ResponseID)
+
+	assert.Equal(t, "/api/v4/projects/2/uploads", entries[1].Endpoint)
+	assert.Equal(t, []string{"file"}, entries[1].Fields)
+	assert.True(t, upload.sent)
+
+	assert.Equal(t, "graphql:iterationCreate", entries[2].Endpoint)
+	assert.Equal(t, []string{"startDate", "title"}, entries[2].Fields)
+	assert.Equal(t, "Sprint 1", entries[2].Title)
+}
+
+func TestTransportRequest(t *testing.T) {
+	logger, err := NewLogger(filepath.Join(t.TempDir(), "audit.log"))
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	var sent *http.Request
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: http.StatusCreated, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	//* The request of the caller is not modified, the base gets a copy which can be sent again
+	req, _ := http.NewRequest(http.MethodPost, "https://gitlab.example.com/api/v4/projects/2/issues", strings.NewReader(`{"title":"Issue"}`))
+	body := req.Body
+	_, err = NewTransport(logger, base).RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, body, req.Body)
+	if assert.NotNil(t, sent) && assert.NotSame(t, req, sent) {
+		content, _ := io.ReadAll(sent.Body)
+		assert.Equal(t, `{"title":"Issue"}`, string(content))
+		again, err := sent.GetBody()
+		assert.NoError(t, err)
+		content, _ = io.ReadAll(again)
+		assert.Equal(t, `{"title":"Issue"}`, string(content))
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+// Package backup reads Jira data from a backup instead of the Jira API.
+// - entities.xml of a Jira backup (System > Backup system)
+// - CSV export of the issue navigator (Export > CSV (All fields))
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// Jira time format of comments and attachments
+const jiraTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+// Reader serves one Jira project of a backup loaded into memory.
+// Attachments are read from the attachment directory of the backup when they are downloaded.
+// The data a backup doesn't have (e.g. remote links, sprints, roles) is empty.
+type Reader struct {
+	project *jira.Project
+	issues  []*jira.Issue
+
+	attachmentDir string
+	// Key: attachment ID, relative paths in the attachment directory
+	attachmentPaths map[string][]string
+}
+
+// Open loads the Jira project (jira.name) of entities.xml or a CSV export, decided by the file extension.
+// The project can be omitted if the backup has only one.
+func Open(path string, attachmentDir string, projectKey string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error opening Jira backup: %s", path))
+	}
+	defer file.Close()
+
+	r := &Reader{
+		attachmentDir:   attachmentDir,
+		attachmentPaths: make(map[string][]string),
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		err = r.loadEntities(file, projectKey)
+	case ".csv":
+		err = r.loadCSV(file, projectKey)
+	default:
+		return nil, errors.Errorf("Unknown Jira backup format: %s (entities.xml or .csv)", path)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading Jira backup: %s", path))
+	}
+
+	return r, nil
+}
+
+func (r *Reader) DownloadAttachment(ctx context.Context, attachmentID string) (io.ReadCloser, error) {
+	if r.attachmentDir == "" {
+		return nil, errors.Errorf("Attachment %s can't be read: the attachment directory of the backup is not configured", attachmentID)
+	}
+
+	for _, path := range r.attachmentPaths[attachmentID] {
+		file, err := os.Open(filepath.Join(r.attachmentDir, path))
+		if err == nil {
+			return file, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error opening attachment %s", attachmentID))
+		}
+	}
+
+	return nil, errors.Errorf("Attachment %s is not found in %s", attachmentID, r.attachmentDir)
+}
+
+//* Helpers
+
+func formatJiraTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(jiraTimeFormat)
+}
+
+// Jira attachment directory: {project key}/{bucket}/{issue key}/{attachment id}, bucket is the issue number rounded up to 10,000
+// Jira before 7 doesn't use the bucket.
+func attachmentPaths(projectKey string, issueKey string, attachmentID string) []string {
+	bucket := ((issueNumber(issueKey)-1)/10000 + 1) * 10000
+
+	return []string{
+		filepath.Join(projectKey, fmt.Sprintf("%d", bucket), issueKey, attachmentID),
+		filepath.Join(projectKey, issueKey, attachmentID),
+	}
+}
+
+func boolPointer(b bool) *bool {
+	return &b
+}
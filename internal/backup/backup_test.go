@@ -0,0 +1,154 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testEntities = `<?xml version="1.0" encoding="UTF-8"?>
+<entity-engine-xml>
+    <Project id="10000" name="Test" key="TEST" description="Test project"/>
+    <IssueType id="1" name="Bug"/>
+    <IssueType id="2" name="Epic"/>
+    <Status id="3" name="Done"/>
+    <Priority id="4" name="High"/>
+    <Resolution id="5" name="Fixed"/>
+    <Version id="20" project="10000" name="1.0" sequence="1" released="true"/>
+    <IssueLinkType id="30" linkname="Epic-Story Link"/>
+    <IssueLinkType id="31" linkname="Blocks" inward="is blocked by" outward="blocks"/>
+    <ApplicationUser id="1" userKey="JIRAUSER10000" lowerUserName="jeff"/>
+    <User id="1" userName="jeff" displayName="Jeff"/>
+    <Issue id="100" project="10000" number="2" summary="Login fails" type="1" status="3" priority="4" resolution="5" assignee="JIRAUSER10000" created="2023-09-06 10:00:00.0">
+        <description>Steps</description>
+    </Issue>
+    <Issue id="101" project="10000" number="1" summary="Auth" type="2" status="3" priority="4" created="2023-09-05 10:00:00.0"/>
+    <Issue id="102" project="10000" number="3" summary="Logout fails" type="1" status="3" priority="4" created="2023-09-07 10:00:00.0"/>
+    <Label id="1" issue="100" label="backend"/>
+    <NodeAssociation sourceNodeId="100" sourceNodeEntity="Issue" sinkNodeId="20" sinkNodeEntity="Version" associationType="IssueFixVersion"/>
+    <IssueLink id="1" linktype="30" source="101" destination="100"/>
+    <IssueLink id="2" linktype="31" source="100" destination="102"/>
+    <Action id="200" issue="100" type="comment" author="JIRAUSER10000" created="2023-09-06 11:00:00.0">
+        <body>Reproduced</body>
+    </Action>
+    <FileAttachment id="300" issue="100" filename="log.txt" created="2023-09-06 10:00:00.0"/>
+</entity-engine-xml>
+`
+
+const testCSV = `Summary,Issue key,Issue id,Issue Type,Status,Project key,Project name,Priority,Assignee,Created,Labels,Labels,Comment,Attachment,Parent id,Custom field (Epic Link)
+Auth,TEST-1,101,Epic,Done,TEST,Test,High,,05/Sep/23 10:00 AM,,,,,,
+Login fails,TEST-2,100,Bug,Done,TEST,Test,High,jeff,06/Sep/23 10:00 AM,backend,ui,06/Sep/23 11:00 AM;jeff;Reproduced,06/Sep/23 10:00 AM;jeff;log.txt;https://jira.example.com/secure/attachment/300/log.txt,,TEST-1
+`
+
+func writeTestBackup(t *testing.T, name string, content string) (string, string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	attachmentDir := filepath.Join(dir, "attachments")
+	assert.NoError(t, os.MkdirAll(filepath.Join(attachmentDir, "TEST", "10000", "TEST-2"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(attachmentDir, "TEST", "10000", "TEST-2", "300"), []byte("log"), 0644))
+	return path, attachmentDir
+}
+
+func TestOpenEntities(t *testing.T) {
+	path, attachmentDir := writeTestBackup(t, "entities.xml", testEntities)
+
+	r, err := Open(path, attachmentDir, "TEST")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	project, _, err := r.GetProject(ctx, "TEST")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test project", project.Description)
+	assert.Equal(t, "1.0", project.Versions[0].Name)
+	assert.True(t, *project.Versions[0].Released)
+
+	epics, _ := r.SearchIssues(ctx, "project = TEST AND type = Epic")
+	issues, _ := r.SearchIssues(ctx, "project = TEST AND type != Epic")
+	assert.Len(t, epics, 1)
+	assert.Equal(t, []string{"TEST-2", "TEST-3"}, []string{issues[0].Key, issues[1].Key})
+
+	issue := issues[0]
+	assert.Equal(t, "Steps", issue.Fields.Description)
+	assert.Equal(t, "jeff", issue.Fields.Assignee.Name)
+	assert.Equal(t, "Fixed", issue.Fields.Resolution.Name)
+	assert.Equal(t, []string{"backend"}, issue.Fields.Labels)
+	assert.Equal(t, "1.0", issue.Fields.FixVersions[0].Name)
+	assert.Equal(t, "TEST-1", issue.Fields.Parent.Key)
+	assert.Equal(t, "Blocks", issue.Fields.IssueLinks[0].Type.Name)
+	assert.Equal(t, "TEST-3", issue.Fields.IssueLinks[0].OutwardIssue.Key)
+	assert.Equal(t, "Reproduced", issue.Fields.Comments.Comments[0].Body)
+	assert.Equal(t, "Jeff", issue.Fields.Comments.Comments[0].Author.DisplayName)
+
+	file, err := r.DownloadAttachment(ctx, issue.Fields.Attachments[0].ID)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "log", string(content))
+}
+
+func TestOpenCSV(t *testing.T) {
+	path, attachmentDir := writeTestBackup(t, "export.csv", testCSV)
+
+	r, err := Open(path, attachmentDir, "TEST")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	issues, _ := r.SearchIssues(ctx, "project = TEST AND type != Epic")
+	assert.Len(t, issues, 1)
+
+	issue := issues[0]
+	assert.Equal(t, []string{"backend", "ui"}, issue.Fields.Labels)
+	assert.Equal(t, "TEST-1", issue.Fields.Parent.Key)
+	assert.Equal(t, "jeff", issue.Fields.Comments.Comments[0].Author.Name)
+	assert.Equal(t, "300", issue.Fields.Attachments[0].ID)
+
+	_, err = r.DownloadAttachment(ctx, "300")
+	assert.NoError(t, err)
+}
+
+func TestOpenEntitiesProject(t *testing.T) {
+	entities := strings.Replace(testEntities, `<IssueType id="1"`, `<Project id="10001" name="Other" key="OTHER"/>
+    <Issue id="900" project="10001" number="1" summary="Other" type="1" status="3" priority="4"/>
+    <IssueType id="1"`, 1)
+	path, attachmentDir := writeTestBackup(t, "entities.xml", entities)
+
+	//* The project of jira.name is read
+	r, err := Open(path, attachmentDir, "OTHER")
+	assert.NoError(t, err)
+	ctx := context.Background()
+	issues, _ := r.SearchIssues(ctx, "project = OTHER Order by key ASC")
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, "OTHER-1", issues[0].Key)
+	}
+	_, _, err = r.GetProject(ctx, "TEST")
+	assert.Error(t, err)
+
+	r, err = Open(path, attachmentDir, "TEST")
+	assert.NoError(t, err)
+	issues, _ = r.SearchIssues(ctx, "key in (TEST-1, TEST-3)")
+	assert.Len(t, issues, 2)
+	issues, _ = r.SearchIssues(ctx, "project = TEST AND key = TEST-2")
+	assert.Len(t, issues, 1)
+
+	_, err = Open(path, attachmentDir, "")
+	assert.Error(t, err)
+	_, err = Open(path, attachmentDir, "MISSING")
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package backup
+
+import (
+	"encoding/csv"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// Default date format of the Jira CSV export (e.g. 06/Sep/23 10:00 AM)
+const csvTimeFormat = "02/Jan/06 3:04 PM"
+
+// csvRow keeps every value of a column; Labels, Comment, Attachment, ... are repeated columns.
+type csvRow map[string][]string
+
+func (row csvRow) get(column string) string {
+	if values := row[column]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func parseCSVTime(value string) time.Time {
+	t, err := time.ParseInLocation(csvTimeFormat, strings.TrimSpace(value), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func csvUser(name string) *jira.User {
+	if name == "" {
+		return nil
+	}
+	return &jira.User{Name: name, Key: name, DisplayName: name}
+}
+
+func (r *Reader) loadCSV(reader io.Reader, projectKey string) error {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "Error parsing CSV")
+	}
+	if len(records) < 2 {
+		return errors.New("No issue in CSV")
+	}
+
+	//* The export of a filter may have the issues of several projects
+	header := records[0]
+	rows := make([]csvRow, 0, len(records)-1)
+	projectKeys := make(map[string]bool)
+	for _, record := range records[1:] {
+		row := make(csvRow)
+		for i, value := range record {
+			if i < len(header) && value != "" {
+				row[header[i]] = append(row[header[i]], value)
+			}
+		}
+		projectKeys[row.get("Project key")] = true
+		if projectKey == "" || strings.EqualFold(row.get("Project key"), projectKey) {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return errors.Errorf("Jira project %s is not in the CSV", projectKey)
+	}
+	if projectKey == "" && len(projectKeys) > 1 {
+		return errors.New("CSV has more than one project, set jira.name to the key of the project to migrate")
+	}
+
+	//* Project
+	r.project = &jira.Project{
+		Key:  rows[0].get("Project key"),
+		Name: rows[0].get("Project name"),
+	}
+
+	versions := make(map[string]bool)
+	issueKeys := make(map[string]string) // Issue id -> Issue key
+	for _, row := range rows {
+		issueKeys[row.get("Issue id")] = row.get("Issue key")
+		for _, name := range row["Fix Version/s"] {
+			versions[name] = true
+		}
+	}
+
+	//* Versions (CSV doesn't have the release state)
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.project.Versions = append(r.project.Versions, jira.Version{Name: name, Released: boolPointer(false), Archived: boolPointer(false)})
+	}
+
+	//* Issues
+	for _, row := range rows {
+		key := row.get("Issue key")
+		fields := &jira.IssueFields{
+			Summary:        row.get("Summary"),
+			Description:    row.get("Description"),
+			Environment:    row.get("Environment"),
+			Type:           jira.IssueType{Name: row.get("Issue Type")},
+			Status:         &jira.Status{Name: row.get("Status")},
+			Priority:       &jira.Priority{Name: row.get("Priority")},
+			Assignee:       csvUser(row.get("Assignee")),
+			Reporter:       csvUser(row.get("Reporter")),
+			Created:        jira.Time(parseCSVTime(row.get("Created"))),
+			Updated:        jira.Time(parseCSVTime(row.get("Updated"))),
+			Resolutiondate: jira.Time(parseCSVTime(row.get("Resolved"))),
+			Duedate:        jira.Date(parseCSVTime(row.get("Due Date"))),
+			Labels:         row["Labels"],
+			Comments:       &jira.Comments{},
+			Unknowns:       map[string]interface{}{},
+		}
+		if resolution := row.get("Resolution"); resolution != "" {
+			fields.Resolution = &jira.Resolution{Name: resolution}
+		}
+		for _, name := range row["Component/s"] {
+			fields.Components = append(fields.Components, &jira.Component{Name: name})
+		}
+		for _, name := range row["Fix Version/s"] {
+			fields.FixVersions = append(fields.FixVersions, &jira.FixVersion{Name: name})
+		}
+
+		//* Sub-task parent or Epic Link
+		if parentKey, ok := issueKeys[row.get("Parent id")]; ok {
+			fields.Parent = &jira.Parent{ID: row.get("Parent id"), Key: parentKey}
+		} else if epicKey := row.get("Custom field (Epic Link)"); epicKey != "" {
+			fields.Parent = &jira.Parent{Key: epicKey}
+		}
+
+		//* Comment: {date};{author};{body}, CSV doesn't have the comment ID
+		for _, value := range row["Comment"] {
+			parts := strings.SplitN(value, ";", 3)
+			if len(parts) < 3 {
+				continue
+			}
+			fields.Comments.Comments = append(fields.Comments.Comments, &jira.Comment{
+				Author:  jira.User{Name: parts[1], Key: parts[1], DisplayName: parts[1]},
+				Body:    parts[2],
+				Created: formatJiraTime(parseCSVTime(parts[0])),
+			})
+		}
+
+		//* Attachment: {date};{author};{filename};{url .../secure/attachment/{id}/{filename}}
+		for _, value := range row["Attachment"] {
+			parts := strings.SplitN(value, ";", 4)
+			if len(parts) < 4 {
+				continue
+			}
+			id := path.Base(path.Dir(parts[3]))
+			fields.Attachments = append(fields.Attachments, &jira.Attachment{
+				ID:       id,
+				Filename: parts[2],
+				Author:   csvUser(parts[1]),
+				Created:  formatJiraTime(parseCSVTime(parts[0])),
+			})
+			r.attachmentPaths[id] = attachmentPaths(r.project.Key, key, id)
+		}
+
+		r.issues = append(r.issues, &jira.Issue{ID: row.get("Issue id"), Key: key, Fields: fields})
+	}
+
+	sort.Slice(r.issues, func(i, j int) bool {
+		return issueNumber(r.issues[i].Key) < issueNumber(r.issues[j].Key)
+	})
+
+	return nil
+}
@@ -0,0 +1,375 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package backup
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// Time format of entities.xml (e.g. 2023-09-06 10:00:00.0)
+const entityTimeFormat = "2006-01-02 15:04:05.0"
+
+// Jira system link types which are not shown as issue links
+const (
+	epicStoryLinkType = "Epic-Story Link"
+	subtaskLinkType   = "jira_subtask_link"
+)
+
+// entity is one row of entities.xml. Long values (e.g. description) are child elements instead of attributes.
+type entity map[string]string
+
+func readEntities(reader io.Reader) (map[string][]entity, error) {
+	entities := make(map[string][]entity)
+	decoder := xml.NewDecoder(reader)
+
+	depth := 0
+	var current entity
+	var currentName string
+	var field string
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Error parsing entities.xml")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 2:
+				currentName = t.Name.Local
+				current = make(entity)
+				for _, attr := range t.Attr {
+					current[attr.Name.Local] = attr.Value
+				}
+			case 3:
+				field = t.Name.Local
+				text.Reset()
+			}
+		case xml.CharData:
+			if depth == 3 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch depth {
+			case 2:
+				entities[currentName] = append(entities[currentName], current)
+			case 3:
+				current[field] = text.String()
+			}
+			depth--
+		}
+	}
+
+	return entities, nil
+}
+
+func parseEntityTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(entityTimeFormat, value, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func byID(entities []entity) map[string]entity {
+	result := make(map[string]entity)
+	for _, e := range entities {
+		result[e["id"]] = e
+	}
+	return result
+}
+
+func (r *Reader) loadEntities(reader io.Reader, projectKey string) error {
+	entities, err := readEntities(reader)
+	if err != nil {
+		return err
+	}
+
+	if len(entities["Project"]) == 0 {
+		return errors.New("No project in entities.xml")
+	}
+	var project entity
+	for _, p := range entities["Project"] {
+		if strings.EqualFold(p["key"], projectKey) {
+			project = p
+		}
+	}
+	if project == nil {
+		if projectKey != "" {
+			return errors.Errorf("Jira project %s is not in entities.xml", projectKey)
+		}
+		if len(entities["Project"]) > 1 {
+			return errors.New("entities.xml has more than one project, set jira.name to the key of the project to migrate")
+		}
+		project = entities["Project"][0]
+	}
+	projectID := project["id"]
+
+	//* Lookups
+	issueTypes := byID(entities["IssueType"])
+	statuses := byID(entities["Status"])
+	priorities := byID(entities["Priority"])
+	resolutions := byID(entities["Resolution"])
+	versions := byID(entities["Version"])
+	components := byID(entities["Component"])
+	linkTypes := byID(entities["IssueLinkType"])
+
+	// User key -> User name -> User
+	userNames := make(map[string]string)
+	for _, u := range entities["ApplicationUser"] {
+		userNames[u["userKey"]] = u["lowerUserName"]
+	}
+	users := make(map[string]*jira.User)
+	for _, u := range entities["User"] {
+		user := &jira.User{Name: u["userName"], Key: u["userName"], DisplayName: u["displayName"], EmailAddress: u["emailAddress"]}
+		users[strings.ToLower(u["userName"])] = user
+	}
+	getUser := func(key string) *jira.User {
+		if key == "" {
+			return nil
+		}
+		name, ok := userNames[key]
+		if !ok {
+			name = strings.ToLower(key)
+		}
+		if user, ok := users[name]; ok {
+			return user
+		}
+		return &jira.User{Name: key, Key: key, DisplayName: key}
+	}
+
+	//* Project
+	r.project = &jira.Project{
+		ID:          projectID,
+		Key:         project["key"],
+		Name:        project["name"],
+		Description: project["description"],
+	}
+
+	versionIDs := make([]string, 0)
+	for id, v := range versions {
+		if v["project"] == projectID {
+			versionIDs = append(versionIDs, id)
+		}
+	}
+	sort.Slice(versionIDs, func(i, j int) bool {
+		return atoi(versions[versionIDs[i]]["sequence"]) < atoi(versions[versionIDs[j]]["sequence"])
+	})
+	for _, id := range versionIDs {
+		v := versions[id]
+		version := jira.Version{
+			ID:          id,
+			Name:        v["name"],
+			Description: v["description"],
+			Released:    boolPointer(v["released"] == "true"),
+			Archived:    boolPointer(v["archived"] == "true"),
+		}
+		if t := parseEntityTime(v["startdate"]); !t.IsZero() {
+			version.StartDate = t.Format("2006-01-02")
+		}
+		if t := parseEntityTime(v["releasedate"]); !t.IsZero() {
+			version.ReleaseDate = t.Format("2006-01-02")
+		}
+		r.project.Versions = append(r.project.Versions, version)
+	}
+	for id, c := range components {
+		if c["project"] == projectID {
+			r.project.Components = append(r.project.Components, jira.ProjectComponent{ID: id, Name: c["name"], Description: c["description"]})
+		}
+	}
+
+	//* Issues
+	issues := make(map[string]*jira.Issue)
+	var issueIDs []string
+	for _, i := range entities["Issue"] {
+		if i["project"] != projectID {
+			continue
+		}
+
+		key := i["key"]
+		if key == "" {
+			key = fmt.Sprintf("%s-%s", r.project.Key, i["number"])
+		}
+
+		fields := &jira.IssueFields{
+			Summary:        i["summary"],
+			Description:    i["description"],
+			Environment:    i["environment"],
+			Type:           jira.IssueType{ID: i["type"], Name: issueTypes[i["type"]]["name"], Description: issueTypes[i["type"]]["description"]},
+			Status:         &jira.Status{ID: i["status"], Name: statuses[i["status"]]["name"], Description: statuses[i["status"]]["description"]},
+			Priority:       &jira.Priority{ID: i["priority"], Name: priorities[i["priority"]]["name"], Description: priorities[i["priority"]]["description"]},
+			Assignee:       getUser(i["assignee"]),
+			Reporter:       getUser(i["reporter"]),
+			Created:        jira.Time(parseEntityTime(i["created"])),
+			Updated:        jira.Time(parseEntityTime(i["updated"])),
+			Resolutiondate: jira.Time(parseEntityTime(i["resolutiondate"])),
+			Duedate:        jira.Date(parseEntityTime(i["duedate"])),
+			Comments:       &jira.Comments{},
+			Unknowns:       map[string]interface{}{},
+		}
+		if resolution, ok := resolutions[i["resolution"]]; ok {
+			fields.Resolution = &jira.Resolution{ID: i["resolution"], Name: resolution["name"], Description: resolution["description"]}
+		}
+
+		issues[i["id"]] = &jira.Issue{ID: i["id"], Key: key, Fields: fields}
+		issueIDs = append(issueIDs, i["id"])
+	}
+
+	//* Labels
+	for _, l := range entities["Label"] {
+		if issue, ok := issues[l["issue"]]; ok && l["fieldid"] == "" {
+			issue.Fields.Labels = append(issue.Fields.Labels, l["label"])
+		}
+	}
+
+	//* Components, Fix Versions
+	for _, n := range entities["NodeAssociation"] {
+		issue, ok := issues[n["sourceNodeId"]]
+		if !ok || n["sourceNodeEntity"] != "Issue" {
+			continue
+		}
+
+		switch n["associationType"] {
+		case "IssueComponent":
+			c := components[n["sinkNodeId"]]
+			issue.Fields.Components = append(issue.Fields.Components, &jira.Component{ID: n["sinkNodeId"], Name: c["name"], Description: c["description"]})
+		case "IssueFixVersion":
+			v := versions[n["sinkNodeId"]]
+			issue.Fields.FixVersions = append(issue.Fields.FixVersions, &jira.FixVersion{ID: n["sinkNodeId"], Name: v["name"]})
+		case "IssueVersion":
+			v := versions[n["sinkNodeId"]]
+			issue.Fields.AffectsVersions = append(issue.Fields.AffectsVersions, &jira.AffectsVersion{ID: n["sinkNodeId"], Name: v["name"]})
+		}
+	}
+
+	//* Custom Fields (customfield_{id})
+	for _, c := range entities["CustomFieldValue"] {
+		issue, ok := issues[c["issue"]]
+		if !ok {
+			continue
+		}
+
+		name := fmt.Sprintf("customfield_%s", c["customfield"])
+		switch {
+		case c["numbervalue"] != "":
+			var number float64
+			fmt.Sscanf(c["numbervalue"], "%g", &number)
+			issue.Fields.Unknowns[name] = number
+		case c["datevalue"] != "":
+			issue.Fields.Unknowns[name] = parseEntityTime(c["datevalue"]).Format("2006-01-02")
+		case c["textvalue"] != "":
+			issue.Fields.Unknowns[name] = c["textvalue"]
+		default:
+			issue.Fields.Unknowns[name] = c["stringvalue"]
+		}
+	}
+
+	//* Comments
+	for _, a := range entities["Action"] {
+		issue, ok := issues[a["issue"]]
+		if !ok || a["type"] != "comment" {
+			continue
+		}
+
+		author := getUser(a["author"])
+		if author == nil {
+			author = &jira.User{}
+		}
+		issue.Fields.Comments.Comments = append(issue.Fields.Comments.Comments, &jira.Comment{
+			ID:      a["id"],
+			Author:  *author,
+			Body:    a["body"],
+			Created: formatJiraTime(parseEntityTime(a["created"])),
+			Updated: formatJiraTime(parseEntityTime(a["updated"])),
+		})
+	}
+
+	//* Attachments
+	for _, a := range entities["FileAttachment"] {
+		issue, ok := issues[a["issue"]]
+		if !ok {
+			continue
+		}
+
+		issue.Fields.Attachments = append(issue.Fields.Attachments, &jira.Attachment{
+			ID:       a["id"],
+			Filename: a["filename"],
+			MimeType: a["mimetype"],
+			Size:     atoi(a["filesize"]),
+			Author:   getUser(a["author"]),
+			Created:  formatJiraTime(parseEntityTime(a["created"])),
+		})
+		r.attachmentPaths[a["id"]] = attachmentPaths(r.project.Key, issue.Key, a["id"])
+	}
+
+	//* Issue Links, Epic Links, Sub-tasks
+	for _, l := range entities["IssueLink"] {
+		source, sourceOK := issues[l["source"]]
+		destination, destinationOK := issues[l["destination"]]
+		if !sourceOK || !destinationOK {
+			continue
+		}
+
+		linkType := linkTypes[l["linktype"]]
+		switch linkType["linkname"] {
+		case epicStoryLinkType, subtaskLinkType:
+			destination.Fields.Parent = &jira.Parent{ID: source.ID, Key: source.Key}
+		default:
+			source.Fields.IssueLinks = append(source.Fields.IssueLinks, &jira.IssueLink{
+				ID:   l["id"],
+				Type: jira.IssueLinkType{ID: l["linktype"], Name: linkType["linkname"], Inward: linkType["inward"], Outward: linkType["outward"]},
+				OutwardIssue: &jira.Issue{
+					ID:     destination.ID,
+					Key:    destination.Key,
+					Fields: &jira.IssueFields{Type: destination.Fields.Type},
+				},
+			})
+		}
+	}
+
+	//* Order by key ASC
+	sort.Slice(issueIDs, func(i, j int) bool {
+		return issueNumber(issues[issueIDs[i]].Key) < issueNumber(issues[issueIDs[j]].Key)
+	})
+	for _, id := range issueIDs {
+		r.issues = append(r.issues, issues[id])
+	}
+
+	return nil
+}
+
+func issueNumber(key string) int {
+	return atoi(key[strings.LastIndex(key, "-")+1:])
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
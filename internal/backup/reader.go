@@ -0,0 +1,174 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package backup
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+//* JQL of the converters: the backup has one project, only the keys and the epic type are searched
+
+var (
+	jqlKeysPattern = regexp.MustCompile(`(?i)\bkey\s+in\s+\(([^)]*)\)`)
+	jqlKeyPattern  = regexp.MustCompile(`(?i)\bkey\s*=\s*"?([A-Z][A-Z0-9_]*-\d+)"?`)
+)
+
+// jqlKeys returns the keys of "key in (...)" or "key = ...", nil without them
+func jqlKeys(jql string) map[string]bool {
+	var keys []string
+	if match := jqlKeysPattern.FindStringSubmatch(jql); match != nil {
+		keys = strings.Split(match[1], ",")
+	} else if match := jqlKeyPattern.FindStringSubmatch(jql); match != nil {
+		keys = []string{match[1]}
+	} else {
+		return nil
+	}
+
+	result := make(map[string]bool)
+	for _, key := range keys {
+		result[strings.ToUpper(strings.Trim(strings.TrimSpace(key), `"`))] = true
+	}
+	return result
+}
+
+func (r *Reader) GetProject(ctx context.Context, projectID string) (*jira.Project, *jira.Response, error) {
+	if !strings.EqualFold(r.project.Key, projectID) && r.project.ID != projectID {
+		return nil, nil, errors.Errorf("Jira project %s is not in the backup, it has %s", projectID, r.project.Key)
+	}
+	return r.project, nil, nil
+}
+
+func (r *Reader) ListProjects(ctx context.Context) (*jira.ProjectList, error) {
+	return &jira.ProjectList{{ID: r.project.ID, Key: r.project.Key, Name: r.project.Name}}, nil
+}
+
+// SearchIssues returns the issues of the keys of the JQL, or every issue. "type = Epic" and "type != Epic" split the epics off.
+func (r *Reader) SearchIssues(ctx context.Context, jql string) ([]*jira.Issue, error) {
+	keys := jqlKeys(jql)
+	var result []*jira.Issue
+	for _, issue := range r.issues {
+		isEpic := issue.Fields.Type.Name == "Epic"
+		if strings.Contains(jql, "type != Epic") && isEpic {
+			continue
+		}
+		if strings.Contains(jql, "type = Epic") && !isEpic {
+			continue
+		}
+		if keys != nil && !keys[strings.ToUpper(issue.Key)] {
+			continue
+		}
+		result = append(result, issue)
+	}
+	return result, nil
+}
+
+func (r *Reader) SampleIssues(ctx context.Context, jql string, maxResults int) ([]*jira.Issue, error) {
+	issues, err := r.SearchIssues(ctx, jql)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) > maxResults {
+		issues = issues[:maxResults]
+	}
+	return issues, nil
+}
+
+func (r *Reader) GetIssueKey(ctx context.Context, issueKey string) (string, *jira.Response, error) {
+	for _, issue := range r.issues {
+		if strings.EqualFold(issue.Key, issueKey) {
+			return issue.Key, nil, nil
+		}
+	}
+	return "", nil, errors.Errorf("Jira issue %s is not in the backup", issueKey)
+}
+
+func (r *Reader) GetComments(ctx context.Context, issueKey string) ([]*jira.Comment, error) {
+	for _, issue := range r.issues {
+		if issue.Key == issueKey {
+			return issue.Fields.Comments.Comments, nil
+		}
+	}
+	return nil, nil
+}
+
+//* Not in a backup
+
+func (r *Reader) GetRemoteLinks(ctx context.Context, issueKey string) (*[]jira.RemoteLink, *jira.Response, error) {
+	return &[]jira.RemoteLink{}, nil, nil
+}
+
+func (r *Reader) GetDevStatusSummary(ctx context.Context, issueID string) (*jirax.DevStatusSummary, *jira.Response, error) {
+	return &jirax.DevStatusSummary{Summary: make(map[string]*jirax.DevStatusSummaryItem)}, nil, nil
+}
+
+func (r *Reader) GetDevStatus(ctx context.Context, issueID string, applicationType string, dataType string) (*jirax.DevStatus, *jira.Response, error) {
+	return &jirax.DevStatus{}, nil, nil
+}
+
+func (r *Reader) GetProjectRole(ctx context.Context, projectKey string, roleID string) (*jira.Role, *jira.Response, error) {
+	return nil, nil, errors.Errorf("Jira project role %s is not in the backup", roleID)
+}
+
+func (r *Reader) ListSprints(ctx context.Context, projectKey string) ([]jira.Sprint, error) {
+	return nil, nil
+}
+
+func (r *Reader) GetCommentParents(ctx context.Context, issueKey string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (r *Reader) GetApprovals(ctx context.Context, issueKey string) ([]*jirax.Approval, *jira.Response, error) {
+	return nil, nil, nil
+}
+
+func (r *Reader) GetTestRuns(ctx context.Context, addOn string, jiraIssue *jira.Issue) ([]*jirax.TestRun, *jira.Response, error) {
+	return nil, nil, nil
+}
+
+func (r *Reader) GetUser(ctx context.Context, username string) (*jira.User, *jira.Response, error) {
+	return nil, nil, errors.Errorf("Jira user %s is not in the backup", username)
+}
+
+func (r *Reader) DownloadProjectAvatar(ctx context.Context, project *jira.Project) (io.ReadCloser, string, error) {
+	return nil, "", errors.Errorf("Avatar of Jira project %s is not in the backup", project.Key)
+}
+
+func (r *Reader) ListProjectStatuses(ctx context.Context, projectKey string) ([]*jirax.IssueTypeStatuses, *jira.Response, error) {
+	return nil, nil, nil
+}
+
+func (r *Reader) GetCreateMeta(ctx context.Context, projectKey string) ([]*jirax.CreateMetaIssueType, *jira.Response, error) {
+	return nil, nil, nil
+}
+
+func (r *Reader) ListBoardConfigurations(ctx context.Context, projectKey string) ([]*jirax.BoardConfiguration, error) {
+	return nil, nil
+}
+
+func (r *Reader) ListFilters(ctx context.Context, projectKey string) ([]*jira.Filter, error) {
+	return nil, nil
+}
+
+func (r *Reader) ListDashboards(ctx context.Context) ([]*jirax.Dashboard, error) {
+	return nil, nil
+}
+
+func (r *Reader) ListFields(ctx context.Context) ([]jira.Field, *jira.Response, error) {
+	return nil, nil, nil
+}
@@ -0,0 +1,298 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+// Package bench measures the throughput of the migration with fakes of Jira and GitLab.
+// Every call of the fakes on the hot path is a request to an httptest server with a latency, through the concurrency controller of the real clients.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/throttle"
+)
+
+const (
+	projectKey  = "BENCH"
+	groupPath   = "bench"
+	projectPath = "bench/project"
+)
+
+type Options struct {
+	Issues      int
+	Comments    int           // per issue
+	Attachments int           // per issue
+	Latency     time.Duration // of every request to the fake APIs
+	Concurrency int           // requests in flight (concurrency.max)
+}
+
+// Result is the outcome of a bench run
+type Result struct {
+	Issues      int
+	Notes       int
+	Uploads     int
+	Requests    int64
+	MaxInFlight int64
+	Duration    time.Duration
+}
+
+// IssuesPerSecond is the throughput of the run
+func (r *Result) IssuesPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Issues) / r.Duration.Seconds()
+}
+
+// server answers every request after the latency and counts the requests in flight
+type server struct {
+	*httptest.Server
+	latency     time.Duration
+	requests    int64
+	inFlight    int64
+	maxInFlight int64
+}
+
+func newServer(latency time.Duration) *server {
+	s := &server{latency: latency}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&s.requests, 1)
+		inFlight := atomic.AddInt64(&s.inFlight, 1)
+		defer atomic.AddInt64(&s.inFlight, -1)
+		for {
+			max := atomic.LoadInt64(&s.maxInFlight)
+			if inFlight <= max || atomic.CompareAndSwapInt64(&s.maxInFlight, max, inFlight) {
+				break
+			}
+		}
+
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "{}")
+	}))
+	return s
+}
+
+// api makes the requests of a fake to the server
+type api struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func (a *api) call(ctx context.Context, method string, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, method, a.url+endpoint, nil)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating %s request", a.name))
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error making %s request", a.name))
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+//* Jira reads of each issue
+
+type benchJira struct {
+	*fake.Jira
+	api *api
+}
+
+func (j *benchJira) SearchIssues(ctx context.Context, jql string) ([]*jira.Issue, error) {
+	if err := j.api.call(ctx, http.MethodGet, "/rest/api/2/search"); err != nil {
+		return nil, err
+	}
+	return j.Jira.SearchIssues(ctx, jql)
+}
+
+func (j *benchJira) GetComments(ctx context.Context, issueKey string) ([]*jira.Comment, error) {
+	if err := j.api.call(ctx, http.MethodGet, "/rest/api/2/issue/"+issueKey+"/comment"); err != nil {
+		return nil, err
+	}
+	return j.Jira.GetComments(ctx, issueKey)
+}
+
+func (j *benchJira) GetRemoteLinks(ctx context.Context, issueKey string) (*[]jira.RemoteLink, *jira.Response, error) {
+	if err := j.api.call(ctx, http.MethodGet, "/rest/api/2/issue/"+issueKey+"/remotelink"); err != nil {
+		return nil, nil, err
+	}
+	return j.Jira.GetRemoteLinks(ctx, issueKey)
+}
+
+func (j *benchJira) DownloadAttachment(ctx context.Context, attachmentID string) (io.ReadCloser, error) {
+	if err := j.api.call(ctx, http.MethodGet, "/secure/attachment/"+attachmentID); err != nil {
+		return nil, err
+	}
+	return j.Jira.DownloadAttachment(ctx, attachmentID)
+}
+
+//* GitLab writes of each issue
+
+type benchGitLab struct {
+	*fake.GitLab
+	api *api
+}
+
+func (g *benchGitLab) CreateIssue(ctx context.Context, pid interface{}, opt *gitlabx.CreateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	if err := g.api.call(ctx, http.MethodPost, projectEndpoint(pid, "/issues")); err != nil {
+		return nil, nil, err
+	}
+	return g.GitLab.CreateIssue(ctx, pid, opt)
+}
+
+func (g *benchGitLab) UpdateIssue(ctx context.Context, pid interface{}, issue int, opt *gitlab.UpdateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	if err := g.api.call(ctx, http.MethodPut, projectEndpoint(pid, fmt.Sprintf("/issues/%d", issue))); err != nil {
+		return nil, nil, err
+	}
+	return g.GitLab.UpdateIssue(ctx, pid, issue, opt)
+}
+
+func (g *benchGitLab) CreateIssueNote(ctx context.Context, pid interface{}, issue int, opt *gitlabx.CreateIssueNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	if err := g.api.call(ctx, http.MethodPost, projectEndpoint(pid, fmt.Sprintf("/issues/%d/notes", issue))); err != nil {
+		return nil, nil, err
+	}
+	return g.GitLab.CreateIssueNote(ctx, pid, issue, opt)
+}
+
+func (g *benchGitLab) UploadFile(ctx context.Context, pid interface{}, content io.Reader, filename string) (*gitlab.ProjectFile, *gitlab.Response, error) {
+	if err := g.api.call(ctx, http.MethodPost, projectEndpoint(pid, "/uploads")); err != nil {
+		return nil, nil, err
+	}
+	return g.GitLab.UploadFile(ctx, pid, content, filename)
+}
+
+func (g *benchGitLab) CreateLabel(ctx context.Context, pid interface{}, opt *gitlab.CreateLabelOptions) (*gitlab.Label, *gitlab.Response, error) {
+	if err := g.api.call(ctx, http.MethodPost, projectEndpoint(pid, "/labels")); err != nil {
+		return nil, nil, err
+	}
+	return g.GitLab.CreateLabel(ctx, pid, opt)
+}
+
+// projectEndpoint is the REST path of the project, e.g. /api/v4/projects/bench%2Fproject/issues
+func projectEndpoint(pid interface{}, path string) string {
+	return "/api/v4/projects/" + url.PathEscape(fmt.Sprint(pid)) + path
+}
+
+// newIssues generates the Jira issues with their comments and attachments
+func newIssues(opts *Options, jr *fake.Jira) []*jira.Issue {
+	issues := make([]*jira.Issue, 0, opts.Issues)
+	for i := 1; i <= opts.Issues; i++ {
+		key := fmt.Sprintf("%s-%d", projectKey, i)
+		issue := &jira.Issue{
+			ID:  fmt.Sprintf("%d", 10000+i),
+			Key: key,
+			Fields: &jira.IssueFields{
+				Summary:     fmt.Sprintf("Issue %d", i),
+				Description: fmt.Sprintf("Description of *issue %d*", i),
+				Type:        jira.IssueType{Name: "Task"},
+				Status:      &jira.Status{Name: "To Do"},
+				Priority:    &jira.Priority{Name: "Medium"},
+				Comments:    &jira.Comments{},
+			},
+		}
+		for c := 1; c <= opts.Comments; c++ {
+			issue.Fields.Comments.Comments = append(issue.Fields.Comments.Comments, &jira.Comment{
+				ID:      fmt.Sprintf("%d%03d", i, c),
+				Body:    fmt.Sprintf("Comment %d", c),
+				Author:  jira.User{DisplayName: "Bench"},
+				Created: "2023-09-06T11:00:00.000+0900",
+			})
+		}
+		for a := 1; a <= opts.Attachments; a++ {
+			id := fmt.Sprintf("%d%03d", i, a)
+			issue.Fields.Attachments = append(issue.Fields.Attachments, &jira.Attachment{ID: id, Filename: fmt.Sprintf("file-%s.txt", id), Created: "2023-09-06T10:00:00.000+0900"})
+			jr.Attachments[id] = []byte(id)
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// Run migrates the generated issues into the fake GitLab and measures it. The loaded config is replaced during the run.
+func Run(ctx context.Context, opts *Options) (*Result, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 20
+	}
+
+	cfg := &config.Config{}
+	cfg.Jira.Host = "https://jira.example.com"
+	cfg.Jira.Name = projectKey
+	cfg.GitLab.Host = "https://gitlab.example.com"
+	cfg.GitLab.Issue = projectPath
+	cfg.GitLab.Epic = groupPath
+	cfg.GitLab.LabelLevel = config.LabelLevelAuto
+	cfg.AllowNonEmpty = true
+	cfg.Concurrency.Min = 1
+	cfg.Concurrency.Max = opts.Concurrency
+	config.SetConfig(cfg)
+	defer config.SetConfig(nil)
+
+	srv := newServer(opts.Latency)
+	defer srv.Close()
+
+	//* Jira and GitLab share the controller like the real clients
+	controller := throttle.New(cfg.Concurrency.Min, cfg.Concurrency.Max)
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = opts.Concurrency
+	newAPI := func(name string) *api {
+		return &api{name: name, url: srv.URL, client: &http.Client{Transport: throttle.NewTransport(name, controller, stats.NewTransport(name, transport))}}
+	}
+
+	fakeJira := fake.NewJira(&jira.Project{ID: "10000", Key: projectKey, Name: "Bench"})
+	fakeJira.Issues = newIssues(opts, fakeJira)
+	jr := &benchJira{fakeJira, newAPI("Jira")}
+
+	fakeGitLab := fake.NewGitLab()
+	fakeGitLab.AddGroup(1, groupPath)
+	fakeGitLab.AddProject(2, projectPath)
+	gl := &benchGitLab{fakeGitLab, newAPI("GitLab")}
+
+	start := time.Now()
+	if err := j2g.ConvertByProject(ctx, gl, jr); err != nil {
+		return nil, errors.Wrap(err, "Error running the bench migration")
+	}
+
+	result := &Result{
+		Duration:    time.Since(start),
+		Requests:    atomic.LoadInt64(&srv.requests),
+		MaxInFlight: atomic.LoadInt64(&srv.maxInFlight),
+	}
+	for _, issues := range fakeGitLab.Issues {
+		result.Issues += len(issues)
+	}
+	for _, notes := range fakeGitLab.IssueNotes {
+		result.Notes += len(notes)
+	}
+	for _, uploads := range fakeGitLab.Uploads {
+		result.Uploads += len(uploads)
+	}
+	return result, nil
+}
@@ -13,10 +13,13 @@ package config
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/pkg/errors"
@@ -25,6 +28,8 @@ import (
 	"golang.org/x/text/language"
 
 	"github.com/spf13/viper"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/secret"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
 )
 
 // Config is the struct for the config file
@@ -34,26 +39,485 @@ import (
 
 type Config struct {
 	Jira struct {
-		Host        string `yaml:"host" validate:"required,url"`
-		Token       string `yaml:"token" validate:"required"`
-		Name        string `yaml:"name" validate:"required"`
-		Jql         string `yaml:"jql"`
-		CustomField struct {
+		Host  string `yaml:"host" validate:"required,url"`
+		Token string `yaml:"token" validate:"required_without=Backup"`
+		Name  string `yaml:"name" validate:"required"`
+		Jql   string `yaml:"jql"`
+
+		// REST API version of the issue search, detected from the server info if it is empty: 3 (ADF text) on Jira Cloud, 2 (wiki markup) on Jira Server and Data Center
+		APIVersion string `yaml:"api_version" validate:"omitempty,oneof=2 3" mapstructure:"api_version"`
+
+		//* Extra headers and cookies of every request, e.g. for an SSO gateway in front of Jira
+		Headers map[string]string `yaml:"headers"`
+		Cookies []string          `yaml:"cookies"` // name=value
+
+		// The responses of jira.cache younger than it are used without asking Jira and every GET is cached, e.g. 1h for the repeated dry-runs (run --export or --diff).
+		// A migration reading the changes of Jira should not use it.
+		CacheMaxAge time.Duration `yaml:"cache_max_age" mapstructure:"cache_max_age"`
+
+		//* Jira backup instead of the Jira API
+		Backup            string `yaml:"backup"`                                               // entities.xml or CSV export
+		BackupAttachments string `yaml:"backup_attachments" mapstructure:"backup_attachments"` // data/attachments of the Jira home
+		ServiceDesk       bool   `yaml:"service_desk" mapstructure:"service_desk"`             // JSM internal comments become internal notes, the approvals a comment
+		Cache             string `yaml:"cache"`                                                // directory of the responses revalidated with ETag and Last-Modified on the next run
+		CustomField       struct {
 			StoryPoint    string `yaml:"story_point" mapstructure:"story_point"`
 			EpicStartDate string `yaml:"epic_start_date" mapstructure:"epic_start_date"`
 			ParentEpic    string `yaml:"parent_epic" mapstructure:"parent_epic"`
+			Sprint        string `yaml:"sprint" mapstructure:"sprint"`   // sprints become milestones
+			Rank          string `yaml:"rank" mapstructure:"rank"`       // issues are reordered by the Jira rank
+			Flagged       string `yaml:"flagged" mapstructure:"flagged"` // flagged issues get the blocked label
+			EpicName      string `yaml:"epic_name" mapstructure:"epic_name"`
 		} `yaml:"custom_field" mapstructure:"custom_field"`
+		//* Epics are the issues of the epic issue types or with a value of the epic field
+		Epic struct {
+			IssueTypes []string `yaml:"issue_types" mapstructure:"issue_types"` // names or IDs (e.g. 에픽 of a localized Jira), Epic if it is empty
+			Field      string   `yaml:"field"`                                  // custom field ID (e.g. the Epic Name)
+		} `yaml:"epic"`
+		//* Test issues (e.g. Xray, Zephyr) -> GitLab test cases (Ultimate), disabled without an issue type
+		TestCase struct {
+			IssueTypes []string `yaml:"issue_types" mapstructure:"issue_types"`         // names or IDs, e.g. Test
+			Steps      string   `yaml:"steps"`                                          // custom field ID of the steps (e.g. Xray Manual Test Steps), a checklist in the description
+			Results    string   `yaml:"results" validate:"omitempty,oneof=xray zephyr"` // table of the test runs at the end of the description
+		} `yaml:"test_case" mapstructure:"test_case"`
+		DevStatus struct {
+			Enabled          bool     `yaml:"enabled"`
+			ApplicationTypes []string `yaml:"application_types" validate:"required_with=Enabled" mapstructure:"application_types"` // stash, github, gitlab, ...
+		} `yaml:"dev_status" mapstructure:"dev_status"`
 	} `yaml:"jira"`
 	GitLab struct {
 		Host  string `yaml:"host" validate:"required,url"`
 		Token string `yaml:"token" validate:"required"`
-		Issue string `yaml:"issue" validate:"required" mapstructure:"issue"`
-		Epic  string `yaml:"epic" validate:"required" mapstructure:"epic"`
+
+		//* Tokens used in turn with the token, for a GitLab limiting the requests per token
+		Tokens    []string `yaml:"tokens"`
+		TokenRate float64  `yaml:"token_rate" validate:"omitempty,min=0" mapstructure:"token_rate"` // requests per second of each token, unlimited if it is 0
+
+		Headers map[string]string `yaml:"headers"`
+		Cookies []string          `yaml:"cookies"` // name=value
+
+		Issue string `yaml:"issue" validate:"required" mapstructure:"issue"` // full path or ID of the project
+		Epic  string `yaml:"epic" validate:"required" mapstructure:"epic"`   // full path or ID of the group
+
+		//* IDs of the project and the group, resolved at the start of a run. The paths above become the full paths.
+		IssueID int `yaml:"-" mapstructure:"-"`
+		EpicID  int `yaml:"-" mapstructure:"-"`
+
+		LabelLevel string `yaml:"label_level" validate:"omitempty,oneof=auto group project" mapstructure:"label_level"`
+		EpicMode   string `yaml:"epic_mode" validate:"omitempty,oneof=auto epic issue csv" mapstructure:"epic_mode"`
+		EpicTitle  string `yaml:"epic_title" validate:"omitempty,oneof=summary epic_name" mapstructure:"epic_title"` // the other one is on top of the description
+
+		MilestoneLevel string `yaml:"milestone_level" validate:"omitempty,oneof=project group sprints" mapstructure:"milestone_level"` // project if it is empty
+
+		EpicDatesFrom string `yaml:"epic_dates_from" validate:"omitempty,oneof=fixed children milestones" mapstructure:"epic_dates_from"` // when the epic has no start or due date
+
+		ReversedDates string `yaml:"reversed_dates" validate:"omitempty,oneof=swap drop" mapstructure:"reversed_dates"` // start date after the due date of an epic, milestone or iteration, swap if it is empty
+
+		EpicAttachments  string `yaml:"epic_attachments" validate:"omitempty,oneof=project group_wiki" mapstructure:"epic_attachments"`
+		EpicChildrenNote bool   `yaml:"epic_children_note" mapstructure:"epic_children_note"` // a comment on each epic with the checklist of its migrated child issues
+
+		// Each epic is mirrored as an issue of gitlab.issue with the "epic overview" label, for the users who can't view the group epics.
+		// The overview issues are updated by the reruns and the sync, GitLab has no API to pin them.
+		EpicOverview bool `yaml:"epic_overview" mapstructure:"epic_overview"`
+
+		// Epic notes have no created_at, they are created in the order of the Jira comments. In parallel they are faster but out of order.
+		ParallelEpicNotes bool `yaml:"parallel_epic_notes" mapstructure:"parallel_epic_notes"`
+
+		InheritEpicLabels bool `yaml:"inherit_epic_labels" mapstructure:"inherit_epic_labels"` // the issues of an epic get its scoped labels (e.g. team::payments) of a scope they don't have
+
+		ThreadedComments bool `yaml:"threaded_comments" mapstructure:"threaded_comments"` // the replies of Jira Cloud comments are added to the discussion of their parent on issues
+
+		MaxNoteSize int `yaml:"max_note_size" validate:"omitempty,gte=1000" mapstructure:"max_note_size"` // characters, a longer comment is split into notes. GitLab's limit (DefaultMaxNoteSize) if it is 0.
+
+		//* Jira sprints -> iterations of the cadence instead of milestones (jira.custom_field.sprint is required)
+		Iterations struct {
+			Enabled bool   `yaml:"enabled"`
+			Cadence string `yaml:"cadence"` // title, created if it doesn't exist
+		} `yaml:"iterations"`
 	} `yaml:"gitlab"`
 
+	ProjectRoles struct {
+		Target       string            `yaml:"target" validate:"omitempty,oneof=project group"`
+		AccessLevels map[string]string `yaml:"access_levels" mapstructure:"access_levels"` // Jira Project Role -> guest, reporter, developer, maintainer, owner
+	} `yaml:"project_roles" mapstructure:"project_roles"`
+
+	ProjectAvatar bool `yaml:"project_avatar" mapstructure:"project_avatar"` // the Jira project avatar becomes the GitLab project avatar
+
+	//* Wiki page of the Jira project (description, components, versions and workflows) as an archival reference
+	Wiki struct {
+		Enabled bool   `yaml:"enabled"`
+		Title   string `yaml:"title"` // "Jira project <KEY>" if it is empty
+	} `yaml:"wiki"`
+
+	//* Jira boards -> GitLab issue boards with a list of the status label of each column and its WIP limit (Premium)
+	Boards struct {
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"boards"`
+
+	// Markdown pages of the Jira features without a GitLab equivalent are written to the directory, e.g. filters.md with a GitLab search of each saved filter and dashboards.md
+	ReportDir string `yaml:"report_dir" mapstructure:"report_dir"`
+
+	//* .gitlab/issue_templates from the create screens of the Jira issue types, existing templates are kept
+	IssueTemplates struct {
+		Enabled bool   `yaml:"enabled"`
+		Branch  string `yaml:"branch"` // the default branch of the project if it is empty
+	} `yaml:"issue_templates" mapstructure:"issue_templates"`
+
+	//* Customers of a Jira custom field -> GitLab CRM contacts of the issues, the CRM of gitlab.epic must be enabled.
+	// A customer is the email of a contact or the name of an organization (all of its contacts) unless it is mapped.
+	CRM struct {
+		Field         string            `yaml:"field"`         // custom field ID, e.g. a select list of the customers
+		Contacts      map[string]string `yaml:"contacts"`      // customer -> email of a contact
+		Organizations map[string]string `yaml:"organizations"` // customer -> name of an organization
+	} `yaml:"crm"`
+
+	//* Jira priority and severity field -> GitLab severity (S1-S4) with the first matching rule of the matrix.
+	// The issues get a severity:: label, the issues of the incident types become GitLab incidents with the severity.
+	Severity struct {
+		Field      string         `yaml:"field"`                                  // custom field ID, e.g. a select list of the severities
+		IssueTypes []string       `yaml:"issue_types" mapstructure:"issue_types"` // names or IDs of the Jira issue types migrated as incidents
+		Matrix     []SeverityRule `yaml:"matrix" validate:"dive"`
+	} `yaml:"severity"`
+
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"` // wait for the response of a Jira or GitLab request (e.g. 60s), not for the transfer of an attachment
+
+	//* Requests in flight to Jira and GitLab, halved on 429 or 5xx and increased again on success
+	Concurrency struct {
+		Min int `yaml:"min" validate:"omitempty,min=1"`
+		Max int `yaml:"max" validate:"omitempty,min=1,gtefield=Min"`
+	} `yaml:"concurrency"`
+
+	//* HTTP transport shared by the Jira and GitLab clients
+	Transport struct {
+		MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host" validate:"omitempty,min=1" mapstructure:"max_idle_conns_per_host"` // concurrency.max if it is empty
+		IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout" mapstructure:"idle_conn_timeout"`
+		DisableHTTP2        bool          `yaml:"disable_http2" mapstructure:"disable_http2"` // e.g. a proxy breaking HTTP/2
+	} `yaml:"transport"`
+
+	AuditLog string `yaml:"audit_log" mapstructure:"audit_log"` // JSONL file of every write request to GitLab, appended
+
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" mapstructure:"shutdown_timeout"` // wait for in-flight work on Ctrl-C
+
+	ProgressInterval time.Duration `yaml:"progress_interval" mapstructure:"progress_interval"` // info log of the migrated epics and issues, disabled if it is negative
+
+	//* Attachments are buffered on disk between the Jira download and the GitLab upload, in memory without it
+	Scratch struct {
+		Dir   string `yaml:"dir"`                                           // a directory per run, removed at the end or by the next run after a crash
+		MaxMB int    `yaml:"max_mb" validate:"gte=0" mapstructure:"max_mb"` // maximum usage, the transfers wait for space
+	} `yaml:"scratch"`
+
+	//* Prefetch of the Jira issues for a slow Jira: the remote links, comments and attachments are downloaded ahead of the conversion,
+	// the GitLab writes don't wait for Jira. The prefetched issues past prefetch.memory spill over to disk (scratch.dir, the temp directory without it).
+	Prefetch struct {
+		Workers int `yaml:"workers" validate:"gte=0"` // concurrent Jira downloads, disabled if it is 0
+		Ahead   int `yaml:"ahead" validate:"gte=0"`   // prefetched issues waiting for the conversion, it bounds the disk usage (DefaultPrefetchAhead)
+		Memory  int `yaml:"memory" validate:"gte=0"`  // prefetched issues kept in memory (DefaultPrefetchMemory)
+	} `yaml:"prefetch"`
+
+	StateFile string `yaml:"state_file" mapstructure:"state_file"`
+
+	// State files of the migrations of the other Jira projects, parents and links to their issues and epics are resolved through them
+	LinkedStateFiles []string `yaml:"linked_state_files" mapstructure:"linked_state_files"`
+
+	// Failed epics and issues are written to the file with their error and the run goes on, retry-failed retries them. The first failure aborts the run if it is empty.
+	Quarantine string `yaml:"quarantine" mapstructure:"quarantine"`
+
+	//* Site migration (site run): a GitLab project per Jira project under the group gitlab.epic, jira.name and gitlab.issue are replaced for each project
+	Site struct {
+		Category    string   `yaml:"category"`                                 // Jira project category, every Jira project if it is empty
+		Exclude     []string `yaml:"exclude"`                                  // Jira project keys
+		ProjectPath string   `yaml:"project_path" mapstructure:"project_path"` // template of the project path under gitlab.epic (DefaultSiteProjectPath), the epics go to the group of the project
+		StateDir    string   `yaml:"state_dir" mapstructure:"state_dir"`       // a state file per Jira project (DefaultSiteStateDir)
+	} `yaml:"site"`
+
+	//* Routing of the Jira issues and epics to other GitLab targets, the first matching route wins.
+	// The issues of no route go to gitlab.issue and gitlab.epic. Each route has the state file <state_file>.route<N>.
+	Routes []Route `yaml:"routes" validate:"dive"`
+
+	// Route of a run of the routes: index + 1 in routes, len(routes) + 1 for the issues of no route. The routes run one by one if it is 0.
+	RouteIndex int `yaml:"-" mapstructure:"-"`
+
+	// Phase of run --only, the other phases are read from the state file. Every phase runs if it is empty.
+	Only string `yaml:"-" validate:"omitempty,oneof=epics issues links" mapstructure:"-"`
+
+	// run --allow-non-empty migrates into a GitLab project or group that already has issues or epics
+	AllowNonEmpty bool `yaml:"-" mapstructure:"-"`
+
+	// Items of the plan of apply by Jira key, the other Jira issues and epics are not migrated. Every issue is migrated if it is nil.
+	Plan map[string]*PlannedItem `yaml:"-" mapstructure:"-"`
+
+	RewriteRules []RewriteRule `yaml:"rewrite_rules" validate:"dive" mapstructure:"rewrite_rules"`
+
+	// Secrets, internal hostnames or PII scrubbed from the migrated descriptions, comments and raw JSON, e.g. for a GitLab with a wider visibility
+	Redactions []RedactionRule `yaml:"redactions" validate:"dive"`
+
+	RestrictedComments string `yaml:"restricted_comments" validate:"omitempty,oneof=internal skip placeholder public" mapstructure:"restricted_comments"`
+
+	OrphanAttachments string `yaml:"orphan_attachments" validate:"omitempty,oneof=batch separate" mapstructure:"orphan_attachments"` // attachments not used in the description or comments
+
+	AttachmentSourceTitle bool `yaml:"attachment_source_title" mapstructure:"attachment_source_title"` // the migrated images have the URL of the Jira attachment as title, e.g. to trace them back during the verification
+
+	RawJSON string `yaml:"raw_json" validate:"omitempty,oneof=attachment details" mapstructure:"raw_json"` // the original Jira issue on the GitLab issue, nothing is lost even if it is not mapped
+
+	//* Incremental sync of the migrated issues (run --sync)
+	Sync struct {
+		ConflictPolicy string `yaml:"conflict_policy" validate:"omitempty,oneof=jira-wins gitlab-wins skip-and-report" mapstructure:"conflict_policy"`
+	} `yaml:"sync"`
+
+	//* "Jira metadata" table on top of the descriptions
+	MetadataTable struct {
+		Enabled  bool   `yaml:"enabled"`
+		Template string `yaml:"template"` // Go text/template, the default table if it is empty
+	} `yaml:"metadata_table" mapstructure:"metadata_table"`
+
+	//* Header and footer of every description, Go text/template of the Jira issue
+	Description struct {
+		Header string `yaml:"header"`
+		Footer string `yaml:"footer"`
+	} `yaml:"description" mapstructure:"description"`
+
+	//* Jira Environment field
+	Environment struct {
+		Mode       string            `yaml:"mode" validate:"omitempty,oneof=section labels both none"`
+		LabelRules []EnvironmentRule `yaml:"label_rules" validate:"dive" mapstructure:"label_rules"`
+	} `yaml:"environment"`
+
+	//* Jira worklogs, the authors are mentioned if they are in users
+	Worklogs struct {
+		Mode string `yaml:"mode" validate:"omitempty,oneof=spend summary none"`
+	} `yaml:"worklogs"`
+
+	//* Jira users without a GitLab user in users
+	UnmappedUsers struct {
+		Allow    bool   `yaml:"allow"`    // migrate instead of failing, the issues are not assigned
+		Template string `yaml:"template"` // Go text/template of the Jira user for the mentions, **{{.DisplayName}} (Jira)** if it is empty
+	} `yaml:"unmapped_users" mapstructure:"unmapped_users"`
+
+	//* PII minimization (e.g. GDPR, an external GitLab), the Jira users become placeholders without their names and emails
+	Anonymize struct {
+		Enabled bool     `yaml:"enabled"`
+		Allow   []string `yaml:"allow"` // Jira usernames or account IDs migrated as usual, e.g. the maintainers known on the GitLab
+	} `yaml:"anonymize"`
+
 	Users map[string]int `yaml:"users" validate:"required" mapstructure:"users"`
+
+	// Jira username -> GitLab username of user.csv, resolved to the IDs of users at the start of a run
+	UserNames map[string]string `yaml:"-" mapstructure:"-"`
+}
+
+// Route sends the Jira issues and epics matching every condition to Project and Epic, a route without conditions matches every issue
+type Route struct {
+	Components []string `yaml:"components"`                             // any of the components
+	IssueTypes []string `yaml:"issue_types" mapstructure:"issue_types"` // names or IDs
+	Labels     []string `yaml:"labels"`                                 // any of the labels
+
+	Project string `yaml:"project" validate:"required"` // GitLab project path or ID of the issues
+	Epic    string `yaml:"epic"`                        // GitLab group path or ID of the epics, gitlab.epic if it is empty
+}
+
+// PlannedItem is the Jira issue or epic of a reviewed plan (apply), the title and labels override the converted ones
+type PlannedItem struct {
+	Title  string
+	Labels []string // every label of the GitLab item
+}
+
+// EnvironmentRule adds Label when Pattern (regex) matches the Jira Environment field
+// The label can use the groups of the pattern (e.g. os::$1)
+type EnvironmentRule struct {
+	Pattern string `yaml:"pattern" validate:"required"`
+	Label   string `yaml:"label" validate:"required"`
+}
+
+// RewriteRule replaces every match of Pattern (regex) with Replacement on the migrated bodies
+// e.g. Confluence page links -> GitLab Wiki links
+type RewriteRule struct {
+	Pattern     string `yaml:"pattern" validate:"required"`
+	Replacement string `yaml:"replacement"`
+}
+
+// DefaultRedaction replaces the matches of a redaction rule without a replacement
+const DefaultRedaction = "[REDACTED]"
+
+// RedactionRule replaces every match of Pattern (regex) with Replacement (DefaultRedaction if it is empty).
+// Unlike the rewrite rules, it applies to the Jira text before the conversion too, a secret split by the markup is still scrubbed.
+type RedactionRule struct {
+	Pattern     string `yaml:"pattern" validate:"required"`
+	Replacement string `yaml:"replacement"`
 }
 
+// SeverityRule matches the Jira issues of any of the priorities and any of the values of the severity field, an empty list matches every issue
+type SeverityRule struct {
+	Priorities []string `yaml:"priorities"`
+	Severities []string `yaml:"severities"`
+	Severity   string   `yaml:"severity" validate:"required,oneof=S1 S2 S3 S4"`
+}
+
+const (
+	ProjectRolesTargetProject = "project"
+	ProjectRolesTargetGroup   = "group"
+)
+
+// How the Jira epics are migrated (gitlab.epic_mode)
+// - auto: epic if the GitLab tier supports epics (Premium), otherwise issue
+// - epic: GitLab epics, the run fails before writing anything if the GitLab tier has no epics
+// - issue: GitLab issues with the epic label and a task list of the child issues, the children are related to them
+// - csv: epics.csv for the GitLab CSV import, the children are not linked
+const (
+	EpicModeAuto  = "auto"
+	EpicModeEpic  = "epic"
+	EpicModeIssue = "issue"
+	EpicModeCSV   = "csv"
+)
+
+// DefaultEpicIssueType is the issue type of the Jira epics (jira.epic.issue_types)
+const DefaultEpicIssueType = "Epic"
+
+// Title of the migrated epics (gitlab.epic_title), jira.custom_field.epic_name is required for both
+// - summary: the summary, the Epic Name is on top of the description
+// - epic_name: the Epic Name, the summary is on top of the description
+const (
+	EpicTitleSummary  = "summary"
+	EpicTitleEpicName = "epic_name"
+)
+
+// Start and due date of the epics without the Jira dates (gitlab.epic_dates_from)
+// - fixed: only the Jira dates are fixed, the others are left to GitLab
+// - children: fixed from the creation and due dates of the child issues
+// - milestones: inherited from the milestones (sprints) of the child issues, the roadmap follows the sprints
+const (
+	EpicDatesFixed      = "fixed"
+	EpicDatesChildren   = "children"
+	EpicDatesMilestones = "milestones"
+)
+
+// Start date after the due date of an epic, milestone or iteration (gitlab.reversed_dates), GitLab rejects them
+// - swap: the start date is the due date and the other way around
+// - drop: both dates are left out
+const (
+	ReversedDatesSwap = "swap"
+	ReversedDatesDrop = "drop"
+)
+
+// Where the attachments of the epics are uploaded (gitlab.epic_attachments), epics don't have uploads of their own
+// - project: the uploads of the gitlab.issue project
+// - group_wiki: the wiki of the gitlab.epic group, the epics don't depend on the uploads of a project
+const (
+	EpicAttachmentsProject   = "project"
+	EpicAttachmentsGroupWiki = "group_wiki"
+)
+
+// Add-on of the test runs of the test cases (jira.test_case.results)
+// - xray: the test runs of the test executions of Xray Server/DC
+// - zephyr: the executions of the test cycles of Zephyr Squad
+const (
+	TestResultsXray   = "xray"
+	TestResultsZephyr = "zephyr"
+)
+
+// DefaultMaxNoteSize is the maximum length of a GitLab note (gitlab.max_note_size)
+const DefaultMaxNoteSize = 1000000
+
+// Site migration (site): the GitLab project of a Jira project under gitlab.epic and the directory of the state files.
+// The template has .Key, .Name and .Category of the Jira project and the functions lower and slug.
+const (
+	DefaultSiteProjectPath = `{{with .Category}}{{slug .}}/{{end}}{{lower .Key}}`
+	DefaultSiteStateDir    = "site-state"
+)
+
+// DefaultScratchMaxMB is the maximum usage of the scratch directory (scratch.max_mb)
+const DefaultScratchMaxMB = 1024
+
+const (
+	DefaultPrefetchAhead  = 100 // prefetch.ahead
+	DefaultPrefetchMemory = 10  // prefetch.memory
+)
+
+// Phases of a migration (run --only), e.g. to review the epics before the issues are created
+// - epics: the epics (and the epics migrated as issues) are created
+// - issues: the issues are created
+// - links: the parents, issue links, ranks and epic dates of the migrated items
+const (
+	PhaseEpics  = "epics"
+	PhaseIssues = "issues"
+	PhaseLinks  = "links"
+)
+
+// Jira comments restricted to a role or group (restricted_comments)
+// - internal: GitLab internal notes, visible to Reporter and above
+// - skip: not migrated
+// - placeholder: a note with the link to the Jira comment instead of the body
+// - public: migrated as public notes
+const (
+	RestrictedCommentsInternal    = "internal"
+	RestrictedCommentsSkip        = "skip"
+	RestrictedCommentsPlaceholder = "placeholder"
+	RestrictedCommentsPublic      = "public"
+)
+
+// How the Jira Environment field is migrated (environment.mode)
+// - section: an "Environment" section at the end of the description
+// - labels: labels of the matching environment.label_rules
+// - both: the section and the labels
+// - none: not migrated
+const (
+	EnvironmentSection = "section"
+	EnvironmentLabels  = "labels"
+	EnvironmentBoth    = "both"
+	EnvironmentNone    = "none"
+)
+
+// How the Jira worklogs are migrated (worklogs.mode)
+// - spend: a note with the /spend quick action per worklog, on the date of the worklog
+// - summary: a single note with a table of the worklogs and the /spend of the total
+// - none: not migrated
+const (
+	WorklogsSpend   = "spend"
+	WorklogsSummary = "summary"
+	WorklogsNone    = "none"
+)
+
+// Notes of the attachments which are not used in the description or comments (orphan_attachments)
+// - batch: a single "Migrated attachments" note with a list of the attachments
+// - separate: a note per attachment
+const (
+	OrphanAttachmentsBatch    = "batch"
+	OrphanAttachmentsSeparate = "separate"
+)
+
+// The original JSON of the Jira issue on the migrated issue (raw_json), not added if it is empty
+// - attachment: a note with the uploaded KEY.json
+// - details: a note with the JSON in a collapsed <details> block, uploaded if it is longer than a note
+const (
+	RawJSONAttachment = "attachment"
+	RawJSONDetails    = "details"
+)
+
+// Field changed in both Jira and GitLab since the last sync (sync.conflict_policy)
+// - jira-wins: the Jira value is written to GitLab
+// - gitlab-wins: the GitLab value is kept
+// - skip-and-report: the field is not synced and the conflict is reported
+const (
+	ConflictPolicyJiraWins      = "jira-wins"
+	ConflictPolicyGitLabWins    = "gitlab-wins"
+	ConflictPolicySkipAndReport = "skip-and-report"
+)
+
+const (
+	LabelLevelAuto    = "auto"
+	LabelLevelGroup   = "group"
+	LabelLevelProject = "project"
+)
+
+// Where the milestones of the Jira versions and sprints are created (gitlab.milestone_level)
+// - project: milestones of gitlab.issue
+// - group: milestones of gitlab.epic, shared by name with the other Jira projects migrated to the group
+// - sprints: the sprints (e.g. of a board across Jira projects) are group milestones, the versions project milestones
+const (
+	MilestoneLevelProject = "project"
+	MilestoneLevelGroup   = "group"
+	MilestoneLevelSprints = "sprints"
+)
+
 var cfg *Config
 
 func capitalizeJiraProject(cfg *Config) {
@@ -62,18 +526,195 @@ func capitalizeJiraProject(cfg *Config) {
 	cfg.Jira.Name = caser.String(jiraProjectID)
 }
 
+// SetConfig replaces the loaded config (e.g. in tests)
+func SetConfig(c *Config) {
+	cfg = c
+}
+
 func GetConfig() (*Config, error) {
 	if cfg != nil {
 		return cfg, nil
 	}
 
-	err := InitConfig()
+	var err error
+	cfg, err = readConfig()
 	if err != nil {
-		return nil, errors.Wrap(err, "Error initializing config")
+		return nil, err
 	}
 
-	err = viper.Unmarshal(&cfg)
+	cfg.Users, cfg.UserNames, err = parseUserCSVs()
 	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing user.csv")
+	}
+
+	capitalizeJiraProject(cfg)
+
+	if cfg.GitLab.LabelLevel == "" {
+		cfg.GitLab.LabelLevel = LabelLevelAuto
+	}
+
+	if cfg.GitLab.EpicMode == "" {
+		cfg.GitLab.EpicMode = EpicModeAuto
+	}
+
+	if len(cfg.Jira.Epic.IssueTypes) == 0 {
+		cfg.Jira.Epic.IssueTypes = []string{DefaultEpicIssueType}
+	}
+
+	if cfg.GitLab.EpicTitle == "" {
+		cfg.GitLab.EpicTitle = EpicTitleSummary
+	}
+
+	if cfg.GitLab.ReversedDates == "" {
+		cfg.GitLab.ReversedDates = ReversedDatesSwap
+	}
+
+	if cfg.GitLab.EpicDatesFrom == "" {
+		cfg.GitLab.EpicDatesFrom = EpicDatesFixed
+	}
+
+	if cfg.GitLab.EpicAttachments == "" {
+		cfg.GitLab.EpicAttachments = EpicAttachmentsProject
+	}
+
+	if cfg.GitLab.Iterations.Enabled && cfg.GitLab.Iterations.Cadence == "" {
+		cfg.GitLab.Iterations.Cadence = "Jira sprints"
+	}
+
+	if cfg.RestrictedComments == "" {
+		cfg.RestrictedComments = RestrictedCommentsInternal
+	}
+
+	if cfg.Worklogs.Mode == "" {
+		cfg.Worklogs.Mode = WorklogsNone
+	}
+
+	if cfg.OrphanAttachments == "" {
+		cfg.OrphanAttachments = OrphanAttachmentsBatch
+	}
+
+	if cfg.Sync.ConflictPolicy == "" {
+		cfg.Sync.ConflictPolicy = ConflictPolicySkipAndReport
+	}
+
+	if cfg.Environment.Mode == "" {
+		cfg.Environment.Mode = EnvironmentSection
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+
+	if cfg.Concurrency.Min == 0 {
+		cfg.Concurrency.Min = 1
+	}
+
+	if cfg.Concurrency.Max == 0 {
+		cfg.Concurrency.Max = 20
+	}
+
+	if cfg.Transport.MaxIdleConnsPerHost == 0 {
+		cfg.Transport.MaxIdleConnsPerHost = cfg.Concurrency.Max
+	}
+
+	if cfg.Transport.IdleConnTimeout == 0 {
+		cfg.Transport.IdleConnTimeout = 90 * time.Second
+	}
+
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+
+	if cfg.ProgressInterval == 0 {
+		cfg.ProgressInterval = 30 * time.Second
+	}
+
+	if cfg.Scratch.MaxMB == 0 {
+		cfg.Scratch.MaxMB = DefaultScratchMaxMB
+	}
+
+	if cfg.Prefetch.Ahead == 0 {
+		cfg.Prefetch.Ahead = DefaultPrefetchAhead
+	}
+	if cfg.Prefetch.Memory == 0 {
+		cfg.Prefetch.Memory = DefaultPrefetchMemory
+	}
+
+	if cfg.StateFile == "" {
+		cfg.StateFile = state.DefaultPath
+	}
+
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.GitLab.Iterations.Enabled && cfg.Jira.CustomField.Sprint == "" {
+		return nil, errors.New("Error validating config: gitlab.iterations requires jira.custom_field.sprint")
+	}
+
+	for _, cookie := range append(append([]string{}, cfg.Jira.Cookies...), cfg.GitLab.Cookies...) {
+		if name, _, ok := strings.Cut(cookie, "="); !ok || name == "" {
+			return nil, errors.Errorf("Error validating config: cookie %q must be name=value", strings.SplitN(cookie, "=", 2)[0])
+		}
+	}
+
+	for _, rule := range cfg.RewriteRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error validating rewrite rule: %s", rule.Pattern))
+		}
+	}
+
+	if cfg.Anonymize.Enabled && cfg.RawJSON != "" {
+		return nil, errors.New("Error validating config: raw_json keeps the names and emails of the Jira users, it can't be used with anonymize")
+	}
+
+	for _, rule := range cfg.Redactions {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error validating redaction rule: %s", rule.Pattern))
+		}
+	}
+
+	for _, rule := range cfg.Severity.Matrix {
+		if len(rule.Severities) > 0 && cfg.Severity.Field == "" {
+			return nil, errors.New("Error validating config: the severities of severity.matrix require severity.field")
+		}
+	}
+
+	if cfg.Jira.CacheMaxAge > 0 && cfg.Jira.Cache == "" {
+		return nil, errors.New("Error validating config: jira.cache_max_age requires jira.cache")
+	}
+
+	for _, rule := range cfg.Environment.LabelRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error validating environment label rule: %s", rule.Pattern))
+		}
+	}
+
+	return cfg, nil
+}
+
+// GetConnectionConfig reads the config without the project options and user.csv, e.g. to discover the projects of the config.
+// Only the fields are validated (e.g. Jira.Host). It doesn't replace the config of GetConfig.
+func GetConnectionConfig(fields ...string) (*Config, error) {
+	c, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validator.New().StructPartial(c, fields...); err != nil {
+		return nil, errors.Wrap(err, "Error validating config")
+	}
+	return c, nil
+}
+
+// readConfig reads the config file with the tokens of the environment variables, decrypted
+func readConfig() (*Config, error) {
+	if err := InitConfig(); err != nil {
+		return nil, errors.Wrap(err, "Error initializing config")
+	}
+
+	var cfg *Config
+	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, errors.Wrap(err, "Error unmarshalling config")
 	}
 
@@ -87,24 +728,27 @@ func GetConfig() (*Config, error) {
 		switch key {
 		case "GITLAB_TOKEN":
 			cfg.GitLab.Token = value
+		case "GITLAB_TOKENS":
+			cfg.GitLab.Tokens = strings.Split(value, ",")
 		case "JIRA_TOKEN":
 			cfg.Jira.Token = value
 		}
 	}
 
-	cfg.Users, err = parseUserCSVs()
-	if err != nil {
-		return nil, errors.Wrap(err, "Error parsing user.csv")
+	//* Tokens encrypted with config encrypt (enc:...)
+	if err := decryptSecrets(cfg); err != nil {
+		return nil, errors.Wrap(err, "Error decrypting config secrets")
 	}
 
-	capitalizeJiraProject(cfg)
+	return cfg, nil
+}
 
-	validate := validator.New()
-	if err := validate.Struct(cfg); err != nil {
-		return nil, errors.Wrap(err, "Error validating config")
+// Validate checks the fields of the config with their validate tags
+func Validate(c *Config) error {
+	if err := validator.New().Struct(c); err != nil {
+		return errors.Wrap(err, "Error validating config")
 	}
-
-	return cfg, nil
+	return nil
 }
 
 // config file is read by yaml format
@@ -112,6 +756,8 @@ func GetConfig() (*Config, error) {
 // If you don't specify the config file, the default config file is used
 // - $HOME/.config/jira2gitlab/config.yaml
 // - $PWD/config.yaml
+// --config may be a directory with config.yaml and the profile files.
+// --profile merges a profile over the config: profiles.<name> of the config file, or <name>.yaml in its directory.
 
 func InitConfig() error {
 	pwd, err := os.Getwd()
@@ -119,15 +765,20 @@ func InitConfig() error {
 		return errors.Wrap(err, "Error getting current working directory")
 	}
 
-	// Search config in home directory with name ".cobra" (without extension).
-	viper.AddConfigPath(pwd)
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 
 	viper.AutomaticEnv()
 
-	if configFile := viper.GetString("CONFIG_FILE"); configFile != "" {
+	//* A --config directory is the only search path, config.yaml of the working directory would win over it
+	configFile := viper.GetString("CONFIG_FILE")
+	if info, err := os.Stat(configFile); configFile != "" && err == nil && info.IsDir() {
+		viper.AddConfigPath(configFile)
+	} else if configFile != "" {
 		viper.SetConfigFile(configFile)
+	} else {
+		// Search config in home directory with name ".cobra" (without extension).
+		viper.AddConfigPath(pwd)
 	}
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -139,13 +790,71 @@ func InitConfig() error {
 	}
 
 	log.Debugf("Using config file: %s", viper.ConfigFileUsed())
+
+	if profile := viper.GetString("PROFILE"); profile != "" {
+		if err := mergeProfile(profile); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error reading profile: %s", profile))
+		}
+		log.Infof("Using profile: %s", profile)
+	}
+	return nil
+}
+
+// mergeProfile merges the profile over the config file, the keys of the profile replace the keys of the config
+func mergeProfile(profile string) error {
+	if settings := viper.GetStringMap("profiles." + profile); len(settings) > 0 {
+		return viper.MergeConfigMap(settings)
+	}
+
+	path := filepath.Join(filepath.Dir(viper.ConfigFileUsed()), profile+".yaml")
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return errors.Errorf("Profile %s is not found: no profiles.%s in %s and no %s", profile, profile, viper.ConfigFileUsed(), path)
+	} else if err != nil {
+		return errors.Wrap(err, "Error opening profile file")
+	}
+	defer file.Close()
+
+	log.Debugf("Using profile file: %s", path)
+	return viper.MergeConfig(file)
+}
+
+// decryptSecrets decrypts the token fields with the passphrase of J2LAB_PASSPHRASE
+func decryptSecrets(cfg *Config) error {
+	passphrase := secret.Passphrase()
+	for _, value := range []*string{&cfg.Jira.Token, &cfg.GitLab.Token} {
+		plaintext, err := secret.DecryptString(*value, passphrase)
+		if err != nil {
+			return err
+		}
+		*value = plaintext
+	}
+	for _, values := range [][]string{cfg.GitLab.Tokens, cfg.Jira.Cookies, cfg.GitLab.Cookies} {
+		for i, value := range values {
+			plaintext, err := secret.DecryptString(value, passphrase)
+			if err != nil {
+				return err
+			}
+			values[i] = plaintext
+		}
+	}
+	for _, headers := range []map[string]string{cfg.Jira.Headers, cfg.GitLab.Headers} {
+		for name, value := range headers {
+			plaintext, err := secret.DecryptString(value, passphrase)
+			if err != nil {
+				return err
+			}
+			headers[name] = plaintext
+		}
+	}
 	return nil
 }
 
-func parseUserCSVs() (map[string]int, error) {
+// parseUserCSVs returns the GitLab user of each Jira user by ID, or by username if it isn't a number
+func parseUserCSVs() (map[string]int, map[string]string, error) {
 	pwd, err := os.Getwd()
 	if err != nil {
-		return nil, errors.Wrap(err, "Error getting home directory")
+		return nil, nil, errors.Wrap(err, "Error getting home directory")
 	}
 
 	var file *os.File
@@ -155,22 +864,23 @@ func parseUserCSVs() (map[string]int, error) {
 		path = filepath.Join(pwd, "user.csv")
 		file, err = os.Open(path)
 		if err != nil {
-			return nil, errors.Wrap(err, "Error opening file")
+			return nil, nil, errors.Wrap(err, "Error opening file")
 		}
 	} else {
 		path, err = filepath.Abs(path)
 		if err != nil {
-			return nil, errors.Wrap(err, "Error getting absolute path")
+			return nil, nil, errors.Wrap(err, "Error getting absolute path")
 		}
 
 		file, err = os.Open(path)
 		if err != nil {
-			return nil, errors.Wrap(err, "Error opening file")
+			return nil, nil, errors.Wrap(err, "Error opening file")
 		}
 	}
 	defer file.Close()
 
 	users := make(map[string]int)
+	usernames := make(map[string]string)
 
 	// Read the file line by line
 	scanner := bufio.NewScanner(file)
@@ -180,19 +890,20 @@ func parseUserCSVs() (map[string]int, error) {
 		parts := strings.Split(line, ",")
 		if len(parts) == 2 {
 			username := strings.TrimSpace(parts[0]) //* Jira Username
-			valueStr := strings.TrimSpace(parts[1]) //* GitLab User ID
+			valueStr := strings.TrimSpace(parts[1]) //* GitLab User ID or username
 
 			gitlabUserId, err := strconv.Atoi(valueStr)
 			if err != nil {
-				log.Fatal("Error parsing user ID: user.csv must be in the format of <Jira Account ID>,<Jira Display Name>,<GitLab User ID>")
+				usernames[username] = strings.TrimPrefix(valueStr, "@")
+				continue
 			}
 
 			users[username] = gitlabUserId
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, errors.Wrap(err, "Error reading users file")
+		return nil, nil, errors.Wrap(err, "Error reading users file")
 	}
 
-	return users, nil
+	return users, usernames, nil
 }
@@ -0,0 +1,44 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitConfigDirectory(t *testing.T) {
+	pwd, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(pwd)
+	defer viper.Reset()
+
+	workDir := t.TempDir()
+	configDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workDir, "config.yaml"), []byte("jira:\n  name: WORK\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("jira:\n  name: DIR\n"), 0644))
+	assert.NoError(t, os.Chdir(workDir))
+
+	//* config.yaml of the --config directory, not of the working directory
+	viper.Reset()
+	viper.Set("CONFIG_FILE", configDir)
+	assert.NoError(t, InitConfig())
+	assert.Equal(t, filepath.Join(configDir, "config.yaml"), viper.ConfigFileUsed())
+	assert.Equal(t, "DIR", viper.GetString("jira.name"))
+
+	viper.Reset()
+	assert.NoError(t, InitConfig())
+	assert.Equal(t, "WORK", viper.GetString("jira.name"))
+}
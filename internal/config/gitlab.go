@@ -12,25 +12,91 @@
 package config
 
 import (
+	"context"
+	"net/http"
+	"os"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
 	log "github.com/sirupsen/logrus"
 	"github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/audit"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/throttle"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/tokens"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
 )
 
 var gitlabClient *gitlab.Client
 
+// retryGitLabRequest retries the reads on 429 and 5xx like go-gitlab, the writes on 429 only.
+// A write answered by a 502 or 504 may be applied already, it is reconciled by the Jira key marker instead of sent again.
+func retryGitLabRequest(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true, nil
+	}
+	if resp.StatusCode < 500 {
+		return false, nil
+	}
+	switch resp.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		return true, nil
+	}
+	return false, nil
+}
+
 func GetGitLabClient(cfg *Config) *gitlab.Client {
 	if gitlabClient != nil {
 		return gitlabClient
 	}
 
-	client, err := gitlab.NewClient(cfg.GitLab.Token, gitlab.WithBaseURL(cfg.GitLab.Host))
+	//* The other tokens are used in turn with the token
+	base := newHeaderTransport(cfg.GitLab.Headers, cfg.GitLab.Cookies, getTransport(cfg))
+	if len(cfg.GitLab.Tokens) > 0 {
+		pool := tokens.NewPool(append([]string{cfg.GitLab.Token}, cfg.GitLab.Tokens...), cfg.GitLab.TokenRate)
+		log.Infof("Rotating %d GitLab tokens", pool.Len())
+		base = tokens.NewTransport(pool, base)
+	}
+
+	//* Audit log of the write requests
+	if cfg.AuditLog != "" {
+		logger, err := audit.NewLogger(cfg.AuditLog)
+		if err != nil {
+			log.Fatalf("Error opening audit log %s: %s", cfg.AuditLog, err)
+		}
+		base = audit.NewTransport(logger, base)
+	}
+
+	httpClient := &http.Client{
+		Transport: throttle.NewTransport("GitLab", getController(cfg), stats.NewTransport("GitLab", base)),
+	}
+
+	client, err := gitlab.NewClient(cfg.GitLab.Token,
+		gitlab.WithBaseURL(cfg.GitLab.Host),
+		gitlab.WithHTTPClient(httpClient),
+		gitlab.WithCustomRetry(retryGitLabRequest),
+		gitlab.WithRequestLogHook(func(_ retryablehttp.Logger, _ *http.Request, attempt int) {
+			if attempt > 0 {
+				stats.Default().AddRetry("GitLab")
+			}
+		}),
+	)
 	if err != nil {
 		log.Fatalf("Error creating GitLab client: %s", err)
 	}
 
 	currnetUser, _, err := client.Users.CurrentUser()
 	if err != nil {
-		log.Fatalf("Error getting current user for GitLab: %s", err)
+		log.Errorf("Error getting current user for GitLab: %s", err)
+		if hint := utils.Hint(utils.ClassifyError(err)); hint != "" {
+			log.Errorf("Hint: %s", hint)
+		}
+		os.Exit(utils.ExitAuth)
 	}
 
 	log.Infof("GitLab client created for user: %s", currnetUser.Username)
@@ -0,0 +1,79 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestGitLabWritesAreNotRetriedOn5xx(t *testing.T) {
+	var mutex sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		count := requests[r.Method+" "+r.URL.Path]
+		mutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/user":
+			w.Write([]byte(`{"id": 1, "username": "root"}`))
+		case "POST /api/v4/projects/1/issues":
+			// The issue is created, the proxy in front of GitLab times out
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case "POST /api/v4/projects/1/issues/1/notes":
+			if count == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 1, "body": "note"}`))
+		case "GET /api/v4/projects/1":
+			if count == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"id": 1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &Config{}
+	cfg.GitLab.Host = server.URL
+	cfg.GitLab.Token = "token"
+	gitlabClient = nil
+	defer func() { gitlabClient = nil }()
+	client := GetGitLabClient(cfg)
+
+	//* Sent once, the reconciliation finds the issue by its marker
+	_, r, err := client.Issues.CreateIssue(1, &gitlab.CreateIssueOptions{Title: gitlab.String("issue")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusGatewayTimeout, r.StatusCode)
+	assert.Equal(t, 1, requests["POST /api/v4/projects/1/issues"])
+
+	//* Rate limited writes and failed reads are retried
+	_, _, err = client.Notes.CreateIssueNote(1, 1, &gitlab.CreateIssueNoteOptions{Body: gitlab.String("note")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests["POST /api/v4/projects/1/issues/1/notes"])
+	_, _, err = client.Projects.GetProject(1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests["GET /api/v4/projects/1"])
+}
@@ -13,23 +13,34 @@ package config
 
 import (
 	"context"
+	"os"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
 	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/throttle"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
 )
 
 var jiraClient *jira.Client
 
+// Server info of the Jira of jiraClient, nil if it is unknown
+var jiraServerInfo *jirax.ServerInfo
+
 func GetJiraClient(cfg *Config) *jira.Client {
 	if jiraClient != nil {
 		return jiraClient
 	}
 
 	tp := jira.BearerAuthTransport{
-		Token: cfg.Jira.Token,
+		Token:     cfg.Jira.Token,
+		Transport: throttle.NewTransport("Jira", getController(cfg), stats.NewTransport("Jira", getJiraTransport(cfg))),
 	}
 
-	client, err := jira.NewClient(cfg.Jira.Host, tp.Client())
+	httpClient := tp.Client()
+
+	client, err := jira.NewClient(cfg.Jira.Host, httpClient)
 	if err != nil {
 		log.Fatalf("Error creating Jira client: %s", err)
 	}
@@ -37,11 +48,31 @@ func GetJiraClient(cfg *Config) *jira.Client {
 	currnetUser, _, err := client.User.GetSelf(context.Background())
 	if err != nil {
 		// log.Fatalf("Error getting current user for Jira: %s", err)
-		log.Fatalf("Error getting current user for Jira")
+		log.Errorf("Error getting current user for Jira")
+		if hint := utils.Hint(utils.ClassifyError(err)); hint != "" {
+			log.Errorf("Hint: %s", hint)
+		}
+		os.Exit(utils.ExitAuth)
 	}
 
 	log.Infof("Jira client created for user: %s", currnetUser.EmailAddress)
 
+	//* Some Jira Server versions don't allow serverInfo to the users, API v2 is used then
+	info, err := jirax.GetServerInfo(context.Background(), client)
+	if err != nil {
+		log.Warnf("Unknown Jira version, the API v2 is used: %s", err)
+	} else {
+		log.Infof("Jira %s %s (build %d)", info.DeploymentType, info.Version, info.BuildNumber)
+		jiraServerInfo = info
+	}
+
 	jiraClient = client
 	return jiraClient
 }
+
+// GetJiraAPI returns the API of the Jira client, jira.api_version or the version detected from the server info
+func GetJiraAPI(cfg *Config) jirax.API {
+	api := jirax.DetectAPI(jiraServerInfo, cfg.Jira.APIVersion)
+	log.Debugf("Jira API v%s (enhanced search: %t)", api.Version, api.EnhancedSearch)
+	return api
+}
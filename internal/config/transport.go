@@ -0,0 +1,92 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package config
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/httpcache"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/throttle"
+)
+
+var (
+	controller     *throttle.Controller
+	controllerOnce sync.Once
+
+	transport     *http.Transport
+	transportOnce sync.Once
+)
+
+// getTransport returns the HTTP transport shared by the Jira and GitLab clients, the connections are kept alive between the requests
+func getTransport(cfg *Config) *http.Transport {
+	transportOnce.Do(func() {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = 2 * cfg.Transport.MaxIdleConnsPerHost // Jira and GitLab
+		transport.MaxIdleConnsPerHost = cfg.Transport.MaxIdleConnsPerHost
+		transport.IdleConnTimeout = cfg.Transport.IdleConnTimeout
+		//* Only the wait for the response is bounded, a large attachment may take longer to transfer
+		transport.ResponseHeaderTimeout = cfg.Timeout
+		transport.DisableCompression = false // gzip is requested and decoded by the transport
+		if cfg.Transport.DisableHTTP2 {
+			transport.ForceAttemptHTTP2 = false
+			transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		}
+	})
+	return transport
+}
+
+// getController returns the concurrency controller shared by the Jira and GitLab clients
+func getController(cfg *Config) *throttle.Controller {
+	controllerOnce.Do(func() {
+		controller = throttle.New(cfg.Concurrency.Min, cfg.Concurrency.Max)
+	})
+	return controller
+}
+
+// getJiraTransport is the shared transport with the extra headers and the conditional requests of jira.cache
+func getJiraTransport(cfg *Config) http.RoundTripper {
+	base := newHeaderTransport(cfg.Jira.Headers, cfg.Jira.Cookies, getTransport(cfg))
+	if cfg.Jira.Cache == "" {
+		return base
+	}
+	return httpcache.NewTransport(cfg.Jira.Cache, cfg.Jira.CacheMaxAge, base)
+}
+
+// headerTransport adds the extra headers and cookies (e.g. of an SSO gateway) to every request
+type headerTransport struct {
+	headers map[string]string
+	cookies []string // name=value
+	base    http.RoundTripper
+}
+
+// newHeaderTransport returns base itself without headers and cookies
+func newHeaderTransport(headers map[string]string, cookies []string, base http.RoundTripper) http.RoundTripper {
+	if len(headers) == 0 && len(cookies) == 0 {
+		return base
+	}
+	return &headerTransport{headers: headers, cookies: cookies, base: base}
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	for _, cookie := range t.cookies {
+		name, value, _ := strings.Cut(cookie, "=")
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,52 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package config
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransportTimeout(t *testing.T) {
+	defer func() { transport, transportOnce = nil, sync.Once{} }()
+	transport, transportOnce = nil, sync.Once{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		//* The body of a large attachment takes longer than the timeout
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "body")
+	}))
+	defer server.Close()
+
+	cfg := &Config{Timeout: 100 * time.Millisecond}
+	client := &http.Client{Transport: getTransport(cfg)}
+
+	resp, err := client.Get(server.URL + "/download")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "body", string(body))
+
+	_, err = client.Get(server.URL + "/slow")
+	assert.Error(t, err)
+}
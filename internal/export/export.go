@@ -0,0 +1,340 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+// Package export converts the migration into a GitLab project export archive (tar.gz)
+// which can be imported through "New project > Import project > GitLab export".
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+const (
+	// Version of the project export format
+	Version = "0.2.4"
+
+	projectID = 1
+	groupID   = 2
+)
+
+// Archive collects the GitLab objects of the project and the group of the config in memory instead of calling the GitLab API.
+// A project export can't contain epics, so the epics are migrated as issues (gitlab.epic_mode: auto).
+// The group labels and milestones become project labels and milestones. Issue links, boards and wiki pages are accepted but not exported.
+type Archive struct {
+	mutex  sync.Mutex
+	nextID int
+
+	project *gitlab.Project
+	group   *gitlab.Group
+
+	projectLabels   []*gitlab.Label
+	groupLabels     []*gitlab.GroupLabel
+	milestones      []*gitlab.Milestone
+	groupMilestones []*gitlab.GroupMilestone
+	issues          []*gitlab.Issue
+
+	// Key: issue ID
+	notes       map[int][]*gitlab.Note
+	discussions map[int][]*gitlab.Discussion
+	// Key: note ID
+	internalNotes map[int]bool
+	// Key: uploads/ path
+	uploads map[string][]byte
+}
+
+func New(projectPath string, groupPath string) *Archive {
+	return &Archive{
+		nextID:        groupID,
+		project:       &gitlab.Project{ID: projectID, PathWithNamespace: projectPath, WebURL: "https://gitlab.example.com/" + projectPath},
+		group:         &gitlab.Group{ID: groupID, FullPath: groupPath, Path: groupPath[strings.LastIndex(groupPath, "/")+1:]},
+		notes:         make(map[int][]*gitlab.Note),
+		discussions:   make(map[int][]*gitlab.Discussion),
+		internalNotes: make(map[int]bool),
+		uploads:       make(map[string][]byte),
+	}
+}
+
+// Configure adjusts the config of the migration into the archive.
+// Offline the GitLab users can't be looked up, so the Jira users are mentioned by their names (unmapped_users), and nothing is left to resume.
+func Configure(cfg *config.Config) {
+	cfg.Users = map[string]int{}
+	cfg.UserNames = nil
+	cfg.UnmappedUsers.Allow = true
+	cfg.StateFile = ""
+}
+
+//* Project export tree
+
+type label struct {
+	Title       string `json:"title"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+}
+
+type labelLink struct {
+	TargetType string `json:"target_type"`
+	Label      label  `json:"label"`
+}
+
+type milestone struct {
+	IID         int             `json:"iid"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	State       string          `json:"state"`
+	StartDate   *gitlab.ISOTime `json:"start_date,omitempty"`
+	DueDate     *gitlab.ISOTime `json:"due_date,omitempty"`
+}
+
+type note struct {
+	Note         string     `json:"note"`
+	NoteableType string     `json:"noteable_type"`
+	Internal     bool       `json:"internal"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type issue struct {
+	IID          int             `json:"iid"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	State        string          `json:"state"`
+	Confidential bool            `json:"confidential"`
+	Weight       *int            `json:"weight,omitempty"`
+	CreatedAt    *time.Time      `json:"created_at"`
+	UpdatedAt    *time.Time      `json:"updated_at"`
+	ClosedAt     *time.Time      `json:"closed_at,omitempty"`
+	DueDate      *gitlab.ISOTime `json:"due_date,omitempty"`
+	Milestone    *milestone      `json:"milestone,omitempty"`
+	LabelLinks   []labelLink     `json:"label_links"`
+	Notes        []note          `json:"notes"`
+}
+
+func (a *Archive) labels() map[string]label {
+	labels := make(map[string]label)
+
+	// Group labels become project labels because the group is not in the archive
+	for _, l := range a.groupLabels {
+		labels[l.Name] = label{Title: l.Name, Color: l.Color, Description: l.Description, Type: "ProjectLabel"}
+	}
+	for _, l := range a.projectLabels {
+		labels[l.Name] = label{Title: l.Name, Color: l.Color, Description: l.Description, Type: "ProjectLabel"}
+	}
+
+	return labels
+}
+
+// exportedMilestones returns the milestones of the export by their GitLab ID.
+// The group milestones get the IIDs after the project milestones, a project milestone wins over a group milestone of its title.
+func (a *Archive) exportedMilestones() map[int]*milestone {
+	milestones := make(map[int]*milestone)
+	titles := make(map[string]*milestone)
+	iid := 0
+	for _, m := range a.milestones {
+		exported := &milestone{IID: m.IID, Title: m.Title, Description: m.Description, State: m.State, StartDate: m.StartDate, DueDate: m.DueDate}
+		milestones[m.ID] = exported
+		titles[m.Title] = exported
+		if m.IID > iid {
+			iid = m.IID
+		}
+	}
+	for _, m := range a.groupMilestones {
+		if exported, ok := titles[m.Title]; ok {
+			milestones[m.ID] = exported
+			continue
+		}
+		iid++
+		milestones[m.ID] = &milestone{IID: iid, Title: m.Title, Description: m.Description, State: m.State, StartDate: m.StartDate, DueDate: m.DueDate}
+	}
+	return milestones
+}
+
+func (a *Archive) tree() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	project := a.project
+	projectJSON, err := json.Marshal(map[string]interface{}{
+		"description":      project.Description,
+		"visibility_level": 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+	files["tree/project.json"] = projectJSON
+
+	//* Labels
+	labels := a.labels()
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines [][]byte
+	for _, name := range names {
+		line, err := json.Marshal(labels[name])
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	files["tree/project/labels.ndjson"] = ndjson(lines)
+
+	//* Milestones, the group milestones follow the project milestones
+	milestones := a.exportedMilestones()
+	exportedMilestones := make([]*milestone, 0, len(milestones))
+	seen := make(map[*milestone]bool)
+	for _, m := range milestones {
+		if !seen[m] {
+			seen[m] = true
+			exportedMilestones = append(exportedMilestones, m)
+		}
+	}
+	sort.Slice(exportedMilestones, func(i, j int) bool { return exportedMilestones[i].IID < exportedMilestones[j].IID })
+
+	lines = nil
+	for _, m := range exportedMilestones {
+		line, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	files["tree/project/milestones.ndjson"] = ndjson(lines)
+
+	//* Issues
+	lines = nil
+	for _, i := range a.issues {
+		exported := issue{
+			IID:          i.IID,
+			Title:        i.Title,
+			Description:  i.Description,
+			State:        i.State,
+			Confidential: i.Confidential,
+			CreatedAt:    i.CreatedAt,
+			UpdatedAt:    i.CreatedAt,
+			ClosedAt:     i.ClosedAt,
+			LabelLinks:   []labelLink{},
+			Notes:        []note{},
+		}
+		if i.Weight != 0 {
+			exported.Weight = &i.Weight
+		}
+		if i.DueDate != nil && !time.Time(*i.DueDate).IsZero() {
+			exported.DueDate = i.DueDate
+		}
+		if i.Milestone != nil {
+			exported.Milestone = milestones[i.Milestone.ID]
+		}
+		for _, name := range i.Labels {
+			l, ok := labels[name]
+			if !ok {
+				l = label{Title: name, Type: "ProjectLabel"}
+			}
+			exported.LabelLinks = append(exported.LabelLinks, labelLink{TargetType: "Issue", Label: l})
+		}
+		for _, n := range a.notes[i.ID] {
+			exported.Notes = append(exported.Notes, note{Note: n.Body, NoteableType: "Issue", Internal: a.internalNotes[n.ID], CreatedAt: n.CreatedAt, UpdatedAt: n.CreatedAt})
+		}
+
+		line, err := json.Marshal(exported)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	files["tree/project/issues.ndjson"] = ndjson(lines)
+
+	return files, nil
+}
+
+func ndjson(lines [][]byte) []byte {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// Write writes the archive as tar.gz
+func (a *Archive) Write(w io.Writer) error {
+	files, err := a.tree()
+	if err != nil {
+		return errors.Wrap(err, "Error building project tree")
+	}
+
+	files["VERSION"] = []byte(Version)
+	for path, content := range a.uploads {
+		files["uploads/"+path] = content
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	modTime := time.Now()
+
+	for _, path := range paths {
+		content := files[path]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    path,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: modTime,
+		}); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error writing header: %s", path))
+		}
+		if _, err := tw.Write(content); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error writing file: %s", path))
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "Error closing tar")
+	}
+	return gw.Close()
+}
+
+// WriteFile writes the archive to path
+func (a *Archive) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating export file: %s", path))
+	}
+
+	if err := a.Write(file); err != nil {
+		file.Close()
+		return errors.Wrap(err, fmt.Sprintf("Error writing export file: %s", path))
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error closing export file: %s", path))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package export
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/j2g"
+)
+
+var _ j2g.GitLabWriter = (*Archive)(nil)
+
+// readArchive returns the files of the tar.gz
+func readArchive(t *testing.T, path string) map[string]string {
+	file, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer file.Close()
+	gr, err := gzip.NewReader(file)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	files := make(map[string]string)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		content, _ := io.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+	return files
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	a := New("group/project", "group")
+
+	_, _, err := a.GetProject(ctx, "group/project")
+	assert.NoError(t, err)
+	_, _, err = a.GetProject(ctx, "group/other")
+	assert.Error(t, err)
+	available, _ := a.EpicsAvailable(ctx, "group")
+	assert.False(t, available)
+
+	_, _, err = a.CreateGroupLabel(ctx, "group", &gitlab.CreateGroupLabelOptions{Name: gitlab.String("type::Bug"), Color: gitlab.String("#ff0000")})
+	assert.NoError(t, err)
+	_, r, err := a.CreateGroupLabel(ctx, "group", &gitlab.CreateGroupLabelOptions{Name: gitlab.String("type::Bug")})
+	if assert.Error(t, err) {
+		assert.Equal(t, 409, r.StatusCode)
+	}
+
+	version, _, err := a.CreateMilestone(ctx, "group/project", &gitlab.CreateMilestoneOptions{Title: gitlab.String("1.0")})
+	assert.NoError(t, err)
+	sprint, _, err := a.CreateGroupMilestone(ctx, "group", &gitlab.CreateGroupMilestoneOptions{Title: gitlab.String("Sprint 1")})
+	assert.NoError(t, err)
+	_, _, err = a.CreateGroupMilestone(ctx, "group", &gitlab.CreateGroupMilestoneOptions{Title: gitlab.String("1.0")})
+	assert.NoError(t, err)
+	_, _, err = a.UpdateGroupMilestone(ctx, "group", sprint.ID, &gitlab.UpdateGroupMilestoneOptions{StateEvent: gitlab.String("close")})
+	assert.NoError(t, err)
+
+	issue, _, err := a.CreateIssue(ctx, "group/project", &gitlabx.CreateIssueOptions{
+		Title:       gitlab.String("Login fails"),
+		Labels:      &gitlab.Labels{"type::Bug"},
+		MilestoneID: gitlab.Int(sprint.ID),
+	})
+	assert.NoError(t, err)
+	_, _, err = a.CreateIssueNote(ctx, "group/project", issue.IID, &gitlabx.CreateIssueNoteOptions{Body: gitlab.String("Reproduced"), Internal: gitlab.Bool(true)})
+	assert.NoError(t, err)
+	other, _, err := a.CreateIssue(ctx, "group/project", &gitlabx.CreateIssueOptions{Title: gitlab.String("Release"), MilestoneID: gitlab.Int(version.ID)})
+	assert.NoError(t, err)
+	_, _, err = a.UpdateIssue(ctx, "group/project", other.IID, &gitlab.UpdateIssueOptions{StateEvent: gitlab.String("close")})
+	assert.NoError(t, err)
+	file, _, err := a.UploadFile(ctx, "group/project", strings.NewReader("log"), "log.txt")
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "export.tar.gz")
+	assert.NoError(t, a.WriteFile(path))
+	files := readArchive(t, path)
+
+	assert.Equal(t, Version, files["VERSION"])
+	assert.Equal(t, "log", files["uploads"+strings.TrimPrefix(file.URL, "/uploads")])
+	assert.Contains(t, files["tree/project/labels.ndjson"], `"title":"type::Bug"`)
+
+	//* The group milestone of the title of a project milestone is merged into it
+	milestones := strings.Split(strings.TrimSpace(files["tree/project/milestones.ndjson"]), "\n")
+	if assert.Len(t, milestones, 2) {
+		assert.Contains(t, milestones[0], `"iid":1,"title":"1.0"`)
+		assert.Contains(t, milestones[1], `"iid":2,"title":"Sprint 1"`)
+		assert.Contains(t, milestones[1], `"state":"closed"`)
+	}
+
+	issues := strings.Split(strings.TrimSpace(files["tree/project/issues.ndjson"]), "\n")
+	if assert.Len(t, issues, 2) {
+		assert.Contains(t, issues[0], `"milestone":{"iid":2,"title":"Sprint 1"`)
+		assert.Contains(t, issues[0], `"note":"Reproduced","noteable_type":"Issue","internal":true`)
+		assert.Contains(t, issues[1], `"state":"closed"`)
+		assert.Contains(t, issues[1], `"milestone":{"iid":1,"title":"1.0"`)
+	}
+}
+
+func TestConvertByProject(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Jira.Host = "https://jira.example.com"
+	cfg.Jira.Name = "TEST"
+	cfg.GitLab.Host = "https://gitlab.example.com"
+	cfg.GitLab.Issue = "group/project"
+	cfg.GitLab.Epic = "group"
+	cfg.GitLab.LabelLevel = config.LabelLevelAuto
+	cfg.Users = map[string]int{"alice": 10}
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	Configure(cfg)
+	config.SetConfig(cfg)
+	t.Cleanup(func() { config.SetConfig(nil) })
+
+	//* The reporter and the assignee have no GitLab user offline, the issue is migrated without them
+	reporter := &jira.User{Name: "alice", DisplayName: "Alice Kim"}
+	jiraIssue := &jira.Issue{ID: "10001", Key: "TEST-1", Fields: &jira.IssueFields{
+		Summary:  "Login fails",
+		Type:     jira.IssueType{Name: "Bug"},
+		Status:   &jira.Status{Name: "To Do"},
+		Priority: &jira.Priority{Name: "High"},
+		Reporter: reporter,
+		Assignee: reporter,
+		Created:  jira.Time(time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)),
+		Comments: &jira.Comments{},
+	}}
+	a := New(cfg.GitLab.Issue, cfg.GitLab.Epic)
+	assert.NoError(t, j2g.ConvertByProject(context.Background(), a, fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)))
+
+	path := filepath.Join(t.TempDir(), "export.tar.gz")
+	assert.NoError(t, a.WriteFile(path))
+	issues := strings.Split(strings.TrimSpace(readArchive(t, path)["tree/project/issues.ndjson"]), "\n")
+	if assert.Len(t, issues, 1) {
+		assert.Contains(t, issues[0], `"title":"Login fails"`)
+	}
+}
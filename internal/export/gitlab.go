@@ -0,0 +1,778 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+//* Helpers
+
+func response(statusCode int) *gitlab.Response {
+	return &gitlab.Response{Response: &http.Response{StatusCode: statusCode}}
+}
+
+func errorResponse(statusCode int, format string, args ...interface{}) (*gitlab.Response, error) {
+	r := response(statusCode)
+	r.Request = &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/export"}} // for ErrorResponse.Error()
+	return r, &gitlab.ErrorResponse{Response: r.Response, Message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(id interface{}) (*gitlab.Response, error) {
+	return errorResponse(http.StatusNotFound, "%v is not in the project export", id)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func now(t *time.Time) *time.Time {
+	if t != nil {
+		return t
+	}
+	n := time.Now()
+	return &n
+}
+
+// isProject reports if the ID or path is the project of the archive
+func (a *Archive) isProject(pid interface{}) bool {
+	switch v := pid.(type) {
+	case int:
+		return v == projectID
+	case string:
+		return v == a.project.PathWithNamespace || v == strconv.Itoa(projectID)
+	}
+	return false
+}
+
+// isGroup reports if the ID or path is the group of the epics
+func (a *Archive) isGroup(gid interface{}) bool {
+	switch v := gid.(type) {
+	case int:
+		return v == groupID
+	case string:
+		return v == a.group.FullPath || v == strconv.Itoa(groupID)
+	}
+	return false
+}
+
+func (a *Archive) id() int {
+	a.nextID++
+	return a.nextID
+}
+
+func (a *Archive) findIssue(iid int) *gitlab.Issue {
+	for _, issue := range a.issues {
+		if issue.IID == iid {
+			return issue
+		}
+	}
+	return nil
+}
+
+// findMilestone returns the project or group milestone of the ID as a milestone of the issue
+func (a *Archive) findMilestone(id int) *gitlab.Milestone {
+	for _, m := range a.milestones {
+		if m.ID == id {
+			return m
+		}
+	}
+	for _, m := range a.groupMilestones {
+		if m.ID == id {
+			return &gitlab.Milestone{ID: m.ID, IID: m.IID, GroupID: m.GroupID, Title: m.Title}
+		}
+	}
+	return nil
+}
+
+//* Project
+
+// GetVersion is unknown, the features of the latest GitLab are used
+func (a *Archive) GetVersion(ctx context.Context) (*gitlab.Version, error) {
+	return &gitlab.Version{}, nil
+}
+
+func (a *Archive) GetProject(ctx context.Context, pid interface{}) (*gitlab.Project, *gitlab.Response, error) {
+	if !a.isProject(pid) {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	return a.project, response(http.StatusOK), nil
+}
+
+func (a *Archive) CreateProject(ctx context.Context, opt *gitlab.CreateProjectOptions) (*gitlab.Project, *gitlab.Response, error) {
+	r, err := errorResponse(http.StatusBadRequest, "a project export has one project")
+	return nil, r, err
+}
+
+func (a *Archive) EditProject(ctx context.Context, pid interface{}, opt *gitlab.EditProjectOptions) (*gitlab.Project, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isProject(pid) {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	if opt.Description != nil {
+		a.project.Description = *opt.Description
+	}
+	return a.project, response(http.StatusOK), nil
+}
+
+// UploadFile keeps the content to write it under uploads/ of the archive
+func (a *Archive) UploadFile(ctx context.Context, pid interface{}, content io.Reader, filename string) (*gitlab.ProjectFile, *gitlab.Response, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error reading file")
+	}
+
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, nil, errors.Wrap(err, "Error generating upload secret")
+	}
+
+	path := fmt.Sprintf("%s/%s", hex.EncodeToString(secret), filename)
+	a.mutex.Lock()
+	a.uploads[path] = data
+	a.mutex.Unlock()
+
+	url := "/uploads/" + path
+	markdown := fmt.Sprintf("[%s](%s)", filename, url)
+	if strings.HasPrefix(mime.TypeByExtension(filepath.Ext(filename)), "image/") {
+		markdown = "!" + markdown
+	}
+
+	return &gitlab.ProjectFile{
+		Alt:      filename,
+		URL:      url,
+		Markdown: markdown,
+	}, response(http.StatusCreated), nil
+}
+
+//* Wiki and repository are not in the export, the pages and files are accepted and dropped
+
+func (a *Archive) GetWikiPage(ctx context.Context, pid interface{}, slug string) (*gitlab.Wiki, *gitlab.Response, error) {
+	r, err := notFound(slug)
+	return nil, r, err
+}
+
+func (a *Archive) CreateWikiPage(ctx context.Context, pid interface{}, opt *gitlab.CreateWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error) {
+	title := stringValue(opt.Title)
+	return &gitlab.Wiki{Title: title, Slug: strings.ReplaceAll(title, " ", "-"), Content: stringValue(opt.Content), Format: gitlab.WikiFormatMarkdown}, response(http.StatusCreated), nil
+}
+
+func (a *Archive) EditWikiPage(ctx context.Context, pid interface{}, slug string, opt *gitlab.EditWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error) {
+	return &gitlab.Wiki{Slug: slug, Content: stringValue(opt.Content), Format: gitlab.WikiFormatMarkdown}, response(http.StatusOK), nil
+}
+
+func (a *Archive) GetFileMetaData(ctx context.Context, pid interface{}, path string, ref string) (*gitlab.File, *gitlab.Response, error) {
+	r, err := notFound(path)
+	return nil, r, err
+}
+
+func (a *Archive) CreateCommit(ctx context.Context, pid interface{}, opt *gitlab.CreateCommitOptions) (*gitlab.Commit, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return &gitlab.Commit{ID: fmt.Sprintf("%040d", a.id()), Title: stringValue(opt.CommitMessage)}, response(http.StatusCreated), nil
+}
+
+//* Group, User, Member
+
+func (a *Archive) GetGroup(ctx context.Context, gid interface{}) (*gitlab.Group, *gitlab.Response, error) {
+	if !a.isGroup(gid) {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+	return a.group, response(http.StatusOK), nil
+}
+
+func (a *Archive) CreateGroup(ctx context.Context, opt *gitlab.CreateGroupOptions) (*gitlab.Group, *gitlab.Response, error) {
+	r, err := errorResponse(http.StatusBadRequest, "a project export doesn't have groups")
+	return nil, r, err
+}
+
+func (a *Archive) UploadGroupWikiAttachment(ctx context.Context, gid interface{}, content io.Reader, filename string) (*gitlabx.WikiAttachment, *gitlab.Response, error) {
+	r, err := errorResponse(http.StatusBadRequest, "a project export doesn't have the group wiki")
+	return nil, r, err
+}
+
+// ListUsers finds no user, the GitLab users can't be looked up offline
+func (a *Archive) ListUsers(ctx context.Context, opt *gitlab.ListUsersOptions) ([]*gitlab.User, *gitlab.Response, error) {
+	return nil, response(http.StatusOK), nil
+}
+
+func (a *Archive) GetUser(ctx context.Context, uid int) (*gitlab.User, *gitlab.Response, error) {
+	r, err := notFound(uid)
+	return nil, r, err
+}
+
+func (a *Archive) ListProjectMembers(ctx context.Context, pid interface{}) ([]*gitlab.ProjectMember, error) {
+	return nil, nil
+}
+
+func (a *Archive) AddProjectMember(ctx context.Context, pid interface{}, opt *gitlab.AddProjectMemberOptions) (*gitlab.ProjectMember, *gitlab.Response, error) {
+	r, err := errorResponse(http.StatusBadRequest, "a project export doesn't have members")
+	return nil, r, err
+}
+
+func (a *Archive) AddGroupMember(ctx context.Context, gid interface{}, opt *gitlab.AddGroupMemberOptions) (*gitlab.GroupMember, *gitlab.Response, error) {
+	r, err := errorResponse(http.StatusBadRequest, "a project export doesn't have members")
+	return nil, r, err
+}
+
+//* Milestone, the group milestones become project milestones of the export
+
+func (a *Archive) ListMilestones(ctx context.Context, pid interface{}) ([]*gitlab.Milestone, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append([]*gitlab.Milestone{}, a.milestones...), nil
+}
+
+func (a *Archive) CreateMilestone(ctx context.Context, pid interface{}, opt *gitlab.CreateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isProject(pid) {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	for _, m := range a.milestones {
+		if m.Title == stringValue(opt.Title) {
+			r, err := errorResponse(http.StatusBadRequest, "Title has already been taken")
+			return nil, r, err
+		}
+	}
+
+	milestone := &gitlab.Milestone{
+		ID:          a.id(),
+		IID:         len(a.milestones) + 1,
+		ProjectID:   projectID,
+		Title:       stringValue(opt.Title),
+		Description: stringValue(opt.Description),
+		StartDate:   opt.StartDate,
+		DueDate:     opt.DueDate,
+		State:       "active",
+	}
+	a.milestones = append(a.milestones, milestone)
+	return milestone, response(http.StatusCreated), nil
+}
+
+func (a *Archive) UpdateMilestone(ctx context.Context, pid interface{}, milestone int, opt *gitlab.UpdateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, m := range a.milestones {
+		if m.ID == milestone {
+			if opt.StateEvent != nil && *opt.StateEvent == "close" {
+				m.State = "closed"
+			}
+			return m, response(http.StatusOK), nil
+		}
+	}
+	r, err := notFound(milestone)
+	return nil, r, err
+}
+
+func (a *Archive) ListGroupMilestones(ctx context.Context, gid interface{}) ([]*gitlab.GroupMilestone, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append([]*gitlab.GroupMilestone{}, a.groupMilestones...), nil
+}
+
+func (a *Archive) CreateGroupMilestone(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isGroup(gid) {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+	for _, m := range a.groupMilestones {
+		if m.Title == stringValue(opt.Title) {
+			r, err := errorResponse(http.StatusBadRequest, "Title has already been taken")
+			return nil, r, err
+		}
+	}
+
+	milestone := &gitlab.GroupMilestone{
+		ID:          a.id(),
+		IID:         len(a.groupMilestones) + 1,
+		GroupID:     groupID,
+		Title:       stringValue(opt.Title),
+		Description: stringValue(opt.Description),
+		StartDate:   opt.StartDate,
+		DueDate:     opt.DueDate,
+		State:       "active",
+	}
+	a.groupMilestones = append(a.groupMilestones, milestone)
+	return milestone, response(http.StatusCreated), nil
+}
+
+func (a *Archive) UpdateGroupMilestone(ctx context.Context, gid interface{}, milestone int, opt *gitlab.UpdateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, m := range a.groupMilestones {
+		if m.ID == milestone {
+			if opt.StateEvent != nil && *opt.StateEvent == "close" {
+				m.State = "closed"
+			}
+			return m, response(http.StatusOK), nil
+		}
+	}
+	r, err := notFound(milestone)
+	return nil, r, err
+}
+
+//* Label
+
+func (a *Archive) ListGroupLabels(ctx context.Context, gid interface{}, opt *gitlab.ListGroupLabelsOptions) ([]*gitlab.GroupLabel, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append([]*gitlab.GroupLabel{}, a.groupLabels...), nil
+}
+
+func (a *Archive) ListLabels(ctx context.Context, pid interface{}, opt *gitlab.ListLabelsOptions) ([]*gitlab.Label, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append([]*gitlab.Label{}, a.projectLabels...), nil
+}
+
+func (a *Archive) CreateGroupLabel(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupLabelOptions) (*gitlab.GroupLabel, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isGroup(gid) {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+	for _, label := range a.groupLabels {
+		if label.Name == stringValue(opt.Name) {
+			r, err := errorResponse(http.StatusConflict, "Label already exists")
+			return nil, r, err
+		}
+	}
+
+	label := &gitlab.GroupLabel{ID: a.id(), Name: stringValue(opt.Name), Description: stringValue(opt.Description), Color: stringValue(opt.Color)}
+	a.groupLabels = append(a.groupLabels, label)
+	return label, response(http.StatusCreated), nil
+}
+
+func (a *Archive) CreateLabel(ctx context.Context, pid interface{}, opt *gitlab.CreateLabelOptions) (*gitlab.Label, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isProject(pid) {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	for _, label := range a.projectLabels {
+		if label.Name == stringValue(opt.Name) {
+			r, err := errorResponse(http.StatusConflict, "Label already exists")
+			return nil, r, err
+		}
+	}
+
+	label := &gitlab.Label{ID: a.id(), Name: stringValue(opt.Name), Description: stringValue(opt.Description), Color: stringValue(opt.Color)}
+	a.projectLabels = append(a.projectLabels, label)
+	return label, response(http.StatusCreated), nil
+}
+
+//* Issue
+
+func (a *Archive) GetIssue(ctx context.Context, pid interface{}, iid int) (*gitlab.Issue, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	issue := a.findIssue(iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+	return issue, response(http.StatusOK), nil
+}
+
+func (a *Archive) CreateIssue(ctx context.Context, pid interface{}, opt *gitlabx.CreateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isProject(pid) {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+
+	//* IIDs stay unique after a deletion
+	iid := 1
+	for _, issue := range a.issues {
+		if issue.IID >= iid {
+			iid = issue.IID + 1
+		}
+	}
+	issue := &gitlab.Issue{
+		ID:          a.id(),
+		IID:         iid,
+		ProjectID:   projectID,
+		Title:       stringValue(opt.Title),
+		Description: stringValue(opt.Description),
+		CreatedAt:   now(opt.CreatedAt),
+		DueDate:     opt.DueDate,
+		State:       "opened",
+		WebURL:      fmt.Sprintf("%s/-/issues/%d", a.project.WebURL, iid),
+		IssueType:   opt.IssueType,
+	}
+	if opt.Labels != nil {
+		issue.Labels = *opt.Labels
+	}
+	if opt.Confidential != nil {
+		issue.Confidential = *opt.Confidential
+	}
+	if opt.Weight != nil {
+		issue.Weight = *opt.Weight
+	}
+	if opt.MilestoneID != nil {
+		issue.Milestone = a.findMilestone(*opt.MilestoneID)
+	}
+	a.issues = append(a.issues, issue)
+	return issue, response(http.StatusCreated), nil
+}
+
+func (a *Archive) UpdateIssue(ctx context.Context, pid interface{}, iid int, opt *gitlab.UpdateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	issue := a.findIssue(iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+
+	if opt.StateEvent != nil && *opt.StateEvent == "close" {
+		issue.State = "closed"
+		issue.ClosedAt = now(nil)
+	}
+	if opt.StateEvent != nil && *opt.StateEvent == "reopen" {
+		issue.State = "opened"
+		issue.ClosedAt = nil
+	}
+	if opt.Title != nil {
+		issue.Title = *opt.Title
+	}
+	if opt.Description != nil {
+		issue.Description = *opt.Description
+	}
+	if opt.DueDate != nil {
+		issue.DueDate = opt.DueDate
+	}
+	if opt.MilestoneID != nil {
+		issue.Milestone = a.findMilestone(*opt.MilestoneID)
+	}
+	if opt.Labels != nil {
+		issue.Labels = *opt.Labels
+	}
+	if opt.AddLabels != nil {
+		issue.Labels = append(issue.Labels, *opt.AddLabels...)
+	}
+	if opt.RemoveLabels != nil {
+		remove := make(map[string]bool)
+		for _, label := range *opt.RemoveLabels {
+			remove[label] = true
+		}
+		var labels gitlab.Labels
+		for _, label := range issue.Labels {
+			if !remove[label] {
+				labels = append(labels, label)
+			}
+		}
+		issue.Labels = labels
+	}
+	return issue, response(http.StatusOK), nil
+}
+
+func (a *Archive) DeleteIssue(ctx context.Context, pid interface{}, iid int) (*gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for i, issue := range a.issues {
+		if issue.IID == iid {
+			a.issues = append(a.issues[:i:i], a.issues[i+1:]...)
+			delete(a.notes, issue.ID)
+			delete(a.discussions, issue.ID)
+			return response(http.StatusNoContent), nil
+		}
+	}
+	return notFound(iid)
+}
+
+func (a *Archive) SearchIssues(ctx context.Context, pid interface{}, search string) ([]*gitlab.Issue, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var result []*gitlab.Issue
+	for _, issue := range a.issues {
+		if strings.Contains(issue.Title, search) || strings.Contains(issue.Description, search) {
+			result = append(result, issue)
+		}
+	}
+	return result, nil
+}
+
+func (a *Archive) CountIssues(ctx context.Context, pid interface{}) (int, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return len(a.issues), nil
+}
+
+// ReorderIssue keeps the order of creation, a project export has no manual order
+func (a *Archive) ReorderIssue(ctx context.Context, pid interface{}, iid int, opt *gitlabx.ReorderIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	return a.GetIssue(ctx, pid, iid)
+}
+
+//* Note
+
+func (a *Archive) ListIssueNotes(ctx context.Context, pid interface{}, iid int) ([]*gitlab.Note, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	issue := a.findIssue(iid)
+	if issue == nil {
+		_, err := notFound(iid)
+		return nil, err
+	}
+	return append([]*gitlab.Note{}, a.notes[issue.ID]...), nil
+}
+
+// addNote adds the note to the issue, the lock is held
+func (a *Archive) addNote(iid int, body *string, createdAt *time.Time, internal bool) (*gitlab.Note, error) {
+	issue := a.findIssue(iid)
+	if issue == nil {
+		_, err := notFound(iid)
+		return nil, err
+	}
+
+	note := &gitlab.Note{ID: a.id(), Body: stringValue(body), CreatedAt: now(createdAt), NoteableID: issue.ID, NoteableIID: iid, NoteableType: "Issue"}
+	a.notes[issue.ID] = append(a.notes[issue.ID], note)
+	if internal {
+		a.internalNotes[note.ID] = true
+	}
+	return note, nil
+}
+
+func (a *Archive) CreateIssueNote(ctx context.Context, pid interface{}, iid int, opt *gitlabx.CreateIssueNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	note, err := a.addNote(iid, opt.Body, opt.CreatedAt, opt.Internal != nil && *opt.Internal)
+	if err != nil {
+		return nil, response(http.StatusNotFound), err
+	}
+	return note, response(http.StatusCreated), nil
+}
+
+// CreateIssueDiscussion adds the note, the threads are flattened in the export
+func (a *Archive) CreateIssueDiscussion(ctx context.Context, pid interface{}, iid int, opt *gitlab.CreateIssueDiscussionOptions) (*gitlab.Discussion, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	note, err := a.addNote(iid, opt.Body, opt.CreatedAt, false)
+	if err != nil {
+		return nil, response(http.StatusNotFound), err
+	}
+	discussion := &gitlab.Discussion{ID: fmt.Sprintf("discussion-%d", note.ID), Notes: []*gitlab.Note{note}}
+	a.discussions[note.NoteableID] = append(a.discussions[note.NoteableID], discussion)
+	return discussion, response(http.StatusCreated), nil
+}
+
+func (a *Archive) AddIssueDiscussionNote(ctx context.Context, pid interface{}, iid int, discussionID string, opt *gitlab.AddIssueDiscussionNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	issue := a.findIssue(iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+	for _, discussion := range a.discussions[issue.ID] {
+		if discussion.ID == discussionID {
+			note, err := a.addNote(iid, opt.Body, opt.CreatedAt, false)
+			if err != nil {
+				return nil, response(http.StatusNotFound), err
+			}
+			discussion.Notes = append(discussion.Notes, note)
+			return note, response(http.StatusCreated), nil
+		}
+	}
+	r, err := notFound(discussionID)
+	return nil, r, err
+}
+
+// CreateIssueLink is accepted but not exported
+func (a *Archive) CreateIssueLink(ctx context.Context, pid interface{}, iid int, opt *gitlab.CreateIssueLinkOptions) (*gitlab.IssueLink, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	source := a.findIssue(iid)
+	targetIID, _ := strconv.Atoi(stringValue(opt.TargetIssueIID))
+	target := a.findIssue(targetIID)
+	if source == nil || target == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+	return &gitlab.IssueLink{SourceIssue: source, TargetIssue: target, LinkType: stringValue(opt.LinkType)}, response(http.StatusCreated), nil
+}
+
+//* Epic, a project export can't contain epics (GitLab Free): they are migrated as issues
+
+func (a *Archive) EpicsAvailable(ctx context.Context, gid interface{}) (bool, error) {
+	return false, nil
+}
+
+func epicsForbidden() (*gitlab.Response, error) {
+	return errorResponse(http.StatusForbidden, "a project export can't contain epics")
+}
+
+func (a *Archive) GetEpic(ctx context.Context, gid interface{}, epic int) (*gitlab.Epic, *gitlab.Response, error) {
+	r, err := epicsForbidden()
+	return nil, r, err
+}
+
+func (a *Archive) CreateEpic(ctx context.Context, gid interface{}, opt *gitlabx.CreateEpicOptions) (*gitlab.Epic, *gitlab.Response, error) {
+	r, err := epicsForbidden()
+	return nil, r, err
+}
+
+func (a *Archive) UpdateEpic(ctx context.Context, gid interface{}, epic int, opt *gitlab.UpdateEpicOptions) (*gitlab.Epic, *gitlab.Response, error) {
+	r, err := epicsForbidden()
+	return nil, r, err
+}
+
+func (a *Archive) DeleteEpic(ctx context.Context, gid interface{}, epic int) (*gitlab.Response, error) {
+	return epicsForbidden()
+}
+
+func (a *Archive) ListEpicNotes(ctx context.Context, gid interface{}, epicID int) ([]*gitlab.Note, error) {
+	_, err := epicsForbidden()
+	return nil, err
+}
+
+func (a *Archive) CreateEpicNote(ctx context.Context, gid interface{}, epic int, opt *gitlabx.CreateEpicNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	r, err := epicsForbidden()
+	return nil, r, err
+}
+
+func (a *Archive) CreateEpicLink(ctx context.Context, gid interface{}, epic int, opt *gitlabx.CreateEpicLinkOptions) (*gitlabx.EpicLink, *gitlab.Response, error) {
+	r, err := epicsForbidden()
+	return nil, r, err
+}
+
+func (a *Archive) SearchEpics(ctx context.Context, gid interface{}, search string) ([]*gitlab.Epic, error) {
+	return nil, nil
+}
+
+func (a *Archive) CountEpics(ctx context.Context, gid interface{}) (int, error) {
+	return 0, nil
+}
+
+func (a *Archive) ListEpicIssues(ctx context.Context, gid interface{}, epic int) ([]*gitlab.Issue, error) {
+	_, err := epicsForbidden()
+	return nil, err
+}
+
+func (a *Archive) UpdateEpicIssueAssignment(ctx context.Context, gid interface{}, epic int, epicIssue int, opt *gitlab.UpdateEpicIsssueAssignmentOptions) ([]*gitlab.Issue, *gitlab.Response, error) {
+	r, err := epicsForbidden()
+	return nil, r, err
+}
+
+//* Board, iteration, severity and CRM contacts are not in the export, they are accepted and dropped
+
+func (a *Archive) ListIssueBoards(ctx context.Context, pid interface{}) ([]*gitlab.IssueBoard, error) {
+	return nil, nil
+}
+
+func (a *Archive) CreateIssueBoard(ctx context.Context, pid interface{}, opt *gitlab.CreateIssueBoardOptions) (*gitlab.IssueBoard, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return &gitlab.IssueBoard{ID: a.id(), Name: stringValue(opt.Name)}, response(http.StatusCreated), nil
+}
+
+func (a *Archive) CreateIssueBoardList(ctx context.Context, pid interface{}, board int, opt *gitlab.CreateIssueBoardListOptions) (*gitlab.BoardList, *gitlab.Response, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return &gitlab.BoardList{ID: a.id()}, response(http.StatusCreated), nil
+}
+
+func (a *Archive) SetBoardListLimit(ctx context.Context, listID int, maxIssueCount int) error {
+	return nil
+}
+
+func (a *Archive) ListIterationCadences(ctx context.Context, groupPath string) ([]*gitlabx.IterationCadence, error) {
+	return nil, nil
+}
+
+func (a *Archive) CreateIterationCadence(ctx context.Context, groupPath string, opt *gitlabx.CreateIterationCadenceOptions) (*gitlabx.IterationCadence, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return &gitlabx.IterationCadence{ID: fmt.Sprintf("gid://gitlab/Iterations::Cadence/%d", a.id()), Title: opt.Title}, nil
+}
+
+func (a *Archive) ListIterations(ctx context.Context, groupPath string, cadenceID string) ([]*gitlabx.Iteration, error) {
+	return nil, nil
+}
+
+func (a *Archive) CreateIteration(ctx context.Context, groupPath string, cadenceID string, opt *gitlabx.CreateIterationOptions) (*gitlabx.Iteration, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return &gitlabx.Iteration{ID: fmt.Sprintf("gid://gitlab/Iteration/%d", a.id()), Title: opt.Title, StartDate: opt.StartDate}, nil
+}
+
+func (a *Archive) SetIssueIteration(ctx context.Context, projectPath string, issue int, iterationID string) error {
+	return nil
+}
+
+func (a *Archive) SetIssueSeverity(ctx context.Context, projectPath string, issue int, severity string) error {
+	return nil
+}
+
+func (a *Archive) ListCRMContacts(ctx context.Context, groupPath string) ([]*gitlabx.CRMContact, error) {
+	return nil, nil
+}
+
+func (a *Archive) AddIssueCRMContacts(ctx context.Context, projectPath string, issue int, contactIDs []string) error {
+	return nil
+}
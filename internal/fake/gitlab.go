@@ -0,0 +1,1476 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+// Package fake provides in-memory Jira and GitLab backends to test the converters without a server.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+// GitLab keeps everything created through it in memory.
+// Projects and groups must be added before use and can be referenced by ID or path.
+type GitLab struct {
+	mutex sync.Mutex
+
+	// GitLab Free: the epics API answers 403
+	NoEpics bool
+	// Answer of /version (e.g. 16.5.1-ee), unknown if it is empty
+	Version string
+	// The next creates of issues and epics succeed but answer 504 Gateway Timeout
+	CreateTimeouts int
+	// The next creates of epic notes succeed but answer 504 Gateway Timeout
+	NoteTimeouts int
+	// The next creates of issue notes answer 401 Unauthorized (e.g. an expired token)
+	NoteErrors int
+	// Number of the calls of ListGroupLabels
+	GroupLabelLists int
+
+	nextID   int
+	paths    map[string]int
+	Projects map[int]*gitlab.Project
+	Groups   map[int]bool
+	Users    map[int]*gitlab.User
+
+	// Key: project or group ID
+	ProjectMembers  map[int][]*gitlab.ProjectMember
+	GroupMembers    map[int][]*gitlab.GroupMember
+	Milestones      map[int][]*gitlab.Milestone
+	GroupMilestones map[int][]*gitlab.GroupMilestone
+	Labels          map[int][]*gitlab.Label
+	GroupLabels     map[int][]*gitlab.GroupLabel
+	Uploads         map[int][]string
+	Wikis           map[int][]*gitlab.Wiki
+	Issues          map[int][]*gitlab.Issue
+	Epics           map[int][]*gitlab.Epic
+	Boards          map[int][]*gitlab.IssueBoard
+	// Key: project ID, branch/path -> content
+	Files map[int]map[string]string
+
+	// Key: issue or epic ID
+	IssueNotes map[int][]*gitlab.Note
+	EpicNotes  map[int][]*gitlab.Note
+	IssueLinks map[int][]*gitlab.IssueLink
+	EpicLinks  map[int][]*gitlabx.EpicLink
+
+	// Key: issue ID, the notes of a discussion are also in IssueNotes
+	IssueDiscussions map[int][]*gitlab.Discussion
+
+	// Key: board list ID, WIP limit
+	BoardListLimits map[int]int
+
+	// Key: note ID, go-gitlab doesn't have the internal flag of a note
+	InternalNotes map[int]bool
+
+	// Key: group path, cadence ID, issue ID
+	IterationCadences map[string][]*gitlabx.IterationCadence
+	Iterations        map[string][]*gitlabx.Iteration
+	IssueIterations   map[int]string
+	IssueSeverities   map[int]string
+
+	// Key: group path, issue ID
+	CRMContacts      map[string][]*gitlabx.CRMContact
+	IssueCRMContacts map[int][]string
+}
+
+func NewGitLab() *GitLab {
+	return &GitLab{
+		nextID:          1,
+		paths:           make(map[string]int),
+		Projects:        make(map[int]*gitlab.Project),
+		Groups:          make(map[int]bool),
+		Users:           make(map[int]*gitlab.User),
+		ProjectMembers:  make(map[int][]*gitlab.ProjectMember),
+		GroupMembers:    make(map[int][]*gitlab.GroupMember),
+		Milestones:      make(map[int][]*gitlab.Milestone),
+		GroupMilestones: make(map[int][]*gitlab.GroupMilestone),
+		Labels:          make(map[int][]*gitlab.Label),
+		GroupLabels:     make(map[int][]*gitlab.GroupLabel),
+		Uploads:         make(map[int][]string),
+		Wikis:           make(map[int][]*gitlab.Wiki),
+		Boards:          make(map[int][]*gitlab.IssueBoard),
+		Files:           make(map[int]map[string]string),
+		Issues:          make(map[int][]*gitlab.Issue),
+		Epics:           make(map[int][]*gitlab.Epic),
+		IssueNotes:      make(map[int][]*gitlab.Note),
+		EpicNotes:       make(map[int][]*gitlab.Note),
+		IssueLinks:      make(map[int][]*gitlab.IssueLink),
+		EpicLinks:       make(map[int][]*gitlabx.EpicLink),
+		InternalNotes:   make(map[int]bool),
+
+		IssueDiscussions: make(map[int][]*gitlab.Discussion),
+
+		BoardListLimits: make(map[int]int),
+
+		IterationCadences: make(map[string][]*gitlabx.IterationCadence),
+		Iterations:        make(map[string][]*gitlabx.Iteration),
+		IssueIterations:   make(map[int]string),
+		IssueSeverities:   make(map[int]string),
+
+		CRMContacts:      make(map[string][]*gitlabx.CRMContact),
+		IssueCRMContacts: make(map[int][]string),
+	}
+}
+
+//* Setup
+
+func (f *GitLab) AddProject(id int, path string) *gitlab.Project {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	project := &gitlab.Project{ID: id, PathWithNamespace: path, WebURL: "https://gitlab.example.com/" + path}
+	f.Projects[id] = project
+	f.paths[path] = id
+	return project
+}
+
+func (f *GitLab) AddGroup(id int, path string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.Groups[id] = true
+	f.paths[path] = id
+}
+
+// AddUser registers a user and makes it a member of the project
+func (f *GitLab) AddUser(pid interface{}, user *gitlab.User) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.Users[user.ID] = user
+	if id, ok := f.resolve(pid); ok {
+		f.ProjectMembers[id] = append(f.ProjectMembers[id], &gitlab.ProjectMember{ID: user.ID, Username: user.Username, Name: user.Name})
+	}
+}
+
+//* Helpers
+
+func (f *GitLab) resolve(id interface{}) (int, bool) {
+	switch v := id.(type) {
+	case int:
+		return v, f.Projects[v] != nil || f.Groups[v]
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return f.resolve(n)
+		}
+		n, ok := f.paths[v]
+		return n, ok
+	}
+	return 0, false
+}
+
+// freeID is an ID of a new project or group, the IDs of AddProject and AddGroup are set by the tests
+func (f *GitLab) freeID() int {
+	id := f.id()
+	for f.Projects[id] != nil || f.Groups[id] {
+		id = f.id()
+	}
+	return id
+}
+
+func (f *GitLab) pathOf(id int) string {
+	for path, pathID := range f.paths {
+		if pathID == id {
+			return path
+		}
+	}
+	return ""
+}
+
+func (f *GitLab) id() int {
+	id := f.nextID
+	f.nextID++
+	return id
+}
+
+func response(statusCode int) *gitlab.Response {
+	return &gitlab.Response{Response: &http.Response{StatusCode: statusCode}}
+}
+
+func errorResponse(statusCode int, format string, args ...interface{}) (*gitlab.Response, error) {
+	r := response(statusCode)
+	r.Request = &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/fake"}} // for ErrorResponse.Error()
+	return r, &gitlab.ErrorResponse{Response: r.Response, Message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(id interface{}) (*gitlab.Response, error) {
+	return errorResponse(http.StatusNotFound, "%v not found", id)
+}
+
+func (f *GitLab) findIssue(pid int, iid int) *gitlab.Issue {
+	for _, issue := range f.Issues[pid] {
+		if issue.IID == iid {
+			return issue
+		}
+	}
+	return nil
+}
+
+func (f *GitLab) findEpic(gid int, iid int) *gitlab.Epic {
+	for _, epic := range f.Epics[gid] {
+		if epic.IID == iid {
+			return epic
+		}
+	}
+	return nil
+}
+
+func now(t *time.Time) *time.Time {
+	if t != nil {
+		return t
+	}
+	n := time.Now()
+	return &n
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func labels(l *gitlab.Labels) gitlab.Labels {
+	if l == nil {
+		return nil
+	}
+	return *l
+}
+
+//* Project
+
+func (f *GitLab) GetVersion(ctx context.Context) (*gitlab.Version, error) {
+	return &gitlab.Version{Version: f.Version}, nil
+}
+
+func (f *GitLab) GetProject(ctx context.Context, pid interface{}) (*gitlab.Project, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok || f.Projects[id] == nil {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	return f.Projects[id], response(http.StatusOK), nil
+}
+
+func (f *GitLab) CreateProject(ctx context.Context, opt *gitlab.CreateProjectOptions) (*gitlab.Project, *gitlab.Response, error) {
+	f.mutex.Lock()
+	id := f.freeID()
+	path := stringValue(opt.Path)
+	if opt.NamespaceID != nil {
+		path = f.pathOf(*opt.NamespaceID) + "/" + path
+	}
+	f.mutex.Unlock()
+
+	project := f.AddProject(id, path)
+	project.Name = stringValue(opt.Name)
+	return project, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) EditProject(ctx context.Context, pid interface{}, opt *gitlab.EditProjectOptions) (*gitlab.Project, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok || f.Projects[id] == nil {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	if opt.Description != nil {
+		f.Projects[id].Description = *opt.Description
+	}
+	if opt.Avatar != nil {
+		f.Projects[id].AvatarURL = fmt.Sprintf("https://gitlab.example.com/uploads/project/avatar/%d/%s", id, opt.Avatar.Filename)
+	}
+	return f.Projects[id], response(http.StatusOK), nil
+}
+
+func (f *GitLab) UploadFile(ctx context.Context, pid interface{}, content io.Reader, filename string) (*gitlab.ProjectFile, *gitlab.Response, error) {
+	if _, err := io.ReadAll(content); err != nil {
+		return nil, nil, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	f.Uploads[id] = append(f.Uploads[id], filename)
+
+	url := fmt.Sprintf("/uploads/%d/%s", f.id(), filename)
+	return &gitlab.ProjectFile{
+		Alt:      filename,
+		URL:      url,
+		Markdown: fmt.Sprintf("[%s](%s)", filename, url),
+	}, response(http.StatusCreated), nil
+}
+
+//* Wiki
+
+func (f *GitLab) findWiki(pid int, slug string) *gitlab.Wiki {
+	for _, wiki := range f.Wikis[pid] {
+		if wiki.Slug == slug {
+			return wiki
+		}
+	}
+	return nil
+}
+
+func (f *GitLab) GetWikiPage(ctx context.Context, pid interface{}, slug string) (*gitlab.Wiki, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	wiki := f.findWiki(id, slug)
+	if !ok || wiki == nil {
+		r, err := notFound(slug)
+		return nil, r, err
+	}
+	return wiki, response(http.StatusOK), nil
+}
+
+func (f *GitLab) CreateWikiPage(ctx context.Context, pid interface{}, opt *gitlab.CreateWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	title := stringValue(opt.Title)
+	slug := strings.ReplaceAll(title, " ", "-")
+	if f.findWiki(id, slug) != nil {
+		r, err := errorResponse(http.StatusBadRequest, "wiki page %s already exists", slug)
+		return nil, r, err
+	}
+
+	wiki := &gitlab.Wiki{Title: title, Slug: slug, Content: stringValue(opt.Content), Format: gitlab.WikiFormatMarkdown}
+	f.Wikis[id] = append(f.Wikis[id], wiki)
+	return wiki, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) EditWikiPage(ctx context.Context, pid interface{}, slug string, opt *gitlab.EditWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	wiki := f.findWiki(id, slug)
+	if !ok || wiki == nil {
+		r, err := notFound(slug)
+		return nil, r, err
+	}
+	if opt.Content != nil {
+		wiki.Content = *opt.Content
+	}
+	return wiki, response(http.StatusOK), nil
+}
+
+//* Repository
+
+func (f *GitLab) GetFileMetaData(ctx context.Context, pid interface{}, path string, ref string) (*gitlab.File, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if _, exist := f.Files[id][ref+"/"+path]; !ok || !exist {
+		r, err := notFound(path)
+		return nil, r, err
+	}
+	return &gitlab.File{FilePath: path, Ref: ref}, response(http.StatusOK), nil
+}
+
+// CreateCommit applies the create and update actions
+func (f *GitLab) CreateCommit(ctx context.Context, pid interface{}, opt *gitlab.CreateCommitOptions) (*gitlab.Commit, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	if f.Files[id] == nil {
+		f.Files[id] = make(map[string]string)
+	}
+
+	branch := stringValue(opt.Branch)
+	for _, action := range opt.Actions {
+		key := branch + "/" + stringValue(action.FilePath)
+		_, exist := f.Files[id][key]
+		if *action.Action == gitlab.FileCreate && exist {
+			r, err := errorResponse(http.StatusBadRequest, "a file with this name already exists")
+			return nil, r, err
+		}
+		f.Files[id][key] = stringValue(action.Content)
+	}
+	return &gitlab.Commit{ID: fmt.Sprintf("%040d", f.id()), Title: stringValue(opt.CommitMessage)}, response(http.StatusCreated), nil
+}
+
+//* User, Member
+
+func (f *GitLab) GetGroup(ctx context.Context, gid interface{}) (*gitlab.Group, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(gid)
+	if !ok || !f.Groups[id] {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+	group := &gitlab.Group{ID: id}
+	for path, pathID := range f.paths {
+		if pathID == id {
+			group.FullPath = path
+			group.Path = path[strings.LastIndex(path, "/")+1:]
+		}
+	}
+	return group, response(http.StatusOK), nil
+}
+
+func (f *GitLab) CreateGroup(ctx context.Context, opt *gitlab.CreateGroupOptions) (*gitlab.Group, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	group := &gitlab.Group{ID: f.freeID(), Name: stringValue(opt.Name), Path: stringValue(opt.Path), FullPath: stringValue(opt.Path)}
+	if opt.ParentID != nil {
+		group.ParentID = *opt.ParentID
+		group.FullPath = f.pathOf(*opt.ParentID) + "/" + group.Path
+	}
+	f.Groups[group.ID] = true
+	f.paths[group.FullPath] = group.ID
+	return group, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) UploadGroupWikiAttachment(ctx context.Context, gid interface{}, content io.Reader, filename string) (*gitlabx.WikiAttachment, *gitlab.Response, error) {
+	if _, err := io.ReadAll(content); err != nil {
+		return nil, nil, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(gid)
+	if !ok || !f.Groups[id] {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+	f.Uploads[id] = append(f.Uploads[id], filename)
+
+	attachment := &gitlabx.WikiAttachment{
+		FileName: filename,
+		FilePath: fmt.Sprintf("uploads/%d/%s", f.id(), filename),
+		Branch:   "main",
+	}
+	attachment.Link.URL = attachment.FilePath
+	attachment.Link.Markdown = fmt.Sprintf("[%s](%s)", filename, attachment.FilePath)
+	if strings.HasSuffix(filename, ".png") {
+		attachment.Link.Markdown = "!" + attachment.Link.Markdown
+	}
+	return attachment, response(http.StatusCreated), nil
+}
+
+// ListUsers understands only the username filter
+func (f *GitLab) ListUsers(ctx context.Context, opt *gitlab.ListUsersOptions) ([]*gitlab.User, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var result []*gitlab.User
+	for _, user := range f.Users {
+		if opt.Username == nil || user.Username == *opt.Username {
+			result = append(result, user)
+		}
+	}
+	return result, response(http.StatusOK), nil
+}
+
+func (f *GitLab) GetUser(ctx context.Context, uid int) (*gitlab.User, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	user, ok := f.Users[uid]
+	if !ok {
+		r, err := notFound(uid)
+		return nil, r, err
+	}
+	return user, response(http.StatusOK), nil
+}
+
+func (f *GitLab) ListProjectMembers(ctx context.Context, pid interface{}) ([]*gitlab.ProjectMember, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	return f.ProjectMembers[id], nil
+}
+
+func (f *GitLab) AddProjectMember(ctx context.Context, pid interface{}, opt *gitlab.AddProjectMemberOptions) (*gitlab.ProjectMember, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	userID := opt.UserID.(int)
+	for _, member := range f.ProjectMembers[id] {
+		if member.ID == userID {
+			r, err := errorResponse(http.StatusConflict, "Member already exists")
+			return nil, r, err
+		}
+	}
+
+	member := &gitlab.ProjectMember{ID: userID, AccessLevel: *opt.AccessLevel}
+	if user, ok := f.Users[userID]; ok {
+		member.Username = user.Username
+		member.Name = user.Name
+	}
+	f.ProjectMembers[id] = append(f.ProjectMembers[id], member)
+	return member, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) AddGroupMember(ctx context.Context, gid interface{}, opt *gitlab.AddGroupMemberOptions) (*gitlab.GroupMember, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(gid)
+	if !ok {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+	for _, member := range f.GroupMembers[id] {
+		if member.ID == *opt.UserID {
+			r, err := errorResponse(http.StatusConflict, "Member already exists")
+			return nil, r, err
+		}
+	}
+
+	member := &gitlab.GroupMember{ID: *opt.UserID, AccessLevel: *opt.AccessLevel}
+	f.GroupMembers[id] = append(f.GroupMembers[id], member)
+	return member, response(http.StatusCreated), nil
+}
+
+//* Milestone
+
+func (f *GitLab) ListMilestones(ctx context.Context, pid interface{}) ([]*gitlab.Milestone, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	return f.Milestones[id], nil
+}
+
+func (f *GitLab) CreateMilestone(ctx context.Context, pid interface{}, opt *gitlab.CreateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+
+	for _, m := range f.Milestones[id] {
+		if m.Title == stringValue(opt.Title) {
+			r, err := errorResponse(http.StatusBadRequest, "Title has already been taken")
+			return nil, r, err
+		}
+	}
+
+	milestone := &gitlab.Milestone{
+		ID:          f.id(),
+		IID:         len(f.Milestones[id]) + 1,
+		ProjectID:   id,
+		Title:       stringValue(opt.Title),
+		Description: stringValue(opt.Description),
+		StartDate:   opt.StartDate,
+		DueDate:     opt.DueDate,
+		State:       "active",
+	}
+	f.Milestones[id] = append(f.Milestones[id], milestone)
+	return milestone, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) UpdateMilestone(ctx context.Context, pid interface{}, milestone int, opt *gitlab.UpdateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	for _, m := range f.Milestones[id] {
+		if m.ID != milestone {
+			continue
+		}
+		if opt.StateEvent != nil && *opt.StateEvent == "close" {
+			m.State = "closed"
+		}
+		return m, response(http.StatusOK), nil
+	}
+
+	r, err := notFound(milestone)
+	return nil, r, err
+}
+
+func (f *GitLab) ListGroupMilestones(ctx context.Context, gid interface{}) ([]*gitlab.GroupMilestone, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	return f.GroupMilestones[id], nil
+}
+
+func (f *GitLab) CreateGroupMilestone(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(gid)
+	if !ok {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+
+	for _, m := range f.GroupMilestones[id] {
+		if m.Title == stringValue(opt.Title) {
+			r, err := errorResponse(http.StatusBadRequest, "Title has already been taken")
+			return nil, r, err
+		}
+	}
+
+	milestone := &gitlab.GroupMilestone{
+		ID:          f.id(),
+		IID:         len(f.GroupMilestones[id]) + 1,
+		GroupID:     id,
+		Title:       stringValue(opt.Title),
+		Description: stringValue(opt.Description),
+		StartDate:   opt.StartDate,
+		DueDate:     opt.DueDate,
+		State:       "active",
+	}
+	f.GroupMilestones[id] = append(f.GroupMilestones[id], milestone)
+	return milestone, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) UpdateGroupMilestone(ctx context.Context, gid interface{}, milestone int, opt *gitlab.UpdateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	for _, m := range f.GroupMilestones[id] {
+		if m.ID != milestone {
+			continue
+		}
+		if opt.StateEvent != nil && *opt.StateEvent == "close" {
+			m.State = "closed"
+		}
+		return m, response(http.StatusOK), nil
+	}
+
+	r, err := notFound(milestone)
+	return nil, r, err
+}
+
+//* Label
+
+func (f *GitLab) ListGroupLabels(ctx context.Context, gid interface{}, opt *gitlab.ListGroupLabelsOptions) ([]*gitlab.GroupLabel, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.GroupLabelLists++
+	id, _ := f.resolve(gid)
+	return f.GroupLabels[id], nil
+}
+
+func (f *GitLab) ListLabels(ctx context.Context, pid interface{}, opt *gitlab.ListLabelsOptions) ([]*gitlab.Label, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	return f.Labels[id], nil
+}
+
+func (f *GitLab) CreateGroupLabel(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupLabelOptions) (*gitlab.GroupLabel, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(gid)
+	if !ok {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+	for _, label := range f.GroupLabels[id] {
+		if label.Name == *opt.Name {
+			r, err := errorResponse(http.StatusConflict, "Label already exists")
+			return nil, r, err
+		}
+	}
+
+	label := &gitlab.GroupLabel{ID: f.id(), Name: *opt.Name, Description: stringValue(opt.Description), Color: stringValue(opt.Color)}
+	f.GroupLabels[id] = append(f.GroupLabels[id], label)
+	return label, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) CreateLabel(ctx context.Context, pid interface{}, opt *gitlab.CreateLabelOptions) (*gitlab.Label, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+	for _, label := range f.Labels[id] {
+		if label.Name == *opt.Name {
+			r, err := errorResponse(http.StatusConflict, "Label already exists")
+			return nil, r, err
+		}
+	}
+
+	label := &gitlab.Label{ID: f.id(), Name: *opt.Name, Description: stringValue(opt.Description), Color: stringValue(opt.Color)}
+	f.Labels[id] = append(f.Labels[id], label)
+	return label, response(http.StatusCreated), nil
+}
+
+//* Issue
+
+func (f *GitLab) GetIssue(ctx context.Context, pid interface{}, iid int) (*gitlab.Issue, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	issue := f.findIssue(id, iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+	return issue, response(http.StatusOK), nil
+}
+
+func (f *GitLab) CreateIssue(ctx context.Context, pid interface{}, opt *gitlabx.CreateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok || f.Projects[id] == nil {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+
+	//* IIDs stay unique after a deletion
+	iid := 1
+	for _, issue := range f.Issues[id] {
+		if issue.IID >= iid {
+			iid = issue.IID + 1
+		}
+	}
+	issue := &gitlab.Issue{
+		ID:          f.id(),
+		IID:         iid,
+		ProjectID:   id,
+		Title:       stringValue(opt.Title),
+		Description: stringValue(opt.Description),
+		Labels:      labels(opt.Labels),
+		CreatedAt:   now(opt.CreatedAt),
+		DueDate:     opt.DueDate,
+		State:       "opened",
+		WebURL:      fmt.Sprintf("%s/-/issues/%d", f.Projects[id].WebURL, iid),
+		IssueType:   opt.IssueType,
+	}
+	if opt.Confidential != nil {
+		issue.Confidential = *opt.Confidential
+	}
+	if opt.Weight != nil {
+		issue.Weight = *opt.Weight
+	}
+	if opt.AssigneeIDs != nil {
+		for _, assigneeID := range *opt.AssigneeIDs {
+			issue.Assignees = append(issue.Assignees, &gitlab.IssueAssignee{ID: assigneeID})
+		}
+	}
+	if opt.MilestoneID != nil {
+		for _, milestone := range f.Milestones[id] {
+			if milestone.ID == *opt.MilestoneID {
+				issue.Milestone = milestone
+			}
+		}
+		//* Milestones of the groups of the project
+		for _, milestones := range f.GroupMilestones {
+			for _, milestone := range milestones {
+				if milestone.ID == *opt.MilestoneID {
+					issue.Milestone = &gitlab.Milestone{ID: milestone.ID, IID: milestone.IID, GroupID: milestone.GroupID, Title: milestone.Title}
+				}
+			}
+		}
+	}
+	f.Issues[id] = append(f.Issues[id], issue)
+	if f.CreateTimeouts > 0 {
+		f.CreateTimeouts--
+		r, err := errorResponse(http.StatusGatewayTimeout, "504 Gateway Timeout")
+		return nil, r, err
+	}
+	return issue, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) UpdateIssue(ctx context.Context, pid interface{}, iid int, opt *gitlab.UpdateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	issue := f.findIssue(id, iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+
+	if opt.StateEvent != nil && *opt.StateEvent == "close" {
+		issue.State = "closed"
+		issue.ClosedAt = now(nil)
+	}
+	if opt.StateEvent != nil && *opt.StateEvent == "reopen" {
+		issue.State = "opened"
+		issue.ClosedAt = nil
+	}
+	if opt.Title != nil {
+		issue.Title = *opt.Title
+	}
+	if opt.DueDate != nil {
+		issue.DueDate = opt.DueDate
+	}
+	if opt.EpicID != nil {
+		issue.Epic = &gitlab.Epic{ID: *opt.EpicID}
+	}
+	if opt.Labels != nil {
+		issue.Labels = *opt.Labels
+	}
+	if opt.AddLabels != nil {
+		issue.Labels = append(issue.Labels, *opt.AddLabels...)
+	}
+	if opt.RemoveLabels != nil {
+		remove := make(map[string]bool)
+		for _, label := range *opt.RemoveLabels {
+			remove[label] = true
+		}
+		var labels gitlab.Labels
+		for _, label := range issue.Labels {
+			if !remove[label] {
+				labels = append(labels, label)
+			}
+		}
+		issue.Labels = labels
+	}
+	if opt.Description != nil {
+		issue.Description = *opt.Description
+	}
+	return issue, response(http.StatusOK), nil
+}
+
+// moveIssue puts the issue after (or before) another issue in Issues, which is the manual order
+func (f *GitLab) DeleteIssue(ctx context.Context, pid interface{}, iid int) (*gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	for i, issue := range f.Issues[id] {
+		if issue.IID == iid {
+			f.Issues[id] = append(f.Issues[id][:i:i], f.Issues[id][i+1:]...)
+			delete(f.IssueNotes, issue.ID)
+			delete(f.IssueLinks, issue.ID)
+			delete(f.IssueDiscussions, issue.ID)
+			return response(http.StatusNoContent), nil
+		}
+	}
+	return notFound(iid)
+}
+
+func (f *GitLab) moveIssue(pid int, id int, afterID *int, beforeID *int) bool {
+	issues := f.Issues[pid]
+	index := -1
+	for i, issue := range issues {
+		if issue.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false
+	}
+
+	moved := issues[index]
+	issues = append(issues[:index:index], issues[index+1:]...)
+	for i, issue := range issues {
+		if afterID != nil && issue.ID == *afterID {
+			issues = append(issues[:i+1], append([]*gitlab.Issue{moved}, issues[i+1:]...)...)
+			f.Issues[pid] = issues
+			return true
+		}
+		if beforeID != nil && issue.ID == *beforeID {
+			issues = append(issues[:i], append([]*gitlab.Issue{moved}, issues[i:]...)...)
+			f.Issues[pid] = issues
+			return true
+		}
+	}
+	return false
+}
+
+func (f *GitLab) ReorderIssue(ctx context.Context, pid interface{}, iid int, opt *gitlabx.ReorderIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	issue := f.findIssue(id, iid)
+	if issue == nil || !f.moveIssue(id, issue.ID, opt.MoveAfterID, opt.MoveBeforeID) {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+	return issue, response(http.StatusOK), nil
+}
+
+func (f *GitLab) SearchIssues(ctx context.Context, pid interface{}, search string) ([]*gitlab.Issue, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	var result []*gitlab.Issue
+	for _, issue := range f.Issues[id] {
+		if strings.Contains(issue.Title, search) || strings.Contains(issue.Description, search) {
+			result = append(result, issue)
+		}
+	}
+	return result, nil
+}
+
+func (f *GitLab) CountIssues(ctx context.Context, pid interface{}) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	return len(f.Issues[id]), nil
+}
+
+func (f *GitLab) ListIssueNotes(ctx context.Context, pid interface{}, iid int) ([]*gitlab.Note, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	issue := f.findIssue(id, iid)
+	if issue == nil {
+		_, err := notFound(iid)
+		return nil, err
+	}
+	return f.IssueNotes[issue.ID], nil
+}
+
+func (f *GitLab) CreateIssueNote(ctx context.Context, pid interface{}, iid int, opt *gitlabx.CreateIssueNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	issue := f.findIssue(id, iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+	if f.NoteErrors > 0 {
+		f.NoteErrors--
+		r, err := errorResponse(http.StatusUnauthorized, "401 Unauthorized")
+		return nil, r, err
+	}
+
+	note := &gitlab.Note{ID: f.id(), Body: stringValue(opt.Body), CreatedAt: now(opt.CreatedAt), NoteableID: issue.ID, NoteableIID: iid, NoteableType: "Issue"}
+	f.IssueNotes[issue.ID] = append(f.IssueNotes[issue.ID], note)
+	if opt.Internal != nil && *opt.Internal {
+		f.InternalNotes[note.ID] = true
+	}
+	return note, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) CreateIssueDiscussion(ctx context.Context, pid interface{}, iid int, opt *gitlab.CreateIssueDiscussionOptions) (*gitlab.Discussion, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	issue := f.findIssue(id, iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+
+	note := &gitlab.Note{ID: f.id(), Body: stringValue(opt.Body), CreatedAt: now(opt.CreatedAt), NoteableID: issue.ID, NoteableIID: iid, NoteableType: "Issue"}
+	f.IssueNotes[issue.ID] = append(f.IssueNotes[issue.ID], note)
+	discussion := &gitlab.Discussion{ID: fmt.Sprintf("discussion-%d", note.ID), Notes: []*gitlab.Note{note}}
+	f.IssueDiscussions[issue.ID] = append(f.IssueDiscussions[issue.ID], discussion)
+	return discussion, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) AddIssueDiscussionNote(ctx context.Context, pid interface{}, iid int, discussionID string, opt *gitlab.AddIssueDiscussionNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	issue := f.findIssue(id, iid)
+	if issue == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+
+	for _, discussion := range f.IssueDiscussions[issue.ID] {
+		if discussion.ID == discussionID {
+			note := &gitlab.Note{ID: f.id(), Body: stringValue(opt.Body), CreatedAt: now(opt.CreatedAt), NoteableID: issue.ID, NoteableIID: iid, NoteableType: "Issue"}
+			f.IssueNotes[issue.ID] = append(f.IssueNotes[issue.ID], note)
+			discussion.Notes = append(discussion.Notes, note)
+			return note, response(http.StatusCreated), nil
+		}
+	}
+	r, err := notFound(discussionID)
+	return nil, r, err
+}
+
+func (f *GitLab) CreateIssueLink(ctx context.Context, pid interface{}, iid int, opt *gitlab.CreateIssueLinkOptions) (*gitlab.IssueLink, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	source := f.findIssue(id, iid)
+	targetID, _ := f.resolve(stringValue(opt.TargetProjectID))
+	targetIID, _ := strconv.Atoi(stringValue(opt.TargetIssueIID))
+	target := f.findIssue(targetID, targetIID)
+	if source == nil || target == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+
+	for _, link := range f.IssueLinks[source.ID] {
+		if link.TargetIssue.ID == target.ID {
+			r, err := errorResponse(http.StatusConflict, "Issue(s) already assigned")
+			return nil, r, err
+		}
+	}
+
+	link := &gitlab.IssueLink{SourceIssue: source, TargetIssue: target, LinkType: stringValue(opt.LinkType)}
+	f.IssueLinks[source.ID] = append(f.IssueLinks[source.ID], link)
+	return link, response(http.StatusCreated), nil
+}
+
+//* Epic
+
+func (f *GitLab) EpicsAvailable(ctx context.Context, gid interface{}) (bool, error) {
+	return !f.NoEpics, nil
+}
+
+func (f *GitLab) GetEpic(ctx context.Context, gid interface{}, iid int) (*gitlab.Epic, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	epic := f.findEpic(id, iid)
+	if epic == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+	return epic, response(http.StatusOK), nil
+}
+
+func (f *GitLab) CreateEpic(ctx context.Context, gid interface{}, opt *gitlabx.CreateEpicOptions) (*gitlab.Epic, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.NoEpics {
+		r, err := errorResponse(http.StatusForbidden, "403 Forbidden")
+		return nil, r, err
+	}
+
+	id, ok := f.resolve(gid)
+	if !ok || !f.Groups[id] {
+		r, err := notFound(gid)
+		return nil, r, err
+	}
+
+	if opt.StartDateFixed != nil && opt.DueDateFixed != nil && time.Time(*opt.StartDateFixed).After(time.Time(*opt.DueDateFixed)) {
+		r, err := errorResponse(http.StatusBadRequest, "Start date must be before the due date")
+		return nil, r, err
+	}
+
+	iid := len(f.Epics[id]) + 1
+	epic := &gitlab.Epic{
+		ID:          f.id(),
+		IID:         iid,
+		GroupID:     id,
+		Title:       stringValue(opt.Title),
+		Description: stringValue(opt.Description),
+		Labels:      labels(opt.Labels),
+		CreatedAt:   now(opt.CreatedAt),
+		StartDate:   opt.StartDateFixed,
+		DueDate:     opt.DueDateFixed,
+		State:       "opened",
+		WebURL:      fmt.Sprintf("https://gitlab.example.com/groups/%d/-/epics/%d", id, iid),
+	}
+	if opt.Confidential != nil {
+		epic.Confidential = *opt.Confidential
+	}
+	if opt.StartDateIsFixed != nil {
+		epic.StartDateIsFixed = *opt.StartDateIsFixed
+	}
+	if opt.DueDateIsFixed != nil {
+		epic.DueDateIsFixed = *opt.DueDateIsFixed
+	}
+	f.Epics[id] = append(f.Epics[id], epic)
+	if f.CreateTimeouts > 0 {
+		f.CreateTimeouts--
+		r, err := errorResponse(http.StatusGatewayTimeout, "504 Gateway Timeout")
+		return nil, r, err
+	}
+	return epic, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) UpdateEpic(ctx context.Context, gid interface{}, iid int, opt *gitlab.UpdateEpicOptions) (*gitlab.Epic, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	epic := f.findEpic(id, iid)
+	if epic == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+
+	if opt.StateEvent != nil && *opt.StateEvent == "close" {
+		epic.State = "closed"
+	}
+	if opt.StateEvent != nil && *opt.StateEvent == "reopen" {
+		epic.State = "opened"
+	}
+	if opt.Title != nil {
+		epic.Title = *opt.Title
+	}
+	if opt.Labels != nil {
+		epic.Labels = *opt.Labels
+	}
+	if opt.StartDateFixed != nil {
+		epic.StartDate = opt.StartDateFixed
+	}
+	if opt.DueDateFixed != nil {
+		epic.DueDate = opt.DueDateFixed
+	}
+	if opt.StartDateIsFixed != nil {
+		epic.StartDateIsFixed = *opt.StartDateIsFixed
+	}
+	if opt.DueDateIsFixed != nil {
+		epic.DueDateIsFixed = *opt.DueDateIsFixed
+	}
+	return epic, response(http.StatusOK), nil
+}
+
+func (f *GitLab) DeleteEpic(ctx context.Context, gid interface{}, iid int) (*gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	for i, epic := range f.Epics[id] {
+		if epic.IID == iid {
+			f.Epics[id] = append(f.Epics[id][:i:i], f.Epics[id][i+1:]...)
+			delete(f.EpicNotes, epic.ID)
+			delete(f.EpicLinks, epic.ID)
+			return response(http.StatusNoContent), nil
+		}
+	}
+	return notFound(iid)
+}
+
+func (f *GitLab) SearchEpics(ctx context.Context, gid interface{}, search string) ([]*gitlab.Epic, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	var result []*gitlab.Epic
+	for _, epic := range f.Epics[id] {
+		if strings.Contains(epic.Title, search) || strings.Contains(epic.Description, search) {
+			result = append(result, epic)
+		}
+	}
+	return result, nil
+}
+
+func (f *GitLab) CountEpics(ctx context.Context, gid interface{}) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	return len(f.Epics[id]), nil
+}
+
+// ListEpicIssues returns the issues in the manual order, the epic issue ID is the issue ID
+func (f *GitLab) ListEpicIssues(ctx context.Context, gid interface{}, iid int) ([]*gitlab.Issue, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	epic := f.findEpic(id, iid)
+	if epic == nil {
+		_, err := notFound(iid)
+		return nil, err
+	}
+
+	var result []*gitlab.Issue
+	for _, issues := range f.Issues {
+		for _, issue := range issues {
+			if issue.Epic != nil && issue.Epic.ID == epic.ID {
+				issue.EpicIssueID = issue.ID
+				result = append(result, issue)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *GitLab) UpdateEpicIssueAssignment(ctx context.Context, gid interface{}, iid int, epicIssue int, opt *gitlab.UpdateEpicIsssueAssignmentOptions) ([]*gitlab.Issue, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for pid := range f.Issues {
+		if f.moveIssue(pid, epicIssue, opt.MoveAfterID, opt.MoveBeforeID) {
+			return f.Issues[pid], response(http.StatusOK), nil
+		}
+	}
+	r, err := notFound(epicIssue)
+	return nil, r, err
+}
+
+func (f *GitLab) ListEpicNotes(ctx context.Context, gid interface{}, epicID int) ([]*gitlab.Note, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.EpicNotes[epicID], nil
+}
+
+func (f *GitLab) CreateEpicNote(ctx context.Context, gid interface{}, epicID int, opt *gitlabx.CreateEpicNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	note := &gitlab.Note{ID: f.id(), Body: stringValue(opt.Body), CreatedAt: now(nil), NoteableID: epicID, NoteableType: "Epic"}
+	f.EpicNotes[epicID] = append(f.EpicNotes[epicID], note)
+	if opt.Internal != nil && *opt.Internal {
+		f.InternalNotes[note.ID] = true
+	}
+	if f.NoteTimeouts > 0 {
+		f.NoteTimeouts--
+		r, err := errorResponse(http.StatusGatewayTimeout, "504 Gateway Timeout")
+		return nil, r, err
+	}
+	return note, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) CreateEpicLink(ctx context.Context, gid interface{}, iid int, opt *gitlabx.CreateEpicLinkOptions) (*gitlabx.EpicLink, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(gid)
+	source := f.findEpic(id, iid)
+	targetID, _ := f.resolve(stringValue(opt.TargetGroupID))
+	targetIID, _ := strconv.Atoi(stringValue(opt.TargetEpicIID))
+	target := f.findEpic(targetID, targetIID)
+	if source == nil || target == nil {
+		r, err := notFound(iid)
+		return nil, r, err
+	}
+
+	for _, link := range f.EpicLinks[source.ID] {
+		if link.TargetEpic.ID == target.ID {
+			r, err := errorResponse(http.StatusConflict, "Epic(s) already assigned")
+			return nil, r, err
+		}
+	}
+
+	link := &gitlabx.EpicLink{SourceEpic: source, TargetEpic: target, LinkType: stringValue(opt.LinkType)}
+	f.EpicLinks[source.ID] = append(f.EpicLinks[source.ID], link)
+	return link, response(http.StatusCreated), nil
+}
+
+//* Iterations
+
+func (f *GitLab) ListIterationCadences(ctx context.Context, groupPath string) ([]*gitlabx.IterationCadence, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.IterationCadences[groupPath], nil
+}
+
+func (f *GitLab) CreateIterationCadence(ctx context.Context, groupPath string, opt *gitlabx.CreateIterationCadenceOptions) (*gitlabx.IterationCadence, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	cadence := &gitlabx.IterationCadence{ID: fmt.Sprintf("gid://gitlab/Iterations::Cadence/%d", f.id()), Title: opt.Title}
+	f.IterationCadences[groupPath] = append(f.IterationCadences[groupPath], cadence)
+	return cadence, nil
+}
+
+func (f *GitLab) ListIterations(ctx context.Context, groupPath string, cadenceID string) ([]*gitlabx.Iteration, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.Iterations[cadenceID], nil
+}
+
+// CreateIteration rejects overlapping dates in a cadence like GitLab
+func (f *GitLab) CreateIteration(ctx context.Context, groupPath string, cadenceID string, opt *gitlabx.CreateIterationOptions) (*gitlabx.Iteration, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, iteration := range f.Iterations[cadenceID] {
+		if opt.StartDate <= iteration.DueDate && iteration.StartDate <= opt.DueDate {
+			return nil, fmt.Errorf("Dates cannot overlap with other existing Iterations within this iterations cadence")
+		}
+	}
+
+	iteration := &gitlabx.Iteration{
+		ID:        fmt.Sprintf("gid://gitlab/Iteration/%d", f.id()),
+		Title:     opt.Title,
+		StartDate: opt.StartDate,
+		DueDate:   opt.DueDate,
+	}
+	f.Iterations[cadenceID] = append(f.Iterations[cadenceID], iteration)
+	return iteration, nil
+}
+
+func (f *GitLab) SetIssueIteration(ctx context.Context, projectPath string, iid int, iterationID string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	pid, _ := f.resolve(projectPath)
+	issue := f.findIssue(pid, iid)
+	if issue == nil {
+		_, err := notFound(iid)
+		return err
+	}
+
+	f.IssueIterations[issue.ID] = iterationID
+	return nil
+}
+
+func (f *GitLab) SetIssueSeverity(ctx context.Context, projectPath string, iid int, severity string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	pid, _ := f.resolve(projectPath)
+	issue := f.findIssue(pid, iid)
+	if issue == nil {
+		_, err := notFound(iid)
+		return err
+	}
+	if issue.IssueType == nil || *issue.IssueType != "incident" {
+		_, err := errorResponse(http.StatusBadRequest, "Severity can only be set on incidents")
+		return err
+	}
+
+	f.IssueSeverities[issue.ID] = severity
+	return nil
+}
+
+//* Board
+
+func (f *GitLab) ListIssueBoards(ctx context.Context, pid interface{}) ([]*gitlab.IssueBoard, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	return f.Boards[id], nil
+}
+
+func (f *GitLab) CreateIssueBoard(ctx context.Context, pid interface{}, opt *gitlab.CreateIssueBoardOptions) (*gitlab.IssueBoard, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.resolve(pid)
+	if !ok || f.Projects[id] == nil {
+		r, err := notFound(pid)
+		return nil, r, err
+	}
+
+	board := &gitlab.IssueBoard{ID: f.id(), Name: stringValue(opt.Name)}
+	f.Boards[id] = append(f.Boards[id], board)
+	return board, response(http.StatusCreated), nil
+}
+
+func (f *GitLab) CreateIssueBoardList(ctx context.Context, pid interface{}, boardID int, opt *gitlab.CreateIssueBoardListOptions) (*gitlab.BoardList, *gitlab.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, _ := f.resolve(pid)
+	for _, board := range f.Boards[id] {
+		if board.ID != boardID {
+			continue
+		}
+
+		list := &gitlab.BoardList{ID: f.id(), Position: len(board.Lists)}
+		if opt.LabelID != nil {
+			list.Label = &gitlab.Label{ID: *opt.LabelID}
+		}
+		board.Lists = append(board.Lists, list)
+		return list, response(http.StatusCreated), nil
+	}
+
+	r, err := notFound(boardID)
+	return nil, r, err
+}
+
+func (f *GitLab) SetBoardListLimit(ctx context.Context, listID int, maxIssueCount int) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.BoardListLimits[listID] = maxIssueCount
+	return nil
+}
+
+//* CRM
+
+func (f *GitLab) ListCRMContacts(ctx context.Context, groupPath string) ([]*gitlabx.CRMContact, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.CRMContacts[groupPath], nil
+}
+
+func (f *GitLab) AddIssueCRMContacts(ctx context.Context, projectPath string, iid int, contactIDs []string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	pid, _ := f.resolve(projectPath)
+	issue := f.findIssue(pid, iid)
+	if issue == nil {
+		_, err := notFound(iid)
+		return err
+	}
+
+	for _, id := range contactIDs {
+		exist := false
+		for _, contactID := range f.IssueCRMContacts[issue.ID] {
+			exist = exist || contactID == id
+		}
+		if !exist {
+			f.IssueCRMContacts[issue.ID] = append(f.IssueCRMContacts[issue.ID], id)
+		}
+	}
+	return nil
+}
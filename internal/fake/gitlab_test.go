@@ -0,0 +1,264 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package fake
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+func TestGitLabIssues(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	//* A project is referenced by ID, numeric string or path
+	var created []*gitlab.Issue
+	for _, pid := range []interface{}{2, "2", "group/project"} {
+		issue, r, err := gl.CreateIssue(ctx, pid, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, r.StatusCode)
+		created = append(created, issue)
+	}
+	assert.Equal(t, []int{1, 2, 3}, []int{created[0].IID, created[1].IID, created[2].IID})
+	assert.Equal(t, "https://gitlab.example.com/group/project/-/issues/1", created[0].WebURL)
+
+	_, r, err := gl.CreateIssue(ctx, "group/missing", &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, r.StatusCode)
+	//* Issues belong to projects, not groups
+	_, _, err = gl.CreateIssue(ctx, 1, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+	assert.Error(t, err)
+
+	issue, _, err := gl.UpdateIssue(ctx, 2, 1, &gitlab.UpdateIssueOptions{StateEvent: gitlab.String("close"), AddLabels: &gitlab.Labels{"type::Bug"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "closed", issue.State)
+	assert.NotNil(t, issue.ClosedAt)
+	assert.Equal(t, gitlab.Labels{"type::Bug"}, issue.Labels)
+}
+
+func TestGitLabReorderIssue(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	var created []*gitlab.Issue
+	for _, title := range []string{"TEST-1", "TEST-2", "TEST-3"} {
+		issue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String(title)})
+		assert.NoError(t, err)
+		created = append(created, issue)
+	}
+
+	_, _, err := gl.ReorderIssue(ctx, 2, 3, &gitlabx.ReorderIssueOptions{MoveBeforeID: &created[0].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 1, 2}, []int{gl.Issues[2][0].IID, gl.Issues[2][1].IID, gl.Issues[2][2].IID})
+}
+
+func TestGitLabCreateTimeout(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	//* The issue is created although the answer is a timeout, the search finds it
+	gl.CreateTimeouts = 1
+	_, r, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusGatewayTimeout, r.StatusCode)
+	assert.Len(t, gl.Issues[2], 1)
+
+	found, err := gl.SearchIssues(ctx, 2, "TEST-1")
+	assert.NoError(t, err)
+	assert.Equal(t, gl.Issues[2], found)
+}
+
+func TestGitLabDeleteIssue(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	for _, title := range []string{"TEST-1", "TEST-2"} {
+		_, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String(title)})
+		assert.NoError(t, err)
+	}
+
+	//* IIDs stay unique after a deletion
+	_, err := gl.DeleteIssue(ctx, 2, 1)
+	assert.NoError(t, err)
+	issue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-3")})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, issue.IID)
+	assert.Len(t, gl.Issues[2], 2)
+}
+
+func TestGitLabCountIssues(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	count, err := gl.CountIssues(ctx, "group/project")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, _, err = gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+	assert.NoError(t, err)
+	count, err = gl.CountIssues(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestGitLabInternalNotes(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	issue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+	assert.NoError(t, err)
+	_, _, err = gl.CreateIssueNote(ctx, 2, issue.IID, &gitlabx.CreateIssueNoteOptions{Body: gitlab.String("Public")})
+	assert.NoError(t, err)
+	_, _, err = gl.CreateIssueNote(ctx, 2, issue.IID, &gitlabx.CreateIssueNoteOptions{Body: gitlab.String("Internal"), Internal: gitlab.Bool(true)})
+	assert.NoError(t, err)
+
+	if assert.Len(t, gl.IssueNotes[issue.ID], 2) {
+		assert.False(t, gl.InternalNotes[gl.IssueNotes[issue.ID][0].ID])
+		assert.True(t, gl.InternalNotes[gl.IssueNotes[issue.ID][1].ID])
+	}
+}
+
+func TestGitLabNoteErrors(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	issue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+	assert.NoError(t, err)
+
+	//* Only the first notes fail
+	gl.NoteErrors = 1
+	_, r, err := gl.CreateIssueNote(ctx, 2, issue.IID, &gitlabx.CreateIssueNoteOptions{Body: gitlab.String("Comment")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, r.StatusCode)
+	_, _, err = gl.CreateIssueNote(ctx, 2, issue.IID, &gitlabx.CreateIssueNoteOptions{Body: gitlab.String("Comment")})
+	assert.NoError(t, err)
+	assert.Len(t, gl.IssueNotes[issue.ID], 1)
+}
+
+func TestGitLabLabels(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	_, r, err := gl.CreateLabel(ctx, 2, &gitlab.CreateLabelOptions{Name: gitlab.String("type::Bug")})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, r.StatusCode)
+	_, r, err = gl.CreateLabel(ctx, 2, &gitlab.CreateLabelOptions{Name: gitlab.String("type::Bug")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusConflict, r.StatusCode)
+}
+
+func TestGitLabMilestones(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	//* The title of a milestone is taken
+	_, _, err := gl.CreateGroupMilestone(ctx, 1, &gitlab.CreateGroupMilestoneOptions{Title: gitlab.String("1.0")})
+	assert.NoError(t, err)
+	_, r, err := gl.CreateGroupMilestone(ctx, "group", &gitlab.CreateGroupMilestoneOptions{Title: gitlab.String("1.0")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, r.StatusCode)
+
+	_, _, err = gl.CreateMilestone(ctx, 2, &gitlab.CreateMilestoneOptions{Title: gitlab.String("1.0")})
+	assert.NoError(t, err)
+	_, r, err = gl.CreateMilestone(ctx, 2, &gitlab.CreateMilestoneOptions{Title: gitlab.String("1.0")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, r.StatusCode)
+
+}
+
+func TestGitLabEpics(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+
+	epic, _, err := gl.CreateEpic(ctx, "group", &gitlabx.CreateEpicOptions{Title: gitlab.String("TEST-1")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, epic.IID)
+	assert.Equal(t, 1, epic.GroupID)
+
+	//* Epics belong to groups
+	_, _, err = gl.CreateEpic(ctx, 2, &gitlabx.CreateEpicOptions{Title: gitlab.String("TEST-2")})
+	assert.Error(t, err)
+	assert.Len(t, gl.Epics[1], 1)
+}
+
+func TestGitLabReversedEpicDates(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+
+	start := gitlab.ISOTime(time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC))
+	due := gitlab.ISOTime(time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC))
+	_, r, err := gl.CreateEpic(ctx, 1, &gitlabx.CreateEpicOptions{Title: gitlab.String("TEST-1"), StartDateFixed: &start, DueDateFixed: &due})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, r.StatusCode)
+	assert.Empty(t, gl.Epics[1])
+}
+
+func TestGitLabFree(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+
+	available, err := gl.EpicsAvailable(ctx, 1)
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	gl.NoEpics = true
+	available, err = gl.EpicsAvailable(ctx, 1)
+	assert.NoError(t, err)
+	assert.False(t, available)
+	_, r, err := gl.CreateEpic(ctx, 1, &gitlabx.CreateEpicOptions{Title: gitlab.String("TEST-1")})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, r.StatusCode)
+	assert.Empty(t, gl.Epics[1])
+}
+
+func TestGitLabIterations(t *testing.T) {
+	ctx := context.Background()
+	gl := NewGitLab()
+	gl.AddGroup(1, "group")
+
+	_, err := gl.CreateIteration(ctx, "group", "cadence", &gitlabx.CreateIterationOptions{Title: "Sprint 1", StartDate: "2023-09-01", DueDate: "2023-09-14"})
+	assert.NoError(t, err)
+	_, err = gl.CreateIteration(ctx, "group", "cadence", &gitlabx.CreateIterationOptions{Title: "Sprint 2", StartDate: "2023-09-15", DueDate: "2023-09-28"})
+	assert.NoError(t, err)
+
+	//* The iterations of a cadence can't overlap
+	_, err = gl.CreateIteration(ctx, "group", "cadence", &gitlabx.CreateIterationOptions{Title: "Sprint 3", StartDate: "2023-09-14", DueDate: "2023-09-20"})
+	assert.Error(t, err)
+	assert.Len(t, gl.Iterations["cadence"], 2)
+}
@@ -0,0 +1,296 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package fake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// Jira serves a fixed set of Jira data
+type Jira struct {
+	Project *jira.Project
+	Issues  []*jira.Issue
+	// Projects of the site, the issues are searched by the project of their key if it is set
+	Projects jira.ProjectList
+
+	// Key: attachment ID
+	Attachments map[string][]byte
+	// Key: issue key
+	RemoteLinks map[string][]jira.RemoteLink
+	// Key: issue ID/application type/data type
+	DevStatus map[string]*jirax.DevStatus
+	// Key: role ID
+	Roles map[string]*jira.Role
+
+	Sprints []jira.Sprint
+	// Key: issue key, comments with their properties. The comments of the issue if it is absent.
+	Comments map[string][]*jira.Comment
+	// Key: issue key, comment ID -> parent comment ID of the replies
+	CommentParents map[string]map[string]string
+	// Key: issue key, JSM approvals
+	Approvals map[string][]*jirax.Approval
+	// Key: issue key, Xray or Zephyr test runs
+	TestRuns map[string][]*jirax.TestRun
+	// Key: username
+	Users map[string]*jira.User
+	// Image of the project avatar and its content type
+	Avatar            []byte
+	AvatarContentType string
+
+	Statuses []*jirax.IssueTypeStatuses
+	// Issue types with the fields of their create screens
+	CreateMeta []*jirax.CreateMetaIssueType
+	Boards     []*jirax.BoardConfiguration
+	Filters    []*jira.Filter
+	Dashboards []*jirax.Dashboard
+	Fields     []jira.Field
+}
+
+func NewJira(project *jira.Project, issues ...*jira.Issue) *Jira {
+	return &Jira{
+		Project:     project,
+		Issues:      issues,
+		Attachments: make(map[string][]byte),
+		RemoteLinks: make(map[string][]jira.RemoteLink),
+		DevStatus:   make(map[string]*jirax.DevStatus),
+		Roles:       make(map[string]*jira.Role),
+		Comments:    make(map[string][]*jira.Comment),
+
+		CommentParents: make(map[string]map[string]string),
+		Approvals:      make(map[string][]*jirax.Approval),
+		TestRuns:       make(map[string][]*jirax.TestRun),
+		Users:          make(map[string]*jira.User),
+	}
+}
+
+func (f *Jira) GetProject(ctx context.Context, projectID string) (*jira.Project, *jira.Response, error) {
+	for _, project := range f.Projects {
+		if strings.EqualFold(project.Key, projectID) {
+			return &jira.Project{ID: project.ID, Key: project.Key, Name: project.Name, ProjectCategory: project.ProjectCategory}, nil, nil
+		}
+	}
+	if f.Project == nil || !strings.EqualFold(f.Project.Key, projectID) {
+		return nil, nil, fmt.Errorf("project %s not found", projectID)
+	}
+	return f.Project, nil, nil
+}
+
+func (f *Jira) ListProjects(ctx context.Context) (*jira.ProjectList, error) {
+	return &f.Projects, nil
+}
+
+// SearchIssues understands only the "type = Epic" and "type != Epic" conditions; other JQL returns every issue.
+func (f *Jira) SearchIssues(ctx context.Context, jql string) ([]*jira.Issue, error) {
+	var result []*jira.Issue
+	for _, issue := range f.Issues {
+		isEpic := issue.Fields != nil && issue.Fields.Type.Name == "Epic"
+		if strings.Contains(jql, "type != Epic") && isEpic {
+			continue
+		}
+		if strings.Contains(jql, "type = Epic") && !isEpic {
+			continue
+		}
+		if keys := jqlKeys(jql); keys != nil && !keys[issue.Key] {
+			continue
+		}
+		if match := jqlProjectPattern.FindStringSubmatch(jql); match != nil && len(f.Projects) > 0 && !strings.HasPrefix(issue.Key, match[1]+"-") {
+			continue
+		}
+		result = append(result, issue)
+	}
+	return result, nil
+}
+
+var (
+	jqlKeysPattern    = regexp.MustCompile(`key in \(([^)]*)\)`)
+	jqlProjectPattern = regexp.MustCompile(`project = (\S+)`)
+)
+
+// jqlKeys returns the keys of "key in (...)", nil without it
+func jqlKeys(jql string) map[string]bool {
+	match := jqlKeysPattern.FindStringSubmatch(jql)
+	if match == nil {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range strings.Split(match[1], ",") {
+		keys[strings.TrimSpace(key)] = true
+	}
+	return keys
+}
+
+func (f *Jira) DownloadAttachment(ctx context.Context, attachmentID string) (io.ReadCloser, error) {
+	content, ok := f.Attachments[attachmentID]
+	if !ok {
+		return nil, fmt.Errorf("attachment %s not found", attachmentID)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (f *Jira) GetRemoteLinks(ctx context.Context, issueKey string) (*[]jira.RemoteLink, *jira.Response, error) {
+	remoteLinks := f.RemoteLinks[issueKey]
+	return &remoteLinks, nil, nil
+}
+
+// GetDevStatusSummary counts the details of DevStatus, "pullrequest" details count as branches and pull requests
+func (f *Jira) GetDevStatusSummary(ctx context.Context, issueID string) (*jirax.DevStatusSummary, *jira.Response, error) {
+	summary := &jirax.DevStatusSummary{Summary: make(map[string]*jirax.DevStatusSummaryItem)}
+	count := func(dataType string, applicationType string, n int) {
+		if n == 0 {
+			return
+		}
+		item, ok := summary.Summary[dataType]
+		if !ok {
+			item = &jirax.DevStatusSummaryItem{ByInstanceType: make(map[string]jirax.DevStatusCount)}
+			summary.Summary[dataType] = item
+		}
+		item.Overall.Count += n
+		c := item.ByInstanceType[applicationType]
+		c.Count += n
+		item.ByInstanceType[applicationType] = c
+	}
+
+	for key, devStatus := range f.DevStatus {
+		parts := strings.Split(key, "/")
+		if len(parts) != 3 || parts[0] != issueID {
+			continue
+		}
+		for _, detail := range devStatus.Detail {
+			count("repository", parts[1], len(detail.Repositories))
+			count("branch", parts[1], len(detail.Branches))
+			count("pullrequest", parts[1], len(detail.PullRequests))
+		}
+	}
+	return summary, nil, nil
+}
+
+func (f *Jira) GetDevStatus(ctx context.Context, issueID string, applicationType string, dataType string) (*jirax.DevStatus, *jira.Response, error) {
+	if devStatus, ok := f.DevStatus[fmt.Sprintf("%s/%s/%s", issueID, applicationType, dataType)]; ok {
+		return devStatus, nil, nil
+	}
+	return &jirax.DevStatus{}, nil, nil
+}
+
+func (f *Jira) GetProjectRole(ctx context.Context, projectKey string, roleID string) (*jira.Role, *jira.Response, error) {
+	role, ok := f.Roles[roleID]
+	if !ok {
+		return nil, nil, fmt.Errorf("role %s not found", roleID)
+	}
+	return role, nil, nil
+}
+
+func (f *Jira) ListSprints(ctx context.Context, projectKey string) ([]jira.Sprint, error) {
+	return f.Sprints, nil
+}
+
+func (f *Jira) GetComments(ctx context.Context, issueKey string) ([]*jira.Comment, error) {
+	if comments, ok := f.Comments[issueKey]; ok {
+		return comments, nil
+	}
+	for _, issue := range f.Issues {
+		if issue.Key == issueKey && issue.Fields != nil && issue.Fields.Comments != nil {
+			return issue.Fields.Comments.Comments, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *Jira) GetCommentParents(ctx context.Context, issueKey string) (map[string]string, error) {
+	return f.CommentParents[issueKey], nil
+}
+
+func (f *Jira) GetApprovals(ctx context.Context, issueKey string) ([]*jirax.Approval, *jira.Response, error) {
+	return f.Approvals[issueKey], nil, nil
+}
+
+func (f *Jira) GetTestRuns(ctx context.Context, addOn string, jiraIssue *jira.Issue) ([]*jirax.TestRun, *jira.Response, error) {
+	return f.TestRuns[jiraIssue.Key], nil, nil
+}
+
+func (f *Jira) DownloadProjectAvatar(ctx context.Context, project *jira.Project) (io.ReadCloser, string, error) {
+	if f.Avatar == nil {
+		return nil, "", fmt.Errorf("project %s has no avatar", project.Key)
+	}
+	return io.NopCloser(bytes.NewReader(f.Avatar)), f.AvatarContentType, nil
+}
+
+func (f *Jira) ListProjectStatuses(ctx context.Context, projectKey string) ([]*jirax.IssueTypeStatuses, *jira.Response, error) {
+	return f.Statuses, nil, nil
+}
+
+func (f *Jira) GetCreateMeta(ctx context.Context, projectKey string) ([]*jirax.CreateMetaIssueType, *jira.Response, error) {
+	return f.CreateMeta, nil, nil
+}
+
+func (f *Jira) ListBoardConfigurations(ctx context.Context, projectKey string) ([]*jirax.BoardConfiguration, error) {
+	return f.Boards, nil
+}
+
+func (f *Jira) ListFilters(ctx context.Context, projectKey string) ([]*jira.Filter, error) {
+	return f.Filters, nil
+}
+
+func (f *Jira) ListDashboards(ctx context.Context) ([]*jirax.Dashboard, error) {
+	return f.Dashboards, nil
+}
+
+func (f *Jira) ListFields(ctx context.Context) ([]jira.Field, *jira.Response, error) {
+	return f.Fields, nil, nil
+}
+
+func (f *Jira) SampleIssues(ctx context.Context, jql string, maxResults int) ([]*jira.Issue, error) {
+	issues, err := f.SearchIssues(ctx, jql)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) > maxResults {
+		issues = issues[:maxResults]
+	}
+	return issues, nil
+}
+
+// GetIssueKey finds the issue by its key or by an old key of its changelog (moved issue)
+func (f *Jira) GetIssueKey(ctx context.Context, issueKey string) (string, *jira.Response, error) {
+	for _, issue := range f.Issues {
+		if strings.EqualFold(issue.Key, issueKey) {
+			return issue.Key, nil, nil
+		}
+		if issue.Changelog == nil {
+			continue
+		}
+		for _, history := range issue.Changelog.Histories {
+			for _, item := range history.Items {
+				if item.Field == "Key" && strings.EqualFold(item.FromString, issueKey) {
+					return issue.Key, nil, nil
+				}
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("issue %s not found", issueKey)
+}
+
+func (f *Jira) GetUser(ctx context.Context, username string) (*jira.User, *jira.Response, error) {
+	user, ok := f.Users[username]
+	if !ok {
+		return nil, nil, fmt.Errorf("user %s not found", username)
+	}
+	return user, nil, nil
+}
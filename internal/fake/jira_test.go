@@ -0,0 +1,154 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package fake
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func TestJiraSearchIssues(t *testing.T) {
+	ctx := context.Background()
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Epic"}}}
+	bug := &jira.Issue{Key: "TEST-2", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Bug"}}}
+	jr := NewJira(&jira.Project{Key: "TEST"}, epic, bug)
+
+	tests := []struct {
+		jql    string
+		issues []*jira.Issue
+	}{
+		{"project = TEST AND type = Epic", []*jira.Issue{epic}},
+		{"project = TEST AND type != Epic", []*jira.Issue{bug}},
+		{"project = TEST", []*jira.Issue{epic, bug}},
+	}
+	for _, test := range tests {
+		issues, err := jr.SearchIssues(ctx, test.jql)
+		assert.NoError(t, err)
+		assert.Equal(t, test.issues, issues, test.jql)
+	}
+}
+
+func TestJiraSearchKeys(t *testing.T) {
+	ctx := context.Background()
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Epic"}}}
+	bug := &jira.Issue{Key: "TEST-2", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Bug"}}}
+	task := &jira.Issue{Key: "TEST-3", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Task"}}}
+	jr := NewJira(&jira.Project{Key: "TEST"}, epic, bug, task)
+
+	issues, err := jr.SearchIssues(ctx, "key in (TEST-2, TEST-3)")
+	assert.NoError(t, err)
+	assert.Equal(t, []*jira.Issue{bug, task}, issues)
+}
+
+func TestJiraSiteProjects(t *testing.T) {
+	ctx := context.Background()
+	bug := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Bug"}}}
+	other := &jira.Issue{Key: "OPS-1", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Task"}}}
+	jr := NewJira(&jira.Project{Key: "TEST"}, bug, other)
+
+	//* The project is used with the projects of a site only
+	issues, err := jr.SearchIssues(ctx, "project = TEST")
+	assert.NoError(t, err)
+	assert.Equal(t, []*jira.Issue{bug, other}, issues)
+
+	jr.Projects = jira.ProjectList{{Key: "TEST"}, {Key: "OPS", Name: "Operations"}}
+	issues, err = jr.SearchIssues(ctx, "project = OPS")
+	assert.NoError(t, err)
+	assert.Equal(t, []*jira.Issue{other}, issues)
+
+	project, _, err := jr.GetProject(ctx, "OPS")
+	assert.NoError(t, err)
+	assert.Equal(t, "Operations", project.Name)
+}
+
+func TestJiraSampleIssues(t *testing.T) {
+	ctx := context.Background()
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Epic"}}}
+	bug := &jira.Issue{Key: "TEST-2", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Bug"}}}
+	jr := NewJira(&jira.Project{Key: "TEST"}, epic, bug)
+
+	issues, err := jr.SampleIssues(ctx, "project = TEST", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*jira.Issue{epic}, issues)
+}
+
+func TestJiraProject(t *testing.T) {
+	ctx := context.Background()
+	jr := NewJira(&jira.Project{Key: "TEST", Name: "Test"})
+
+	project, _, err := jr.GetProject(ctx, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", project.Name)
+	_, _, err = jr.GetProject(ctx, "OPS")
+	assert.Error(t, err)
+}
+
+func TestJiraAttachments(t *testing.T) {
+	ctx := context.Background()
+	jr := NewJira(&jira.Project{Key: "TEST"})
+	jr.Attachments["1"] = []byte("log")
+
+	body, err := jr.DownloadAttachment(ctx, "1")
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(body)
+	assert.Equal(t, "log", string(content))
+	_, err = jr.DownloadAttachment(ctx, "2")
+	assert.Error(t, err)
+}
+
+func TestJiraComments(t *testing.T) {
+	ctx := context.Background()
+	issue := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{
+		Comments: &jira.Comments{Comments: []*jira.Comment{{ID: "100", Body: "Search"}}},
+	}}
+	jr := NewJira(&jira.Project{Key: "TEST"}, issue)
+
+	//* The comments of the issue unless they are set
+	comments, err := jr.GetComments(ctx, "TEST-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Search", comments[0].Body)
+	jr.Comments["TEST-1"] = []*jira.Comment{{ID: "100", Body: "Full"}}
+	comments, err = jr.GetComments(ctx, "TEST-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Full", comments[0].Body)
+}
+
+func TestJiraMovedKeys(t *testing.T) {
+	ctx := context.Background()
+	moved := &jira.Issue{Key: "TEST-2", Fields: &jira.IssueFields{}, Changelog: &jira.Changelog{Histories: []jira.ChangelogHistory{
+		{Items: []jira.ChangelogItems{{Field: "Key", FromString: "OLD-7", ToString: "TEST-2"}}},
+	}}}
+	jr := NewJira(&jira.Project{Key: "TEST"}, moved)
+
+	key, _, err := jr.GetIssueKey(ctx, "old-7")
+	assert.NoError(t, err)
+	assert.Equal(t, "TEST-2", key)
+	_, _, err = jr.GetIssueKey(ctx, "GONE-1")
+	assert.Error(t, err)
+}
+
+func TestJiraDevStatusSummary(t *testing.T) {
+	ctx := context.Background()
+	jr := NewJira(&jira.Project{Key: "TEST"})
+	jr.DevStatus["10002/GitHub/repository"] = &jirax.DevStatus{Detail: []*jirax.DevStatusDetail{{
+		Branches: []*jirax.DevStatusBranch{{Name: "feature/TEST-2"}},
+	}}}
+
+	summary, _, err := jr.GetDevStatusSummary(ctx, "10002")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Summary["branch"].ByInstanceType["GitHub"].Count)
+	assert.Nil(t, summary.Summary["repository"])
+}
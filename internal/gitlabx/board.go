@@ -0,0 +1,43 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlabx
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// SetBoardListLimit sets the WIP limit (maximum issue count) of a board list. GraphQL only, GitLab Premium.
+func SetBoardListLimit(gl *gitlab.Client, listID int, maxIssueCount int, options ...gitlab.RequestOptionFunc) error {
+	query := `mutation($input: BoardListUpdateLimitMetricsInput!) {
+  boardListUpdateLimitMetrics(input: $input) { errors }
+}`
+
+	input := map[string]interface{}{
+		"listId":        fmt.Sprintf("gid://gitlab/List/%d", listID),
+		"limitMetric":   "issue_count",
+		"maxIssueCount": maxIssueCount,
+	}
+
+	var data struct {
+		BoardListUpdateLimitMetrics struct {
+			Errors []string `json:"errors"`
+		} `json:"boardListUpdateLimitMetrics"`
+	}
+	if _, err := GraphQL(gl, query, map[string]interface{}{"input": input}, &data, options...); err != nil {
+		return errors.Wrap(err, "Error setting board list limit")
+	}
+
+	return mutationErrors(data.BoardListUpdateLimitMetrics.Errors)
+}
@@ -0,0 +1,101 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlabx
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// CRM contacts and organizations are GraphQL only, the IDs are global IDs (e.g. gid://gitlab/CustomerRelations::Contact/1)
+
+type CRMOrganization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type CRMContact struct {
+	ID           string           `json:"id"`
+	Email        string           `json:"email"`
+	FirstName    string           `json:"firstName"`
+	LastName     string           `json:"lastName"`
+	Organization *CRMOrganization `json:"organization"`
+}
+
+// ListCRMContacts returns the active contacts of the group, the CRM must be enabled on the group
+func ListCRMContacts(gl *gitlab.Client, groupPath string, options ...gitlab.RequestOptionFunc) ([]*CRMContact, error) {
+	query := `query($fullPath: ID!, $after: String) {
+  group(fullPath: $fullPath) {
+    contacts(state: active, first: 100, after: $after) {
+      nodes { id email firstName lastName organization { id name } }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+	var result []*CRMContact
+	variables := map[string]interface{}{"fullPath": groupPath}
+	for {
+		var data struct {
+			Group *struct {
+				Contacts struct {
+					Nodes    []*CRMContact `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"contacts"`
+			} `json:"group"`
+		}
+		if _, err := GraphQL(gl, query, variables, &data, options...); err != nil {
+			return nil, errors.Wrap(err, "Error listing CRM contacts")
+		}
+		if data.Group == nil {
+			return nil, errors.Errorf("Group %s is not found", groupPath)
+		}
+
+		result = append(result, data.Group.Contacts.Nodes...)
+		if !data.Group.Contacts.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = data.Group.Contacts.PageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+// AddIssueCRMContacts adds the contacts to the issue, the contacts already on it are kept
+func AddIssueCRMContacts(gl *gitlab.Client, projectPath string, issue int, contactIDs []string, options ...gitlab.RequestOptionFunc) error {
+	query := `mutation($input: IssueSetCrmContactsInput!) {
+  issueSetCrmContacts(input: $input) { errors }
+}`
+
+	input := map[string]interface{}{
+		"projectPath":   projectPath,
+		"iid":           fmt.Sprintf("%d", issue),
+		"contactIds":    contactIDs,
+		"operationMode": "APPEND",
+	}
+
+	var data struct {
+		IssueSetCrmContacts struct {
+			Errors []string `json:"errors"`
+		} `json:"issueSetCrmContacts"`
+	}
+	if _, err := GraphQL(gl, query, map[string]interface{}{"input": input}, &data, options...); err != nil {
+		return errors.Wrap(err, "Error adding CRM contacts")
+	}
+
+	return mutationErrors(data.IssueSetCrmContacts.Errors)
+}
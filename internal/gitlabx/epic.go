@@ -36,14 +36,14 @@ type CreateEpicOptions struct {
 	// ParentID ...
 }
 
-func CreateEpic(gl *gitlab.Client, gid interface{}, opt *CreateEpicOptions) (*gitlab.Epic, *gitlab.Response, error) {
+func CreateEpic(gl *gitlab.Client, gid interface{}, opt *CreateEpicOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Epic, *gitlab.Response, error) {
 	group, err := parseID(gid)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Error parsing ID")
 	}
 	u := fmt.Sprintf("groups/%s/epics", gitlab.PathEscape(group))
 
-	req, err := gl.NewRequest(http.MethodPost, u, opt, nil)
+	req, err := gl.NewRequest(http.MethodPost, u, opt, options)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Error creating request")
 	}
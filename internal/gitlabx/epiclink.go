@@ -31,13 +31,13 @@ type EpicLink struct {
 	LinkType   string       `json:"link_type"`
 }
 
-func CreateEpicLink(gl *gitlab.Client, gid interface{}, epic int, opt *CreateEpicLinkOptions) (*EpicLink, *gitlab.Response, error) {
+func CreateEpicLink(gl *gitlab.Client, gid interface{}, epic int, opt *CreateEpicLinkOptions, options ...gitlab.RequestOptionFunc) (*EpicLink, *gitlab.Response, error) {
 	group, err := parseID(gid)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Error parsing ID")
 	}
 	u := fmt.Sprintf("groups/%s/epics/%d/related_epics", gitlab.PathEscape(group), epic)
-	req, err := gl.NewRequest(http.MethodPost, u, opt, nil)
+	req, err := gl.NewRequest(http.MethodPost, u, opt, options)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Error creating request")
 	}
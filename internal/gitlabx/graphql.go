@@ -0,0 +1,76 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlabx
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GraphQL runs the query on /api/graphql and decodes "data" into result.
+// Some features (e.g. iteration cadences) are only available on GraphQL.
+func GraphQL(gl *gitlab.Client, query string, variables map[string]interface{}, result interface{}, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	req, err := gl.NewRequest(http.MethodPost, "", &graphQLRequest{Query: query, Variables: variables}, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating request")
+	}
+
+	//* /api/v4/ -> /api/graphql
+	req.URL.Path = strings.TrimSuffix(strings.TrimSuffix(req.URL.Path, "/"), "v4") + "graphql"
+	req.URL.RawPath = ""
+
+	r := new(graphQLResponse)
+	resp, err := gl.Do(req, r)
+	if err != nil {
+		return resp, errors.Wrap(err, "Error making request")
+	}
+
+	if len(r.Errors) > 0 {
+		messages := make([]string, 0, len(r.Errors))
+		for _, e := range r.Errors {
+			messages = append(messages, e.Message)
+		}
+		return resp, errors.Errorf("GraphQL error: %s", strings.Join(messages, ", "))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(r.Data, result); err != nil {
+			return resp, errors.Wrap(err, "Error decoding GraphQL data")
+		}
+	}
+
+	return resp, nil
+}
+
+// mutationErrors returns the errors field of a mutation payload as an error
+func mutationErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, ", "))
+}
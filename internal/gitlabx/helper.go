@@ -13,7 +13,9 @@ package gitlabx
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/xanzy/go-gitlab"
@@ -60,3 +62,70 @@ func Unpaginate[T any](
 
 	return result, nil
 }
+
+// FindOrCreate returns the item found by find, or the item created by create if there is none. found is true if the item existed.
+// A create failing because the item exists (e.g. created by a run in parallel) looks it up once more, so a rerun never creates a duplicate.
+func FindOrCreate[T any](
+	find func() (*T, error),
+	create func() (*T, *gitlab.Response, error),
+) (item *T, found bool, err error) {
+	item, err = find()
+	if err != nil {
+		return nil, false, errors.Wrap(err, "Error finding item")
+	}
+	if item != nil {
+		return item, true, nil
+	}
+
+	return CreateOrFind(create, find)
+}
+
+// CreateOrFind returns the item created by create, or the item found by find if the create fails because the item exists. found is true if the item existed.
+// It is for a caller which already looked for the item, find is called only after the conflict.
+func CreateOrFind[T any](
+	create func() (*T, *gitlab.Response, error),
+	find func() (*T, error),
+) (item *T, found bool, err error) {
+	item, resp, err := create()
+	if err == nil {
+		return item, false, nil
+	}
+	if !IsAlreadyExists(resp, err) {
+		return nil, false, err
+	}
+
+	item, findErr := find()
+	if findErr != nil {
+		return nil, false, errors.Wrap(findErr, "Error finding item")
+	}
+	if item == nil {
+		return nil, false, err
+	}
+	return item, true, nil
+}
+
+// FindByName returns the item of items named name (e.g. the title of a milestone), nil if there is none. The name is case sensitive like in GitLab.
+func FindByName[T any](items []*T, nameOf func(*T) string, name string) *T {
+	for _, item := range items {
+		if nameOf(item) == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// IsAlreadyExists is true if the create failed because the name is taken:
+// 409 Conflict (e.g. labels) or 400 Bad Request "has already been taken" (e.g. milestones)
+func IsAlreadyExists(resp *gitlab.Response, err error) bool {
+	if err == nil || resp == nil {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusConflict:
+		return true
+	case http.StatusBadRequest:
+		message := strings.ToLower(err.Error())
+		return strings.Contains(message, "already been taken") || strings.Contains(message, "already exists")
+	}
+	return false
+}
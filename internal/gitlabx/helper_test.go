@@ -0,0 +1,80 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package gitlabx
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+type namedItem struct {
+	Name string
+}
+
+func TestCreateOrFind(t *testing.T) {
+	finds := 0
+	find := func() (*namedItem, error) {
+		finds++
+		return &namedItem{Name: "existing"}, nil
+	}
+	respond := func(status int, err error) func() (*namedItem, *gitlab.Response, error) {
+		return func() (*namedItem, *gitlab.Response, error) {
+			if err != nil {
+				return nil, &gitlab.Response{Response: &http.Response{StatusCode: status}}, err
+			}
+			return &namedItem{Name: "created"}, &gitlab.Response{Response: &http.Response{StatusCode: status}}, nil
+		}
+	}
+
+	//* The caller already looked for the item, there is no lookup before the create
+	item, found, err := CreateOrFind(respond(http.StatusCreated, nil), find)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, "created", item.Name)
+	assert.Equal(t, 0, finds)
+
+	//* The item created meanwhile is looked up after the conflict
+	item, found, err = CreateOrFind(respond(http.StatusBadRequest, errors.New("Title has already been taken")), find)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "existing", item.Name)
+	assert.Equal(t, 1, finds)
+
+	_, _, err = CreateOrFind(respond(http.StatusForbidden, errors.New("403 Forbidden")), find)
+	assert.EqualError(t, err, "403 Forbidden")
+	assert.Equal(t, 1, finds)
+}
+
+func TestFindOrCreate(t *testing.T) {
+	var existing *namedItem
+	find := func() (*namedItem, error) {
+		return existing, nil
+	}
+	create := func() (*namedItem, *gitlab.Response, error) {
+		existing = &namedItem{Name: "created"}
+		return existing, &gitlab.Response{Response: &http.Response{StatusCode: http.StatusCreated}}, nil
+	}
+
+	item, found, err := FindOrCreate(find, create)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, "created", item.Name)
+
+	//* A rerun finds the item
+	item, found, err = FindOrCreate(find, create)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "created", item.Name)
+}
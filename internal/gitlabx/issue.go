@@ -59,3 +59,30 @@ func CreateIssue(gl *gitlab.Client, pid interface{}, opt *CreateIssueOptions, op
 
 	return i, resp, nil
 }
+
+type ReorderIssueOptions struct {
+	MoveAfterID  *int `url:"move_after_id,omitempty" json:"move_after_id,omitempty"`
+	MoveBeforeID *int `url:"move_before_id,omitempty" json:"move_before_id,omitempty"`
+}
+
+// ReorderIssue changes the manual order (relative position) of the issue, the IDs are global issue IDs
+func ReorderIssue(gl *gitlab.Client, pid interface{}, issue int, opt *ReorderIssueOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Issue, *gitlab.Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/issues/%d/reorder", gitlab.PathEscape(project), issue)
+
+	req, err := gl.NewRequest(http.MethodPut, u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i := new(gitlab.Issue)
+	resp, err := gl.Do(req, i)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return i, resp, nil
+}
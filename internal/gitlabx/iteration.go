@@ -0,0 +1,200 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlabx
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// IDs are GraphQL global IDs (e.g. gid://gitlab/Iteration/1)
+
+type IterationCadence struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type Iteration struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	StartDate string `json:"startDate"`
+	DueDate   string `json:"dueDate"`
+}
+
+type CreateIterationCadenceOptions struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+type CreateIterationOptions struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	StartDate   string `json:"startDate"` // YYYY-MM-DD
+	DueDate     string `json:"dueDate"`
+}
+
+func ListIterationCadences(gl *gitlab.Client, groupPath string, options ...gitlab.RequestOptionFunc) ([]*IterationCadence, error) {
+	query := `query($fullPath: ID!) {
+  group(fullPath: $fullPath) {
+    iterationCadences { nodes { id title } }
+  }
+}`
+
+	var data struct {
+		Group *struct {
+			IterationCadences struct {
+				Nodes []*IterationCadence `json:"nodes"`
+			} `json:"iterationCadences"`
+		} `json:"group"`
+	}
+	if _, err := GraphQL(gl, query, map[string]interface{}{"fullPath": groupPath}, &data, options...); err != nil {
+		return nil, errors.Wrap(err, "Error listing iteration cadences")
+	}
+	if data.Group == nil {
+		return nil, errors.Errorf("Group %s is not found", groupPath)
+	}
+
+	return data.Group.IterationCadences.Nodes, nil
+}
+
+// CreateIterationCadence creates a manual cadence, the iterations of Jira sprints have their own dates
+func CreateIterationCadence(gl *gitlab.Client, groupPath string, opt *CreateIterationCadenceOptions, options ...gitlab.RequestOptionFunc) (*IterationCadence, error) {
+	query := `mutation($input: IterationCadenceCreateInput!) {
+  iterationCadenceCreate(input: $input) {
+    iterationCadence { id title }
+    errors
+  }
+}`
+
+	input := map[string]interface{}{
+		"groupPath":   groupPath,
+		"title":       opt.Title,
+		"description": opt.Description,
+		"automatic":   false,
+		"active":      true,
+	}
+
+	var data struct {
+		IterationCadenceCreate struct {
+			IterationCadence *IterationCadence `json:"iterationCadence"`
+			Errors           []string          `json:"errors"`
+		} `json:"iterationCadenceCreate"`
+	}
+	if _, err := GraphQL(gl, query, map[string]interface{}{"input": input}, &data, options...); err != nil {
+		return nil, errors.Wrap(err, "Error creating iteration cadence")
+	}
+	if err := mutationErrors(data.IterationCadenceCreate.Errors); err != nil {
+		return nil, errors.Wrap(err, "Error creating iteration cadence")
+	}
+
+	return data.IterationCadenceCreate.IterationCadence, nil
+}
+
+func ListIterations(gl *gitlab.Client, groupPath string, cadenceID string, options ...gitlab.RequestOptionFunc) ([]*Iteration, error) {
+	query := `query($fullPath: ID!, $cadenceIds: [IterationsCadenceID!], $after: String) {
+  group(fullPath: $fullPath) {
+    iterations(iterationCadenceIds: $cadenceIds, first: 100, after: $after) {
+      nodes { id title startDate dueDate }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+	var result []*Iteration
+	variables := map[string]interface{}{"fullPath": groupPath, "cadenceIds": []string{cadenceID}}
+	for {
+		var data struct {
+			Group *struct {
+				Iterations struct {
+					Nodes    []*Iteration `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"iterations"`
+			} `json:"group"`
+		}
+		if _, err := GraphQL(gl, query, variables, &data, options...); err != nil {
+			return nil, errors.Wrap(err, "Error listing iterations")
+		}
+		if data.Group == nil {
+			return nil, errors.Errorf("Group %s is not found", groupPath)
+		}
+
+		result = append(result, data.Group.Iterations.Nodes...)
+		if !data.Group.Iterations.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = data.Group.Iterations.PageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+func CreateIteration(gl *gitlab.Client, groupPath string, cadenceID string, opt *CreateIterationOptions, options ...gitlab.RequestOptionFunc) (*Iteration, error) {
+	query := `mutation($input: iterationCreateInput!) {
+  iterationCreate(input: $input) {
+    iteration { id title startDate dueDate }
+    errors
+  }
+}`
+
+	input := map[string]interface{}{
+		"groupPath":           groupPath,
+		"iterationsCadenceId": cadenceID,
+		"title":               opt.Title,
+		"description":         opt.Description,
+		"startDate":           opt.StartDate,
+		"dueDate":             opt.DueDate,
+	}
+
+	var data struct {
+		IterationCreate struct {
+			Iteration *Iteration `json:"iteration"`
+			Errors    []string   `json:"errors"`
+		} `json:"iterationCreate"`
+	}
+	if _, err := GraphQL(gl, query, map[string]interface{}{"input": input}, &data, options...); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating iteration: %s", opt.Title))
+	}
+	if err := mutationErrors(data.IterationCreate.Errors); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating iteration: %s", opt.Title))
+	}
+
+	return data.IterationCreate.Iteration, nil
+}
+
+// SetIssueIteration is GraphQL only, the REST API can't set the iteration of an issue
+func SetIssueIteration(gl *gitlab.Client, projectPath string, issue int, iterationID string, options ...gitlab.RequestOptionFunc) error {
+	query := `mutation($input: IssueSetIterationInput!) {
+  issueSetIteration(input: $input) { errors }
+}`
+
+	input := map[string]interface{}{
+		"projectPath": projectPath,
+		"iid":         fmt.Sprintf("%d", issue),
+		"iterationId": iterationID,
+	}
+
+	var data struct {
+		IssueSetIteration struct {
+			Errors []string `json:"errors"`
+		} `json:"issueSetIteration"`
+	}
+	if _, err := GraphQL(gl, query, map[string]interface{}{"input": input}, &data, options...); err != nil {
+		return errors.Wrap(err, "Error setting iteration")
+	}
+
+	return mutationErrors(data.IssueSetIteration.Errors)
+}
@@ -0,0 +1,99 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package gitlabx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// newGraphQLServer answers the GraphQL requests with answer, the requests are kept
+func newGraphQLServer(t *testing.T, answer func(request *graphQLRequest) string) (*gitlab.Client, *[]*graphQLRequest) {
+	var requests []*graphQLRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/graphql", r.URL.Path)
+		request := new(graphQLRequest)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(request))
+		requests = append(requests, request)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(answer(request)))
+	}))
+	t.Cleanup(server.Close)
+
+	gl, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL), gitlab.WithoutRetries())
+	assert.NoError(t, err)
+	return gl, &requests
+}
+
+func TestListIterations(t *testing.T) {
+	gl, requests := newGraphQLServer(t, func(request *graphQLRequest) string {
+		if request.Variables["after"] == nil {
+			return `{"data": {"group": {"iterations": {"nodes": [{"id": "gid://gitlab/Iteration/1", "title": "Sprint 1", "startDate": "2023-09-01", "dueDate": "2023-09-14"}], "pageInfo": {"hasNextPage": true, "endCursor": "c1"}}}}}`
+		}
+		return `{"data": {"group": {"iterations": {"nodes": [{"id": "gid://gitlab/Iteration/2", "title": "Sprint 2"}], "pageInfo": {"hasNextPage": false}}}}}`
+	})
+
+	iterations, err := ListIterations(gl, "group", "gid://gitlab/Iterations::Cadence/1")
+	assert.NoError(t, err)
+	assert.Equal(t, []*Iteration{
+		{ID: "gid://gitlab/Iteration/1", Title: "Sprint 1", StartDate: "2023-09-01", DueDate: "2023-09-14"},
+		{ID: "gid://gitlab/Iteration/2", Title: "Sprint 2"},
+	}, iterations)
+	if assert.Len(t, *requests, 2) {
+		assert.Equal(t, "group", (*requests)[0].Variables["fullPath"])
+		assert.Equal(t, []interface{}{"gid://gitlab/Iterations::Cadence/1"}, (*requests)[0].Variables["cadenceIds"])
+		assert.Equal(t, "c1", (*requests)[1].Variables["after"])
+	}
+
+	gl, _ = newGraphQLServer(t, func(request *graphQLRequest) string {
+		return `{"data": {"group": null}}`
+	})
+	_, err = ListIterations(gl, "missing", "gid://gitlab/Iterations::Cadence/1")
+	assert.EqualError(t, err, "Group missing is not found")
+}
+
+func TestCreateIteration(t *testing.T) {
+	gl, requests := newGraphQLServer(t, func(request *graphQLRequest) string {
+		input := request.Variables["input"].(map[string]interface{})
+		if input["title"] == "Sprint 2" {
+			return `{"data": {"iterationCreate": {"iteration": null, "errors": ["Dates cannot overlap with other existing Iterations within this iterations cadence"]}}}`
+		}
+		return `{"data": {"iterationCreate": {"iteration": {"id": "gid://gitlab/Iteration/1", "title": "Sprint 1", "startDate": "2023-09-01", "dueDate": "2023-09-14"}, "errors": []}}}`
+	})
+
+	iteration, err := CreateIteration(gl, "group", "gid://gitlab/Iterations::Cadence/1", &CreateIterationOptions{Title: "Sprint 1", StartDate: "2023-09-01", DueDate: "2023-09-14"})
+	assert.NoError(t, err)
+	assert.Equal(t, "gid://gitlab/Iteration/1", iteration.ID)
+	input := (*requests)[0].Variables["input"].(map[string]interface{})
+	assert.Equal(t, "gid://gitlab/Iterations::Cadence/1", input["iterationsCadenceId"])
+	assert.Equal(t, "2023-09-01", input["startDate"])
+	assert.Contains(t, (*requests)[0].Query, "iterationCreate")
+
+	//* The errors of the mutation payload
+	_, err = CreateIteration(gl, "group", "gid://gitlab/Iterations::Cadence/1", &CreateIterationOptions{Title: "Sprint 2", StartDate: "2023-09-10", DueDate: "2023-09-20"})
+	assert.EqualError(t, err, "Error creating iteration: Sprint 2: Dates cannot overlap with other existing Iterations within this iterations cadence")
+}
+
+func TestSetIssueIteration(t *testing.T) {
+	gl, requests := newGraphQLServer(t, func(request *graphQLRequest) string {
+		return `{"errors": [{"message": "Iteration not found"}]}`
+	})
+
+	err := SetIssueIteration(gl, "group/project", 1, "gid://gitlab/Iteration/9")
+	assert.ErrorContains(t, err, "GraphQL error: Iteration not found")
+	input := (*requests)[0].Variables["input"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"projectPath": "group/project", "iid": "1", "iterationId": "gid://gitlab/Iteration/9"}, input)
+}
@@ -0,0 +1,77 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlabx
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+type CreateIssueNoteOptions struct {
+	Body      *string    `url:"body,omitempty" json:"body,omitempty"`
+	CreatedAt *time.Time `url:"created_at,omitempty" json:"created_at,omitempty"`
+
+	//* 라이브러리에서 지원하지 않는 추가 옵션
+	Internal *bool `url:"internal,omitempty" json:"internal,omitempty"` // visible to Reporter and above
+}
+
+type CreateEpicNoteOptions struct {
+	Body *string `url:"body,omitempty" json:"body,omitempty"`
+
+	//* 라이브러리에서 지원하지 않는 추가 옵션
+	Internal *bool `url:"internal,omitempty" json:"internal,omitempty"`
+}
+
+func CreateIssueNote(gl *gitlab.Client, pid interface{}, issue int, opt *CreateIssueNoteOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Note, *gitlab.Response, error) {
+	project, err := parseID(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("projects/%s/issues/%d/notes", gitlab.PathEscape(project), issue)
+
+	req, err := gl.NewRequest(http.MethodPost, u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := new(gitlab.Note)
+	resp, err := gl.Do(req, n)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return n, resp, nil
+}
+
+func CreateEpicNote(gl *gitlab.Client, gid interface{}, epic int, opt *CreateEpicNoteOptions, options ...gitlab.RequestOptionFunc) (*gitlab.Note, *gitlab.Response, error) {
+	group, err := parseID(gid)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := fmt.Sprintf("groups/%s/epics/%d/notes", gitlab.PathEscape(group), epic)
+
+	req, err := gl.NewRequest(http.MethodPost, u, opt, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := new(gitlab.Note)
+	resp, err := gl.Do(req, n)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return n, resp, nil
+}
@@ -0,0 +1,51 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlabx
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// Severities of the IssuableSeverity enum of GraphQL, S1 is the highest
+const (
+	SeverityCritical = "CRITICAL" // S1
+	SeverityHigh     = "HIGH"     // S2
+	SeverityMedium   = "MEDIUM"   // S3
+	SeverityLow      = "LOW"      // S4
+)
+
+// SetIssueSeverity is GraphQL only and the issue must be an incident
+func SetIssueSeverity(gl *gitlab.Client, projectPath string, issue int, severity string, options ...gitlab.RequestOptionFunc) error {
+	query := `mutation($input: IssueSetSeverityInput!) {
+  issueSetSeverity(input: $input) { errors }
+}`
+
+	input := map[string]interface{}{
+		"projectPath": projectPath,
+		"iid":         fmt.Sprintf("%d", issue),
+		"severity":    severity,
+	}
+
+	var data struct {
+		IssueSetSeverity struct {
+			Errors []string `json:"errors"`
+		} `json:"issueSetSeverity"`
+	}
+	if _, err := GraphQL(gl, query, map[string]interface{}{"input": input}, &data, options...); err != nil {
+		return errors.Wrap(err, "Error setting severity")
+	}
+
+	return mutationErrors(data.IssueSetSeverity.Errors)
+}
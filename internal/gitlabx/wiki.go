@@ -0,0 +1,53 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package gitlabx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+type WikiAttachment struct {
+	FileName string `json:"file_name"`
+	FilePath string `json:"file_path"` // in the wiki repository, e.g. uploads/<hash>/image.png
+	Branch   string `json:"branch"`
+	Link     struct {
+		URL      string `json:"url"`
+		Markdown string `json:"markdown"`
+	} `json:"link"`
+}
+
+// UploadGroupWikiAttachment uploads a file to the wiki repository of the group, go-gitlab only has the project one
+func UploadGroupWikiAttachment(gl *gitlab.Client, gid interface{}, content io.Reader, filename string, options ...gitlab.RequestOptionFunc) (*WikiAttachment, *gitlab.Response, error) {
+	group, err := parseID(gid)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error parsing ID")
+	}
+	u := fmt.Sprintf("groups/%s/wikis/attachments", gitlab.PathEscape(group))
+
+	req, err := gl.UploadRequest(http.MethodPost, u, content, filename, gitlab.UploadFile, nil, options)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	a := new(WikiAttachment)
+	resp, err := gl.Do(req, a)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, "Error making request")
+	}
+
+	return a, resp, nil
+}
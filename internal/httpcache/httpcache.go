@@ -0,0 +1,175 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// entry is a cached response with its validators (ETag, Last-Modified)
+type entry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	StoredAt     time.Time   `json:"stored_at,omitempty"`
+}
+
+type transport struct {
+	dir    string
+	maxAge time.Duration
+	base   http.RoundTripper
+}
+
+// NewTransport returns a RoundTripper revalidating the GET responses cached in dir.
+// A response with an ETag or Last-Modified is stored; the next request sends If-None-Match or If-Modified-Since,
+// and 304 Not Modified is answered with the stored body, so a delta run only downloads what changed.
+// The bodies are stored decoded, the base transport negotiates gzip.
+// Only the JSON responses of the API are stored, an attachment or an avatar is streamed to the client.
+//
+// With a maxAge, a response stored for less than maxAge is answered without a request, e.g. for the repeated dry-runs of a configuration,
+// and the responses without validators (e.g. the searches) are stored too.
+func NewTransport(dir string, maxAge time.Duration, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{dir: dir, maxAge: maxAge, base: base}
+}
+
+// response is the stored response of the request
+func (cached *entry) response(req *http.Request, proto string, major int, minor int) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        cached.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}
+}
+
+func (t *transport) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (t *transport) load(url string) *entry {
+	data, err := os.ReadFile(t.path(url))
+	if err != nil {
+		return nil
+	}
+	cached := new(entry)
+	if err := json.Unmarshal(data, cached); err != nil || cached.URL != url {
+		return nil
+	}
+	return cached
+}
+
+func (t *transport) store(cached *entry) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(t.dir, 0o700); err != nil {
+		log.Debugf("Error creating HTTP cache: %s", err)
+		return
+	}
+
+	//* Written to a temporary file first, a concurrent reader never sees a partial entry
+	tmp, err := os.CreateTemp(t.dir, "entry-*")
+	if err != nil {
+		log.Debugf("Error writing HTTP cache: %s", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), t.path(cached.URL))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Debugf("Error writing HTTP cache: %s", err)
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	url := req.URL.String()
+	cached := t.load(url)
+
+	//* Fresh: no request at all
+	if cached != nil && t.maxAge > 0 && time.Since(cached.StoredAt) < t.maxAge {
+		return cached.response(req, "HTTP/1.1", 1, 1), nil
+	}
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	//* Not Modified: the stored response
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		if t.maxAge > 0 {
+			cached.StoredAt = time.Now()
+			t.store(cached)
+		}
+		return cached.response(req, resp.Proto, resp.ProtoMajor, resp.ProtoMinor), nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "json") ||
+		(etag == "" && lastModified == "" && t.maxAge <= 0) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.store(&entry{URL: url, ETag: etag, LastModified: lastModified, Header: resp.Header, Body: body, StoredAt: time.Now()})
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
@@ -0,0 +1,158 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newServer answers every path with its name, /etag with an ETag and /attachment as a binary download
+func newServer(t *testing.T, requests *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/attachment":
+			w.Header().Set("ETag", `"a1"`)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			io.WriteString(w, "binary")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `"`+r.URL.Path+`"`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func entries(t *testing.T, dir string) int {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	assert.NoError(t, err)
+	return len(files)
+}
+
+func TestTransportNotModified(t *testing.T) {
+	var requests int32
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewTransport(dir, 0, nil)}
+
+	assert.Equal(t, `"/etag"`, get(t, client, server.URL+"/etag"))
+	assert.Equal(t, 1, entries(t, dir))
+
+	//* 304 is answered with the stored body
+	assert.Equal(t, `"/etag"`, get(t, client, server.URL+"/etag"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestTransportNoValidator(t *testing.T) {
+	var requests int32
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewTransport(dir, 0, nil)}
+
+	//* Not stored without a maxAge
+	assert.Equal(t, `"/search"`, get(t, client, server.URL+"/search"))
+	assert.Equal(t, `"/search"`, get(t, client, server.URL+"/search"))
+	assert.Equal(t, 0, entries(t, dir))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestTransportAttachment(t *testing.T) {
+	var requests int32
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewTransport(dir, 0, nil)}
+
+	//* Not stored despite the ETag
+	assert.Equal(t, "binary", get(t, client, server.URL+"/attachment"))
+	assert.Equal(t, 0, entries(t, dir))
+}
+
+func TestTransportRequests(t *testing.T) {
+	var requests int32
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewTransport(dir, 0, nil)}
+
+	//* A POST and a Range request are never stored
+	resp, err := client.Post(server.URL+"/etag", "application/json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/etag", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 0, entries(t, dir))
+}
+
+func TestTransportFresh(t *testing.T) {
+	var requests int32
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewTransport(dir, time.Hour, nil)}
+
+	//* Stored without validators with a maxAge, and answered without a request while fresh
+	assert.Equal(t, `"/search"`, get(t, client, server.URL+"/search"))
+	assert.Equal(t, `"/search"`, get(t, client, server.URL+"/search"))
+	assert.Equal(t, `"/etag"`, get(t, client, server.URL+"/etag"))
+	assert.Equal(t, `"/etag"`, get(t, client, server.URL+"/etag"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, 2, entries(t, dir))
+}
+
+func TestTransportStale(t *testing.T) {
+	var requests int32
+	server := newServer(t, &requests)
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewTransport(dir, time.Hour, nil)}
+	assert.Equal(t, `"/etag"`, get(t, client, server.URL+"/etag"))
+
+	//* A stale entry is revalidated, and fresh again after the 304
+	transport := client.Transport.(*transport)
+	cached := transport.load(server.URL + "/etag")
+	cached.StoredAt = time.Now().Add(-2 * time.Hour)
+	transport.store(cached)
+	assert.Equal(t, `"/etag"`, get(t, client, server.URL+"/etag"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.WithinDuration(t, time.Now(), transport.load(server.URL+"/etag").StoredAt, time.Minute)
+
+	assert.Equal(t, `"/etag"`, get(t, client, server.URL+"/etag"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
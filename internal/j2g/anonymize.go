@@ -0,0 +1,65 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// emailPattern matches the email addresses stripped from the texts (anonymize)
+var emailPattern = regexp.MustCompile(`[\w.%+-]+@[\w-]+(\.[\w-]+)+`)
+
+// isAnonymized is true for a Jira user migrated as a placeholder (anonymize.enabled and not in anonymize.allow)
+func isAnonymized(cfg *config.Config, jiraUsername string) bool {
+	if !cfg.Anonymize.Enabled {
+		return false
+	}
+	for _, allowed := range cfg.Anonymize.Allow {
+		if allowed == jiraUsername {
+			return false
+		}
+	}
+	return true
+}
+
+// anonymousName is the placeholder of the Jira user, the same user has the same placeholder in every run
+func anonymousName(jiraUsername string) string {
+	sum := sha256.Sum256([]byte(jiraUsername))
+	return "Jira user " + hex.EncodeToString(sum[:])[:8]
+}
+
+// newAnonymousUser is the unmapped user of the placeholder, without the name and email of the Jira user
+func newAnonymousUser(jiraUsername string) *gitlab.User {
+	name := anonymousName(jiraUsername)
+	return &gitlab.User{Username: name, Name: name}
+}
+
+// displayName is the display name of the Jira user, its placeholder if it is anonymized
+func displayName(cfg *config.Config, user *jira.User) string {
+	username := user.Name
+	if username == "" {
+		username = user.AccountID
+	}
+	if username == "" && user.DisplayName == "" {
+		return ""
+	}
+	if isAnonymized(cfg, username) {
+		return anonymousName(username)
+	}
+	return user.DisplayName
+}
@@ -0,0 +1,54 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestAnonymize(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Anonymize.Enabled = true
+	cfg.Anonymize.Allow = []string{"kim"}
+	cfg.Users = map[string]int{"hong": 10, "kim": 11}
+
+	gl.Users[10] = &gitlab.User{ID: 10, Username: "hong"}
+	gl.Users[11] = &gitlab.User{ID: 11, Username: "kim"}
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Description = "Asked [~hong] (hong@example.com)\n\nand [~kim]"
+	jiraIssue.Fields.Assignee = &jira.User{Name: "hong", DisplayName: "홍길동", EmailAddress: "hong@example.com"}
+	jiraIssue.Fields.Comments.Comments[0].Author = jira.User{Name: "hong", DisplayName: "홍길동"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	userMap, err := newUserMap(context.Background(), gl, jr, []*jira.Issue{jiraIssue}, cfg)
+	assert.NoError(t, err)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, userMap, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	placeholder := anonymousName("hong")
+	assert.Contains(t, gitlabIssue.Description, fmt.Sprintf("Asked **%s (Jira)** ([REDACTED])\n\nand @kim", placeholder))
+	assert.Empty(t, gitlabIssue.Assignees)
+
+	notes := gl.IssueNotes[gitlabIssue.ID]
+	if assert.NotEmpty(t, notes) {
+		assert.Contains(t, notes[0].Body, "by "+placeholder)
+		assert.NotContains(t, notes[0].Body, "홍길동")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// migrateApprovals creates a note with the table of the JSM approvals of the request, the audit trail of the decisions
+func migrateApprovals(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config, jiraIssue *jira.Issue, gitlabIssue *gitlab.Issue, userMap UserMap) error {
+	approvals, r, err := jr.GetApprovals(ctx, jiraIssue.Key)
+	if err != nil {
+		//* The issue is not a request of a service desk
+		if r != nil && r.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return errors.Wrap(err, fmt.Sprintf("Error getting approvals: issue %s", jiraIssue.Key))
+	}
+	if len(approvals) == 0 {
+		return nil
+	}
+
+	body, createdAt, err := formatApprovals(approvals, userMap)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error formatting approvals: issue %s", jiraIssue.Key))
+	}
+
+	options := &gitlabx.CreateIssueNoteOptions{Body: &body}
+	if !createdAt.IsZero() {
+		options.CreatedAt = &createdAt
+	}
+	if _, _, err := gl.CreateIssueNote(ctx, cfg.GitLab.Issue, gitlabIssue.IID, options); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating approval note: issue %s", jiraIssue.Key))
+	}
+	return nil
+}
+
+// formatApprovals returns the table of the approvals, a row per approver, and the time of the last decision
+func formatApprovals(approvals []*jirax.Approval, userMap UserMap) (string, time.Time, error) {
+	var b strings.Builder
+	fmt.Fprint(&b, "**Jira approvals**\n\n| Approval | Final decision | Approver | Decision | Created | Completed |\n|---|---|---|---|---|---|\n")
+
+	var last time.Time
+	for _, approval := range approvals {
+		created, completed := approval.CreatedDate.Time(), approval.CompletedDate.Time()
+		for _, t := range []time.Time{created, completed} {
+			if t.After(last) {
+				last = t
+			}
+		}
+
+		approvers := approval.Approvers
+		if len(approvers) == 0 {
+			approvers = []*jirax.Approver{{}}
+		}
+		for _, approver := range approvers {
+			name := "-"
+			if approver.Approver != nil {
+				author, err := formatWorklogAuthor(approver.Approver, userMap)
+				if err != nil {
+					return "", time.Time{}, err
+				}
+				name = tableCell(author)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+				orDash(tableCell(approval.Name)), orDash(approval.FinalDecision), name, orDash(approver.ApproverDecision),
+				orDash(formatApprovalTime(created)), orDash(formatApprovalTime(completed)))
+		}
+	}
+
+	return b.String(), last, nil
+}
+
+func formatApprovalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,50 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func TestServiceDeskApprovals(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.ServiceDesk = true
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Comments = &jira.Comments{}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	completed := time.Date(2023, 9, 7, 9, 0, 0, 0, time.UTC)
+	jr.Approvals["TEST-1"] = []*jirax.Approval{{
+		Name:          "Manager approval",
+		FinalDecision: "approved",
+		Approvers: []*jirax.Approver{
+			{Approver: &jira.User{Name: "jeff", DisplayName: "Jeff"}, ApproverDecision: "approved"},
+			{Approver: &jira.User{Name: "kim", DisplayName: "Kim"}, ApproverDecision: "pending"},
+		},
+		CompletedDate: &jirax.ServiceDeskDate{EpochMillis: completed.UnixMilli()},
+	}}
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	if notes := gl.IssueNotes[gitlabIssue.ID]; assert.Len(t, notes, 1) {
+		assert.Contains(t, notes[0].Body, "| Manager approval | approved | Jeff | approved | - |")
+		assert.Contains(t, notes[0].Body, "| Manager approval | approved | Kim | pending | - |")
+		assert.True(t, completed.Equal(*notes[0].CreatedAt))
+	}
+}
@@ -12,43 +12,291 @@
 package j2g
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/scratch"
+	"golang.org/x/text/unicode/norm"
 )
 
 type AttachmentMap map[string]*Attachment
 
+// attachmentScratch buffers the attachments during a run (scratch.dir), nil without it
+var attachmentScratch *scratch.Dir
+
+// openScratch opens the scratch directory of the run, call the returned function at the end of the run
+func openScratch(cfg *config.Config) (func(), error) {
+	if cfg.Scratch.Dir == "" {
+		return func() {}, nil
+	}
+
+	dir, err := scratch.Open(cfg.Scratch.Dir, int64(cfg.Scratch.MaxMB)<<20)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Buffering attachments in %s (up to %d MB)", dir.Path(), cfg.Scratch.MaxMB)
+	attachmentScratch = dir
+
+	return func() {
+		attachmentScratch = nil
+		if err := dir.Close(); err != nil {
+			log.Warnf("%s", err)
+		}
+	}, nil
+}
+
 type Attachment struct {
 	Markdown  string
 	Filename  string
 	Alt       string
 	URL       string
+	Title     string // URL of the Jira attachment on the images (attachment_source_title)
 	CreatedAt string
 }
 
-func convertJiraAttachmentToMarkdown(gl *gitlab.Client, jr *jira.Client, id interface{}, attachement *jira.Attachment) (*Attachment, error) {
-	res, err := jr.Issue.DownloadAttachment(context.Background(), attachement.ID)
+// imageMarkdown is the markdown of an image with the title if it is not empty
+func imageMarkdown(alt string, url string, title string) string {
+	if title == "" {
+		return fmt.Sprintf("![%s](%s)", alt, url)
+	}
+	return fmt.Sprintf(`![%s](%s "%s")`, alt, url, strings.ReplaceAll(title, `"`, `\"`))
+}
+
+// jiraAttachmentURL is the URL of the Jira attachment, the download URL or the API URL of a Jira without it
+func jiraAttachmentURL(attachement *jira.Attachment) string {
+	if attachement.Content != "" {
+		return attachement.Content
+	}
+	return attachement.Self
+}
+
+func convertJiraAttachmentToMarkdown(ctx context.Context, gl GitLabWriter, jr JiraReader, id interface{}, attachement *jira.Attachment) (*Attachment, error) {
+	return convertJiraAttachment(ctx, jr, fmt.Sprint(id), attachement, func(content io.Reader, filename string) (*gitlab.ProjectFile, error) {
+		file, _, err := gl.UploadFile(ctx, id, content, filename)
+		return file, err
+	})
+}
+
+// convertJiraAttachmentToGroupWiki uploads the attachment to the wiki of the epic group (gitlab.epic_attachments: group_wiki), the URL is absolute
+func convertJiraAttachmentToGroupWiki(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config, attachement *jira.Attachment) (*Attachment, error) {
+	gid := cfg.GitLab.Epic
+	return convertJiraAttachment(ctx, jr, "wiki:"+gid, attachement, func(content io.Reader, filename string) (*gitlab.ProjectFile, error) {
+		wikiAttachment, _, err := gl.UploadGroupWikiAttachment(ctx, gid, content, filename)
+		if err != nil {
+			return nil, err
+		}
+
+		url := fmt.Sprintf("%s/groups/%s/-/wikis/%s", cfg.GitLab.Host, gid, wikiAttachment.FilePath)
+		return &gitlab.ProjectFile{
+			Alt:      wikiAttachment.FileName,
+			URL:      url,
+			Markdown: strings.Replace(wikiAttachment.Link.Markdown, "("+wikiAttachment.Link.URL+")", "("+url+")", 1),
+		}, nil
+	})
+}
+
+// convertJiraAttachment downloads the Jira attachment and uploads it once by target and content
+func convertJiraAttachment(ctx context.Context, jr JiraReader, target string, attachement *jira.Attachment, upload func(content io.Reader, filename string) (*gitlab.ProjectFile, error)) (*Attachment, error) {
+	fileReader, err := jr.DownloadAttachment(ctx, attachement.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error downloading file")
 	}
 
-	fileReader := res.Body
 	defer fileReader.Close()
 
+	//* Downloaded completely before the upload starts, the Jira connection isn't held while GitLab is slow.
+	//* The content hash finds the attachments shared by issues.
+	hash := sha256.New()
+	var content io.Reader
+	if attachmentScratch != nil {
+		file, err := attachmentScratch.Buffer(ctx, io.TeeReader(fileReader, hash), int64(attachement.Size))
+		if err != nil {
+			return nil, errors.Wrap(err, "Error buffering file")
+		}
+		defer file.Close()
+		content = file
+	} else {
+		data, err := io.ReadAll(io.TeeReader(fileReader, hash))
+		if err != nil {
+			return nil, errors.Wrap(err, "Error downloading file")
+		}
+		content = bytes.NewReader(data)
+	}
+
 	// Upload image to GitLab and retreive a URL
-	gitlabUploadedFile, _, err := gl.Projects.UploadFile(id, fileReader, attachement.Filename, nil)
+	filename := normalizeAttachmentFilename(attachement.Filename)
+	gitlabUploadedFile, reused, err := uploadedFiles.upload(fmt.Sprintf("%s:%x", target, hash.Sum(nil)), func() (*gitlab.ProjectFile, error) {
+		return upload(content, filename)
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Error uploading file")
 	}
+	if reused {
+		log.Debugf("Reusing the upload of the same content: %s to %s", attachement.Filename, gitlabUploadedFile.URL)
+	}
+
+	//* The link text keeps the Jira filename
+	markdown, alt := gitlabUploadedFile.Markdown, gitlabUploadedFile.Alt
+	if reused || filename != attachement.Filename {
+		alt = escapeLinkText(attachement.Filename)
+		image := ""
+		if strings.HasPrefix(markdown, "!") {
+			image = "!"
+		}
+		markdown = fmt.Sprintf("%s[%s](%s)", image, alt, gitlabUploadedFile.URL)
+	}
+
+	//* The images keep the URL of the Jira attachment as title (attachment_source_title)
+	var title string
+	if cfg, err := config.GetConfig(); err == nil && cfg.AttachmentSourceTitle && strings.HasPrefix(markdown, "!") {
+		if title = jiraAttachmentURL(attachement); title != "" {
+			markdown = imageMarkdown(alt, gitlabUploadedFile.URL, title)
+		}
+	}
 
 	return &Attachment{
-		Markdown:  gitlabUploadedFile.Markdown,
+		Markdown:  markdown,
 		Filename:  attachement.Filename,
 		CreatedAt: attachement.Created,
-		Alt:       gitlabUploadedFile.Alt,
+		Alt:       alt,
 		URL:       gitlabUploadedFile.URL,
+		Title:     title,
 	}, nil
 }
+
+// uploadCache is the uploads of a run by project and content hash, an attachment shared by issues is uploaded once
+type uploadCache struct {
+	mutex sync.Mutex
+	files map[string]*cachedUpload
+}
+
+type cachedUpload struct {
+	done chan struct{}
+	file *gitlab.ProjectFile
+	err  error
+}
+
+// uploadedFiles is the upload cache of the run, nil outside ConvertByProject and MigrateIssue
+var uploadedFiles *uploadCache
+
+// startUploadCache starts the upload cache of the run, call the returned function at the end of the run
+func startUploadCache() func() {
+	uploadedFiles = &uploadCache{files: make(map[string]*cachedUpload)}
+	return func() {
+		uploadedFiles = nil
+	}
+}
+
+// upload returns the upload of the key, the upload is called once by key. It is true if the upload is reused.
+// A failed upload is not kept, the next attachment of the key uploads again.
+func (c *uploadCache) upload(key string, upload func() (*gitlab.ProjectFile, error)) (*gitlab.ProjectFile, bool, error) {
+	if c == nil {
+		file, err := upload()
+		return file, false, err
+	}
+
+	c.mutex.Lock()
+	if cached, ok := c.files[key]; ok {
+		c.mutex.Unlock()
+		<-cached.done
+		if cached.err == nil {
+			return cached.file, true, nil
+		}
+		file, err := upload()
+		return file, false, err
+	}
+	cached := &cachedUpload{done: make(chan struct{})}
+	c.files[key] = cached
+	c.mutex.Unlock()
+
+	cached.file, cached.err = upload()
+	close(cached.done)
+	if cached.err != nil {
+		c.mutex.Lock()
+		delete(c.files, key)
+		c.mutex.Unlock()
+	}
+	return cached.file, false, cached.err
+}
+
+// windowsReservedNames can't be the name of a file on Windows, with any extension
+var windowsReservedNames = regexp.MustCompile(`(?i)^(CON|PRN|AUX|NUL|COM[1-9]|LPT[1-9])(\.|$)`)
+
+// normalizeAttachmentFilename returns the filename of the upload: NFC (e.g. Korean of macOS), without the characters
+// breaking a Markdown link or reserved on Windows, and without the Windows reserved names
+func normalizeAttachmentFilename(name string) string {
+	name = norm.NFC.String(name)
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsControl(r), unicode.IsSpace(r):
+			b.WriteRune('_')
+		case strings.ContainsRune(`<>:"/\|?*[]()#%`, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	result := strings.TrimRight(b.String(), ". ")
+	if result == "" {
+		return "attachment"
+	}
+	if windowsReservedNames.MatchString(result) {
+		result = "_" + result
+	}
+	return result
+}
+
+// escapeLinkText escapes the characters ending the text of a Markdown link
+func escapeLinkText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(text)
+}
+
+// orphanAttachments returns the attachments which are not used in the description or comments, ordered by creation
+func orphanAttachments(attachments AttachmentMap, used map[string]bool) []*Attachment {
+	var result []*Attachment
+	for id, attachment := range attachments {
+		if used[id] {
+			continue
+		}
+		result = append(result, attachment)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].CreatedAt != result[j].CreatedAt {
+			return result[i].CreatedAt < result[j].CreatedAt
+		}
+		return result[i].Filename < result[j].Filename
+	})
+	return result
+}
+
+// formatAttachmentsNote formats the "Migrated attachments" note of the orphan attachments (orphan_attachments: batch)
+func formatAttachmentsNote(attachments []*Attachment) string {
+	var note strings.Builder
+	note.WriteString("**Migrated attachments**\n")
+	for _, attachment := range attachments {
+		note.WriteString(fmt.Sprintf("\n- %s", attachment.Markdown))
+	}
+	return note.String()
+}
+
+// attachmentCreatedAt parses the creation time of the Jira attachment
+func attachmentCreatedAt(attachment *Attachment) (time.Time, error) {
+	return time.Parse("2006-01-02T15:04:05.000-0700", attachment.CreatedAt)
+}
@@ -0,0 +1,165 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestScratchAttachments(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Scratch.Dir = t.TempDir()
+	cfg.Scratch.MaxMB = 1
+
+	//* Left by a killed run
+	stale := filepath.Join(cfg.Scratch.Dir, "j2lab-4194304-1")
+	assert.NoError(t, os.MkdirAll(stale, 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(stale, "buffer-1"), []byte("log"), 0o600))
+
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, newTestJiraIssue())
+	jr.Attachments["1"] = []byte("log")
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	assert.Equal(t, []string{"log.txt"}, gl.Uploads[2])
+
+	entries, err := os.ReadDir(cfg.Scratch.Dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDedupeAttachments(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	other := newTestJiraIssue()
+	other.ID = "10002"
+	other.Key = "TEST-2"
+	other.Fields.Attachments = []*jira.Attachment{{ID: "2", Filename: "copy.txt", Created: "2023-09-06T10:00:00.000+0900"}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, newTestJiraIssue(), other)
+	jr.Attachments["1"] = []byte("log")
+	jr.Attachments["2"] = []byte("log")
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	//* The issues run in parallel, either name is uploaded
+	if assert.Len(t, gl.Uploads[2], 1) && assert.Len(t, gl.Issues[2], 2) {
+		url := fmt.Sprintf("/%s)", gl.Uploads[2][0])
+		for _, issue := range gl.Issues[2] {
+			notes := ""
+			for _, note := range gl.IssueNotes[issue.ID] {
+				notes += note.Body
+			}
+			assert.Contains(t, notes, url)
+		}
+	}
+}
+
+func TestAttachmentSourceTitle(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.AttachmentSourceTitle = true
+	config.SetConfig(cfg)
+	defer config.SetConfig(nil)
+
+	jr := fake.NewJira(&jira.Project{Key: "TEST"})
+	jr.Attachments["1"] = []byte("png")
+	jr.Attachments["2"] = []byte("log")
+	upload := func(content io.Reader, filename string) (*gitlab.ProjectFile, error) {
+		url := "/uploads/1/" + filename
+		if strings.HasSuffix(filename, ".png") {
+			return &gitlab.ProjectFile{Alt: filename, URL: url, Markdown: fmt.Sprintf("![%s](%s)", filename, url)}, nil
+		}
+		return &gitlab.ProjectFile{Alt: filename, URL: url, Markdown: fmt.Sprintf("[%s](%s)", filename, url)}, nil
+	}
+
+	image := &jira.Attachment{ID: "1", Filename: "screen.png", Content: "https://jira.example.com/secure/attachment/1/screen.png"}
+	attachment, err := convertJiraAttachment(context.Background(), jr, "2", image, upload)
+	assert.NoError(t, err)
+	assert.Equal(t, `![screen.png](/uploads/1/screen.png "https://jira.example.com/secure/attachment/1/screen.png")`, attachment.Markdown)
+
+	markdown, _, err := JiraToMD("!screen.png|width=200!", AttachmentMap{"screen.png": attachment}, UserMap{})
+	assert.NoError(t, err)
+	assert.Contains(t, markdown, `title="https://jira.example.com/secure/attachment/1/screen.png"`)
+
+	//* Only the images
+	file := &jira.Attachment{ID: "2", Filename: "log.txt", Content: "https://jira.example.com/secure/attachment/2/log.txt"}
+	attachment, err = convertJiraAttachment(context.Background(), jr, "2", file, upload)
+	assert.NoError(t, err)
+	assert.Equal(t, "[log.txt](/uploads/1/log.txt)", attachment.Markdown)
+}
+
+func TestEpicAttachmentsGroupWiki(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.GitLab.EpicAttachments = config.EpicAttachmentsGroupWiki
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{
+		Summary:     "Auth",
+		Description: "!diagram.png!",
+		Type:        jira.IssueType{Name: "Epic"},
+		Status:      &jira.Status{Name: "To Do"},
+		Priority:    &jira.Priority{Name: "High"},
+		Attachments: []*jira.Attachment{{ID: "1", Filename: "diagram.png", Created: "2023-09-06T10:00:00.000+0900"}},
+		Comments:    &jira.Comments{},
+	}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic)
+	jr.Attachments["1"] = []byte("png")
+
+	gitlabEpic, err := ConvertJiraIssueToGitLabEpic(context.Background(), gl, jr, epic, UserMap{}, map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"diagram.png"}, gl.Uploads[1])
+	assert.Empty(t, gl.Uploads[2])
+	assert.Contains(t, gitlabEpic.Description, "(https://gitlab.example.com/groups/group/-/wikis/uploads/")
+}
+
+func TestOrphanAttachments(t *testing.T) {
+	for _, mode := range []string{config.OrphanAttachmentsBatch, config.OrphanAttachmentsSeparate} {
+		cfg, gl := newTestEnv(t)
+		cfg.OrphanAttachments = mode
+
+		jiraIssue := newTestJiraIssue()
+		jiraIssue.Fields.Comments = &jira.Comments{}
+		jiraIssue.Fields.Attachments = append(jiraIssue.Fields.Attachments, &jira.Attachment{ID: "2", Filename: "trace.txt", Created: "2023-09-06T12:00:00.000+0900"})
+		jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+		jr.Attachments["1"] = []byte("log")
+		jr.Attachments["2"] = []byte("trace")
+
+		gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+		assert.NoError(t, err)
+
+		notes := gl.IssueNotes[gitlabIssue.ID]
+		if mode == config.OrphanAttachmentsBatch {
+			assert.Len(t, notes, 1, mode)
+			assert.True(t, strings.HasPrefix(notes[0].Body, "**Migrated attachments**\n\n- "), mode)
+			assert.Less(t, strings.Index(notes[0].Body, "log.txt"), strings.Index(notes[0].Body, "trace.txt"), mode)
+		} else {
+			assert.Len(t, notes, 2, mode)
+		}
+	}
+}
+
+func TestNormalizeAttachmentFilename(t *testing.T) {
+	assert.Equal(t, "log.txt", normalizeAttachmentFilename("log.txt"))
+	assert.Equal(t, "화면_캡처__1__.png", normalizeAttachmentFilename("화면 캡처 (1) .png"))
+	assert.Equal(t, "what_.txt", normalizeAttachmentFilename("what?.txt"))
+	assert.Equal(t, "_con.log", normalizeAttachmentFilename("con.log"))
+	assert.Equal(t, "attachment", normalizeAttachmentFilename("..."))
+	//* NFD (macOS) is composed
+	assert.Equal(t, "한.txt", normalizeAttachmentFilename("\u1112\u1161\u11ab.txt"))
+}
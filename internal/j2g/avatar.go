@@ -0,0 +1,67 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// Avatar formats of GitLab, the default Jira avatars are SVG
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// migrateProjectAvatar sets the Jira project avatar on the GitLab project, a failure is a warning only
+func migrateProjectAvatar(ctx context.Context, gl GitLabWriter, jr JiraReader, jiraProject *jira.Project, gitlabProjectPath string) {
+	body, contentType, err := jr.DownloadProjectAvatar(ctx, jiraProject)
+	if err != nil {
+		log.Warnf("Skipping project avatar: %s", err)
+		return
+	}
+	defer body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	extension, ok := avatarExtensions[mediaType]
+	if !ok {
+		log.Warnf("Skipping project avatar: GitLab doesn't support %s avatars", contentType)
+		return
+	}
+
+	//* Read first, a failed upload is not retried from a consumed stream
+	content, err := io.ReadAll(body)
+	if err != nil {
+		log.Warnf("Skipping project avatar: %s", err)
+		return
+	}
+
+	_, _, err = gl.EditProject(ctx, gitlabProjectPath, &gitlab.EditProjectOptions{
+		Avatar: &gitlab.ProjectAvatar{
+			Filename: "avatar" + extension,
+			Image:    bytes.NewReader(content),
+		},
+	})
+	if err != nil {
+		log.Warnf("Error setting project avatar: %s", err)
+		return
+	}
+	log.Infof("Project avatar of %s is set on %s", jiraProject.Key, gitlabProjectPath)
+}
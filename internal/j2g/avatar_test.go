@@ -0,0 +1,39 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestProjectAvatar(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	project := &jira.Project{Key: "TEST"}
+	jr := fake.NewJira(project)
+	jr.Avatar = []byte("\x89PNG")
+	jr.AvatarContentType = "image/png"
+
+	migrateProjectAvatar(context.Background(), gl, jr, project, "group/project")
+	assert.True(t, strings.HasSuffix(gl.Projects[2].AvatarURL, "/avatar.png"))
+
+	//* SVG is skipped
+	gl.Projects[2].AvatarURL = ""
+	jr.AvatarContentType = "image/svg+xml"
+	migrateProjectAvatar(context.Background(), gl, jr, project, "group/project")
+	assert.Empty(t, gl.Projects[2].AvatarURL)
+}
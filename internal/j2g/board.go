@@ -0,0 +1,146 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// migrateBoards creates a GitLab issue board for each Jira board missing in the project.
+// A column becomes the list of the status label of its first status, with the max issues of the column as WIP limit.
+func migrateBoards(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config, pid int) error {
+	jiraBoards, err := jr.ListBoardConfigurations(ctx, cfg.Jira.Name)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error getting Jira boards: %s", cfg.Jira.Name))
+	}
+	if len(jiraBoards) == 0 {
+		return nil
+	}
+
+	//* Status ID -> Name, the columns have the status IDs only
+	issueTypes, _, err := jr.ListProjectStatuses(ctx, cfg.Jira.Name)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error getting Jira statuses: %s", cfg.Jira.Name))
+	}
+	statusNames := make(map[string]string)
+	for _, issueType := range issueTypes {
+		for _, status := range issueType.Statuses {
+			statusNames[status.ID] = status.Name
+		}
+	}
+
+	labelIDs, err := listLabelIDs(ctx, gl, cfg, pid)
+	if err != nil {
+		return err
+	}
+
+	existingBoards, err := gl.ListIssueBoards(ctx, pid)
+	if err != nil {
+		return errors.Wrap(err, "Error getting GitLab issue boards")
+	}
+
+	for _, jiraBoard := range jiraBoards {
+		exist := false
+		for _, board := range existingBoards {
+			if board.Name == jiraBoard.Name {
+				exist = true
+				break
+			}
+		}
+		if exist {
+			log.Infof("Issue board already exists: %s", jiraBoard.Name)
+			continue
+		}
+
+		log.Infof("Creating issue board: %s", jiraBoard.Name)
+		board, _, err := gl.CreateIssueBoard(ctx, pid, &gitlab.CreateIssueBoardOptions{Name: gitlab.String(jiraBoard.Name)})
+		if err != nil {
+			// e.g. GitLab Free has one board per project
+			log.Warnf("Skipping Jira board %s: %s", jiraBoard.Name, err)
+			continue
+		}
+
+		if err := createBoardLists(ctx, gl, pid, board, jiraBoard, statusNames, labelIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createBoardLists(ctx context.Context, gl GitLabWriter, pid int, board *gitlab.IssueBoard, jiraBoard *jirax.BoardConfiguration, statusNames map[string]string, labelIDs map[string]int) error {
+	for _, column := range jiraBoard.ColumnConfig.Columns {
+		labelID, label := 0, ""
+		for _, status := range column.Statuses {
+			name := fmt.Sprintf("status::%s", statusNames[status.ID])
+			if id, ok := labelIDs[name]; ok {
+				labelID, label = id, name
+				break
+			}
+		}
+		if labelID == 0 {
+			log.Debugf("Skipping column %s of board %s: no status label", column.Name, jiraBoard.Name)
+			continue
+		}
+		if len(column.Statuses) > 1 {
+			log.Warnf("Column %s of board %s has %d statuses, only %s is listed", column.Name, jiraBoard.Name, len(column.Statuses), label)
+		}
+
+		list, _, err := gl.CreateIssueBoardList(ctx, pid, board.ID, &gitlab.CreateIssueBoardListOptions{LabelID: gitlab.Int(labelID)})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error creating list %s of board %s", label, jiraBoard.Name))
+		}
+
+		//* WIP Limit
+		if column.Max > 0 && jiraBoard.ColumnConfig.ConstraintType == jirax.BoardConstraintIssueCount {
+			if err := gl.SetBoardListLimit(ctx, list.ID, column.Max); err != nil {
+				log.Warnf("Skipping WIP limit %d of column %s (GitLab Premium): %s", column.Max, column.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// listLabelIDs returns the IDs of the labels of the project and the group of the epics. Key: label name
+func listLabelIDs(ctx context.Context, gl GitLabWriter, cfg *config.Config, pid int) (map[string]int, error) {
+	result := make(map[string]int)
+
+	groupLabels, err := gl.ListGroupLabels(ctx, cfg.GitLab.Epic, &gitlab.ListGroupLabelsOptions{
+		IncludeAncestorGroups: gitlab.Bool(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting GitLab group labels from GitLab")
+	}
+	for _, label := range groupLabels {
+		result[label.Name] = label.ID
+	}
+
+	projectLabels, err := gl.ListLabels(ctx, pid, &gitlab.ListLabelsOptions{
+		IncludeAncestorGroups: gitlab.Bool(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting GitLab project labels from GitLab")
+	}
+	for _, label := range projectLabels {
+		result[label.Name] = label.ID
+	}
+
+	return result, nil
+}
@@ -0,0 +1,53 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func TestMigrateBoards(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Boards.Enabled = true
+
+	inProgress, _, err := gl.CreateLabel(context.Background(), 2, &gitlab.CreateLabelOptions{Name: gitlab.String("status::In Progress")})
+	assert.NoError(t, err)
+
+	jr := fake.NewJira(&jira.Project{Key: "TEST"})
+	jr.Statuses = []*jirax.IssueTypeStatuses{{Name: "Bug", Statuses: []jira.Status{{ID: "1", Name: "Open"}, {ID: "3", Name: "In Progress"}}}}
+	board := &jirax.BoardConfiguration{ID: 10, Name: "TEST board"}
+	board.ColumnConfig.ConstraintType = jirax.BoardConstraintIssueCount
+	board.ColumnConfig.Columns = []jirax.BoardColumn{
+		{Name: "To Do", Statuses: []jirax.BoardColumnStatus{{ID: "1"}}},
+		{Name: "Doing", Statuses: []jirax.BoardColumnStatus{{ID: "3"}}, Max: 3},
+	}
+	jr.Boards = []*jirax.BoardConfiguration{board}
+
+	assert.NoError(t, migrateBoards(context.Background(), gl, jr, cfg, 2))
+	assert.Len(t, gl.Boards[2], 1)
+
+	//* The column of Open has no label
+	lists := gl.Boards[2][0].Lists
+	assert.Len(t, lists, 1)
+	assert.Equal(t, inProgress.ID, lists[0].Label.ID)
+	assert.Equal(t, 3, gl.BoardListLimits[lists[0].ID])
+
+	//* Existing board is kept
+	assert.NoError(t, migrateBoards(context.Background(), gl, jr, cfg, 2))
+	assert.Len(t, gl.Boards[2], 1)
+}
@@ -0,0 +1,589 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/backup"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// JiraReader is the source of a migration.
+// The converters only read from Jira through this interface, so a backup file or a fake can replace the Jira API.
+type JiraReader interface {
+	GetProject(ctx context.Context, projectID string) (*jira.Project, *jira.Response, error)
+	ListProjects(ctx context.Context) (*jira.ProjectList, error)
+	SearchIssues(ctx context.Context, jql string) ([]*jira.Issue, error)
+	DownloadAttachment(ctx context.Context, attachmentID string) (io.ReadCloser, error)
+	GetRemoteLinks(ctx context.Context, issueKey string) (*[]jira.RemoteLink, *jira.Response, error)
+	GetDevStatusSummary(ctx context.Context, issueID string) (*jirax.DevStatusSummary, *jira.Response, error)
+	GetDevStatus(ctx context.Context, issueID string, applicationType string, dataType string) (*jirax.DevStatus, *jira.Response, error)
+	GetProjectRole(ctx context.Context, projectKey string, roleID string) (*jira.Role, *jira.Response, error)
+	ListSprints(ctx context.Context, projectKey string) ([]jira.Sprint, error)
+	GetComments(ctx context.Context, issueKey string) ([]*jira.Comment, error)
+	GetCommentParents(ctx context.Context, issueKey string) (map[string]string, error)
+	GetApprovals(ctx context.Context, issueKey string) ([]*jirax.Approval, *jira.Response, error)
+	GetTestRuns(ctx context.Context, addOn string, jiraIssue *jira.Issue) ([]*jirax.TestRun, *jira.Response, error)
+	GetUser(ctx context.Context, username string) (*jira.User, *jira.Response, error)
+	DownloadProjectAvatar(ctx context.Context, project *jira.Project) (io.ReadCloser, string, error)
+	ListProjectStatuses(ctx context.Context, projectKey string) ([]*jirax.IssueTypeStatuses, *jira.Response, error)
+	GetCreateMeta(ctx context.Context, projectKey string) ([]*jirax.CreateMetaIssueType, *jira.Response, error)
+	ListBoardConfigurations(ctx context.Context, projectKey string) ([]*jirax.BoardConfiguration, error)
+	ListFilters(ctx context.Context, projectKey string) ([]*jira.Filter, error)
+	ListDashboards(ctx context.Context) ([]*jirax.Dashboard, error)
+	ListFields(ctx context.Context) ([]jira.Field, *jira.Response, error)
+	SampleIssues(ctx context.Context, jql string, maxResults int) ([]*jira.Issue, error)
+	GetIssueKey(ctx context.Context, issueKey string) (string, *jira.Response, error)
+}
+
+// GitLabWriter is the target of a migration.
+// List methods return every page. The *gitlab.Response is returned to check the status code (e.g. 409 Conflict).
+type GitLabWriter interface {
+	GetVersion(ctx context.Context) (*gitlab.Version, error)
+	GetProject(ctx context.Context, pid interface{}) (*gitlab.Project, *gitlab.Response, error)
+	EditProject(ctx context.Context, pid interface{}, opt *gitlab.EditProjectOptions) (*gitlab.Project, *gitlab.Response, error)
+	CreateProject(ctx context.Context, opt *gitlab.CreateProjectOptions) (*gitlab.Project, *gitlab.Response, error)
+	UploadFile(ctx context.Context, pid interface{}, content io.Reader, filename string) (*gitlab.ProjectFile, *gitlab.Response, error)
+	GetWikiPage(ctx context.Context, pid interface{}, slug string) (*gitlab.Wiki, *gitlab.Response, error)
+	CreateWikiPage(ctx context.Context, pid interface{}, opt *gitlab.CreateWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error)
+	EditWikiPage(ctx context.Context, pid interface{}, slug string, opt *gitlab.EditWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error)
+	GetFileMetaData(ctx context.Context, pid interface{}, path string, ref string) (*gitlab.File, *gitlab.Response, error)
+	CreateCommit(ctx context.Context, pid interface{}, opt *gitlab.CreateCommitOptions) (*gitlab.Commit, *gitlab.Response, error)
+
+	GetGroup(ctx context.Context, gid interface{}) (*gitlab.Group, *gitlab.Response, error)
+	CreateGroup(ctx context.Context, opt *gitlab.CreateGroupOptions) (*gitlab.Group, *gitlab.Response, error)
+	UploadGroupWikiAttachment(ctx context.Context, gid interface{}, content io.Reader, filename string) (*gitlabx.WikiAttachment, *gitlab.Response, error)
+
+	GetUser(ctx context.Context, uid int) (*gitlab.User, *gitlab.Response, error)
+	ListUsers(ctx context.Context, opt *gitlab.ListUsersOptions) ([]*gitlab.User, *gitlab.Response, error)
+	ListProjectMembers(ctx context.Context, pid interface{}) ([]*gitlab.ProjectMember, error)
+	AddProjectMember(ctx context.Context, pid interface{}, opt *gitlab.AddProjectMemberOptions) (*gitlab.ProjectMember, *gitlab.Response, error)
+	AddGroupMember(ctx context.Context, gid interface{}, opt *gitlab.AddGroupMemberOptions) (*gitlab.GroupMember, *gitlab.Response, error)
+
+	ListMilestones(ctx context.Context, pid interface{}) ([]*gitlab.Milestone, error)
+	CreateMilestone(ctx context.Context, pid interface{}, opt *gitlab.CreateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error)
+	UpdateMilestone(ctx context.Context, pid interface{}, milestone int, opt *gitlab.UpdateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error)
+	ListGroupMilestones(ctx context.Context, gid interface{}) ([]*gitlab.GroupMilestone, error)
+	CreateGroupMilestone(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error)
+	UpdateGroupMilestone(ctx context.Context, gid interface{}, milestone int, opt *gitlab.UpdateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error)
+
+	ListGroupLabels(ctx context.Context, gid interface{}, opt *gitlab.ListGroupLabelsOptions) ([]*gitlab.GroupLabel, error)
+	ListLabels(ctx context.Context, pid interface{}, opt *gitlab.ListLabelsOptions) ([]*gitlab.Label, error)
+	CreateGroupLabel(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupLabelOptions) (*gitlab.GroupLabel, *gitlab.Response, error)
+	CreateLabel(ctx context.Context, pid interface{}, opt *gitlab.CreateLabelOptions) (*gitlab.Label, *gitlab.Response, error)
+
+	GetIssue(ctx context.Context, pid interface{}, issue int) (*gitlab.Issue, *gitlab.Response, error)
+	CreateIssue(ctx context.Context, pid interface{}, opt *gitlabx.CreateIssueOptions) (*gitlab.Issue, *gitlab.Response, error)
+	UpdateIssue(ctx context.Context, pid interface{}, issue int, opt *gitlab.UpdateIssueOptions) (*gitlab.Issue, *gitlab.Response, error)
+	DeleteIssue(ctx context.Context, pid interface{}, issue int) (*gitlab.Response, error)
+	ListIssueNotes(ctx context.Context, pid interface{}, issue int) ([]*gitlab.Note, error)
+	CreateIssueNote(ctx context.Context, pid interface{}, issue int, opt *gitlabx.CreateIssueNoteOptions) (*gitlab.Note, *gitlab.Response, error)
+	CreateIssueDiscussion(ctx context.Context, pid interface{}, issue int, opt *gitlab.CreateIssueDiscussionOptions) (*gitlab.Discussion, *gitlab.Response, error)
+	AddIssueDiscussionNote(ctx context.Context, pid interface{}, issue int, discussion string, opt *gitlab.AddIssueDiscussionNoteOptions) (*gitlab.Note, *gitlab.Response, error)
+	CreateIssueLink(ctx context.Context, pid interface{}, issue int, opt *gitlab.CreateIssueLinkOptions) (*gitlab.IssueLink, *gitlab.Response, error)
+	ReorderIssue(ctx context.Context, pid interface{}, issue int, opt *gitlabx.ReorderIssueOptions) (*gitlab.Issue, *gitlab.Response, error)
+	SearchIssues(ctx context.Context, pid interface{}, search string) ([]*gitlab.Issue, error)
+	CountIssues(ctx context.Context, pid interface{}) (int, error)
+
+	EpicsAvailable(ctx context.Context, gid interface{}) (bool, error)
+	GetEpic(ctx context.Context, gid interface{}, epic int) (*gitlab.Epic, *gitlab.Response, error)
+	CreateEpic(ctx context.Context, gid interface{}, opt *gitlabx.CreateEpicOptions) (*gitlab.Epic, *gitlab.Response, error)
+	UpdateEpic(ctx context.Context, gid interface{}, epic int, opt *gitlab.UpdateEpicOptions) (*gitlab.Epic, *gitlab.Response, error)
+	DeleteEpic(ctx context.Context, gid interface{}, epic int) (*gitlab.Response, error)
+	ListEpicNotes(ctx context.Context, gid interface{}, epicID int) ([]*gitlab.Note, error)
+	CreateEpicNote(ctx context.Context, gid interface{}, epic int, opt *gitlabx.CreateEpicNoteOptions) (*gitlab.Note, *gitlab.Response, error)
+	CreateEpicLink(ctx context.Context, gid interface{}, epic int, opt *gitlabx.CreateEpicLinkOptions) (*gitlabx.EpicLink, *gitlab.Response, error)
+	SearchEpics(ctx context.Context, gid interface{}, search string) ([]*gitlab.Epic, error)
+	CountEpics(ctx context.Context, gid interface{}) (int, error)
+	ListEpicIssues(ctx context.Context, gid interface{}, epic int) ([]*gitlab.Issue, error)
+	UpdateEpicIssueAssignment(ctx context.Context, gid interface{}, epic int, epicIssue int, opt *gitlab.UpdateEpicIsssueAssignmentOptions) ([]*gitlab.Issue, *gitlab.Response, error)
+
+	ListIssueBoards(ctx context.Context, pid interface{}) ([]*gitlab.IssueBoard, error)
+	CreateIssueBoard(ctx context.Context, pid interface{}, opt *gitlab.CreateIssueBoardOptions) (*gitlab.IssueBoard, *gitlab.Response, error)
+	CreateIssueBoardList(ctx context.Context, pid interface{}, board int, opt *gitlab.CreateIssueBoardListOptions) (*gitlab.BoardList, *gitlab.Response, error)
+
+	// GraphQL only, the IDs are global IDs
+	ListIterationCadences(ctx context.Context, groupPath string) ([]*gitlabx.IterationCadence, error)
+	CreateIterationCadence(ctx context.Context, groupPath string, opt *gitlabx.CreateIterationCadenceOptions) (*gitlabx.IterationCadence, error)
+	ListIterations(ctx context.Context, groupPath string, cadenceID string) ([]*gitlabx.Iteration, error)
+	CreateIteration(ctx context.Context, groupPath string, cadenceID string, opt *gitlabx.CreateIterationOptions) (*gitlabx.Iteration, error)
+	SetIssueIteration(ctx context.Context, projectPath string, issue int, iterationID string) error
+	SetIssueSeverity(ctx context.Context, projectPath string, issue int, severity string) error
+	SetBoardListLimit(ctx context.Context, listID int, maxIssueCount int) error
+	ListCRMContacts(ctx context.Context, groupPath string) ([]*gitlabx.CRMContact, error)
+	AddIssueCRMContacts(ctx context.Context, projectPath string, issue int, contactIDs []string) error
+}
+
+// OpenJiraReader reads from the Jira backup (jira.backup) if it is configured, otherwise from the Jira API
+func OpenJiraReader(cfg *config.Config) (JiraReader, error) {
+	if cfg.Jira.Backup != "" {
+		//* The backup can't evaluate JQL, every issue of the project would be migrated
+		if cfg.Jira.Jql != "" {
+			return nil, errors.New("jira.jql can't be used with jira.backup, export the issues of the JQL instead")
+		}
+		return backup.Open(cfg.Jira.Backup, cfg.Jira.BackupAttachments, cfg.Jira.Name)
+	}
+	jr := config.GetJiraClient(cfg)
+	return NewJiraReader(jr, config.GetJiraAPI(cfg)), nil
+}
+
+//* Jira API
+
+type jiraClient struct {
+	jr  *jira.Client
+	api jirax.API
+}
+
+// NewJiraReader reads from the Jira API, the issues are searched with the API
+func NewJiraReader(jr *jira.Client, api jirax.API) JiraReader {
+	return &jiraClient{jr: jr, api: api}
+}
+
+func (c *jiraClient) GetProject(ctx context.Context, projectID string) (*jira.Project, *jira.Response, error) {
+	return c.jr.Project.Get(ctx, projectID)
+}
+
+func (c *jiraClient) ListProjects(ctx context.Context) (*jira.ProjectList, error) {
+	projects, _, err := c.jr.Project.GetAll(ctx, nil)
+	return projects, err
+}
+
+func (c *jiraClient) SearchIssues(ctx context.Context, jql string) ([]*jira.Issue, error) {
+	return jirax.UnpaginateIssue(ctx, c.jr, c.api, jql)
+}
+
+func (c *jiraClient) DownloadAttachment(ctx context.Context, attachmentID string) (io.ReadCloser, error) {
+	res, err := c.jr.Issue.DownloadAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (c *jiraClient) GetRemoteLinks(ctx context.Context, issueKey string) (*[]jira.RemoteLink, *jira.Response, error) {
+	return c.jr.Issue.GetRemoteLinks(ctx, issueKey)
+}
+
+func (c *jiraClient) GetDevStatusSummary(ctx context.Context, issueID string) (*jirax.DevStatusSummary, *jira.Response, error) {
+	return jirax.GetDevStatusSummary(ctx, c.jr, issueID)
+}
+
+func (c *jiraClient) GetDevStatus(ctx context.Context, issueID string, applicationType string, dataType string) (*jirax.DevStatus, *jira.Response, error) {
+	return jirax.GetDevStatus(ctx, c.jr, issueID, applicationType, dataType)
+}
+
+func (c *jiraClient) GetProjectRole(ctx context.Context, projectKey string, roleID string) (*jira.Role, *jira.Response, error) {
+	return jirax.GetProjectRole(ctx, c.jr, projectKey, roleID)
+}
+
+func (c *jiraClient) ListSprints(ctx context.Context, projectKey string) ([]jira.Sprint, error) {
+	return jirax.UnpaginateSprints(ctx, c.jr, projectKey)
+}
+
+func (c *jiraClient) GetComments(ctx context.Context, issueKey string) ([]*jira.Comment, error) {
+	return jirax.GetComments(ctx, c.jr, issueKey)
+}
+
+func (c *jiraClient) GetCommentParents(ctx context.Context, issueKey string) (map[string]string, error) {
+	return jirax.GetCommentParents(ctx, c.jr, issueKey)
+}
+
+func (c *jiraClient) GetApprovals(ctx context.Context, issueKey string) ([]*jirax.Approval, *jira.Response, error) {
+	return jirax.GetApprovals(ctx, c.jr, issueKey)
+}
+
+// GetTestRuns returns the test runs of the add-on (jira.test_case.results), Zephyr by the issue ID and Xray by the key
+func (c *jiraClient) GetTestRuns(ctx context.Context, addOn string, jiraIssue *jira.Issue) ([]*jirax.TestRun, *jira.Response, error) {
+	if addOn == config.TestResultsZephyr {
+		return jirax.GetZephyrExecutions(ctx, c.jr, jiraIssue.ID)
+	}
+	return jirax.GetXrayTestRuns(ctx, c.jr, jiraIssue.Key)
+}
+
+func (c *jiraClient) GetUser(ctx context.Context, username string) (*jira.User, *jira.Response, error) {
+	return jirax.GetUser(ctx, c.jr, &jirax.UserQueryOptions{Username: username})
+}
+
+func (c *jiraClient) DownloadProjectAvatar(ctx context.Context, project *jira.Project) (io.ReadCloser, string, error) {
+	return jirax.DownloadProjectAvatar(ctx, c.jr, project)
+}
+
+func (c *jiraClient) ListProjectStatuses(ctx context.Context, projectKey string) ([]*jirax.IssueTypeStatuses, *jira.Response, error) {
+	return jirax.ListProjectStatuses(ctx, c.jr, projectKey)
+}
+
+func (c *jiraClient) GetCreateMeta(ctx context.Context, projectKey string) ([]*jirax.CreateMetaIssueType, *jira.Response, error) {
+	return jirax.GetCreateMeta(ctx, c.jr, projectKey)
+}
+
+func (c *jiraClient) ListBoardConfigurations(ctx context.Context, projectKey string) ([]*jirax.BoardConfiguration, error) {
+	return jirax.ListBoardConfigurations(ctx, c.jr, projectKey)
+}
+
+func (c *jiraClient) ListFilters(ctx context.Context, projectKey string) ([]*jira.Filter, error) {
+	return jirax.ListProjectFilters(ctx, c.jr, projectKey)
+}
+
+func (c *jiraClient) ListDashboards(ctx context.Context) ([]*jirax.Dashboard, error) {
+	return jirax.ListDashboards(ctx, c.jr)
+}
+
+func (c *jiraClient) ListFields(ctx context.Context) ([]jira.Field, *jira.Response, error) {
+	return c.jr.Field.GetList(ctx)
+}
+
+func (c *jiraClient) SampleIssues(ctx context.Context, jql string, maxResults int) ([]*jira.Issue, error) {
+	return jirax.SampleIssues(ctx, c.jr, c.api, jql, maxResults)
+}
+
+// GetIssueKey returns the current key of the issue, Jira finds a moved issue by its old key
+func (c *jiraClient) GetIssueKey(ctx context.Context, issueKey string) (string, *jira.Response, error) {
+	issue, r, err := c.jr.Issue.Get(ctx, issueKey, &jira.GetQueryOptions{Fields: "key"})
+	if err != nil {
+		return "", r, err
+	}
+	return issue.Key, r, nil
+}
+
+//* GitLab API
+
+type gitlabClient struct {
+	gl *gitlab.Client
+}
+
+// NewGitLabWriter writes to the GitLab API
+func NewGitLabWriter(gl *gitlab.Client) GitLabWriter {
+	return &gitlabClient{gl: gl}
+}
+
+func (c *gitlabClient) GetVersion(ctx context.Context) (*gitlab.Version, error) {
+	version, _, err := c.gl.Version.GetVersion(gitlab.WithContext(ctx))
+	return version, err
+}
+
+func (c *gitlabClient) GetProject(ctx context.Context, pid interface{}) (*gitlab.Project, *gitlab.Response, error) {
+	return c.gl.Projects.GetProject(pid, nil, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) EditProject(ctx context.Context, pid interface{}, opt *gitlab.EditProjectOptions) (*gitlab.Project, *gitlab.Response, error) {
+	return c.gl.Projects.EditProject(pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateProject(ctx context.Context, opt *gitlab.CreateProjectOptions) (*gitlab.Project, *gitlab.Response, error) {
+	return c.gl.Projects.CreateProject(opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) UploadFile(ctx context.Context, pid interface{}, content io.Reader, filename string) (*gitlab.ProjectFile, *gitlab.Response, error) {
+	return c.gl.Projects.UploadFile(pid, content, filename, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) GetWikiPage(ctx context.Context, pid interface{}, slug string) (*gitlab.Wiki, *gitlab.Response, error) {
+	return c.gl.Wikis.GetWikiPage(pid, slug, &gitlab.GetWikiPageOptions{}, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateWikiPage(ctx context.Context, pid interface{}, opt *gitlab.CreateWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error) {
+	return c.gl.Wikis.CreateWikiPage(pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) EditWikiPage(ctx context.Context, pid interface{}, slug string, opt *gitlab.EditWikiPageOptions) (*gitlab.Wiki, *gitlab.Response, error) {
+	return c.gl.Wikis.EditWikiPage(pid, slug, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) GetFileMetaData(ctx context.Context, pid interface{}, path string, ref string) (*gitlab.File, *gitlab.Response, error) {
+	return c.gl.RepositoryFiles.GetFileMetaData(pid, path, &gitlab.GetFileMetaDataOptions{Ref: gitlab.String(ref)}, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateCommit(ctx context.Context, pid interface{}, opt *gitlab.CreateCommitOptions) (*gitlab.Commit, *gitlab.Response, error) {
+	return c.gl.Commits.CreateCommit(pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) GetGroup(ctx context.Context, gid interface{}) (*gitlab.Group, *gitlab.Response, error) {
+	return c.gl.Groups.GetGroup(gid, &gitlab.GetGroupOptions{WithProjects: gitlab.Bool(false)}, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateGroup(ctx context.Context, opt *gitlab.CreateGroupOptions) (*gitlab.Group, *gitlab.Response, error) {
+	return c.gl.Groups.CreateGroup(opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) UploadGroupWikiAttachment(ctx context.Context, gid interface{}, content io.Reader, filename string) (*gitlabx.WikiAttachment, *gitlab.Response, error) {
+	return gitlabx.UploadGroupWikiAttachment(c.gl, gid, content, filename, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) GetUser(ctx context.Context, uid int) (*gitlab.User, *gitlab.Response, error) {
+	return c.gl.Users.GetUser(uid, gitlab.GetUsersOptions{}, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListUsers(ctx context.Context, opt *gitlab.ListUsersOptions) ([]*gitlab.User, *gitlab.Response, error) {
+	return c.gl.Users.ListUsers(opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListProjectMembers(ctx context.Context, pid interface{}) ([]*gitlab.ProjectMember, error) {
+	return gitlabx.Unpaginate[gitlab.ProjectMember](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.ProjectMember, *gitlab.Response, error) {
+		return c.gl.ProjectMembers.ListAllProjectMembers(pid, &gitlab.ListProjectMembersOptions{ListOptions: *opt}, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) AddProjectMember(ctx context.Context, pid interface{}, opt *gitlab.AddProjectMemberOptions) (*gitlab.ProjectMember, *gitlab.Response, error) {
+	return c.gl.ProjectMembers.AddProjectMember(pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) AddGroupMember(ctx context.Context, gid interface{}, opt *gitlab.AddGroupMemberOptions) (*gitlab.GroupMember, *gitlab.Response, error) {
+	return c.gl.GroupMembers.AddGroupMember(gid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListMilestones(ctx context.Context, pid interface{}) ([]*gitlab.Milestone, error) {
+	return gitlabx.Unpaginate[gitlab.Milestone](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.Milestone, *gitlab.Response, error) {
+		return c.gl.Milestones.ListMilestones(pid, &gitlab.ListMilestonesOptions{ListOptions: *opt}, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) CreateMilestone(ctx context.Context, pid interface{}, opt *gitlab.CreateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error) {
+	return c.gl.Milestones.CreateMilestone(pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) UpdateMilestone(ctx context.Context, pid interface{}, milestone int, opt *gitlab.UpdateMilestoneOptions) (*gitlab.Milestone, *gitlab.Response, error) {
+	return c.gl.Milestones.UpdateMilestone(pid, milestone, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListGroupMilestones(ctx context.Context, gid interface{}) ([]*gitlab.GroupMilestone, error) {
+	return gitlabx.Unpaginate[gitlab.GroupMilestone](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.GroupMilestone, *gitlab.Response, error) {
+		return c.gl.GroupMilestones.ListGroupMilestones(gid, &gitlab.ListGroupMilestonesOptions{ListOptions: *opt}, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) CreateGroupMilestone(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error) {
+	return c.gl.GroupMilestones.CreateGroupMilestone(gid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) UpdateGroupMilestone(ctx context.Context, gid interface{}, milestone int, opt *gitlab.UpdateGroupMilestoneOptions) (*gitlab.GroupMilestone, *gitlab.Response, error) {
+	return c.gl.GroupMilestones.UpdateGroupMilestone(gid, milestone, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListGroupLabels(ctx context.Context, gid interface{}, opt *gitlab.ListGroupLabelsOptions) ([]*gitlab.GroupLabel, error) {
+	return gitlabx.Unpaginate[gitlab.GroupLabel](c.gl, func(listOpt *gitlab.ListOptions) ([]*gitlab.GroupLabel, *gitlab.Response, error) {
+		pageOpt := *opt
+		pageOpt.ListOptions = *listOpt
+		return c.gl.GroupLabels.ListGroupLabels(gid, &pageOpt, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) ListLabels(ctx context.Context, pid interface{}, opt *gitlab.ListLabelsOptions) ([]*gitlab.Label, error) {
+	return gitlabx.Unpaginate[gitlab.Label](c.gl, func(listOpt *gitlab.ListOptions) ([]*gitlab.Label, *gitlab.Response, error) {
+		pageOpt := *opt
+		pageOpt.ListOptions = *listOpt
+		return c.gl.Labels.ListLabels(pid, &pageOpt, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) CreateGroupLabel(ctx context.Context, gid interface{}, opt *gitlab.CreateGroupLabelOptions) (*gitlab.GroupLabel, *gitlab.Response, error) {
+	return c.gl.GroupLabels.CreateGroupLabel(gid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateLabel(ctx context.Context, pid interface{}, opt *gitlab.CreateLabelOptions) (*gitlab.Label, *gitlab.Response, error) {
+	return c.gl.Labels.CreateLabel(pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) GetIssue(ctx context.Context, pid interface{}, issue int) (*gitlab.Issue, *gitlab.Response, error) {
+	return c.gl.Issues.GetIssue(pid, issue, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateIssue(ctx context.Context, pid interface{}, opt *gitlabx.CreateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	return gitlabx.CreateIssue(c.gl, pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) UpdateIssue(ctx context.Context, pid interface{}, issue int, opt *gitlab.UpdateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	return c.gl.Issues.UpdateIssue(pid, issue, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListIssueNotes(ctx context.Context, pid interface{}, issue int) ([]*gitlab.Note, error) {
+	return gitlabx.Unpaginate[gitlab.Note](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.Note, *gitlab.Response, error) {
+		return c.gl.Notes.ListIssueNotes(pid, issue, &gitlab.ListIssueNotesOptions{ListOptions: *opt}, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) DeleteIssue(ctx context.Context, pid interface{}, issue int) (*gitlab.Response, error) {
+	return c.gl.Issues.DeleteIssue(pid, issue, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateIssueNote(ctx context.Context, pid interface{}, issue int, opt *gitlabx.CreateIssueNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	return gitlabx.CreateIssueNote(c.gl, pid, issue, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateIssueDiscussion(ctx context.Context, pid interface{}, issue int, opt *gitlab.CreateIssueDiscussionOptions) (*gitlab.Discussion, *gitlab.Response, error) {
+	return c.gl.Discussions.CreateIssueDiscussion(pid, issue, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) AddIssueDiscussionNote(ctx context.Context, pid interface{}, issue int, discussion string, opt *gitlab.AddIssueDiscussionNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	return c.gl.Discussions.AddIssueDiscussionNote(pid, issue, discussion, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateIssueLink(ctx context.Context, pid interface{}, issue int, opt *gitlab.CreateIssueLinkOptions) (*gitlab.IssueLink, *gitlab.Response, error) {
+	return c.gl.IssueLinks.CreateIssueLink(pid, issue, opt, gitlab.WithContext(ctx))
+}
+
+// Epics API answers 403 (or 404) when the license tier of the group doesn't include epics.
+// The group is looked up first, a missing group or a rejected token is an error, not GitLab Free.
+func (c *gitlabClient) EpicsAvailable(ctx context.Context, gid interface{}) (bool, error) {
+	if _, _, err := c.GetGroup(ctx, gid); err != nil {
+		return false, err
+	}
+
+	_, r, err := c.gl.Epics.ListGroupEpics(gid, &gitlab.ListGroupEpicsOptions{ListOptions: gitlab.ListOptions{PerPage: 1}}, gitlab.WithContext(ctx))
+	if r != nil && (r.StatusCode == http.StatusForbidden || r.StatusCode == http.StatusNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *gitlabClient) GetEpic(ctx context.Context, gid interface{}, epic int) (*gitlab.Epic, *gitlab.Response, error) {
+	return c.gl.Epics.GetEpic(gid, epic, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateEpic(ctx context.Context, gid interface{}, opt *gitlabx.CreateEpicOptions) (*gitlab.Epic, *gitlab.Response, error) {
+	return gitlabx.CreateEpic(c.gl, gid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) UpdateEpic(ctx context.Context, gid interface{}, epic int, opt *gitlab.UpdateEpicOptions) (*gitlab.Epic, *gitlab.Response, error) {
+	return c.gl.Epics.UpdateEpic(gid, epic, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) DeleteEpic(ctx context.Context, gid interface{}, epic int) (*gitlab.Response, error) {
+	return c.gl.Epics.DeleteEpic(gid, epic, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListEpicNotes(ctx context.Context, gid interface{}, epicID int) ([]*gitlab.Note, error) {
+	return gitlabx.Unpaginate[gitlab.Note](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.Note, *gitlab.Response, error) {
+		return c.gl.Notes.ListEpicNotes(gid, epicID, &gitlab.ListEpicNotesOptions{ListOptions: *opt}, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) CreateEpicNote(ctx context.Context, gid interface{}, epic int, opt *gitlabx.CreateEpicNoteOptions) (*gitlab.Note, *gitlab.Response, error) {
+	return gitlabx.CreateEpicNote(c.gl, gid, epic, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateEpicLink(ctx context.Context, gid interface{}, epic int, opt *gitlabx.CreateEpicLinkOptions) (*gitlabx.EpicLink, *gitlab.Response, error) {
+	return gitlabx.CreateEpicLink(c.gl, gid, epic, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ReorderIssue(ctx context.Context, pid interface{}, issue int, opt *gitlabx.ReorderIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	return gitlabx.ReorderIssue(c.gl, pid, issue, opt, gitlab.WithContext(ctx))
+}
+
+// SearchIssues searches the title and the description of the issues
+func (c *gitlabClient) SearchIssues(ctx context.Context, pid interface{}, search string) ([]*gitlab.Issue, error) {
+	return gitlabx.Unpaginate[gitlab.Issue](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.Issue, *gitlab.Response, error) {
+		return c.gl.Issues.ListProjectIssues(pid, &gitlab.ListProjectIssuesOptions{ListOptions: *opt, Search: gitlab.String(search)}, gitlab.WithContext(ctx))
+	})
+}
+
+// CountIssues returns the number of issues of the project (open and closed) from one page of one item
+func (c *gitlabClient) CountIssues(ctx context.Context, pid interface{}) (int, error) {
+	issues, res, err := c.gl.Issues.ListProjectIssues(pid, &gitlab.ListProjectIssuesOptions{ListOptions: gitlab.ListOptions{PerPage: 1}}, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return countItems(len(issues), res), nil
+}
+
+// SearchEpics searches the title and the description of the epics
+func (c *gitlabClient) SearchEpics(ctx context.Context, gid interface{}, search string) ([]*gitlab.Epic, error) {
+	return gitlabx.Unpaginate[gitlab.Epic](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.Epic, *gitlab.Response, error) {
+		return c.gl.Epics.ListGroupEpics(gid, &gitlab.ListGroupEpicsOptions{ListOptions: *opt, Search: gitlab.String(search)}, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) CountEpics(ctx context.Context, gid interface{}) (int, error) {
+	epics, res, err := c.gl.Epics.ListGroupEpics(gid, &gitlab.ListGroupEpicsOptions{ListOptions: gitlab.ListOptions{PerPage: 1}}, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return countItems(len(epics), res), nil
+}
+
+// countItems is the X-Total of the response, GitLab omits it over 10,000 items
+func countItems(page int, res *gitlab.Response) int {
+	if res != nil && res.TotalItems > page {
+		return res.TotalItems
+	}
+	return page
+}
+
+func (c *gitlabClient) ListEpicIssues(ctx context.Context, gid interface{}, epic int) ([]*gitlab.Issue, error) {
+	return gitlabx.Unpaginate[gitlab.Issue](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.Issue, *gitlab.Response, error) {
+		return c.gl.EpicIssues.ListEpicIssues(gid, epic, opt, gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) UpdateEpicIssueAssignment(ctx context.Context, gid interface{}, epic int, epicIssue int, opt *gitlab.UpdateEpicIsssueAssignmentOptions) ([]*gitlab.Issue, *gitlab.Response, error) {
+	return c.gl.EpicIssues.UpdateEpicIssueAssignment(gid, epic, epicIssue, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListIterationCadences(ctx context.Context, groupPath string) ([]*gitlabx.IterationCadence, error) {
+	return gitlabx.ListIterationCadences(c.gl, groupPath, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateIterationCadence(ctx context.Context, groupPath string, opt *gitlabx.CreateIterationCadenceOptions) (*gitlabx.IterationCadence, error) {
+	return gitlabx.CreateIterationCadence(c.gl, groupPath, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListIterations(ctx context.Context, groupPath string, cadenceID string) ([]*gitlabx.Iteration, error) {
+	return gitlabx.ListIterations(c.gl, groupPath, cadenceID, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateIteration(ctx context.Context, groupPath string, cadenceID string, opt *gitlabx.CreateIterationOptions) (*gitlabx.Iteration, error) {
+	return gitlabx.CreateIteration(c.gl, groupPath, cadenceID, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) SetIssueIteration(ctx context.Context, projectPath string, issue int, iterationID string) error {
+	return gitlabx.SetIssueIteration(c.gl, projectPath, issue, iterationID, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) SetIssueSeverity(ctx context.Context, projectPath string, issue int, severity string) error {
+	return gitlabx.SetIssueSeverity(c.gl, projectPath, issue, severity, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) SetBoardListLimit(ctx context.Context, listID int, maxIssueCount int) error {
+	return gitlabx.SetBoardListLimit(c.gl, listID, maxIssueCount, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListCRMContacts(ctx context.Context, groupPath string) ([]*gitlabx.CRMContact, error) {
+	return gitlabx.ListCRMContacts(c.gl, groupPath, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) AddIssueCRMContacts(ctx context.Context, projectPath string, issue int, contactIDs []string) error {
+	return gitlabx.AddIssueCRMContacts(c.gl, projectPath, issue, contactIDs, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) ListIssueBoards(ctx context.Context, pid interface{}) ([]*gitlab.IssueBoard, error) {
+	return gitlabx.Unpaginate[gitlab.IssueBoard](c.gl, func(opt *gitlab.ListOptions) ([]*gitlab.IssueBoard, *gitlab.Response, error) {
+		return c.gl.Boards.ListIssueBoards(pid, (*gitlab.ListIssueBoardsOptions)(opt), gitlab.WithContext(ctx))
+	})
+}
+
+func (c *gitlabClient) CreateIssueBoard(ctx context.Context, pid interface{}, opt *gitlab.CreateIssueBoardOptions) (*gitlab.IssueBoard, *gitlab.Response, error) {
+	return c.gl.Boards.CreateIssueBoard(pid, opt, gitlab.WithContext(ctx))
+}
+
+func (c *gitlabClient) CreateIssueBoardList(ctx context.Context, pid interface{}, board int, opt *gitlab.CreateIssueBoardListOptions) (*gitlab.BoardList, *gitlab.Response, error) {
+	return c.gl.Boards.CreateIssueBoardList(pid, board, opt, gitlab.WithContext(ctx))
+}
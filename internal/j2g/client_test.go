@@ -0,0 +1,63 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+var (
+	_ JiraReader   = (*fake.Jira)(nil)
+	_ GitLabWriter = (*fake.GitLab)(nil)
+)
+
+func TestEpicsAvailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v4/groups/premium", "/api/v4/groups/free":
+			w.Write([]byte(`{"id": 1}`))
+		case "/api/v4/groups/premium/epics":
+			w.Write([]byte(`[]`))
+		case "/api/v4/groups/free/epics":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "403 Forbidden"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "404 Group Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL), gitlab.WithoutRetries())
+	assert.NoError(t, err)
+	gl := NewGitLabWriter(client)
+	ctx := context.Background()
+
+	available, err := gl.EpicsAvailable(ctx, "premium")
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	available, err = gl.EpicsAvailable(ctx, "free")
+	assert.NoError(t, err)
+	assert.False(t, available)
+
+	//* A missing group is not GitLab Free
+	_, err = gl.EpicsAvailable(ctx, "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// CRMContacts are the IDs of the GitLab CRM contacts by email and by organization name, lower case
+type CRMContacts struct {
+	byEmail        map[string]string
+	byOrganization map[string][]string
+}
+
+// listCRMContacts reads the CRM contacts of the epic group
+func listCRMContacts(ctx context.Context, gl GitLabWriter, cfg *config.Config) (*CRMContacts, error) {
+	contacts, err := gl.ListCRMContacts(ctx, cfg.GitLab.Epic)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting CRM contacts: %s", cfg.GitLab.Epic))
+	}
+
+	result := &CRMContacts{byEmail: make(map[string]string), byOrganization: make(map[string][]string)}
+	for _, contact := range contacts {
+		if contact.Email != "" {
+			result.byEmail[strings.ToLower(contact.Email)] = contact.ID
+		}
+		if contact.Organization != nil {
+			name := strings.ToLower(contact.Organization.Name)
+			result.byOrganization[name] = append(result.byOrganization[name], contact.ID)
+		}
+	}
+	log.Infof("Found %d CRM contacts of %s", len(contacts), cfg.GitLab.Epic)
+
+	return result, nil
+}
+
+// find returns the contacts of the customer, the mapping of crm.contacts and crm.organizations first
+func (c *CRMContacts) find(cfg *config.Config, customer string) []string {
+	if email, ok := cfg.CRM.Contacts[customer]; ok {
+		if id, ok := c.byEmail[strings.ToLower(email)]; ok {
+			return []string{id}
+		}
+		return nil
+	}
+	if organization, ok := cfg.CRM.Organizations[customer]; ok {
+		return c.byOrganization[strings.ToLower(organization)]
+	}
+
+	if id, ok := c.byEmail[strings.ToLower(customer)]; ok {
+		return []string{id}
+	}
+	return c.byOrganization[strings.ToLower(customer)]
+}
+
+// setIssueCRMContacts adds the contacts of the customers of the Jira issue (crm.field)
+func setIssueCRMContacts(ctx context.Context, gl GitLabWriter, cfg *config.Config, jiraIssue *jira.Issue, gitlabIssue *gitlab.Issue, contacts *CRMContacts) error {
+	//* A single or multi select list, or a text
+	value := jiraIssue.Fields.Unknowns[cfg.CRM.Field]
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	var contactIDs []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		customer := customFieldText(v)
+		if customer == "" {
+			continue
+		}
+
+		ids := contacts.find(cfg, customer)
+		if len(ids) == 0 {
+			log.Warnf("CRM contact of customer %s is not found: issue %s", customer, jiraIssue.Key)
+			continue
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				contactIDs = append(contactIDs, id)
+			}
+		}
+	}
+	if len(contactIDs) == 0 {
+		return nil
+	}
+
+	if err := gl.AddIssueCRMContacts(ctx, cfg.GitLab.Issue, gitlabIssue.IID, contactIDs); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error adding CRM contacts: issue %s", jiraIssue.Key))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+func TestCRMContacts(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.CRM.Field = "customfield_10070"
+	cfg.CRM.Contacts = map[string]string{"Jane Doe": "jane@example.com"}
+
+	gl.CRMContacts["group"] = []*gitlabx.CRMContact{
+		{ID: "gid://gitlab/CustomerRelations::Contact/1", Email: "jane@example.com"},
+		{ID: "gid://gitlab/CustomerRelations::Contact/2", Email: "john@acme.com", Organization: &gitlabx.CRMOrganization{Name: "ACME"}},
+		{ID: "gid://gitlab/CustomerRelations::Contact/3", Email: "kim@acme.com", Organization: &gitlabx.CRMOrganization{Name: "ACME"}},
+	}
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = nil
+	issue.Fields.Unknowns = map[string]interface{}{"customfield_10070": []interface{}{
+		map[string]interface{}{"value": "Jane Doe"},
+		map[string]interface{}{"value": "acme"},
+		map[string]interface{}{"value": "Unknown"},
+	}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue)
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	if assert.Len(t, gl.Issues[2], 1) {
+		assert.Equal(t, []string{
+			"gid://gitlab/CustomerRelations::Contact/1",
+			"gid://gitlab/CustomerRelations::Contact/2",
+			"gid://gitlab/CustomerRelations::Contact/3",
+		}, gl.IssueCRMContacts[gl.Issues[2][0].ID])
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// gadgetEquivalent is the GitLab page suggested for a type of Jira gadget
+type gadgetEquivalent struct {
+	// Part of the module key or URI of the gadget, e.g. pie-chart of com.atlassian.jira.gadgets:pie-chart-gadget
+	Types []string
+	Name  string
+	// Path under the project URL, the group URL or the GitLab host (Scope). The issue search of the filter if it is empty.
+	Path  string
+	Scope string
+}
+
+const (
+	gadgetScopeGroup = "group"
+	gadgetScopeSite  = "site"
+)
+
+// gadgetEquivalents are matched in order
+var gadgetEquivalents = []gadgetEquivalent{
+	{Types: []string{"filter-results", "filterresults"}, Name: "Issue search of the filter"},
+	{Types: []string{"assigned-to-me", "assignedtome"}, Name: "Issues assigned to me", Path: "/dashboard/issues", Scope: gadgetScopeSite},
+	{Types: []string{"created-vs-resolved", "createdvsresolved", "recently-created", "recentlycreated"}, Name: "Issue analytics", Path: "/-/analytics/issues_analytics"},
+	{Types: []string{"pie-chart", "piechart", "two-dimensional", "twodimensional", "heat-map", "heatmap", "stats-gadget", "statsgadget"}, Name: "Insights chart of the labels (.gitlab/insights.yml)", Path: "/insights/"},
+	{Types: []string{"average-age", "averageage", "resolution-time", "resolutiontime", "time-since", "timesince"}, Name: "Value stream analytics", Path: "/-/value_stream_analytics"},
+	{Types: []string{"burndown", "sprint-health", "sprinthealth", "sprint-days", "days-left"}, Name: "Burndown chart of the milestone or iteration", Path: "/-/milestones"},
+	{Types: []string{"road-map", "roadmap"}, Name: "Epic roadmap", Path: "/-/roadmap", Scope: gadgetScopeGroup},
+	{Types: []string{"activity-stream", "activitystream"}, Name: "Project activity", Path: "/activity"},
+}
+
+// gadgetType is the module key of the gadget, the URI of a legacy gadget
+func gadgetType(gadget *jirax.DashboardGadget) string {
+	if gadget.ModuleKey != "" {
+		return gadget.ModuleKey
+	}
+	return gadget.URI
+}
+
+// suggestGadget returns the name and URL of the GitLab equivalent of the gadget, empty if there is none
+func suggestGadget(cfg *config.Config, gadget *jirax.DashboardGadget, projectKey string, projectURL string, userMap UserMap) (string, string) {
+	lower := strings.ToLower(gadgetType(gadget))
+	for _, equivalent := range gadgetEquivalents {
+		for _, t := range equivalent.Types {
+			if !strings.Contains(lower, t) {
+				continue
+			}
+			host := strings.TrimSuffix(cfg.GitLab.Host, "/")
+			switch {
+			case equivalent.Scope == gadgetScopeGroup:
+				return equivalent.Name, fmt.Sprintf("%s/groups/%s%s", host, cfg.GitLab.Epic, equivalent.Path)
+			case equivalent.Scope == gadgetScopeSite:
+				return equivalent.Name, host + equivalent.Path
+			case equivalent.Path != "":
+				return equivalent.Name, projectURL + equivalent.Path
+			case gadget.Filter != nil:
+				return equivalent.Name, translateFilter(cfg, gadget.Filter, projectKey, projectURL, userMap).URL
+			}
+			return "", ""
+		}
+	}
+	return "", ""
+}
+
+// dashboardOfProject reports if a gadget of the dashboard shows the Jira project or a filter of it
+func dashboardOfProject(dashboard *jirax.Dashboard, jiraProject *jira.Project) bool {
+	for _, gadget := range dashboard.Gadgets {
+		if gadget.ProjectID != "" && gadget.ProjectID == jiraProject.ID {
+			return true
+		}
+		if gadget.Filter != nil && mentionsProject(gadget.Filter.Jql, jiraProject.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDashboardsReport writes dashboards.md of the report directory, the gadgets of the dashboards of the Jira project with a GitLab equivalent of each
+func writeDashboardsReport(ctx context.Context, jr JiraReader, cfg *config.Config, jiraProject *jira.Project, projectURL string, userMap UserMap) error {
+	dashboards, err := jr.ListDashboards(ctx)
+	if err != nil {
+		log.Warnf("Skipping the dashboards report: %s", err)
+		return nil
+	}
+
+	var projectDashboards []*jirax.Dashboard
+	for _, dashboard := range dashboards {
+		if dashboardOfProject(dashboard, jiraProject) {
+			projectDashboards = append(projectDashboards, dashboard)
+		}
+	}
+
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating report directory: %s", cfg.ReportDir))
+	}
+	path := filepath.Join(cfg.ReportDir, "dashboards.md")
+	if err := os.WriteFile(path, []byte(formatDashboardsReport(cfg, jiraProject, projectDashboards, projectURL, userMap)), 0o644); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing dashboards report: %s", path))
+	}
+	log.Infof("Wrote %d Jira dashboards to %s", len(projectDashboards), path)
+	return nil
+}
+
+func formatDashboardsReport(cfg *config.Config, jiraProject *jira.Project, dashboards []*jirax.Dashboard, projectURL string, userMap UserMap) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Jira dashboards of %s\n\n", jiraProject.Key)
+	b.WriteString("GitLab has no dashboards, the GitLab page of each gadget shows the same data.\n\n")
+	if len(dashboards) == 0 {
+		b.WriteString("No dashboard shows the project.\n")
+		return b.String()
+	}
+
+	for _, dashboard := range dashboards {
+		if dashboard.View != "" {
+			fmt.Fprintf(&b, "## [%s](%s)\n\n", dashboard.Name, dashboard.View)
+		} else {
+			fmt.Fprintf(&b, "## %s\n\n", dashboard.Name)
+		}
+
+		b.WriteString("| Gadget | Type | Filter | GitLab |\n|---|---|---|---|\n")
+		for _, gadget := range dashboard.Gadgets {
+			filter := "-"
+			if gadget.Filter != nil {
+				filter = fmt.Sprintf("%s (`%s`)", gadget.Filter.Name, gadget.Filter.Jql)
+			}
+			equivalent := "No GitLab equivalent"
+			if name, url := suggestGadget(cfg, gadget, jiraProject.Key, projectURL, userMap); name != "" {
+				equivalent = fmt.Sprintf("[%s](%s)", name, url)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", tableCell(orDash(gadget.Title)), tableCell(gadgetType(gadget)), tableCell(filter), equivalent)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func TestDashboardsReport(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ReportDir = t.TempDir()
+
+	jr := fake.NewJira(&jira.Project{ID: "10000", Key: "TEST"})
+	bugs := &jira.Filter{Name: "Bugs", Jql: "project = TEST AND type = Bug"}
+	jr.Dashboards = []*jirax.Dashboard{
+		{Name: "Team", View: "https://jira.example.com/secure/Dashboard.jspa?selectPageId=1", Gadgets: []*jirax.DashboardGadget{
+			{Title: "Bugs", ModuleKey: "com.atlassian.jira.gadgets:filter-results-gadget", Filter: bugs},
+			{Title: "By status", ModuleKey: "com.atlassian.jira.gadgets:pie-chart-gadget", ProjectID: "10000"},
+			{Title: "Roadmap", URI: "rest/gadgets/1.0/g/com.atlassian.jpo:road-map-gadget/gadget.xml"},
+			{Title: "Weather", ModuleKey: "com.example:weather"},
+		}},
+		{Name: "Other", Gadgets: []*jirax.DashboardGadget{{Title: "Pie", ModuleKey: "com.atlassian.jira.gadgets:pie-chart-gadget", ProjectID: "10001"}}},
+	}
+
+	url := "https://gitlab.example.com/group/project"
+	assert.NoError(t, writeDashboardsReport(context.Background(), jr, cfg, jr.Project, url, UserMap{}))
+	content, err := os.ReadFile(filepath.Join(cfg.ReportDir, "dashboards.md"))
+	assert.NoError(t, err)
+	report := string(content)
+
+	assert.Contains(t, report, "## [Team](https://jira.example.com/secure/Dashboard.jspa?selectPageId=1)")
+	assert.Contains(t, report, "| Bugs (`project = TEST AND type = Bug`) | [Issue search of the filter]("+url+"/-/issues?label_name%5B%5D=type%3A%3ABug&state=all) |")
+	assert.Contains(t, report, "| By status | com.atlassian.jira.gadgets:pie-chart-gadget | - | [Insights chart of the labels (.gitlab/insights.yml)]("+url+"/insights/) |")
+	assert.Contains(t, report, "[Epic roadmap](https://gitlab.example.com/groups/group/-/roadmap)")
+	assert.Contains(t, report, "| Weather | com.example:weather | - | No GitLab equivalent |")
+	assert.NotContains(t, report, "## Other")
+}
@@ -0,0 +1,55 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+)
+
+// isValidDate rejects the zero value (0001-01-01) of an empty Jira date and the years GitLab doesn't accept
+func isValidDate(t time.Time) bool {
+	return !t.IsZero() && t.Year() >= 1900 && t.Year() <= 9999
+}
+
+// isoDate returns nil for an invalid date, so the date is not sent to GitLab
+func isoDate(t time.Time) *gitlab.ISOTime {
+	if !isValidDate(t) {
+		return nil
+	}
+	date := gitlab.ISOTime(t)
+	return &date
+}
+
+// checkDateRange corrects a start date after the due date (gitlab.reversed_dates), GitLab rejects the item otherwise.
+// The correction is a warning of the run report. item names the epic, milestone or iteration, e.g. epic TEST-1.
+func checkDateRange(cfg *config.Config, item string, startDate **gitlab.ISOTime, dueDate **gitlab.ISOTime) {
+	if *startDate == nil || *dueDate == nil || !time.Time(**startDate).After(time.Time(**dueDate)) {
+		return
+	}
+
+	start, due := (*startDate).String(), (*dueDate).String()
+	if cfg.GitLab.ReversedDates == config.ReversedDatesDrop {
+		*startDate, *dueDate = nil, nil
+		log.Warnf("Start date %s of %s is after its due date %s, the dates are dropped", start, item, due)
+		stats.Default().AddWarning("%s: start date %s after due date %s, dropped", item, start, due)
+		return
+	}
+
+	*startDate, *dueDate = *dueDate, *startDate
+	log.Warnf("Start date %s of %s is after its due date %s, the dates are swapped", start, item, due)
+	stats.Default().AddWarning("%s: start date %s after due date %s, swapped", item, start, due)
+}
@@ -0,0 +1,55 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+)
+
+func TestReversedDates(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.EpicStartDate = "customfield_10015"
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Duedate: jira.Date(time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC)), Comments: &jira.Comments{}}}
+	epic.Fields.Unknowns = map[string]interface{}{"customfield_10015": "2023-10-15"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic)
+
+	//* Swapped by default instead of failing the epic
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	if assert.Len(t, gl.Epics[1], 1) {
+		assert.Equal(t, "2023-09-30", gl.Epics[1][0].StartDate.String())
+		assert.Equal(t, "2023-10-15", gl.Epics[1][0].DueDate.String())
+	}
+	assert.Contains(t, stats.Default().Warnings(), "epic TEST-1: start date 2023-10-15 after due date 2023-09-30, swapped")
+
+	cfg.GitLab.ReversedDates = config.ReversedDatesDrop
+	startDate, dueDate := isoDate(time.Date(2023, 10, 15, 0, 0, 0, 0, time.UTC)), isoDate(time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC))
+	checkDateRange(cfg, "milestone v1", &startDate, &dueDate)
+	assert.Nil(t, startDate)
+	assert.Nil(t, dueDate)
+}
+
+func TestIsoDate(t *testing.T) {
+	//* The zero value of an empty Jira date and the years GitLab rejects
+	assert.Nil(t, isoDate(time.Time{}))
+	assert.Nil(t, isoDate(time.Time(jira.Date{})))
+	assert.Nil(t, isoDate(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Nil(t, isoDate(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2023-09-30", isoDate(time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC)).String())
+}
@@ -0,0 +1,104 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// Jira Development Panel (Commit, Branch, Pull Request) -> "Related development" section of the description
+func formatDevStatus(ctx context.Context, jr JiraReader, jiraIssue *jira.Issue) (string, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "Error getting config")
+	}
+
+	if !cfg.Jira.DevStatus.Enabled {
+		return "", nil
+	}
+
+	commits := []string{}
+	branches := []string{}
+	pullRequests := []string{}
+
+	summary, _, err := jr.GetDevStatusSummary(ctx, jiraIssue.ID)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error getting dev status summary: issue %s", jiraIssue.Key))
+	}
+
+	for _, applicationType := range cfg.Jira.DevStatus.ApplicationTypes {
+		//* Commits
+		if summary.Count(applicationType, "repository") > 0 {
+			devStatus, _, err := jr.GetDevStatus(ctx, jiraIssue.ID, applicationType, "repository")
+			if err != nil {
+				return "", errors.Wrap(err, fmt.Sprintf("Error getting commits of %s: issue %s", applicationType, jiraIssue.Key))
+			}
+
+			for _, detail := range devStatus.Detail {
+				for _, repository := range detail.Repositories {
+					for _, commit := range repository.Commits {
+						commits = append(commits, fmt.Sprintf("* [`%s`](%s) %s (%s)", commit.DisplayID, commit.URL, firstLine(commit.Message), repository.Name))
+					}
+				}
+			}
+		}
+
+		//* Branches and Pull Requests
+		if summary.Count(applicationType, "branch") > 0 || summary.Count(applicationType, "pullrequest") > 0 {
+			devStatus, _, err := jr.GetDevStatus(ctx, jiraIssue.ID, applicationType, "pullrequest")
+			if err != nil {
+				return "", errors.Wrap(err, fmt.Sprintf("Error getting pull requests of %s: issue %s", applicationType, jiraIssue.Key))
+			}
+
+			for _, detail := range devStatus.Detail {
+				for _, branch := range detail.Branches {
+					repositoryName := ""
+					if branch.Repository != nil {
+						repositoryName = fmt.Sprintf(" (%s)", branch.Repository.Name)
+					}
+					branches = append(branches, fmt.Sprintf("* [%s](%s)%s", branch.Name, branch.URL, repositoryName))
+				}
+
+				for _, pullRequest := range detail.PullRequests {
+					pullRequests = append(pullRequests, fmt.Sprintf("* [%s %s](%s) %s", pullRequest.ID, pullRequest.Name, pullRequest.URL, pullRequest.Status))
+				}
+			}
+		}
+	}
+
+	if len(commits) == 0 && len(branches) == 0 && len(pullRequests) == 0 {
+		return "", nil
+	}
+
+	result := "\n\n### Related development"
+	if len(commits) > 0 {
+		result += fmt.Sprintf("\n\n#### Commits\n\n%s", strings.Join(commits, "\n"))
+	}
+	if len(branches) > 0 {
+		result += fmt.Sprintf("\n\n#### Branches\n\n%s", strings.Join(branches, "\n"))
+	}
+	if len(pullRequests) > 0 {
+		result += fmt.Sprintf("\n\n#### Merge Requests\n\n%s", strings.Join(pullRequests, "\n"))
+	}
+
+	return result, nil
+}
+
+func firstLine(text string) string {
+	return strings.SplitN(strings.TrimSpace(text), "\n", 2)[0]
+}
@@ -0,0 +1,82 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// countingJira records the dev status and comment requests
+type countingJira struct {
+	*fake.Jira
+	devStatus []string
+	comments  int
+}
+
+func (j *countingJira) GetDevStatus(ctx context.Context, issueID string, applicationType string, dataType string) (*jirax.DevStatus, *jira.Response, error) {
+	j.devStatus = append(j.devStatus, applicationType+"/"+dataType)
+	return j.Jira.GetDevStatus(ctx, issueID, applicationType, dataType)
+}
+
+func (j *countingJira) GetComments(ctx context.Context, issueKey string) ([]*jira.Comment, error) {
+	j.comments++
+	return j.Jira.GetComments(ctx, issueKey)
+}
+
+func TestFormatDevStatus(t *testing.T) {
+	cfg, _ := newTestEnv(t)
+	cfg.Jira.DevStatus.Enabled = true
+	cfg.Jira.DevStatus.ApplicationTypes = []string{"stash", "github"}
+
+	jiraIssue := newTestJiraIssue()
+	jr := &countingJira{Jira: fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)}
+	jr.DevStatus[jiraIssue.ID+"/stash/repository"] = &jirax.DevStatus{Detail: []*jirax.DevStatusDetail{{
+		Repositories: []*jirax.DevStatusRepository{{Name: "backend", Commits: []*jirax.DevStatusCommit{
+			{DisplayID: "a1b2c3d", URL: "https://stash.example.com/commits/a1b2c3d", Message: "Fix the login\n\nDetails"},
+		}}},
+	}}}
+
+	//* Only the data types of the summary are requested
+	section, err := formatDevStatus(context.Background(), jr, jiraIssue)
+	assert.NoError(t, err)
+	assert.Equal(t, "\n\n### Related development\n\n#### Commits\n\n* [`a1b2c3d`](https://stash.example.com/commits/a1b2c3d) Fix the login (backend)", section)
+	assert.Equal(t, []string{"stash/repository"}, jr.devStatus)
+
+	//* Without anything in the Development panel, there is no detail request and no section
+	jr.devStatus = nil
+	delete(jr.DevStatus, jiraIssue.ID+"/stash/repository")
+	section, err = formatDevStatus(context.Background(), jr, jiraIssue)
+	assert.NoError(t, err)
+	assert.Empty(t, section)
+	assert.Empty(t, jr.devStatus)
+}
+
+func TestLoadCommentsWithoutComments(t *testing.T) {
+	cfg, _ := newTestEnv(t)
+	cfg.Jira.ServiceDesk = true
+
+	//* The properties of the comments are loaded only if the issue has comments
+	jiraIssue := newTestJiraIssue()
+	jr := &countingJira{Jira: fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)}
+	assert.NoError(t, loadComments(context.Background(), jr, cfg, jiraIssue))
+	assert.Equal(t, 1, jr.comments)
+
+	jiraIssue.Fields.Comments = nil
+	assert.NoError(t, loadComments(context.Background(), jr, cfg, jiraIssue))
+	assert.Equal(t, 1, jr.comments)
+	assert.Empty(t, jiraIssue.Fields.Comments.Comments)
+}
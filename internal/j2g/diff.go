@@ -0,0 +1,218 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ChangeCreate = "create" // Jira issue without a GitLab item
+	ChangeUpdate = "update" // Fields or comments of the GitLab item differ from Jira
+)
+
+// Fields compared and synced with Jira
+const (
+	SyncFieldTitle   = "title"
+	SyncFieldState   = "state"
+	SyncFieldDueDate = "due_date"
+	SyncFieldLabels  = "labels"
+)
+
+// FieldChange is a field of the GitLab item which differs from Jira
+type FieldChange struct {
+	Field string
+	From  string // GitLab
+	To    string // Jira
+}
+
+// Change is what a sync would do to the GitLab item of a Jira issue
+type Change struct {
+	JiraKey  string
+	Action   string
+	WebURL   string
+	IsEpic   bool
+	Fields   []*FieldChange
+	Comments []*jira.Comment // Jira comments without a note
+}
+
+// Diff compares the Jira issues with the migrated GitLab issues and epics and returns what a sync would change.
+// Nothing is written to GitLab.
+func Diff(ctx context.Context, gl GitLabWriter, jr JiraReader) ([]*Change, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+	if len(cfg.Routes) > 0 {
+		return nil, errors.New("A diff can't be made with routes, each route has its own GitLab targets and state file")
+	}
+
+	issues, epicMode, migrationState, err := readComparedIssues(ctx, gl, jr, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*Change
+	for _, jiraIssue := range issues {
+		item, err := getMigratedItem(ctx, gl, cfg, migrationState, jiraIssue.Key, jiraIssue.isEpic)
+		if err != nil {
+			return nil, err
+		}
+
+		if item == nil {
+			changes = append(changes, &Change{JiraKey: jiraIssue.Key, Action: ChangeCreate, IsEpic: jiraIssue.isEpic})
+			continue
+		}
+
+		if change := diffMigratedItem(cfg, jiraIssue.Issue, item, jiraIssue.isEpic, epicMode == config.EpicModeIssue); change != nil {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// diffMigratedItem returns the change of the GitLab item, nil if it is up to date
+func diffMigratedItem(cfg *config.Config, jiraIssue *jira.Issue, item *migratedItem, isEpic bool, epicAsIssue bool) *Change {
+	change := &Change{JiraKey: jiraIssue.Key, Action: ChangeUpdate, WebURL: item.webURL, IsEpic: isEpic}
+	field := func(name string, from string, to string) {
+		if from != to {
+			change.Fields = append(change.Fields, &FieldChange{name, from, to})
+		}
+	}
+
+	fields := syncedFields(cfg, jiraIssue, isEpic, epicAsIssue)
+	field(SyncFieldTitle, item.title, fields[SyncFieldTitle])
+	field(SyncFieldState, item.state, fields[SyncFieldState])
+	if !isEpic {
+		field(SyncFieldDueDate, item.dueDate, fields[SyncFieldDueDate])
+	}
+
+	//* Labels, other labels added in GitLab are kept
+	if added, removed := diffLabels(splitLabels(fields[SyncFieldLabels]), item.labels); len(added) > 0 || len(removed) > 0 {
+		var labels []string
+		for _, label := range added {
+			labels = append(labels, "+"+label)
+		}
+		for _, label := range removed {
+			labels = append(labels, "-"+label)
+		}
+		change.Fields = append(change.Fields, &FieldChange{SyncFieldLabels, strings.Join(item.labels, ", "), strings.Join(labels, ", ")})
+	}
+
+	//* Comments, every migrated note links to its Jira comment
+	if jiraIssue.Fields.Comments != nil {
+		for _, jiraComment := range jiraIssue.Fields.Comments.Comments {
+			if body, _ := convertCommentVisibility(cfg, jiraIssue.Key, jiraComment, &jiraComment.Body); body == nil {
+				continue
+			}
+			marker := fmt.Sprintf("focusedCommentId=%s)", jiraComment.ID)
+			migrated := strings.Contains(item.description, marker)
+			for _, note := range item.notes {
+				migrated = migrated || strings.Contains(note.Body, marker)
+			}
+			if !migrated {
+				change.Comments = append(change.Comments, jiraComment)
+			}
+		}
+	}
+
+	if len(change.Fields) == 0 && len(change.Comments) == 0 {
+		return nil
+	}
+	return change
+}
+
+// syncedFields are the values of the Jira fields written to the GitLab item, the labels are sorted and comma separated.
+// They are the base of the next sync in the state file.
+func syncedFields(cfg *config.Config, jiraIssue *jira.Issue, isEpic bool, epicAsIssue bool) map[string]string {
+	fields := make(map[string]string)
+
+	fields[SyncFieldTitle] = jiraIssue.Fields.Summary
+	if isJiraEpic(cfg, jiraIssue) {
+		fields[SyncFieldTitle] = epicTitle(cfg, jiraIssue)
+	}
+
+	//* Resolved Jira issues are closed
+	fields[SyncFieldState] = "opened"
+	if jiraIssue.Fields.Resolution != nil {
+		fields[SyncFieldState] = "closed"
+	}
+
+	//* The due date of an epic may be inherited from its milestones
+	if !isEpic {
+		fields[SyncFieldDueDate] = ""
+		if isValidDate(time.Time(jiraIssue.Fields.Duedate)) {
+			fields[SyncFieldDueDate] = time.Time(jiraIssue.Fields.Duedate).Format("2006-01-02")
+		}
+	}
+
+	labels := make(map[string]bool)
+	for _, label := range expectedLabels(cfg, jiraIssue, epicAsIssue) {
+		labels[label] = true
+	}
+	sorted := make([]string, 0, len(labels))
+	for label := range labels {
+		sorted = append(sorted, label)
+	}
+	sort.Strings(sorted)
+	fields[SyncFieldLabels] = strings.Join(sorted, ",")
+
+	return fields
+}
+
+func splitLabels(labels string) []string {
+	if labels == "" {
+		return nil
+	}
+	return strings.Split(labels, ",")
+}
+
+// jiraScopedLabels are the prefixes of the scoped labels owned by Jira, a scoped label of Jira replaces the old one
+var jiraScopedLabels = []string{"type::", "status::", "priority::"}
+
+// diffLabels returns the expected labels missing in GitLab, and the Jira scoped labels which are no longer in Jira
+func diffLabels(expected []string, labels []string) ([]string, []string) {
+	has := make(map[string]bool)
+	for _, label := range labels {
+		has[label] = true
+	}
+	want := make(map[string]bool)
+	for _, label := range expected {
+		want[label] = true
+	}
+
+	var added, removed []string
+	for label := range want {
+		if !has[label] {
+			added = append(added, label)
+		}
+	}
+	for _, label := range labels {
+		for _, prefix := range jiraScopedLabels {
+			if strings.HasPrefix(label, prefix) && !want[label] {
+				removed = append(removed, label)
+				break
+			}
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
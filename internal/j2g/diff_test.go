@@ -0,0 +1,56 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestDiff(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	jiraIssue := newTestJiraIssue()
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Attachments["1"] = []byte("log")
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+
+	changes, err := Diff(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	//* The summary and the type changed in Jira, and a comment is added
+	jiraIssue.Fields.Summary = "New summary"
+	jiraIssue.Fields.Type.Name = "Story"
+	jiraIssue.Fields.Comments.Comments = append(jiraIssue.Fields.Comments.Comments, &jira.Comment{ID: "99", Body: "new", Author: jira.User{DisplayName: "Alice"}})
+
+	changes, err = Diff(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, ChangeUpdate, changes[0].Action)
+	assert.Equal(t, gitlabIssue.WebURL, changes[0].WebURL)
+
+	fields := map[string]*FieldChange{}
+	for _, field := range changes[0].Fields {
+		fields[field.Field] = field
+	}
+	assert.Equal(t, "New summary", fields["title"].To)
+	assert.Equal(t, "+type::Story, -type::Bug", fields["labels"].To)
+	assert.Len(t, changes[0].Comments, 1)
+	assert.Equal(t, "99", changes[0].Comments[0].ID)
+	assert.Len(t, gl.Issues[2], 1)
+}
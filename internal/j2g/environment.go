@@ -0,0 +1,58 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"regexp"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// environmentSection reports if the Jira Environment field is a section of the description
+func environmentSection(cfg *config.Config) bool {
+	switch cfg.Environment.Mode {
+	case "", config.EnvironmentSection, config.EnvironmentBoth:
+		return true
+	}
+	return false
+}
+
+// environmentLabels returns the labels of the label rules matching the Jira Environment field, once each
+func environmentLabels(cfg *config.Config, issue *jira.Issue) []string {
+	if cfg.Environment.Mode != config.EnvironmentLabels && cfg.Environment.Mode != config.EnvironmentBoth {
+		return nil
+	}
+	if issue.Fields.Environment == "" {
+		return nil
+	}
+
+	var labels []string
+	seen := make(map[string]bool)
+	for _, rule := range cfg.Environment.LabelRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			log.Warnf("Skipping environment label rule %s: %s", rule.Pattern, err)
+			continue
+		}
+
+		for _, match := range re.FindAllStringSubmatchIndex(issue.Fields.Environment, -1) {
+			label := string(re.ExpandString(nil, rule.Label, issue.Fields.Environment, match))
+			if label != "" && !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels
+}
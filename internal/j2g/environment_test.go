@@ -0,0 +1,37 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestEnvironment(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Environment.Mode = config.EnvironmentBoth
+	cfg.Environment.LabelRules = []config.EnvironmentRule{{Pattern: `(?i)\b(windows|linux)\b`, Label: "os::$1"}}
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Environment = "Chrome 117 on Windows"
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Contains(t, gitlabIssue.Description, "### Environment\n\nChrome 117 on Windows")
+	assert.Contains(t, gitlabIssue.Labels, "os::Windows")
+}
@@ -12,8 +12,11 @@
 package j2g
 
 import (
+	"context"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,12 +27,11 @@ import (
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
-	"golang.org/x/sync/errgroup"
 )
 
-func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue *jira.Issue, userMap UserMap, existingLabels map[string]string) (*gitlab.Epic, error) {
+func ConvertJiraIssueToGitLabEpic(ctx context.Context, gl GitLabWriter, jr JiraReader, jiraIssue *jira.Issue, userMap UserMap, existingLabels map[string]string) (*gitlab.Epic, error) {
 	log := logrus.WithField("jiraEpic", jiraIssue.Key)
-	var g errgroup.Group
+	var g workerGroup
 	g.SetLimit(5)
 	mutex := sync.RWMutex{}
 
@@ -40,23 +42,23 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 
 	gid := cfg.GitLab.Epic
 
-	labels, err := convertJiraToGitLabLabels(gl, gid, jiraIssue, existingLabels, true)
+	labels, err := convertJiraToGitLabLabels(ctx, gl, gid, jiraIssue, existingLabels, true)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error converting Jira labels to GitLab labels")
 	}
 
 	gitlabCreateEpicOptions := gitlabx.CreateEpicOptions{
-		Title:        gitlab.String(jiraIssue.Fields.Summary),
-		Color:        utils.RandomColor(),
-		CreatedAt:    (*time.Time)(&jiraIssue.Fields.Created),
-		Labels:       labels,
-		DueDateFixed: (*gitlab.ISOTime)(&jiraIssue.Fields.Duedate),
+		Title:     gitlab.String(epicTitle(cfg, jiraIssue)),
+		Color:     utils.RandomColor(),
+		CreatedAt: (*time.Time)(&jiraIssue.Fields.Created),
+		Labels:    labels,
 	}
 
 	//* Attachment for Description and Comments
 	//! Epic Attachment는 API가 없는 관계로 우회한다.
 	// 1. cfg.Project.GitLab.Issue 프로젝트에 attachement를 붙인다.
 	// 2. 결과 markdown을 절대 경로로 바꾼 후 epic description에 붙인다
+	// gitlab.epic_attachments: group_wiki 이면 epic group의 wiki에 붙인다 (절대 경로)
 	pid := cfg.GitLab.Issue
 	usedAttachment := make(map[string]bool)
 
@@ -64,12 +66,18 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 	for _, jiraAttachment := range jiraIssue.Fields.Attachments {
 		g.Go(func(jiraAttachment *jira.Attachment) func() error {
 			return func() error {
-				attachment, err := convertJiraAttachmentToMarkdown(gl, jr, pid, jiraAttachment)
+				var attachment *Attachment
+				var err error
+				if cfg.GitLab.EpicAttachments == config.EpicAttachmentsGroupWiki {
+					attachment, err = convertJiraAttachmentToGroupWiki(ctx, gl, jr, cfg, jiraAttachment)
+				} else {
+					attachment, err = convertJiraAttachmentToMarkdown(ctx, gl, jr, pid, jiraAttachment)
+				}
 				if err != nil {
 					return errors.Wrap(err, "Error converting Jira attachment to GitLab attachment")
 				}
 
-				regexp := regexp.MustCompile(`!\[(.+)\]\((.+)\)`)
+				regexp := regexp.MustCompile(`!\[(.+)\]\(([^ )]+)(?: "(?:[^"\\]|\\.)*")?\)`)
 				matches := regexp.FindStringSubmatch(attachment.Markdown)
 
 				if len(matches) != 3 {
@@ -79,15 +87,19 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 				alt := matches[1]
 				url := matches[2]
 
-				absUrl := fmt.Sprintf("%s/%s/%s", cfg.GitLab.Host, cfg.GitLab.Issue, url)
+				absUrl := url
+				if cfg.GitLab.EpicAttachments != config.EpicAttachmentsGroupWiki {
+					absUrl = fmt.Sprintf("%s/%s/%s", cfg.GitLab.Host, cfg.GitLab.Issue, url)
+				}
 
 				mutex.Lock()
 				attachments[jiraAttachment.Filename] = &Attachment{
-					Markdown:  fmt.Sprintf("![%s](%s)", alt, absUrl),
+					Markdown:  imageMarkdown(alt, absUrl, attachment.Title),
 					Filename:  attachment.Filename,
 					CreatedAt: attachment.CreatedAt,
 					Alt:       alt,
 					URL:       absUrl,
+					Title:     attachment.Title,
 				}
 				mutex.Unlock()
 				log.Debugf("Converted attachment: %s to %s", jiraAttachment.Filename, attachment.Markdown)
@@ -105,44 +117,62 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 	if err != nil {
 		return nil, errors.Wrap(err, "Error formatting description")
 	}
+
+	//* Remote Link -> Description
+	remoteLinks, err := formatRemoteLinks(ctx, jr, jiraIssue)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error formatting remote links")
+	}
+	*description += remoteLinks
 	gitlabCreateEpicOptions.Description = description
 
 	for _, attachment := range usedImages {
 		usedAttachment[attachment] = true
 	}
 
-	//* StartDate
-	if cfg.Jira.CustomField.EpicStartDate != "" {
-		startDateStr, ok := jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.EpicStartDate].(string)
-		if ok {
-			startDate, err := time.Parse("2006-01-02", startDateStr)
-			if err != nil {
-				return nil, errors.Wrap(err, "Error parsing time")
-			}
-
-			gitlabCreateEpicOptions.StartDateIsFixed = gitlab.Bool(true)
-			gitlabCreateEpicOptions.StartDateFixed = (*gitlab.ISOTime)(&startDate)
-		} else {
-			log.Warnf("Unable to convert epic start date from Jira issue %s to GitLab start date", jiraIssue.Key)
-		}
+	//* StartDate, DueDate (inherited from the milestones without the Jira dates)
+	inherited := cfg.GitLab.EpicDatesFrom == config.EpicDatesMilestones
+	startDate, dueDate := epicStartDate(cfg, jiraIssue), isoDate(time.Time(jiraIssue.Fields.Duedate))
+	checkDateRange(cfg, fmt.Sprintf("epic %s", jiraIssue.Key), &startDate, &dueDate)
+	if startDate != nil {
+		gitlabCreateEpicOptions.StartDateIsFixed = gitlab.Bool(true)
+		gitlabCreateEpicOptions.StartDateFixed = startDate
+	} else if inherited {
+		gitlabCreateEpicOptions.StartDateIsFixed = gitlab.Bool(false)
 	}
 
-	//* DueDate
-	if jiraIssue.Fields.Duedate != (jira.Date{}) {
+	if dueDate != nil {
 		gitlabCreateEpicOptions.DueDateIsFixed = gitlab.Bool(true)
-		gitlabCreateEpicOptions.DueDateFixed = (*gitlab.ISOTime)(&jiraIssue.Fields.Duedate)
+		gitlabCreateEpicOptions.DueDateFixed = dueDate
+	} else if inherited {
+		gitlabCreateEpicOptions.DueDateIsFixed = gitlab.Bool(false)
 	}
 
 	//* 에픽을 생성합니다.
-	gitlabEpic, _, err := gitlabx.CreateEpic(gl, cfg.GitLab.Epic, &gitlabCreateEpicOptions)
+	gitlabEpic, err := createEpic(ctx, gl, cfg, cfg.GitLab.Epic, jiraIssue.Key, &gitlabCreateEpicOptions)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error creating GitLab epic")
 	}
 	log.Debugf("Created GitLab epic: %d from Jira issue: %s", gitlabEpic.IID, jiraIssue.Key)
 
-	//* Comment -> Comment
-	for _, jiraComment := range jiraIssue.Fields.Comments.Comments {
-		g.Go(func(jiraComment *jira.Comment) func() error {
+	//* Comment -> Comment, in the order of Jira without gitlab.parallel_epic_notes
+	if err := loadComments(ctx, jr, cfg, jiraIssue); err != nil {
+		return nil, err
+	}
+	goNote := func(fn func() error) error {
+		if cfg.GitLab.ParallelEpicNotes {
+			g.Go(fn)
+			return nil
+		}
+		return fn()
+	}
+
+	comments := jiraIssue.Fields.Comments.Comments
+	if !cfg.GitLab.ParallelEpicNotes {
+		comments = sortCommentsByCreated(comments)
+	}
+	for _, jiraComment := range comments {
+		err := goNote(func(jiraComment *jira.Comment) func() error {
 			return func() error {
 				body, _, usedImages, err := formatNote(jiraIssue.Key, jiraComment, userMap, attachments, true)
 				if err != nil {
@@ -155,32 +185,61 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 					mutex.Unlock()
 				}
 
-				createEpicNoteOptions := gitlab.CreateEpicNoteOptions{
-					Body: body,
+				body, internal := convertCommentVisibility(cfg, jiraIssue.Key, jiraComment, body)
+				if body == nil {
+					return nil
 				}
 
-				_, _, err = gl.Notes.CreateEpicNote(gid, gitlabEpic.ID, &createEpicNoteOptions)
-				if err != nil {
-					return errors.Wrap(err, "Error creating note")
+				for _, part := range splitNoteBody(*body, cfg.GitLab.MaxNoteSize) {
+					createEpicNoteOptions := gitlabx.CreateEpicNoteOptions{
+						Body:     gitlab.String(part),
+						Internal: gitlab.Bool(internal),
+					}
+
+					if _, err := createEpicNote(ctx, gl, gid, gitlabEpic.ID, &createEpicNoteOptions); err != nil {
+						return errors.Wrap(err, "Error creating note")
+					}
 				}
 				return nil
 			}
 		}(jiraComment))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating GitLab comment with gid %s, epic ID %d", gid, gitlabEpic.ID))
+		}
 	}
 
 	if err := g.Wait(); err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("Error creating GitLab comment with gid %s, epic ID %d", gid, gitlabEpic.ID))
 	}
 
-	//* Reamin Attachment -> Comment
-	for id, markdown := range attachments {
-		if used, ok := usedAttachment[id]; ok || used {
-			continue
+	//* Flag -> Note
+	flagNote, usedImages, err := formatFlagNote(cfg, jiraIssue, userMap, attachments, true)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error formatting flag note: issue %s", jiraIssue.Key))
+	}
+	if flagNote != nil {
+		for _, attachment := range usedImages {
+			usedAttachment[attachment] = true
+		}
+
+		if _, err := createEpicNote(ctx, gl, gid, gitlabEpic.ID, &gitlabx.CreateEpicNoteOptions{Body: flagNote}); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating flag note: issue %s", jiraIssue.Key))
 		}
+	}
 
-		g.Go(func(markdown *Attachment) func() error {
+	//* Reamin Attachment -> Comment
+	orphans := orphanAttachments(attachments, usedAttachment)
+	if cfg.OrphanAttachments != config.OrphanAttachmentsSeparate && len(orphans) > 0 {
+		body := formatAttachmentsNote(orphans)
+		if _, err := createEpicNote(ctx, gl, gid, gitlabEpic.ID, &gitlabx.CreateEpicNoteOptions{Body: &body}); err != nil {
+			return nil, errors.Wrap(err, "Error creating attachments note")
+		}
+		orphans = nil
+	}
+	for _, markdown := range orphans {
+		err := goNote(func(markdown *Attachment) func() error {
 			return func() error {
-				_, _, err = gl.Notes.CreateEpicNote(gid, gitlabEpic.ID, &gitlab.CreateEpicNoteOptions{
+				_, err := createEpicNote(ctx, gl, gid, gitlabEpic.ID, &gitlabx.CreateEpicNoteOptions{
 					Body: &markdown.Markdown,
 				})
 				if err != nil {
@@ -189,6 +248,9 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 				return nil
 			}
 		}(markdown))
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating GitLab issue")
+		}
 	}
 
 	if err := g.Wait(); err != nil {
@@ -197,7 +259,7 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 
 	//* Resolution -> Close issue (CloseAt)
 	if jiraIssue.Fields.Resolution != nil {
-		gl.Epics.UpdateEpic(gid, gitlabEpic.IID, &gitlab.UpdateEpicOptions{
+		gl.UpdateEpic(ctx, gid, gitlabEpic.IID, &gitlab.UpdateEpicOptions{
 			StateEvent: gitlab.String("close"),
 		})
 		log.Debugf("Closed GitLab epic: %d", gitlabEpic.IID)
@@ -205,3 +267,195 @@ func ConvertJiraIssueToGitLabEpic(gl *gitlab.Client, jr *jira.Client, jiraIssue
 
 	return gitlabEpic, nil
 }
+
+// Start date of the epic custom field, nil if it is absent or invalid
+func epicStartDate(cfg *config.Config, jiraIssue *jira.Issue) *gitlab.ISOTime {
+	if cfg.Jira.CustomField.EpicStartDate == "" {
+		return nil
+	}
+
+	startDateStr, ok := jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.EpicStartDate].(string)
+	if !ok {
+		logrus.Debugf("Jira epic %s doesn't have a start date", jiraIssue.Key)
+		return nil
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil || !isValidDate(startDate) {
+		logrus.Warnf("Unable to convert epic start date %q from Jira issue %s to GitLab start date", startDateStr, jiraIssue.Key)
+		return nil
+	}
+	return isoDate(startDate)
+}
+
+// deriveEpicDates sets the missing start and due date of the epics from their child issues (gitlab.epic_dates_from: children)
+// - start date: the earliest creation date of the children
+// - due date: the latest due date of the children
+func deriveEpicDates(ctx context.Context, gl GitLabWriter, cfg *config.Config, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) error {
+	for epicKey, epicLink := range epicLinks {
+		needStartDate := epicStartDate(cfg, epicLink.Issue) == nil
+		needDueDate := !isValidDate(time.Time(epicLink.Fields.Duedate))
+		if !needStartDate && !needDueDate {
+			continue
+		}
+
+		var startDate, dueDate time.Time
+		for _, issueLink := range issueLinks {
+			if findParentKey(cfg, issueLink.Issue) != epicKey {
+				continue
+			}
+
+			created := time.Time(issueLink.Fields.Created)
+			if isValidDate(created) && (startDate.IsZero() || created.Before(startDate)) {
+				startDate = created
+			}
+			due := time.Time(issueLink.Fields.Duedate)
+			if isValidDate(due) && due.After(dueDate) {
+				dueDate = due
+			}
+		}
+
+		if (!needStartDate || !isValidDate(startDate)) && (!needDueDate || !isValidDate(dueDate)) {
+			continue
+		}
+
+		//* A derived date may be on the wrong side of the Jira one
+		fixedStartDate, fixedDueDate := epicStartDate(cfg, epicLink.Issue), isoDate(time.Time(epicLink.Fields.Duedate))
+		if needStartDate {
+			fixedStartDate = isoDate(startDate)
+		}
+		if needDueDate {
+			fixedDueDate = isoDate(dueDate)
+		}
+		checkDateRange(cfg, fmt.Sprintf("epic %s", epicKey), &fixedStartDate, &fixedDueDate)
+
+		opt := &gitlab.UpdateEpicOptions{}
+		if fixedStartDate != nil {
+			opt.StartDateIsFixed = gitlab.Bool(true)
+			opt.StartDateFixed = fixedStartDate
+		}
+		if fixedDueDate != nil {
+			opt.DueDateIsFixed = gitlab.Bool(true)
+			opt.DueDateFixed = fixedDueDate
+		}
+		if opt.StartDateFixed == nil && opt.DueDateFixed == nil {
+			continue
+		}
+
+		_, _, err := gl.UpdateEpic(ctx, cfg.GitLab.Epic, epicLink.gitlabEpic.IID, opt)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error updating dates of epic %s", epicKey))
+		}
+		logrus.Infof("Derived dates of epic %s(%d) from its child issues", epicKey, epicLink.gitlabEpic.IID)
+	}
+
+	return nil
+}
+
+// isJiraEpic is true for an issue of an epic issue type (name or ID) or with a value of the epic field (jira.epic)
+func isJiraEpic(cfg *config.Config, jiraIssue *jira.Issue) bool {
+	if jiraIssue.Fields == nil {
+		return false
+	}
+
+	issueTypes := cfg.Jira.Epic.IssueTypes
+	if len(issueTypes) == 0 {
+		issueTypes = []string{config.DefaultEpicIssueType}
+	}
+	for _, issueType := range issueTypes {
+		if jiraIssue.Fields.Type.Name == issueType || (jiraIssue.Fields.Type.ID != "" && jiraIssue.Fields.Type.ID == issueType) {
+			return true
+		}
+	}
+
+	if cfg.Jira.Epic.Field != "" {
+		return customFieldText(jiraIssue.Fields.Unknowns[cfg.Jira.Epic.Field]) != ""
+	}
+	return false
+}
+
+// epicName returns the Epic Name custom field of the Jira epic (jira.custom_field.epic_name)
+func epicName(cfg *config.Config, jiraIssue *jira.Issue) string {
+	if cfg.Jira.CustomField.EpicName == "" {
+		return ""
+	}
+	return strings.TrimSpace(customFieldText(jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.EpicName]))
+}
+
+// epicTitle returns the title of the migrated epic (gitlab.epic_title), the summary if the epic has no Epic Name
+func epicTitle(cfg *config.Config, jiraIssue *jira.Issue) string {
+	if cfg.GitLab.EpicTitle == config.EpicTitleEpicName {
+		if name := epicName(cfg, jiraIssue); name != "" {
+			return name
+		}
+	}
+	return jiraIssue.Fields.Summary
+}
+
+// epicTitleHeader returns the line of the Epic Name or summary which is not the title, empty if both are the same
+func epicTitleHeader(cfg *config.Config, jiraIssue *jira.Issue) string {
+	name := epicName(cfg, jiraIssue)
+	if name == "" || name == jiraIssue.Fields.Summary {
+		return ""
+	}
+	if epicTitle(cfg, jiraIssue) == name {
+		return fmt.Sprintf("**Summary:** %s", jiraIssue.Fields.Summary)
+	}
+	return fmt.Sprintf("**Epic Name:** %s", name)
+}
+
+// epicChildrenHeading starts the comment of addEpicChildrenNotes, a comment with it is not added again
+const epicChildrenHeading = "### Migrated child issues"
+
+// addEpicChildrenNotes comments the checklist of the migrated child issues on each epic, to verify the epic and issue association
+func addEpicChildrenNotes(ctx context.Context, gl GitLabWriter, cfg *config.Config, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) error {
+	children := make(map[string][]*JiraIssueLink)
+	for _, issueLink := range issueLinks {
+		parentKey := findParentKey(cfg, issueLink.Issue)
+		if _, ok := epicLinks[parentKey]; ok {
+			children[parentKey] = append(children[parentKey], issueLink)
+		}
+	}
+
+	for epicKey, childLinks := range children {
+		epic := epicLinks[epicKey]
+
+		notes, err := gl.ListEpicNotes(ctx, cfg.GitLab.Epic, epic.gitlabEpic.ID)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting comments of epic %s", epicKey))
+		}
+		exist := false
+		for _, note := range notes {
+			if strings.HasPrefix(note.Body, epicChildrenHeading) {
+				exist = true
+				break
+			}
+		}
+		if exist {
+			logrus.Debugf("Skipping child issue comment of epic %s: already added", epicKey)
+			continue
+		}
+
+		sort.Slice(childLinks, func(i, j int) bool {
+			return childLinks[i].gitlabIssue.IID < childLinks[j].gitlabIssue.IID
+		})
+
+		var body strings.Builder
+		body.WriteString(epicChildrenHeading + "\n\n")
+		for _, child := range childLinks {
+			check := " "
+			if child.Fields.Resolution != nil {
+				check = "x"
+			}
+			body.WriteString(fmt.Sprintf("- [%s] %s#%d Jira [%s](%s/browse/%s)\n", check, cfg.GitLab.Issue, child.gitlabIssue.IID, child.Key, cfg.Jira.Host, child.Key))
+		}
+
+		text := body.String()
+		if _, _, err := gl.CreateEpicNote(ctx, cfg.GitLab.Epic, epic.gitlabEpic.ID, &gitlabx.CreateEpicNoteOptions{Body: &text}); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error adding child issue comment to epic %s", epicKey))
+		}
+		logrus.Infof("Commented %d child issues on epic %s(%d)", len(childLinks), epicKey, epic.gitlabEpic.IID)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestEpicChildrenNote(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Jira.CustomField.ParentEpic = "customfield_10100"
+	cfg.GitLab.EpicChildrenNote = true
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{}}}
+	issue := newTestJiraIssue()
+	issue.Key = "TEST-2"
+	issue.Fields.Attachments = nil
+	issue.Fields.Unknowns = map[string]interface{}{"customfield_10100": "TEST-1"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic, issue)
+
+	//* Not added again by a second run
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	}
+	if assert.Len(t, gl.Epics[1], 1) && assert.Len(t, gl.Issues[2], 1) {
+		var notes []string
+		for _, note := range gl.EpicNotes[gl.Epics[1][0].ID] {
+			if strings.HasPrefix(note.Body, epicChildrenHeading) {
+				notes = append(notes, note.Body)
+			}
+		}
+		assert.Equal(t, []string{fmt.Sprintf("%s\n\n- [x] group/project#%d Jira [TEST-2](https://jira.example.com/browse/TEST-2)\n", epicChildrenHeading, gl.Issues[2][0].IID)}, notes)
+	}
+}
+
+func TestOrderedEpicNotes(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	gl.NoteTimeouts = 1
+
+	author := jira.User{DisplayName: "Jane Doe"}
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{Comments: []*jira.Comment{
+		{ID: "3", Body: "third", Author: author, Created: "2023-09-03T12:00:00.000+0900"},
+		{ID: "1", Body: "first", Author: author, Created: "2023-09-01T12:00:00.000+0900"},
+		{ID: "2", Body: "second", Author: author, Created: "2023-09-02T12:00:00.000+0900"},
+	}}}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic)
+
+	//* The note of the timeout is not created twice
+	gitlabEpic, err := ConvertJiraIssueToGitLabEpic(context.Background(), gl, jr, epic, UserMap{}, map[string]string{})
+	assert.NoError(t, err)
+	var bodies []string
+	for _, note := range gl.EpicNotes[gitlabEpic.ID] {
+		bodies = append(bodies, strings.SplitN(note.Body, "\n", 2)[0])
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, bodies)
+}
+
+func TestEpicTitle(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.EpicName = "customfield_10011"
+	cfg.GitLab.EpicTitle = config.EpicTitleEpicName
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Type.Name = "Epic"
+	jiraIssue.Fields.Summary = "Migrate the login to OAuth"
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10011": "OAuth"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Equal(t, "OAuth", gitlabIssue.Title)
+	assert.True(t, strings.HasPrefix(gitlabIssue.Description, "**Summary:** Migrate the login to OAuth\n\n"))
+
+	cfg.GitLab.EpicTitle = config.EpicTitleSummary
+	assert.Equal(t, "Migrate the login to OAuth", epicTitle(cfg, jiraIssue))
+	assert.Equal(t, "**Epic Name:** OAuth", epicTitleHeader(cfg, jiraIssue))
+}
+
+func TestEpicDatesFromMilestones(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.GitLab.EpicDatesFrom = config.EpicDatesMilestones
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Duedate: jira.Date(time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC)), Comments: &jira.Comments{}}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic)
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	if assert.Len(t, gl.Epics[1], 1) {
+		assert.False(t, gl.Epics[1][0].StartDateIsFixed)
+		assert.True(t, gl.Epics[1][0].DueDateIsFixed)
+		assert.Equal(t, "2023-09-30", gl.Epics[1][0].DueDate.String())
+	}
+}
+
+func TestEpicDatesFromChildren(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.GitLab.EpicDatesFrom = config.EpicDatesChildren
+
+	//* The due date of the epic is empty (0001-01-01), it is not sent to GitLab
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{}}}
+	var children []*jira.Issue
+	for i, day := range []int{5, 1} {
+		child := newTestJiraIssue()
+		child.ID = fmt.Sprint(10002 + i)
+		child.Key = fmt.Sprintf("TEST-%d", i+2)
+		child.Fields.Attachments = nil
+		child.Fields.Parent = &jira.Parent{Key: "TEST-1"}
+		child.Fields.Created = jira.Time(time.Date(2023, 9, day, 10, 0, 0, 0, time.UTC))
+		child.Fields.Duedate = jira.Date(time.Date(2023, 9, 20+day, 0, 0, 0, 0, time.UTC))
+		children = append(children, child)
+	}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, append([]*jira.Issue{epic}, children...)...)
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	if assert.Len(t, gl.Epics[1], 1) {
+		gitlabEpic := gl.Epics[1][0]
+		assert.Equal(t, "2023-09-01", gitlabEpic.StartDate.String())
+		assert.Equal(t, "2023-09-25", gitlabEpic.DueDate.String())
+		assert.True(t, gitlabEpic.StartDateIsFixed)
+		assert.True(t, gitlabEpic.DueDateIsFixed)
+	}
+}
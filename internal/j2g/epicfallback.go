@@ -0,0 +1,166 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+const (
+	// Label of the Jira epics migrated as GitLab issues
+	EpicLabel = "epic"
+
+	// File for the GitLab CSV import (Issues > Import CSV) of gitlab.epic_mode: csv without a state file
+	EpicCSVFile = "epics.csv"
+
+	childIssuesHeading = "### Child issues"
+)
+
+// resolveEpicMode decides how the epics are migrated. auto checks if the GitLab group has epics (Premium), epic fails without them.
+func resolveEpicMode(ctx context.Context, gl GitLabWriter, cfg *config.Config) (string, error) {
+	if cfg.GitLab.EpicMode != "" && cfg.GitLab.EpicMode != config.EpicModeAuto && cfg.GitLab.EpicMode != config.EpicModeEpic {
+		return cfg.GitLab.EpicMode, nil
+	}
+
+	available, err := gl.EpicsAvailable(ctx, cfg.GitLab.Epic)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error checking GitLab epics: %s", cfg.GitLab.Epic))
+	}
+
+	if cfg.GitLab.EpicMode == config.EpicModeEpic {
+		if !available {
+			return "", &utils.TierFeatureError{Feature: "Epics", Tier: "Premium", Config: "set gitlab.epic_mode to issue or auto to migrate the epics as issues"}
+		}
+		return config.EpicModeEpic, nil
+	}
+
+	if !available {
+		log.Warnf("GitLab group %s doesn't have epics (Premium), epics are migrated as issues with the %s label", cfg.GitLab.Epic, EpicLabel)
+		return config.EpicModeIssue, nil
+	}
+	return config.EpicModeEpic, nil
+}
+
+// epicCSVPath is the CSV of the epics next to the state file, <state_file>.epics.csv for state.json.
+// The projects of a site or the routes have their own state files, so their epics don't overwrite each other.
+func epicCSVPath(cfg *config.Config) string {
+	if cfg.StateFile == "" {
+		return EpicCSVFile
+	}
+	return strings.TrimSuffix(cfg.StateFile, filepath.Ext(cfg.StateFile)) + "." + EpicCSVFile
+}
+
+// writeEpicCSV writes the epics as the GitLab issue CSV import (title, description)
+func writeEpicCSV(path string, jiraEpics []*jira.Issue, userMap UserMap) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating CSV file: %s", path))
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write([]string{"title", "description"}); err != nil {
+		return errors.Wrap(err, "Error writing CSV header")
+	}
+
+	for _, jiraEpic := range jiraEpics {
+		// Attachments are not uploaded, the CSV import can't link them
+		description, _, err := formatDescription(jiraEpic, userMap, AttachmentMap{}, true)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error formatting description: epic %s", jiraEpic.Key))
+		}
+
+		if err := w.Write([]string{epicTitle(cfg, jiraEpic), *description}); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error writing CSV: epic %s", jiraEpic.Key))
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing CSV file: %s", path))
+	}
+
+	log.Infof("Wrote %d epics to %s, import it with Issues > Import CSV", len(jiraEpics), path)
+	return file.Close()
+}
+
+// addChildTaskLists appends the child issues to the description of the epics migrated as issues
+func addChildTaskLists(ctx context.Context, gl GitLabWriter, cfg *config.Config, issueLinks map[string]*JiraIssueLink) error {
+	children := make(map[string][]*JiraIssueLink)
+	for _, issueLink := range issueLinks {
+		parentKey := findParentKey(cfg, issueLink.Issue)
+		if parent, ok := issueLinks[parentKey]; ok && isJiraEpic(cfg, parent.Issue) {
+			children[parentKey] = append(children[parentKey], issueLink)
+		}
+	}
+
+	for epicKey, childLinks := range children {
+		epic := issueLinks[epicKey]
+
+		//* Restored from the state file (e.g. run --only links), the description is read from GitLab
+		description := epic.gitlabIssue.Description
+		if description == "" {
+			gitlabIssue, _, err := gl.GetIssue(ctx, epic.gitlabIssue.ProjectID, epic.gitlabIssue.IID)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error getting GitLab issue of epic %s", epicKey))
+			}
+			description = gitlabIssue.Description
+		}
+		if strings.Contains(description, childIssuesHeading) {
+			log.Debugf("Skipping child issues of epic %s: already added", epicKey)
+			continue
+		}
+
+		sort.Slice(childLinks, func(i, j int) bool {
+			return childLinks[i].gitlabIssue.IID < childLinks[j].gitlabIssue.IID
+		})
+
+		var taskList strings.Builder
+		taskList.WriteString("\n\n" + childIssuesHeading + "\n\n")
+		for _, child := range childLinks {
+			check := " "
+			if child.Fields.Resolution != nil {
+				check = "x"
+			}
+			taskList.WriteString(fmt.Sprintf("- [%s] #%d\n", check, child.gitlabIssue.IID))
+		}
+
+		description += taskList.String()
+		_, _, err := gl.UpdateIssue(ctx, epic.gitlabIssue.ProjectID, epic.gitlabIssue.IID, &gitlab.UpdateIssueOptions{
+			Description: &description,
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error adding child issues to epic %s", epicKey))
+		}
+		log.Infof("Added %d child issues to epic %s(%d)", len(childLinks), epicKey, epic.gitlabIssue.IID)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestAddChildTaskLists(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{}}}
+	child := newTestJiraIssue()
+	child.Key = "TEST-2"
+	child.Fields.Parent = &jira.Parent{Key: "TEST-1"}
+	child.Fields.Attachments = nil
+
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic, child)
+	ctx := context.Background()
+
+	issueLinks := make(map[string]*JiraIssueLink)
+	for _, jiraIssue := range []*jira.Issue{epic, child} {
+		gitlabIssue, err := ConvertJiraIssueToGitLabIssue(ctx, gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+		assert.NoError(t, err)
+		issueLinks[jiraIssue.Key] = &JiraIssueLink{jiraIssue, gitlabIssue}
+	}
+	assert.Contains(t, issueLinks["TEST-1"].gitlabIssue.Labels, EpicLabel)
+
+	assert.NoError(t, addChildTaskLists(ctx, gl, cfg, issueLinks))
+	epicIssue := gl.Issues[2][0]
+	assert.Contains(t, epicIssue.Description, "### Child issues\n\n- [x] #2\n")
+
+	//* The child is related to the epic, blocks is Premium only
+	assert.NoError(t, Link(ctx, gl, jr, map[string]*JiraEpicLink{}, issueLinks))
+	links := gl.IssueLinks[issueLinks["TEST-2"].gitlabIssue.ID]
+	if assert.Len(t, links, 1) {
+		assert.Equal(t, "relates_to", links[0].LinkType)
+		assert.Equal(t, epicIssue.ID, links[0].TargetIssue.ID)
+	}
+}
+
+func TestEpicCSVPath(t *testing.T) {
+	cfg := newTestConfig()
+	assert.Equal(t, EpicCSVFile, epicCSVPath(cfg))
+
+	//* Next to the state file, the routes and the projects of a site have their own
+	cfg.StateFile = filepath.Join("migration", "state.route1.json")
+	assert.Equal(t, filepath.Join("migration", "state.route1.epics.csv"), epicCSVPath(cfg))
+}
@@ -0,0 +1,152 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+// EpicOverviewLabel is the label of the issues mirroring the epics (gitlab.epic_overview)
+const EpicOverviewLabel = "epic overview"
+
+// epicOverviewMarker is the last line of an overview issue instead of the Jira key marker, the overview is not the migrated item of the epic
+func epicOverviewMarker(cfg *config.Config, epicKey string) string {
+	return fmt.Sprintf("Overview of the Jira epic [%s](%s/browse/%s)", epicKey, cfg.Jira.Host, epicKey)
+}
+
+// findEpicOverview returns the overview issue of the Jira epic, nil if there is none
+func findEpicOverview(ctx context.Context, gl GitLabWriter, cfg *config.Config, epicKey string) (*gitlab.Issue, error) {
+	issues, err := gl.SearchIssues(ctx, cfg.GitLab.Issue, epicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error searching GitLab issues: epic %s", epicKey))
+	}
+
+	marker := epicOverviewMarker(cfg, epicKey)
+	for _, issue := range issues {
+		if strings.HasSuffix(strings.TrimSpace(issue.Description), marker) {
+			return issue, nil
+		}
+	}
+	return nil, nil
+}
+
+// formatEpicOverview is the description of the overview issue: the epic description and the checklist of its child issues
+func formatEpicOverview(cfg *config.Config, epicKey string, epic *gitlab.Epic, children []*JiraIssueLink) string {
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Overview of the epic [&%d](%s) for the users without access to the epics of the group, it is updated by the migration.\n\n", epic.IID, epic.WebURL))
+
+	description := strings.TrimSpace(strings.Replace(epic.Description, jiraKeyMarker(cfg, epicKey), "", 1))
+	if description != "" {
+		body.WriteString(description + "\n\n")
+	}
+
+	if len(children) > 0 {
+		body.WriteString(childIssuesHeading + "\n\n")
+		for _, child := range children {
+			check := " "
+			if child.Fields.Resolution != nil {
+				check = "x"
+			}
+			body.WriteString(fmt.Sprintf("- [%s] #%d\n", check, child.gitlabIssue.IID))
+		}
+		body.WriteString("\n")
+	}
+
+	body.WriteString(epicOverviewMarker(cfg, epicKey))
+	return body.String()
+}
+
+// mirrorEpicOverviews creates or updates an issue with the EpicOverviewLabel for each epic (gitlab.epic_overview).
+// The group epics are Premium, the overview issues show them to the users of the project only, e.g. on a free tier group or without access to the group.
+func mirrorEpicOverviews(ctx context.Context, gl GitLabWriter, cfg *config.Config, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink, existingLabels map[string]string) error {
+	id, isGroup := issueLabelTarget(cfg)
+	if err := ensureLabel(ctx, gl, id, EpicOverviewLabel, "Overview of a group epic", existingLabels, isGroup); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating Epic Overview label with %s", EpicOverviewLabel))
+	}
+
+	children := make(map[string][]*JiraIssueLink)
+	for _, issueLink := range issueLinks {
+		parentKey := findParentKey(cfg, issueLink.Issue)
+		if _, ok := epicLinks[parentKey]; ok {
+			children[parentKey] = append(children[parentKey], issueLink)
+		}
+	}
+
+	epicKeys := make([]string, 0, len(epicLinks))
+	for epicKey := range epicLinks {
+		epicKeys = append(epicKeys, epicKey)
+	}
+	sort.Strings(epicKeys)
+
+	for _, epicKey := range epicKeys {
+		//* The epics skipped by a rerun only have the IDs of the state file
+		epic, _, err := gl.GetEpic(ctx, cfg.GitLab.Epic, epicLinks[epicKey].gitlabEpic.IID)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting GitLab epic: epic %s", epicKey))
+		}
+
+		childLinks := children[epicKey]
+		sort.Slice(childLinks, func(i, j int) bool {
+			return childLinks[i].gitlabIssue.IID < childLinks[j].gitlabIssue.IID
+		})
+		description := formatEpicOverview(cfg, epicKey, epic, childLinks)
+
+		overview, found, err := gitlabx.FindOrCreate(func() (*gitlab.Issue, error) {
+			return findEpicOverview(ctx, gl, cfg, epicKey)
+		}, func() (*gitlab.Issue, *gitlab.Response, error) {
+			return gl.CreateIssue(ctx, cfg.GitLab.Issue, &gitlabx.CreateIssueOptions{
+				Title:       &epic.Title,
+				Description: &description,
+				Labels:      &gitlab.Labels{EpicOverviewLabel},
+			})
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error creating epic overview: epic %s", epicKey))
+		}
+
+		opt := &gitlab.UpdateIssueOptions{}
+		changed := false
+		if found && (overview.Title != epic.Title || overview.Description != description) {
+			opt.Title, opt.Description = &epic.Title, &description
+			changed = true
+		}
+		if (epic.State == "closed") != (overview.State == "closed") {
+			opt.StateEvent = gitlab.String("reopen")
+			if epic.State == "closed" {
+				opt.StateEvent = gitlab.String("close")
+			}
+			changed = true
+		}
+		if changed {
+			if _, _, err := gl.UpdateIssue(ctx, cfg.GitLab.Issue, overview.IID, opt); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error updating epic overview %d: epic %s", overview.IID, epicKey))
+			}
+		}
+
+		if !found {
+			log.Infof("Created epic overview of %s: #%d", epicKey, overview.IID)
+		} else if changed {
+			log.Infof("Updated epic overview of %s: #%d", epicKey, overview.IID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestEpicOverview(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Jira.CustomField.ParentEpic = "customfield_10100"
+	cfg.GitLab.EpicOverview = true
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Description: "Login and SSO", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{}}}
+	issue := newTestJiraIssue()
+	issue.Key = "TEST-2"
+	issue.Fields.Attachments = nil
+	issue.Fields.Unknowns = map[string]interface{}{"customfield_10100": "TEST-1"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic, issue)
+	ctx := context.Background()
+
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	overview, err := findEpicOverview(ctx, gl, cfg, "TEST-1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, overview) {
+		assert.Equal(t, "Auth", overview.Title)
+		assert.Contains(t, overview.Labels, EpicOverviewLabel)
+		assert.Contains(t, overview.Description, "Login and SSO")
+		assert.Contains(t, overview.Description, "- [x] #1\n")
+		assert.NotContains(t, overview.Description, jiraKeyMarker(cfg, "TEST-1"))
+	}
+
+	//* A rerun updates the overview instead of creating another one
+	gl.Epics[1][0].Title = "Auth and SSO"
+	gl.Epics[1][0].State = "closed"
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	assert.Len(t, gl.Issues[2], 2)
+	overview, err = findEpicOverview(ctx, gl, cfg, "TEST-1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, overview) {
+		assert.Equal(t, "Auth and SSO", overview.Title)
+		assert.Equal(t, "closed", overview.State)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// FieldInfo is a Jira field with a sample value of the project, to find the IDs of jira.custom_field
+type FieldInfo struct {
+	ID     string
+	Name   string
+	Type   string
+	Custom bool
+	Sample string
+}
+
+// ListFields returns the Jira fields ordered by ID, the custom fields have the first value of the sample issues of the project
+func ListFields(ctx context.Context, jr JiraReader, cfg *config.Config, samples int) ([]*FieldInfo, error) {
+	fields, _, err := jr.ListFields(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting Jira fields")
+	}
+
+	jql := fmt.Sprintf("project = %s Order by updated DESC", cfg.Jira.Name)
+	issues, err := jr.SampleIssues(ctx, jql, samples)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira issues: %s", cfg.Jira.Name))
+	}
+
+	result := make([]*FieldInfo, 0, len(fields))
+	for _, field := range fields {
+		info := &FieldInfo{ID: field.ID, Name: field.Name, Type: fieldType(field.Schema), Custom: field.Custom}
+		for _, issue := range issues {
+			if issue.Fields == nil {
+				continue
+			}
+			if sample := strings.TrimSpace(customFieldText(issue.Fields.Unknowns[field.ID])); sample != "" {
+				info.Sample = sample
+				break
+			}
+		}
+		result = append(result, info)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Custom != result[j].Custom {
+			return !result[i].Custom
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// fieldType formats the schema of a field, e.g. array<string> (labels) or option (select)
+func fieldType(schema jira.FieldSchema) string {
+	result := schema.Type
+	if schema.Items != "" {
+		result = fmt.Sprintf("%s<%s>", schema.Type, schema.Items)
+	}
+	if i := strings.LastIndex(schema.Custom, ":"); i >= 0 {
+		result = fmt.Sprintf("%s (%s)", result, schema.Custom[i+1:])
+	}
+	return result
+}
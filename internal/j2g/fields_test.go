@@ -0,0 +1,39 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestListFields(t *testing.T) {
+	cfg := newTestConfig()
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10011": map[string]interface{}{"value": "High"}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Fields = []jira.Field{
+		{ID: "customfield_10011", Name: "심각도", Custom: true, Schema: jira.FieldSchema{Type: "option", Custom: "com.atlassian.jira.plugin.system.customfieldtypes:select"}},
+		{ID: "labels", Name: "Labels", Schema: jira.FieldSchema{Type: "array", Items: "string"}},
+	}
+
+	fields, err := ListFields(context.Background(), jr, cfg, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*FieldInfo{
+		{ID: "labels", Name: "Labels", Type: "array<string>"},
+		{ID: "customfield_10011", Name: "심각도", Type: "option (select)", Custom: true, Sample: "High"},
+	}, fields)
+}
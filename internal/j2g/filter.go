@@ -0,0 +1,300 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// GitLabSearch is a Jira filter translated to the issue list of the GitLab project
+type GitLabSearch struct {
+	Filter *jira.Filter
+	URL    string
+	// Clauses of the JQL without a GitLab equivalent, they are dropped from the URL
+	Untranslated []string
+}
+
+// e.g. status = "In Progress", labels in (a, b), assignee is EMPTY, text ~ "crash"
+var jqlClausePattern = regexp.MustCompile(`(?i)^("[^"]+"|[\w.\[\]]+)\s*(!=|=|~|not in|in|is not|is)\s*(.+)$`)
+
+var jqlOrderPattern = regexp.MustCompile(`(?i)\s+order\s+by\s+(.+)$`)
+
+// splitJQL splits the JQL at the top level AND, quotes and parentheses are kept. ok is false if there is a top level OR or NOT.
+func splitJQL(jql string) (clauses []string, ok bool) {
+	var current strings.Builder
+	depth, quoted := 0, false
+	words := strings.Fields(jql)
+	for _, word := range words {
+		if depth == 0 && !quoted {
+			switch strings.ToUpper(word) {
+			case "AND":
+				clauses = append(clauses, current.String())
+				current.Reset()
+				continue
+			case "OR", "NOT":
+				return nil, false
+			}
+		}
+		if current.Len() > 0 {
+			current.WriteByte(' ')
+		}
+		current.WriteString(word)
+		for _, r := range word {
+			switch {
+			case r == '"':
+				quoted = !quoted
+			case r == '(' && !quoted:
+				depth++
+			case r == ')' && !quoted:
+				depth--
+			}
+		}
+	}
+	if current.Len() > 0 {
+		clauses = append(clauses, current.String())
+	}
+	return clauses, true
+}
+
+// jqlValues returns the values of a JQL value or list without quotes, e.g. ("a b", c) -> [a b, c]
+func jqlValues(value string) []string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = value[1 : len(value)-1]
+	}
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		values = append(values, strings.Trim(strings.TrimSpace(v), `"'`))
+	}
+	return values
+}
+
+// translateFilter translates the JQL of the filter to the URL parameters of the GitLab issue list.
+// The project, status, issue type, priority, component, label, resolution, user, version, sprint and text clauses of the JQL are translated.
+func translateFilter(cfg *config.Config, filter *jira.Filter, projectKey string, projectURL string, userMap UserMap) *GitLabSearch {
+	search := &GitLabSearch{Filter: filter}
+	params := url.Values{}
+	jql := strings.TrimSpace(filter.Jql)
+
+	//* ORDER BY
+	if match := jqlOrderPattern.FindStringSubmatchIndex(jql); match != nil {
+		order := jql[match[2]:match[3]]
+		jql = jql[:match[0]]
+		sort := map[string]string{
+			"created desc": "created_date",
+			"created asc":  "created_asc",
+			"updated desc": "updated_desc",
+			"updated asc":  "updated_asc",
+			"duedate asc":  "due_date",
+		}[strings.ToLower(strings.Join(strings.Fields(order), " "))]
+		if sort == "" {
+			search.Untranslated = append(search.Untranslated, "ORDER BY "+order)
+		} else {
+			params.Set("sort", sort)
+		}
+	}
+
+	clauses, ok := splitJQL(jql)
+	if !ok {
+		clauses = nil
+		search.Untranslated = append(search.Untranslated, jql)
+	}
+
+	user := func(name string) (string, bool) {
+		if gitlabUser, ok := userMap[name]; ok && !isUnmappedUser(gitlabUser) {
+			return gitlabUser.Username, true
+		}
+		return "", false
+	}
+
+	for _, clause := range clauses {
+		match := jqlClausePattern.FindStringSubmatch(clause)
+		if match == nil {
+			search.Untranslated = append(search.Untranslated, clause)
+			continue
+		}
+		field := strings.ToLower(strings.Trim(match[1], `"`))
+		operator := strings.ToLower(strings.Join(strings.Fields(match[2]), " "))
+		values := jqlValues(match[3])
+		value := strings.ToLower(values[0])
+		single := len(values) == 1 && (operator == "=" || operator == "!=" || operator == "in" || operator == "not in")
+		negated := operator == "!=" || operator == "not in"
+
+		label := func(name string) bool {
+			if !single {
+				return false
+			}
+			if negated {
+				params.Add("not[label_name][]", name)
+			} else {
+				params.Add("label_name[]", name)
+			}
+			return true
+		}
+
+		translated := false
+		switch field {
+		case "project":
+			translated = (operator == "=" || operator == "in") && len(values) == 1 && strings.EqualFold(values[0], projectKey)
+		case "status":
+			translated = label(fmt.Sprintf("status::%s", values[0]))
+		case "type", "issuetype":
+			translated = label(fmt.Sprintf("type::%s", values[0]))
+		case "priority":
+			translated = label(fmt.Sprintf("priority::%s", values[0]))
+		case "component":
+			translated = label(fmt.Sprintf("component:%s", values[0]))
+		case "labels":
+			translated = label(values[0])
+		case "statuscategory":
+			if single && value == "done" {
+				if negated {
+					params.Set("state", "opened")
+				} else {
+					params.Set("state", "closed")
+				}
+				translated = true
+			}
+		case "resolution":
+			switch {
+			case (operator == "=" && value == "unresolved") || (operator == "is" && value == "empty"):
+				params.Set("state", "opened")
+				translated = true
+			case (operator == "!=" && value == "unresolved") || (operator == "is not" && value == "empty"):
+				params.Set("state", "closed")
+				translated = true
+			}
+		case "assignee", "reporter":
+			param := "assignee_username"
+			if field == "reporter" {
+				param = "author_username"
+			}
+			if field == "assignee" && value == "empty" && operator == "is" {
+				params.Set("assignee_id", "None")
+				translated = true
+			} else if field == "assignee" && value == "empty" && operator == "is not" {
+				params.Set("assignee_id", "Any")
+				translated = true
+			} else if username, ok := user(values[0]); ok && single {
+				if negated {
+					param = fmt.Sprintf("not[%s]", param)
+				}
+				params.Set(param, username)
+				translated = true
+			}
+		case "fixversion":
+			if value == "empty" && operator == "is" {
+				params.Set("milestone_title", "None")
+				translated = true
+			} else if single && !negated {
+				params.Set("milestone_title", values[0])
+				translated = true
+			}
+		case "sprint":
+			switch {
+			case cfg.GitLab.Iterations.Enabled && operator == "in" && value == "opensprints()":
+				params.Set("iteration_id", "Current")
+				translated = true
+			case !cfg.GitLab.Iterations.Enabled && cfg.Jira.CustomField.Sprint != "" && single && !negated && !strings.HasSuffix(value, "()"):
+				params.Set("milestone_title", values[0])
+				translated = true
+			}
+		case "text", "summary":
+			if operator == "~" && len(values) == 1 {
+				params.Set("search", values[0])
+				if field == "summary" {
+					params.Set("in", "title")
+				}
+				translated = true
+			}
+		}
+		if !translated {
+			search.Untranslated = append(search.Untranslated, clause)
+		}
+	}
+
+	//* Jira filters include resolved issues unless they say otherwise
+	if params.Get("state") == "" {
+		params.Set("state", "all")
+	}
+	search.URL = fmt.Sprintf("%s/-/issues?%s", projectURL, params.Encode())
+	return search
+}
+
+// mentionsProject reports if the JQL of the filter searches the Jira project
+func mentionsProject(jql string, projectKey string) bool {
+	key := `("` + regexp.QuoteMeta(projectKey) + `"|` + regexp.QuoteMeta(projectKey) + `\b)`
+	return regexp.MustCompile(`(?i)\bproject\s*(=|in)\s*(` + key + `|\(([^)]*,)?\s*` + key + `\s*(,[^)]*)?\))`).MatchString(jql)
+}
+
+// writeFiltersReport writes filters.md of the report directory, the saved filters of the Jira project with the GitLab issue search of each
+func writeFiltersReport(ctx context.Context, jr JiraReader, cfg *config.Config, jiraProject *jira.Project, projectURL string, userMap UserMap) error {
+	filters, err := jr.ListFilters(ctx, jiraProject.Key)
+	if err != nil {
+		log.Warnf("Skipping the filters report: %s", err)
+		return nil
+	}
+
+	var searches []*GitLabSearch
+	for _, filter := range filters {
+		if mentionsProject(filter.Jql, jiraProject.Key) {
+			searches = append(searches, translateFilter(cfg, filter, jiraProject.Key, projectURL, userMap))
+		}
+	}
+
+	if err := os.MkdirAll(cfg.ReportDir, 0o755); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating report directory: %s", cfg.ReportDir))
+	}
+	path := filepath.Join(cfg.ReportDir, "filters.md")
+	if err := os.WriteFile(path, []byte(formatFiltersReport(jiraProject, searches)), 0o644); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing filters report: %s", path))
+	}
+	log.Infof("Wrote %d Jira filters to %s", len(searches), path)
+	return nil
+}
+
+func formatFiltersReport(jiraProject *jira.Project, searches []*GitLabSearch) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Jira filters of %s\n\n", jiraProject.Key)
+	b.WriteString("GitLab has no saved searches, bookmark the issue search of each filter. The clauses without a GitLab equivalent are not in the search.\n\n")
+	if len(searches) == 0 {
+		b.WriteString("No filter searches the project.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Filter | Owner | JQL | GitLab | Not translated |\n|---|---|---|---|---|\n")
+	for _, search := range searches {
+		owner := search.Filter.Owner.DisplayName
+		if owner == "" {
+			owner = search.Filter.Owner.Name
+		}
+		untranslated := make([]string, 0, len(search.Untranslated))
+		for _, clause := range search.Untranslated {
+			untranslated = append(untranslated, fmt.Sprintf("`%s`", clause))
+		}
+		fmt.Fprintf(&b, "| %s | %s | `%s` | [Issues](%s) | %s |\n",
+			tableCell(search.Filter.Name), tableCell(orDash(owner)), tableCell(search.Filter.Jql), search.URL, tableCell(orDash(strings.Join(untranslated, ", "))))
+	}
+	return b.String()
+}
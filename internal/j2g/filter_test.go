@@ -0,0 +1,49 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestFiltersReport(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ReportDir = t.TempDir()
+
+	jr := fake.NewJira(&jira.Project{Key: "TEST"})
+	jr.Filters = []*jira.Filter{
+		{Name: "My bugs", Owner: jira.User{DisplayName: "Jeff"}, Jql: `project = TEST AND type = Bug AND assignee = jeff AND resolution = Unresolved ORDER BY created DESC`},
+		{Name: "Hot", Jql: `project in ("TEST", OTHER) AND labels = hot AND "Epic Link" = TEST-1`},
+		{Name: "Either", Jql: `project = TEST OR status = Open`},
+		{Name: "Other", Jql: `project = OTHER AND summary ~ TEST`},
+	}
+	userMap := UserMap{"jeff": &gitlab.User{ID: 1, Username: "jeff.gl"}}
+
+	url := "https://gitlab.example.com/group/project"
+	assert.NoError(t, writeFiltersReport(context.Background(), jr, cfg, jr.Project, url, userMap))
+	content, err := os.ReadFile(filepath.Join(cfg.ReportDir, "filters.md"))
+	assert.NoError(t, err)
+	report := string(content)
+
+	assert.Contains(t, report, "| My bugs | Jeff |")
+	assert.Contains(t, report, url+"/-/issues?assignee_username=jeff.gl&label_name%5B%5D=type%3A%3ABug&sort=created_date&state=opened) | - |")
+	assert.Contains(t, report, url+"/-/issues?label_name%5B%5D=hot&state=all) | `project in (\"TEST\", OTHER)`, `\"Epic Link\" = TEST-1` |")
+	assert.Contains(t, report, "`project = TEST OR status = Open` |")
+	assert.NotContains(t, report, "| Other |")
+}
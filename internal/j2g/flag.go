@@ -0,0 +1,59 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"fmt"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// BlockedLabel is the label of the issues flagged in Jira (jira.custom_field.flagged)
+const BlockedLabel = "blocked"
+
+func isFlagged(cfg *config.Config, jiraIssue *jira.Issue) bool {
+	return cfg.Jira.CustomField.Flagged != "" && jirax.IsFlagged(jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.Flagged])
+}
+
+// formatFlagNote returns the note of the flag comment (the impediment), nil if the issue is not flagged or the flag has no comment
+func formatFlagNote(cfg *config.Config, jiraIssue *jira.Issue, userMap UserMap, attachments AttachmentMap, isProject bool) (*string, []string, error) {
+	if !isFlagged(cfg, jiraIssue) {
+		return nil, nil, nil
+	}
+
+	flagComment := jirax.FlagComment(jiraIssue)
+	if flagComment == nil {
+		return nil, nil, nil
+	}
+
+	//* "(flag) Flag added" is the first line
+	reason := ""
+	if lines := strings.SplitN(strings.TrimSpace(flagComment.Body), "\n", 2); len(lines) == 2 {
+		reason = strings.TrimSpace(lines[1])
+	}
+
+	markdown, usedAttachments, err := textToGitLabMarkdown(reason, userMap, attachments, isProject)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error converting Text to GitLab Markdown")
+	}
+
+	commentLink := fmt.Sprintf("%s/browse/%s?focusedCommentId=%s", cfg.Jira.Host, jiraIssue.Key, flagComment.ID)
+	result := fmt.Sprintf(":triangular_flag_on_post: **Flagged as an impediment in Jira** [[Original](%s)]", commentLink)
+	if markdown != "" {
+		result += "\n\n" + markdown
+	}
+	return &result, usedAttachments, nil
+}
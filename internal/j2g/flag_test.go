@@ -0,0 +1,46 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestFlaggedIssue(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.Flagged = "customfield_10021"
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10021": []interface{}{map[string]interface{}{"value": "Impediment"}}}
+	jiraIssue.Fields.Comments.Comments = append(jiraIssue.Fields.Comments.Comments,
+		&jira.Comment{ID: "101", Body: "(flag) Flag added\n\nWaiting for the API key", Created: "2023-09-06T12:00:00.000+0900"})
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Contains(t, gitlabIssue.Labels, BlockedLabel)
+
+	flagNotes := 0
+	for _, note := range gl.IssueNotes[gitlabIssue.ID] {
+		if strings.HasPrefix(note.Body, ":triangular_flag_on_post: **Flagged as an impediment in Jira**") {
+			flagNotes++
+			assert.Contains(t, note.Body, "Waiting for the API key")
+		}
+	}
+	assert.Equal(t, 1, flagNotes)
+}
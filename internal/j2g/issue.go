@@ -12,6 +12,7 @@
 package j2g
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -22,12 +23,12 @@ import (
 	gitlab "github.com/xanzy/go-gitlab"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
-	"golang.org/x/sync/errgroup"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
 )
 
-func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue *jira.Issue, userMap UserMap, existingLabels map[string]string, existingMilestone map[string]*Milestone) (*gitlab.Issue, error) {
+func ConvertJiraIssueToGitLabIssue(ctx context.Context, gl GitLabWriter, jr JiraReader, jiraIssue *jira.Issue, userMap UserMap, existingLabels map[string]string, existingMilestone map[string]*Milestone) (*gitlab.Issue, error) {
 	log := logrus.WithField("jiraIssue", jiraIssue.Key)
-	var g errgroup.Group
+	var g workerGroup
 	g.SetLimit(5)
 	mutex := sync.RWMutex{}
 
@@ -38,15 +39,29 @@ func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue
 
 	pid := cfg.GitLab.Issue
 
-	labels, err := convertJiraToGitLabLabels(gl, pid, jiraIssue, existingLabels, false)
+	labelID, isGroupLabel := issueLabelTarget(cfg)
+	labels, err := convertJiraToGitLabLabels(ctx, gl, labelID, jiraIssue, existingLabels, isGroupLabel)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("Error converting Jira labels to GitLab labels: issue %s", jiraIssue.Key))
 	}
 
+	//* Epic migrated as an issue (gitlab.epic_mode: issue)
+	if isJiraEpic(cfg, jiraIssue) {
+		if err := ensureLabel(ctx, gl, labelID, EpicLabel, "Jira epic", existingLabels, isGroupLabel); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating epic label: issue %s", jiraIssue.Key))
+		}
+		*labels = append(*labels, EpicLabel)
+	}
+
+	title := jiraIssue.Fields.Summary
+	if isJiraEpic(cfg, jiraIssue) {
+		title = epicTitle(cfg, jiraIssue)
+	}
+
 	gitlabCreateIssueOptions := &gitlabx.CreateIssueOptions{
-		Title:     &jiraIssue.Fields.Summary,
+		Title:     &title,
 		CreatedAt: (*time.Time)(&jiraIssue.Fields.Created),
-		DueDate:   (*gitlab.ISOTime)(&jiraIssue.Fields.Duedate),
+		DueDate:   isoDate(time.Time(jiraIssue.Fields.Duedate)),
 		Labels:    labels,
 	}
 
@@ -57,7 +72,7 @@ func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue
 	for _, jiraAttachment := range jiraIssue.Fields.Attachments {
 		g.Go(func(jiraAttachment *jira.Attachment) func() error {
 			return func() error {
-				attachment, err := convertJiraAttachmentToMarkdown(gl, jr, pid, jiraAttachment)
+				attachment, err := convertJiraAttachmentToMarkdown(ctx, gl, jr, pid, jiraAttachment)
 				if err != nil {
 					return errors.Wrap(err, fmt.Sprintf("Error converting Jira attachment to GitLab Markdown: %s on issue %s", jiraAttachment.Filename, jiraIssue.Key))
 				}
@@ -80,6 +95,38 @@ func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("Error formatting description: issue %s", jiraIssue.Key))
 	}
+
+	//* Remote Link -> Description
+	remoteLinks, err := formatRemoteLinks(ctx, jr, jiraIssue)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error formatting remote links: issue %s", jiraIssue.Key))
+	}
+	*description += remoteLinks
+
+	//* Development Panel -> Description
+	devStatus, err := formatDevStatus(ctx, jr, jiraIssue)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error formatting development information: issue %s", jiraIssue.Key))
+	}
+	*description += devStatus
+
+	//* Test -> Test Case, the steps become a checklist and the test runs a table
+	if isJiraTestCase(cfg, jiraIssue) {
+		steps, err := formatTestSteps(cfg, jiraIssue, userMap)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error formatting test steps: issue %s", jiraIssue.Key))
+		}
+		*description += steps
+
+		results, err := formatTestRuns(ctx, jr, cfg, jiraIssue, userMap)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error formatting test runs: issue %s", jiraIssue.Key))
+		}
+		*description += results
+		gitlabCreateIssueOptions.IssueType = gitlab.String(testCaseIssueType)
+	} else if isJiraIncident(cfg, jiraIssue) {
+		gitlabCreateIssueOptions.IssueType = gitlab.String(incidentIssueType)
+	}
 	gitlabCreateIssueOptions.Description = description
 
 	for _, attachment := range usedImages {
@@ -88,7 +135,7 @@ func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue
 
 	//* Assignee
 	if jiraIssue.Fields.Assignee != nil {
-		if assignee, ok := userMap[jiraIssue.Fields.Assignee.Name]; ok {
+		if assignee, ok := userMap[jiraIssue.Fields.Assignee.Name]; ok && !isUnmappedUser(assignee) {
 			gitlabCreateIssueOptions.AssigneeIDs = &[]int{assignee.ID}
 			gitlabCreateIssueOptions.AssigneeID = &assignee.ID
 		}
@@ -104,6 +151,18 @@ func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue
 		gitlabCreateIssueOptions.MilestoneID = &milestone.ID
 	}
 
+	//* Sprint -> Milestone, takes the place of the version for the burndown chart of the sprint
+	if cfg.Jira.CustomField.Sprint != "" && !cfg.GitLab.Iterations.Enabled {
+		if sprints := jirax.SprintNames(jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.Sprint]); len(sprints) > 0 {
+			sprint := sprints[len(sprints)-1]
+			if milestone, ok := existingMilestone[sprint]; ok {
+				gitlabCreateIssueOptions.MilestoneID = &milestone.ID
+			} else {
+				log.Warnf("Milestone of sprint %s is not found", sprint)
+			}
+		}
+	}
+
 	//* Storypoint -> Weight (if custom field is provided)
 	if cfg.Jira.CustomField.StoryPoint != "" {
 		storyPoint, ok := jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.StoryPoint].(float64)
@@ -116,59 +175,126 @@ func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue
 	}
 
 	//* 이슈를 생성합니다.
-	gitlabIssue, _, err := gitlabx.CreateIssue(gl, pid, gitlabCreateIssueOptions)
+	gitlabIssue, err := createIssue(ctx, gl, cfg, pid, jiraIssue.Key, gitlabCreateIssueOptions)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("Error creating GitLab issue: issue %s", jiraIssue.Key))
 	}
 	log.Debugf("Created GitLab issue: %d from Jira issue: %s", gitlabIssue.IID, jiraIssue.Key)
 
 	//* Comment -> Comment
-	for _, jiraComment := range jiraIssue.Fields.Comments.Comments {
-		g.Go(func(jiraComment *jira.Comment) func() error {
-			return func() error {
-				note, created, usedImages, err := formatNote(jiraIssue.Key, jiraComment, userMap, attachments, true)
-				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("Error formatting note: issue %s", jiraIssue.Key))
-				}
+	if err := loadComments(ctx, jr, cfg, jiraIssue); err != nil {
+		return nil, err
+	}
+	var parents map[string]string
+	if cfg.GitLab.ThreadedComments {
+		parents, err = jr.GetCommentParents(ctx, jiraIssue.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting comment replies: issue %s", jiraIssue.Key))
+		}
+	}
+	formatComment := func(jiraComment *jira.Comment) (*gitlabx.CreateIssueNoteOptions, error) {
+		note, created, usedImages, err := formatNote(jiraIssue.Key, jiraComment, userMap, attachments, true)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error formatting note: issue %s", jiraIssue.Key))
+		}
 
-				for _, attachment := range usedImages {
-					mutex.Lock()
-					usedAttachment[attachment] = true
-					mutex.Unlock()
-				}
+		for _, attachment := range usedImages {
+			mutex.Lock()
+			usedAttachment[attachment] = true
+			mutex.Unlock()
+		}
 
-				options := gitlab.CreateIssueNoteOptions{
-					Body:      note,
-					CreatedAt: created,
-				}
+		note, internal := convertCommentVisibility(cfg, jiraIssue.Key, jiraComment, note)
+		if note == nil {
+			return nil, nil
+		}
 
-				_, _, err = gl.Notes.CreateIssueNote(pid, gitlabIssue.IID, &options)
-				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("Error creating note: issue %s", jiraIssue.Key))
+		return &gitlabx.CreateIssueNoteOptions{
+			Body:      note,
+			CreatedAt: created,
+			Internal:  gitlab.Bool(internal),
+		}, nil
+	}
+
+	if len(parents) > 0 {
+		//* A reply needs the discussion of its parent, the comments are created in order
+		comments := sortCommentsByCreated(jiraIssue.Fields.Comments.Comments)
+		if err := createThreadedNotes(ctx, gl, pid, gitlabIssue.IID, comments, parents, cfg.GitLab.MaxNoteSize, formatComment); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating GitLab issue: issue %s", jiraIssue.Key))
+		}
+	} else {
+		for _, jiraComment := range jiraIssue.Fields.Comments.Comments {
+			g.Go(func(jiraComment *jira.Comment) func() error {
+				return func() error {
+					options, err := formatComment(jiraComment)
+					if err != nil || options == nil {
+						return err
+					}
+
+					//* The parts of a long comment have the same date, they are in the order of creation
+					for _, body := range splitNoteBody(*options.Body, cfg.GitLab.MaxNoteSize) {
+						part := *options
+						part.Body = gitlab.String(body)
+						if _, _, err := gl.CreateIssueNote(ctx, pid, gitlabIssue.IID, &part); err != nil {
+							return errors.Wrap(err, fmt.Sprintf("Error creating note: issue %s", jiraIssue.Key))
+						}
+					}
+					return nil
 				}
-				return nil
-			}
-		}(jiraComment))
+			}(jiraComment))
+		}
 	}
 
 	if err := g.Wait(); err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("Error creating GitLab issue: issue %s", jiraIssue.Key))
 	}
 
+	//* Flag -> Note
+	flagNote, usedImages, err := formatFlagNote(cfg, jiraIssue, userMap, attachments, true)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error formatting flag note: issue %s", jiraIssue.Key))
+	}
+	if flagNote != nil {
+		for _, attachment := range usedImages {
+			usedAttachment[attachment] = true
+		}
+
+		if _, _, err := gl.CreateIssueNote(ctx, pid, gitlabIssue.IID, &gitlabx.CreateIssueNoteOptions{Body: flagNote}); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating flag note: issue %s", jiraIssue.Key))
+		}
+	}
+
 	//* Reamin Attachment -> Comment
-	for id, markdown := range attachments {
-		if used, ok := usedAttachment[id]; ok || used {
-			continue
+	orphans := orphanAttachments(attachments, usedAttachment)
+	if cfg.OrphanAttachments != config.OrphanAttachmentsSeparate && len(orphans) > 0 {
+		// the note is created with the last attachment
+		createdAt, err := attachmentCreatedAt(orphans[len(orphans)-1])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error parsing time: issue %s", jiraIssue.Key))
 		}
 
-		createdAt, err := time.Parse("2006-01-02T15:04:05.000-0700", markdown.CreatedAt)
+		body := formatAttachmentsNote(orphans)
+		g.Go(func() error {
+			_, _, err := gl.CreateIssueNote(ctx, pid, gitlabIssue.IID, &gitlabx.CreateIssueNoteOptions{
+				Body:      &body,
+				CreatedAt: &createdAt,
+			})
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error creating attachments note: issue %s", jiraIssue.Key))
+			}
+			return nil
+		})
+		orphans = nil
+	}
+	for _, markdown := range orphans {
+		createdAt, err := attachmentCreatedAt(markdown)
 		if err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("Error parsing time: issue %s", jiraIssue.Key))
 		}
 
 		g.Go(func(attachment *Attachment) func() error {
 			return func() error {
-				_, _, err = gl.Notes.CreateIssueNote(pid, gitlabIssue.IID, &gitlab.CreateIssueNoteOptions{
+				_, _, err = gl.CreateIssueNote(ctx, pid, gitlabIssue.IID, &gitlabx.CreateIssueNoteOptions{
 					Body:      &attachment.Markdown,
 					CreatedAt: &createdAt,
 				})
@@ -184,9 +310,28 @@ func ConvertJiraIssueToGitLabIssue(gl *gitlab.Client, jr *jira.Client, jiraIssue
 		return nil, errors.Wrap(err, fmt.Sprintf("Error creating GitLab issue: issue %s", jiraIssue.Key))
 	}
 
+	//* Worklog -> /spend
+	if err := migrateWorklogs(ctx, gl, cfg, jiraIssue, gitlabIssue, userMap); err != nil {
+		return nil, err
+	}
+
+	//* JSM Approval -> Note
+	if cfg.Jira.ServiceDesk {
+		if err := migrateApprovals(ctx, gl, jr, cfg, jiraIssue, gitlabIssue, userMap); err != nil {
+			return nil, err
+		}
+	}
+
+	//* Jira JSON -> Note
+	if cfg.RawJSON != "" {
+		if err := addRawJSON(ctx, gl, cfg, jiraIssue, gitlabIssue); err != nil {
+			return nil, err
+		}
+	}
+
 	//* Resolution -> Close issue (CloseAt)
 	if jiraIssue.Fields.Resolution != nil {
-		gl.Issues.UpdateIssue(pid, gitlabIssue.IID, &gitlab.UpdateIssueOptions{
+		gl.UpdateIssue(ctx, pid, gitlabIssue.IID, &gitlab.UpdateIssueOptions{
 			StateEvent: gitlab.String("close"),
 			UpdatedAt:  (*time.Time)(&jiraIssue.Fields.Resolutiondate), // 적용안됨
 		})
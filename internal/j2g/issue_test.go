@@ -0,0 +1,175 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func newTestJiraIssue() *jira.Issue {
+	return &jira.Issue{
+		ID:  "10001",
+		Key: "TEST-1",
+		Fields: &jira.IssueFields{
+			Summary:     "Login fails",
+			Description: "Steps in *bold*",
+			Type:        jira.IssueType{Name: "Bug"},
+			Status:      &jira.Status{Name: "Done"},
+			Priority:    &jira.Priority{Name: "High"},
+			Resolution:  &jira.Resolution{Name: "Fixed"},
+			Attachments: []*jira.Attachment{
+				{ID: "1", Filename: "log.txt", Created: "2023-09-06T10:00:00.000+0900"},
+			},
+			Comments: &jira.Comments{
+				Comments: []*jira.Comment{
+					{ID: "100", Body: "Reproduced", Author: jira.User{DisplayName: "Jeff"}, Created: "2023-09-06T11:00:00.000+0900"},
+				},
+			},
+		},
+	}
+}
+
+func TestConvertJiraIssueToGitLabIssue(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	jiraIssue := newTestJiraIssue()
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Attachments["1"] = []byte("log")
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Login fails", gitlabIssue.Title)
+	assert.Contains(t, gitlabIssue.Description, "Imported from Jira [TEST-1](https://jira.example.com/browse/TEST-1)")
+	assert.ElementsMatch(t, []string{"type::Bug", "status::Done", "priority::High"}, gitlabIssue.Labels)
+	assert.Equal(t, "closed", gitlabIssue.State)
+	assert.Equal(t, []string{"log.txt"}, gl.Uploads[2])
+	assert.Len(t, gl.Labels[2], 3)
+
+	// Comment + the attachment which is not used in the description
+	notes := gl.IssueNotes[gitlabIssue.ID]
+	assert.Len(t, notes, 2)
+}
+
+func TestCommentPagination(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	var comments []*jira.Comment
+	for i := 0; i < 120; i++ {
+		comments = append(comments, &jira.Comment{ID: fmt.Sprint(100 + i), Body: fmt.Sprintf("Comment %d", i), Author: jira.User{DisplayName: "Jeff"}, Created: "2023-09-06T11:00:00.000+0900"})
+	}
+	jiraIssue.Fields.Comments = &jira.Comments{Comments: comments[:jirax.SearchCommentLimit]}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Comments[jiraIssue.Key] = comments
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Len(t, gl.IssueNotes[gitlabIssue.ID], 120)
+}
+
+func TestServiceDeskInternalComments(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.ServiceDesk = true
+	cfg.RestrictedComments = config.RestrictedCommentsPublic
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Comments["TEST-1"] = []*jira.Comment{
+		{ID: "100", Body: "Public reply", Created: "2023-09-06T11:00:00.000+0900",
+			Properties: []jira.EntityProperty{{Key: "sd.public.comment", Value: map[string]interface{}{"internal": false}}}},
+		{ID: "101", Body: "Internal note", Created: "2023-09-06T12:00:00.000+0900",
+			Properties: []jira.EntityProperty{{Key: "sd.public.comment", Value: map[string]interface{}{"internal": true}}}},
+		{ID: "102", Body: "Developers only", Created: "2023-09-06T13:00:00.000+0900",
+			Visibility: jira.CommentVisibility{Type: "role", Value: "Developers"}},
+	}
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+
+	internal := make(map[string]bool)
+	for _, note := range gl.IssueNotes[gitlabIssue.ID] {
+		internal[strings.SplitN(note.Body, "\n", 2)[0]] = gl.InternalNotes[note.ID]
+	}
+	// restricted_comments: public doesn't make a JSM internal comment public
+	assert.Equal(t, map[string]bool{"Public reply": false, "Internal note": true, "Developers only": false}, internal)
+}
+
+func TestRestrictedComments(t *testing.T) {
+	comments := []*jira.Comment{
+		{ID: "100", Body: "Everyone", Created: "2023-09-06T11:00:00.000+0900"},
+		{ID: "101", Body: "Developers only", Created: "2023-09-06T12:00:00.000+0900",
+			Visibility: jira.CommentVisibility{Type: "role", Value: "Developers"}},
+	}
+	placeholder := "_A comment restricted to the Jira role Developers is not migrated._ [[Original](https://jira.example.com/browse/TEST-1?focusedCommentId=101)]"
+
+	tests := []struct {
+		policy   string
+		internal map[string]bool
+	}{
+		{config.RestrictedCommentsInternal, map[string]bool{"Everyone": false, "Developers only": true}},
+		{config.RestrictedCommentsPlaceholder, map[string]bool{"Everyone": false, placeholder: false}},
+		{config.RestrictedCommentsSkip, map[string]bool{"Everyone": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			cfg, gl := newTestEnv(t)
+			cfg.Jira.Host = "https://jira.example.com"
+			cfg.RestrictedComments = tt.policy
+
+			jiraIssue := newTestJiraIssue()
+			jiraIssue.Fields.Attachments = nil
+			jiraIssue.Fields.Comments = &jira.Comments{Comments: comments}
+			jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+			gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+			assert.NoError(t, err)
+
+			internal := make(map[string]bool)
+			for _, note := range gl.IssueNotes[gitlabIssue.ID] {
+				internal[strings.SplitN(note.Body, "\n", 2)[0]] = gl.InternalNotes[note.ID]
+			}
+			assert.Equal(t, tt.internal, internal)
+		})
+	}
+}
+
+func TestSplitLongComment(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.GitLab.MaxNoteSize = 1000
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Comments.Comments[0].Body = "Server log:\n{code}\n" + strings.Repeat("ERROR connection refused\n", 100) + "{code}"
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	notes := gl.IssueNotes[gitlabIssue.ID]
+	if assert.Len(t, notes, 3) {
+		for i, note := range notes {
+			assert.LessOrEqual(t, len(note.Body), cfg.GitLab.MaxNoteSize)
+			assert.True(t, strings.HasPrefix(note.Body, fmt.Sprintf("(part %d/3)", i+1)))
+			assert.Equal(t, 0, strings.Count(note.Body, "```")%2)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+const issueTemplatesPath = ".gitlab/issue_templates"
+
+// Fields of the create screen that GitLab has in the issue form (title, assignee, labels, ...)
+var issueFormFields = map[string]bool{
+	"summary":     true,
+	"description": true,
+	"issuetype":   true,
+	"project":     true,
+	"reporter":    true,
+	"assignee":    true,
+	"labels":      true,
+	"attachment":  true,
+	"issuelinks":  true,
+	"parent":      true,
+	"duedate":     true,
+}
+
+// writeIssueTemplates commits a description template for each Jira issue type missing in .gitlab/issue_templates
+func writeIssueTemplates(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config, jiraProject *jira.Project, gitlabProject *gitlab.Project) error {
+	issueTypes, _, err := jr.GetCreateMeta(ctx, jiraProject.Key)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error getting create screens: %s", jiraProject.Key))
+	}
+
+	branch := cfg.IssueTemplates.Branch
+	if branch == "" {
+		branch = gitlabProject.DefaultBranch
+	}
+	if branch == "" {
+		branch = "main"
+	}
+
+	var actions []*gitlab.CommitActionOptions
+	for _, issueType := range issueTypes {
+		if issueType.Subtask {
+			continue
+		}
+
+		path := fmt.Sprintf("%s/%s.md", issueTemplatesPath, strings.ReplaceAll(issueType.Name, "/", "-"))
+		_, r, err := gl.GetFileMetaData(ctx, gitlabProject.ID, path, branch)
+		if err == nil {
+			log.Infof("Issue template already exists: %s", path)
+			continue
+		}
+		if r == nil || r.StatusCode != http.StatusNotFound {
+			return errors.Wrap(err, fmt.Sprintf("Error getting issue template: %s", path))
+		}
+
+		actions = append(actions, &gitlab.CommitActionOptions{
+			Action:   gitlab.FileAction(gitlab.FileCreate),
+			FilePath: gitlab.String(path),
+			Content:  gitlab.String(formatIssueTemplate(issueType)),
+		})
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+
+	log.Infof("Creating %d issue templates on %s", len(actions), branch)
+	_, _, err = gl.CreateCommit(ctx, gitlabProject.ID, &gitlab.CreateCommitOptions{
+		Branch:        gitlab.String(branch),
+		CommitMessage: gitlab.String(fmt.Sprintf("Add issue templates of the Jira project %s", jiraProject.Key)),
+		Actions:       actions,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Error committing issue templates")
+	}
+	return nil
+}
+
+// formatIssueTemplate renders a section for each field of the create screen that the issue form doesn't have.
+// Required fields come first, the allowed values are a task list.
+func formatIssueTemplate(issueType *jirax.CreateMetaIssueType) string {
+	ids := make([]string, 0, len(issueType.Fields))
+	for id := range issueType.Fields {
+		if !issueFormFields[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := issueType.Fields[ids[i]], issueType.Fields[ids[j]]
+		if a.Required != b.Required {
+			return a.Required
+		}
+		return a.Name < b.Name
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- Jira issue type %s", issueType.Name)
+	if issueType.Description != "" {
+		fmt.Fprintf(&b, ": %s", issueType.Description)
+	}
+	b.WriteString(" -->\n\n## Description\n\n\n")
+
+	for _, id := range ids {
+		field := issueType.Fields[id]
+		fmt.Fprintf(&b, "## %s", field.Name)
+		if field.Required {
+			b.WriteString(" (required)")
+		}
+		b.WriteString("\n\n")
+
+		for _, allowed := range field.AllowedValues {
+			value := allowed.Value
+			if value == "" {
+				value = allowed.Name
+			}
+			fmt.Fprintf(&b, "- [ ] %s\n", value)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "/label ~\"type::%s\"\n", issueType.Name)
+	return b.String()
+}
@@ -0,0 +1,53 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func TestIssueTemplates(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.IssueTemplates.Enabled = true
+
+	gl := fake.NewGitLab()
+	gl.AddGroup(1, "group")
+	gitlabProject := gl.AddProject(2, "group/project")
+	gl.Files[2] = map[string]string{"main/.gitlab/issue_templates/Task.md": "kept"}
+
+	project := &jira.Project{Key: "TEST"}
+	jr := fake.NewJira(project)
+	severity := &jirax.CreateMetaField{Name: "Severity", Required: true, AllowedValues: []jirax.CreateMetaValue{{Value: "Critical"}}}
+	jr.CreateMeta = []*jirax.CreateMetaIssueType{
+		{Name: "Bug", Fields: map[string]*jirax.CreateMetaField{
+			"summary":           {Name: "Summary", Required: true},
+			"environment":       {Name: "Environment"},
+			"customfield_10100": severity,
+		}},
+		{Name: "Task", Fields: map[string]*jirax.CreateMetaField{"summary": {Name: "Summary"}}},
+		{Name: "Sub-task", Subtask: true},
+	}
+
+	assert.NoError(t, writeIssueTemplates(context.Background(), gl, jr, cfg, project, gitlabProject))
+	assert.Len(t, gl.Files[2], 2)
+	assert.Equal(t, "kept", gl.Files[2]["main/.gitlab/issue_templates/Task.md"])
+
+	bug := gl.Files[2]["main/.gitlab/issue_templates/Bug.md"]
+	assert.NotContains(t, bug, "## Summary")
+	assert.Contains(t, bug, "## Severity (required)\n\n- [ ] Critical\n\n## Environment\n\n")
+	assert.Contains(t, bug, `/label ~"type::Bug"`)
+}
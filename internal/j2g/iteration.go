@@ -0,0 +1,124 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// migrateSprintsToIterations creates an iteration of the cadence for each Jira sprint. Key: sprint name
+func migrateSprintsToIterations(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config) (map[string]*gitlabx.Iteration, error) {
+	groupPath := cfg.GitLab.Epic
+	cadenceTitle := cfg.GitLab.Iterations.Cadence
+
+	//* Cadence
+	cadence, _, err := gitlabx.FindOrCreate(func() (*gitlabx.IterationCadence, error) {
+		cadences, err := gl.ListIterationCadences(ctx, groupPath)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting iteration cadences: %s", groupPath))
+		}
+		return gitlabx.FindByName(cadences, func(c *gitlabx.IterationCadence) string { return c.Title }, cadenceTitle), nil
+	}, func() (*gitlabx.IterationCadence, *gitlab.Response, error) {
+		log.Infof("Creating iteration cadence: %s", cadenceTitle)
+		cadence, err := gl.CreateIterationCadence(ctx, groupPath, &gitlabx.CreateIterationCadenceOptions{
+			Title:       cadenceTitle,
+			Description: fmt.Sprintf("Sprints of Jira project %s", cfg.Jira.Name),
+		})
+		return cadence, nil, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating iteration cadence: %s", cadenceTitle))
+	}
+
+	existingIterations, err := gl.ListIterations(ctx, groupPath, cadence.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting iterations: %s", cadenceTitle))
+	}
+
+	//* Sprints, created in order because the iterations of a cadence can't overlap
+	sprints, err := jr.ListSprints(ctx, cfg.Jira.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira sprints: %s", cfg.Jira.Name))
+	}
+	sort.SliceStable(sprints, func(i, j int) bool {
+		if sprints[i].StartDate == nil || sprints[j].StartDate == nil {
+			return sprints[j].StartDate == nil && sprints[i].StartDate != nil
+		}
+		return sprints[i].StartDate.Before(*sprints[j].StartDate)
+	})
+
+	iterations := make(map[string]*gitlabx.Iteration)
+	for _, sprint := range sprints {
+		if iteration := gitlabx.FindByName(existingIterations, func(i *gitlabx.Iteration) string { return i.Title }, sprint.Name); iteration != nil {
+			log.Infof("Iteration already exists: %s", sprint.Name)
+			iterations[sprint.Name] = iteration
+			continue
+		}
+
+		//* A future sprint doesn't have dates yet
+		if sprint.StartDate == nil || sprint.EndDate == nil {
+			log.Warnf("Skipping sprint %s: iterations require the start and end date", sprint.Name)
+			continue
+		}
+		startDate, dueDate := isoDate(*sprint.StartDate), isoDate(*sprint.EndDate)
+		checkDateRange(cfg, fmt.Sprintf("iteration %s", sprint.Name), &startDate, &dueDate)
+		if startDate == nil || dueDate == nil {
+			log.Warnf("Skipping sprint %s: iterations require the start and end date", sprint.Name)
+			continue
+		}
+
+		log.Infof("Creating iteration: %s", sprint.Name)
+		iteration, err := gl.CreateIteration(ctx, groupPath, cadence.ID, &gitlabx.CreateIterationOptions{
+			Title:     sprint.Name,
+			StartDate: startDate.String(),
+			DueDate:   dueDate.String(),
+		})
+		if err != nil {
+			// e.g. sprints of parallel boards overlap
+			log.Warnf("Skipping sprint %s: %s", sprint.Name, err)
+			continue
+		}
+		iterations[sprint.Name] = iteration
+	}
+
+	return iterations, nil
+}
+
+// setIssueIteration sets the iteration of the last sprint of the Jira issue
+func setIssueIteration(ctx context.Context, gl GitLabWriter, cfg *config.Config, jiraIssue *jira.Issue, gitlabIssue *gitlab.Issue, iterations map[string]*gitlabx.Iteration) error {
+	sprints := jirax.SprintNames(jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.Sprint])
+	if len(sprints) == 0 {
+		return nil
+	}
+
+	sprint := sprints[len(sprints)-1]
+	iteration, ok := iterations[sprint]
+	if !ok {
+		log.Warnf("Iteration of sprint %s is not found: issue %s", sprint, jiraIssue.Key)
+		return nil
+	}
+
+	if err := gl.SetIssueIteration(ctx, cfg.GitLab.Issue, gitlabIssue.IID, iteration.ID); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error setting iteration %s: issue %s", sprint, jiraIssue.Key))
+	}
+	return nil
+}
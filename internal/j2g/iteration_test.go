@@ -0,0 +1,76 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+func TestMigrateSprintsToIterations(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.Sprint = "customfield_10020"
+	cfg.GitLab.Iterations.Enabled = true
+	cfg.GitLab.Iterations.Cadence = "Jira sprints"
+
+	date := func(day int) *time.Time {
+		d := time.Date(2023, 9, day, 9, 0, 0, 0, time.UTC)
+		return &d
+	}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"})
+	//* Listed out of order, the iterations of a cadence are created by their dates
+	jr.Sprints = []jira.Sprint{
+		{ID: 2, Name: "Sprint 2", StartDate: date(15), EndDate: date(28)},
+		{ID: 1, Name: "Sprint 1", StartDate: date(1), EndDate: date(14)},
+		{ID: 3, Name: "Parallel board", StartDate: date(10), EndDate: date(20)},
+		{ID: 4, Name: "Future sprint"},
+	}
+	ctx := context.Background()
+
+	iterations, err := migrateSprintsToIterations(ctx, gl, jr, cfg)
+	assert.NoError(t, err)
+	assert.Len(t, iterations, 2)
+	if assert.Len(t, gl.IterationCadences["group"], 1) && assert.Len(t, gl.Iterations[gl.IterationCadences["group"][0].ID], 2) {
+		created := gl.Iterations[gl.IterationCadences["group"][0].ID]
+		assert.Equal(t, "Sprint 1", created[0].Title)
+		assert.Equal(t, "2023-09-01", created[0].StartDate)
+		assert.Equal(t, "2023-09-14", created[0].DueDate)
+		assert.Equal(t, "Sprint 2", created[1].Title)
+	}
+
+	//* A rerun finds the cadence and the iterations
+	again, err := migrateSprintsToIterations(ctx, gl, jr, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, iterations, again)
+	assert.Len(t, gl.IterationCadences["group"], 1)
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10020": []interface{}{
+		map[string]interface{}{"name": "Sprint 1"},
+		map[string]interface{}{"name": "Sprint 2"},
+	}}
+	gitlabIssue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: gitlab.String("TEST-1")})
+	assert.NoError(t, err)
+	assert.NoError(t, setIssueIteration(ctx, gl, cfg, jiraIssue, gitlabIssue, iterations))
+	assert.Equal(t, iterations["Sprint 2"].ID, gl.IssueIterations[gitlabIssue.ID])
+
+	//* Without an iteration the issue is kept as it is
+	jiraIssue.Fields.Unknowns["customfield_10020"] = []interface{}{map[string]interface{}{"name": "Future sprint"}}
+	assert.NoError(t, setIssueIteration(ctx, gl, cfg, jiraIssue, gitlabIssue, iterations))
+	assert.Equal(t, iterations["Sprint 2"].ID, gl.IssueIterations[gitlabIssue.ID])
+}
@@ -22,39 +22,55 @@ import (
 	gitlab "github.com/xanzy/go-gitlab"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
-	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
-	"golang.org/x/sync/errgroup"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/quarantine"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
 )
 
-func GetJiraIssues(jr *jira.Client, jiraProjectID string, jql string) ([]*jira.Issue, []*jira.Issue, error) {
+// GetJiraIssues returns the epics and the other issues of the Jira project (jira.epic)
+func GetJiraIssues(ctx context.Context, jr JiraReader, cfg *config.Config) ([]*jira.Issue, []*jira.Issue, error) {
 	//* JQL
 	var prefixJql string
-	if jql != "" {
-		prefixJql = fmt.Sprintf("(%s) AND", jql)
+	if cfg.Jira.Jql != "" {
+		prefixJql = fmt.Sprintf("(%s) AND", cfg.Jira.Jql)
 	} else {
 		prefixJql = ""
 	}
 
-	//* Get Jira Issues for Epic
-	epicJql := fmt.Sprintf("%s project = %s AND type = Epic Order by key ASC", prefixJql, jiraProjectID)
-	jiraEpics, err := jirax.UnpaginateIssue(jr, epicJql)
+	//* The epic issue types can be localized or detected by a field, the issues are split after the search
+	jql := fmt.Sprintf("%s project = %s Order by key ASC", prefixJql, cfg.Jira.Name)
+	result, err := jr.SearchIssues(ctx, jql)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "Error getting Jira issues for GitLab Epics")
+		return nil, nil, errors.Wrap(err, "Error getting Jira issues")
 	}
 
-	//* Get Jira Issues for Issue
-	issueJql := fmt.Sprintf("%s project = %s AND type != Epic Order by key ASC", prefixJql, jiraProjectID)
-	jiraIssues, err := jirax.UnpaginateIssue(jr, issueJql)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "Error getting Jira issues for GitLab Issues")
+	var jiraEpics, jiraIssues []*jira.Issue
+	for _, jiraIssue := range result {
+		if isJiraEpic(cfg, jiraIssue) {
+			jiraEpics = append(jiraEpics, jiraIssue)
+		} else {
+			jiraIssues = append(jiraIssues, jiraIssue)
+		}
 	}
 
 	return jiraEpics, jiraIssues, nil
 }
 
+// runsPhase is true if the phase runs (run --only), every phase runs by default
+func runsPhase(cfg *config.Config, phase string) bool {
+	return cfg.Only == "" || cfg.Only == phase
+}
+
+// ErrInterrupted is returned when the migration is stopped by a signal. The progress is kept in the state file.
+var ErrInterrupted = errors.New("Migration interrupted")
+
+// ErrQuarantined is returned when the run went on after failed items, they are kept in the quarantine file for retry-failed.
+var ErrQuarantined = errors.New("Items are quarantined")
+
 // ! Entry
-func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
-	var g errgroup.Group
+func ConvertByProject(ctx context.Context, gl GitLabWriter, jr JiraReader) error {
+	var g workerGroup
 	g.SetLimit(5)
 	mutex := sync.RWMutex{}
 
@@ -63,43 +79,178 @@ func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
 		return errors.Wrap(err, "Error getting config")
 	}
 
+	//* A run per route
+	if len(cfg.Routes) > 0 && cfg.RouteIndex == 0 {
+		return migrateRoutes(ctx, gl, jr, cfg)
+	}
+
+	if cfg.MetadataTable.Enabled {
+		if _, err := ParseMetadataTemplate(cfg.MetadataTable.Template); err != nil {
+			return errors.Wrap(err, "Error parsing metadata_table.template")
+		}
+	}
+	if _, err := ParseDescriptionTemplate("header", cfg.Description.Header); err != nil {
+		return errors.Wrap(err, "Error parsing description.header")
+	}
+	if _, err := ParseDescriptionTemplate("footer", cfg.Description.Footer); err != nil {
+		return errors.Wrap(err, "Error parsing description.footer")
+	}
+
+	//* Scratch directory of the attachments
+	closeScratch, err := openScratch(cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error opening scratch directory")
+	}
+	defer closeScratch()
+	defer startUploadCache()()
+	defer startGroupCache()()
+
+	//* GitLab project, group and users by path or ID
+	if err := resolveTargets(ctx, gl, cfg); err != nil {
+		return errors.Wrap(err, "Error resolving GitLab targets")
+	}
+
 	//* Get Project Information
 	jiraProjectID := cfg.Jira.Name
 	gitlabProjectPath := cfg.GitLab.Issue
 
-	jiraProject, _, err := jr.Project.Get(context.Background(), jiraProjectID)
+	jiraProject, _, err := jr.GetProject(ctx, jiraProjectID)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error getting Jira project: %s", jiraProjectID))
 	}
 
-	gitlabProject, _, err := gl.Projects.GetProject(gitlabProjectPath, nil)
+	gitlabProject, _, err := gl.GetProject(ctx, gitlabProjectPath)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error getting GitLab project: %s", gitlabProjectPath))
 	}
 
+	//* Features of the GitLab version
+	gateFeatures(ctx, gl, cfg)
+
 	//* Get Jira Issues
-	jiraEpics, jiraIssues, err := GetJiraIssues(jr, jiraProjectID, cfg.Jira.Jql)
+	stopStage := stats.Default().StartStage("Jira issues")
+	jiraEpics, jiraIssues, err := GetJiraIssues(ctx, jr, cfg)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error getting Jira issues: %s", jiraProjectID))
 	}
+	stopStage()
+	if cfg.RouteIndex > 0 {
+		jiraEpics, jiraIssues = filterRoute(cfg, jiraEpics), filterRoute(cfg, jiraIssues)
+	}
+	if cfg.Plan != nil {
+		jiraEpics, jiraIssues = applyPlan(cfg, jiraEpics), applyPlan(cfg, jiraIssues)
+		if missing := len(cfg.Plan) - len(jiraEpics) - len(jiraIssues); missing > 0 {
+			log.Warnf("%d Jira issues of the plan are not found, e.g. deleted or moved since the plan", missing)
+		}
+	}
+
+	//* Size of the migration
+	newPreflight(jiraEpics, jiraIssues).Log()
+
+	//* Epic Mode (GitLab Free doesn't have epics)
+	epicMode, err := resolveEpicMode(ctx, gl, cfg)
+	if err != nil {
+		return errors.Wrap(err, "Error resolving epic mode")
+	}
+
+	//* State (Jira Key -> GitLab Issue/Epic), kept in memory only without a state file
+	migrationState := state.New(cfg.Jira.Host)
+	if cfg.StateFile != "" {
+		migrationState, err = state.Load(cfg.StateFile)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", cfg.StateFile))
+		}
+		migrationState.JiraHost = cfg.Jira.Host
+
+		defer func() {
+			if err := migrationState.Save(cfg.StateFile); err != nil {
+				log.Errorf("Error saving state file %s: %s", cfg.StateFile, err)
+			}
+		}()
+	}
+
+	setMovedKeys(migrationState, append(jiraEpics, jiraIssues...))
+
+	//* Prefetch of the Jira issues to convert, a slow Jira doesn't hold up the GitLab writes
+	prefetch, err := startPrefetch(ctx, jr, cfg, prefetchedIssues(cfg, migrationState, epicMode, jiraEpics, jiraIssues))
+	if err != nil {
+		return errors.Wrap(err, "Error starting prefetch")
+	}
+	if prefetch != nil {
+		defer prefetch.Close()
+		jr = prefetch
+	}
+
+	//* Quarantine of the failed items, the first failure aborts the run without it
+	var quarantined *quarantine.Quarantine
+	failed := 0
+	if cfg.Quarantine != "" {
+		quarantined, err = quarantine.Load(cfg.Quarantine)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error loading quarantine file: %s", cfg.Quarantine))
+		}
+
+		defer func() {
+			if err := quarantined.Save(cfg.Quarantine); err != nil {
+				log.Errorf("Error saving quarantine file %s: %s", cfg.Quarantine, err)
+			}
+		}()
+	}
+
+	// fail quarantines the failed item and the run goes on, the error is returned without a quarantine
+	fail := func(jiraKey string, itemType string, err error) error {
+		stats.Default().AddItem(stats.ItemFailed)
+		if quarantined == nil || ctx.Err() != nil {
+			return err
+		}
+
+		log.Errorf("Quarantined %s: %s", jiraKey, err)
+		//* The project or group of the route or site project, retry-failed deletes what is left there
+		target := cfg.GitLab.Issue
+		if itemType == state.ItemTypeEpic {
+			target = cfg.GitLab.Epic
+		}
+		quarantined.Add(jiraKey, itemType, target, err)
+		mutex.Lock()
+		failed++
+		mutex.Unlock()
+		return nil
+	}
+
+	release := func(jiraKey string) {
+		if quarantined != nil {
+			quarantined.Remove(jiraKey)
+		}
+	}
+
+	//* Nothing migrated yet: the target must not be in use
+	if err := checkEmptyTargets(ctx, gl, cfg, epicMode == config.EpicModeEpic, migrationState); err != nil {
+		return err
+	}
 
 	//* User Map
-	userMap, err := newUserMap(gl, append(jiraEpics, jiraIssues...), cfg.Users)
+	stopStage = stats.Default().StartStage("Users")
+	userMap, err := newUserMap(ctx, gl, jr, append(jiraEpics, jiraIssues...), cfg)
 	if err != nil {
 		return errors.Wrap(err, "Error creating user map")
 	}
 
-	//* Check if Users are members of GitLab project
-	// TODO : Unpaginate
+	//* Jira Project Role -> GitLab Member
+	if err := migrateProjectRoles(ctx, gl, jr, jiraProject); err != nil {
+		return errors.Wrap(err, "Error migrating Jira project roles")
+	}
 
-	gitlabProjectMembers, err := gitlabx.Unpaginate[gitlab.ProjectMember](gl, func(opt *gitlab.ListOptions) ([]*gitlab.ProjectMember, *gitlab.Response, error) {
-		return gl.ProjectMembers.ListAllProjectMembers(gitlabProjectPath, &gitlab.ListProjectMembersOptions{ListOptions: *opt})
-	})
+	//* Check if Users are members of GitLab project
+	gitlabProjectMembers, err := gl.ListProjectMembers(ctx, gitlabProjectPath)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error getting GitLab project members: %s", gitlabProjectPath))
 	}
 
 	for _, user := range userMap {
+		if isUnmappedUser(user) {
+			continue
+		}
+
 		exist := false
 		for _, member := range gitlabProjectMembers {
 			if member.Username == user.Username {
@@ -112,20 +263,50 @@ func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
 			return errors.Errorf("User %s with id %d is not a member of GitLab project %s", user.Username, user.ID, gitlabProjectPath)
 		}
 	}
+	stopStage()
 
 	//* Project Description
-	_, _, err = gl.Projects.EditProject(gitlabProjectPath, &gitlab.EditProjectOptions{
+	_, _, err = gl.EditProject(ctx, gitlabProjectPath, &gitlab.EditProjectOptions{
 		Description: gitlab.String(jiraProject.Description),
 	})
 	if err != nil {
 		return errors.Wrap(err, "Error editing GitLab project: %s")
 	}
 
-	//* Project Milestones
+	//* Project Avatar
+	if cfg.ProjectAvatar {
+		migrateProjectAvatar(ctx, gl, jr, jiraProject, gitlabProjectPath)
+	}
+
+	//* Project Wiki
+	if cfg.Wiki.Enabled && runsPhase(cfg, config.PhaseEpics) {
+		if err := writeProjectWiki(ctx, gl, jr, cfg, jiraProject, gitlabProjectPath); err != nil {
+			return errors.Wrap(err, "Error writing project wiki page")
+		}
+	}
+
+	//* Filters and Dashboards Reports
+	if cfg.ReportDir != "" && runsPhase(cfg, config.PhaseEpics) {
+		if err := writeFiltersReport(ctx, jr, cfg, jiraProject, gitlabProject.WebURL, userMap); err != nil {
+			return errors.Wrap(err, "Error writing filters report")
+		}
+		if err := writeDashboardsReport(ctx, jr, cfg, jiraProject, gitlabProject.WebURL, userMap); err != nil {
+			return errors.Wrap(err, "Error writing dashboards report")
+		}
+	}
+
+	//* Issue Templates
+	if cfg.IssueTemplates.Enabled && runsPhase(cfg, config.PhaseEpics) {
+		if err := writeIssueTemplates(ctx, gl, jr, cfg, jiraProject, gitlabProject); err != nil {
+			return errors.Wrap(err, "Error writing issue templates")
+		}
+	}
+
+	//* Project Milestones, or group milestones shared by the Jira projects of the group (gitlab.milestone_level)
+	stopStage = stats.Default().StartStage("Milestones")
+	versionTarget := versionMilestoneTarget(cfg, gitlabProject.ID)
 	//* Sensitive to the title
-	existingMilestones, err := gitlabx.Unpaginate[gitlab.Milestone](gl, func(opt *gitlab.ListOptions) ([]*gitlab.Milestone, *gitlab.Response, error) {
-		return gl.Milestones.ListMilestones(gitlabProject.ID, &gitlab.ListMilestonesOptions{ListOptions: *opt})
-	})
+	existingMilestones, err := versionTarget.list(ctx, gl)
 	if err != nil {
 		return errors.Wrap(err, "Error getting GitLab milestones from GitLab: %s")
 	}
@@ -133,20 +314,15 @@ func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
 	milestones := make(map[string]*Milestone)
 	for _, version := range jiraProject.Versions {
 		jiraVersion := version
-		exist := false
-		for _, gitlabMilesone := range existingMilestones {
-			if gitlabMilesone.Title == version.Name {
-				log.Infof("Milestone already exists: %s", version.Name)
-				milestones[version.Name] = &Milestone{gitlabMilesone, &jiraVersion}
-				exist = true
-				break
-			}
-		}
-
-		if !exist {
+		if gitlabMilestone := gitlabx.FindByName(existingMilestones, milestoneTitle, version.Name); gitlabMilestone != nil {
+			log.Infof("Milestone already exists: %s", version.Name)
+			mutex.Lock()
+			milestones[version.Name] = &Milestone{Milestone: gitlabMilestone, JiraVersion: &jiraVersion}
+			mutex.Unlock()
+		} else {
 			g.Go(func(version jira.Version) func() error {
 				return func() error {
-					milestone, err := createMilestoneFromJiraVersion(jr, gl, gitlabProject.ID, &version)
+					milestone, err := createMilestoneFromJiraVersion(ctx, jr, gl, versionTarget, &version)
 					if err != nil {
 						return errors.Wrap(err, "Error creating GitLab milestone")
 					}
@@ -164,37 +340,106 @@ func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
 		return errors.Wrap(err, "Error creating GitLab milestones")
 	}
 
-	//* Project and Group Labels
-	existingGroupLabels := make(map[string]string)
-	existingProjectLabels := make(map[string]string)
-
-	gruopLabels, err := gitlabx.Unpaginate[gitlab.GroupLabel](gl, func(opt *gitlab.ListOptions) ([]*gitlab.GroupLabel, *gitlab.Response, error) {
-		return gl.GroupLabels.ListGroupLabels(cfg.GitLab.Epic, &gitlab.ListGroupLabelsOptions{
-			ListOptions:              *opt,
-			IncludeAncestorGroups:    gitlab.Bool(true),
-			IncludeDescendantGrouops: gitlab.Bool(true),
-			OnlyGroupLabels:          gitlab.Bool(true),
-		})
-	})
-	if err != nil {
-		return errors.Wrap(err, "Error getting GitLab group labels from GitLab")
+	//* Jira Sprint -> Milestone (if the sprint custom field is provided)
+	if cfg.Jira.CustomField.Sprint != "" && !cfg.GitLab.Iterations.Enabled {
+		jiraSprints, err := jr.ListSprints(ctx, jiraProjectID)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting Jira sprints: %s", jiraProjectID))
+		}
+
+		sprintTarget := sprintMilestoneTarget(cfg, gitlabProject.ID)
+		existingMilestones := existingMilestones
+		if sprintTarget != versionTarget {
+			existingMilestones, err = sprintTarget.list(ctx, gl)
+			if err != nil {
+				return errors.Wrap(err, "Error getting GitLab milestones of the sprints")
+			}
+		}
+
+		for _, sprint := range jiraSprints {
+			jiraSprint := sprint
+			//* The milestones of the previous sprints are written by the creators
+			mutex.RLock()
+			_, ok := milestones[jiraSprint.Name]
+			mutex.RUnlock()
+			if ok {
+				log.Warnf("Sprint %s has the same name as a version, the milestone of the version is used", jiraSprint.Name)
+				continue
+			}
+
+			if gitlabMilestone := gitlabx.FindByName(existingMilestones, milestoneTitle, jiraSprint.Name); gitlabMilestone != nil {
+				log.Infof("Milestone already exists: %s", jiraSprint.Name)
+				mutex.Lock()
+				milestones[jiraSprint.Name] = &Milestone{Milestone: gitlabMilestone, JiraSprint: &jiraSprint}
+				mutex.Unlock()
+			} else {
+				g.Go(func(sprint *jira.Sprint) func() error {
+					return func() error {
+						milestone, err := createMilestoneFromJiraSprint(ctx, gl, sprintTarget, sprint)
+						if err != nil {
+							return errors.Wrap(err, fmt.Sprintf("Error creating GitLab milestone from sprint: %s", sprint.Name))
+						}
+
+						mutex.Lock()
+						milestones[sprint.Name] = milestone
+						mutex.Unlock()
+						return nil
+					}
+				}(&jiraSprint))
+			}
+		}
+
+		if err := g.Wait(); err != nil {
+			return errors.Wrap(err, "Error creating GitLab milestones from sprints")
+		}
+	}
+	stopStage()
+
+	//* Jira Sprint -> Iteration
+	iterations := make(map[string]*gitlabx.Iteration)
+	if cfg.GitLab.Iterations.Enabled {
+		if cfg.GitLab.EpicDatesFrom == config.EpicDatesMilestones {
+			log.Warnf("The sprints become iterations, the epics without the Jira dates don't get the dates of the sprints (epic_dates_from: milestones)")
+		}
+		stopStage = stats.Default().StartStage("Iterations")
+		iterations, err = migrateSprintsToIterations(ctx, gl, jr, cfg)
+		if err != nil {
+			return errors.Wrap(err, "Error creating GitLab iterations")
+		}
+		stopStage()
 	}
 
-	for _, label := range gruopLabels {
-		existingGroupLabels[label.Name] = label.Name
+	//* Jira Customer -> CRM Contact
+	crmContacts := &CRMContacts{}
+	if cfg.CRM.Field != "" && runsPhase(cfg, config.PhaseIssues) {
+		crmContacts, err = listCRMContacts(ctx, gl, cfg)
+		if err != nil {
+			return errors.Wrap(err, "Error getting GitLab CRM contacts")
+		}
 	}
 
-	projectLabels, err := gitlabx.Unpaginate[gitlab.Label](gl, func(opt *gitlab.ListOptions) ([]*gitlab.Label, *gitlab.Response, error) {
-		return gl.Labels.ListLabels(gitlabProject.ID, &gitlab.ListLabelsOptions{ListOptions: *opt,
-			IncludeAncestorGroups: gitlab.Bool(true),
-		})
-	})
+	//* Project and Group Labels
+	existingGroupLabels, existingProjectLabels, err := listExistingLabels(ctx, gl, cfg.GitLab.Epic, gitlabProject.ID)
 	if err != nil {
-		return errors.Wrap(err, "Error getting GitLab project labels from GitLab")
+		return errors.Wrap(err, "Error getting GitLab labels")
 	}
 
-	for _, label := range projectLabels {
-		existingProjectLabels[label.Name] = label.Name
+	switch epicMode {
+	case config.EpicModeIssue:
+		jiraIssues = append(jiraEpics, jiraIssues...)
+		jiraEpics = nil
+	case config.EpicModeCSV:
+		if runsPhase(cfg, config.PhaseEpics) {
+			path := epicCSVPath(cfg)
+			if err := writeEpicCSV(path, jiraEpics, userMap); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error writing epics to CSV: %s", path))
+			}
+		}
+		jiraEpics = nil
+	}
+
+	if cfg.Only != "" {
+		log.Infof("Running the %s phase only, the other items are read from the state file %s", cfg.Only, cfg.StateFile)
 	}
 
 	//* Main Game
@@ -202,19 +447,68 @@ func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
 	issueLinks := make(map[string]*JiraIssueLink)
 
 	//* Epic
+	//* A rerun may have epics missing in the state file (e.g. a crash before it was saved), they are found by the Jira key marker.
+	// A new migration into an empty group has none.
+	findMigrated := cfg.AllowNonEmpty || len(migrationState.Items) > 0
+	stopStage = stats.Default().StartStage("Epics")
 	log.Infof("Converting %d epics", len(jiraEpics))
+	stopHeartbeat := startHeartbeat(cfg, "epics", len(jiraEpics))
+	defer stopHeartbeat()
 	for _, jiraEpic := range jiraEpics {
+		if utils.IsStopping(ctx) {
+			break
+		}
+
+		if item, ok := migrationState.Get(jiraEpic.Key); ok && item.Type == state.ItemTypeEpic {
+			log.Infof("Skipping already migrated epic: %s", jiraEpic.Key)
+			stats.Default().AddItem(stats.ItemSkipped)
+			release(jiraEpic.Key)
+			mutex.Lock()
+			epicLinks[jiraEpic.Key] = &JiraEpicLink{jiraEpic, item.GitLabEpic()}
+			mutex.Unlock()
+			continue
+		}
+		if !runsPhase(cfg, config.PhaseEpics) {
+			continue
+		}
+
 		g.Go(func(epic *jira.Issue) func() error {
-			return func() error {
+			return func() (err error) {
+				//* A panic is a failure of the item, quarantined like the other failures
+				defer func() {
+					if value := recover(); value != nil {
+						err = fail(epic.Key, state.ItemTypeEpic, errors.Wrap(newPanicError(value), fmt.Sprintf("Error converting epic: %s", epic.Key)))
+					}
+				}()
+
+				defer prefetch.Done(epic.Key)
+
 				log.Infof("Converting epic: %s", epic.Key)
-				gitlabEpic, err := ConvertJiraIssueToGitLabEpic(gl, jr, epic, userMap, existingGroupLabels)
+				gitlabEpic, found, err := gitlabx.FindOrCreate(func() (*gitlab.Epic, error) {
+					if !findMigrated {
+						return nil, nil
+					}
+					return findEpicByMarker(ctx, gl, cfg, cfg.GitLab.Epic, epic.Key)
+				}, func() (*gitlab.Epic, *gitlab.Response, error) {
+					gitlabEpic, err := ConvertJiraIssueToGitLabEpic(ctx, gl, jr, epic, userMap, existingGroupLabels)
+					return gitlabEpic, nil, err
+				})
 				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("Error converting epic: %s", epic.Key))
+					return fail(epic.Key, state.ItemTypeEpic, errors.Wrap(err, fmt.Sprintf("Error converting epic: %s", epic.Key)))
 				}
 
 				mutex.Lock()
 				epicLinks[epic.Key] = &JiraEpicLink{epic, gitlabEpic}
 				mutex.Unlock()
+				migrationState.SetEpic(epic.Key, gitlabEpic)
+				if found {
+					log.Infof("Epic already exists: %s(%d)", epic.Key, gitlabEpic.IID)
+					stats.Default().AddItem(stats.ItemSkipped)
+				} else {
+					migrationState.SetSynced(epic.Key, syncedFields(cfg, epic, true, false))
+					stats.Default().AddItem(stats.ItemCreated)
+				}
+				release(epic.Key)
 
 				return nil
 			}
@@ -225,20 +519,94 @@ func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
 		return errors.Wrap(err, "Error converting epic")
 	}
 
+	if utils.IsStopping(ctx) {
+		return ErrInterrupted
+	}
+	stopHeartbeat()
+	stopStage()
+
+	//* Refresh labels created by epics
+	existingGroupLabels, existingProjectLabels, err = listExistingLabels(ctx, gl, cfg.GitLab.Epic, gitlabProject.ID)
+	if err != nil {
+		return errors.Wrap(err, "Error getting GitLab labels")
+	}
+
+	existingIssueLabels := existingProjectLabels
+	if cfg.GitLab.LabelLevel != config.LabelLevelProject {
+		//* auto, group: Detect existing labels at both levels
+		existingIssueLabels = mergeLabels(existingProjectLabels, existingGroupLabels)
+	}
+
 	//* Issue
+	stopStage = stats.Default().StartStage("Issues")
 	log.Infof("Converting %d issues", len(jiraIssues))
+	stopHeartbeat = startHeartbeat(cfg, "issues", len(jiraIssues))
+	defer stopHeartbeat()
 	for _, jiraIssue := range jiraIssues {
+		if utils.IsStopping(ctx) {
+			break
+		}
+
+		if item, ok := migrationState.Get(jiraIssue.Key); ok && item.Type == state.ItemTypeIssue {
+			log.Infof("Skipping already migrated issue: %s", jiraIssue.Key)
+			stats.Default().AddItem(stats.ItemSkipped)
+			release(jiraIssue.Key)
+			mutex.Lock()
+			issueLinks[jiraIssue.Key] = &JiraIssueLink{jiraIssue, item.GitLabIssue()}
+			mutex.Unlock()
+			continue
+		}
+
+		//* An epic migrated as an issue belongs to the epics phase
+		phase := config.PhaseIssues
+		if isJiraEpic(cfg, jiraIssue) {
+			phase = config.PhaseEpics
+		}
+		if !runsPhase(cfg, phase) {
+			continue
+		}
+
 		g.Go(func(jiraIssue *jira.Issue) func() error {
-			return func() error {
+			return func() (err error) {
+				defer func() {
+					if value := recover(); value != nil {
+						err = fail(jiraIssue.Key, state.ItemTypeIssue, errors.Wrap(newPanicError(value), fmt.Sprintf("Error converting issue: %s", jiraIssue.Key)))
+					}
+				}()
+
+				defer prefetch.Done(jiraIssue.Key)
+
 				log.Infof("Converting issue: %s", jiraIssue.Key)
-				gitlabIssue, err := ConvertJiraIssueToGitLabIssue(gl, jr, jiraIssue, userMap, existingProjectLabels, milestones)
+				gitlabIssue, err := ConvertJiraIssueToGitLabIssue(ctx, gl, jr, jiraIssue, userMap, existingIssueLabels, milestones)
 				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", jiraIssue.Key))
+					return fail(jiraIssue.Key, state.ItemTypeIssue, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", jiraIssue.Key)))
+				}
+
+				if cfg.GitLab.Iterations.Enabled {
+					if err := setIssueIteration(ctx, gl, cfg, jiraIssue, gitlabIssue, iterations); err != nil {
+						return fail(jiraIssue.Key, state.ItemTypeIssue, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", jiraIssue.Key)))
+					}
+				}
+
+				if len(cfg.Severity.Matrix) > 0 {
+					if err := setIssueSeverity(ctx, gl, cfg, jiraIssue, gitlabIssue); err != nil {
+						return fail(jiraIssue.Key, state.ItemTypeIssue, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", jiraIssue.Key)))
+					}
+				}
+
+				if cfg.CRM.Field != "" {
+					if err := setIssueCRMContacts(ctx, gl, cfg, jiraIssue, gitlabIssue, crmContacts); err != nil {
+						return fail(jiraIssue.Key, state.ItemTypeIssue, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", jiraIssue.Key)))
+					}
 				}
 
 				mutex.Lock()
 				issueLinks[jiraIssue.Key] = &JiraIssueLink{jiraIssue, gitlabIssue}
 				mutex.Unlock()
+				migrationState.SetIssue(jiraIssue.Key, gitlabIssue)
+				migrationState.SetSynced(jiraIssue.Key, syncedFields(cfg, jiraIssue, false, epicMode == config.EpicModeIssue))
+				stats.Default().AddItem(stats.ItemCreated)
+				release(jiraIssue.Key)
 
 				return nil
 			}
@@ -249,24 +617,81 @@ func ConvertByProject(gl *gitlab.Client, jr *jira.Client) error {
 		return errors.Wrap(err, "Error converting issue")
 	}
 
+	if utils.IsStopping(ctx) {
+		return ErrInterrupted
+	}
+	stopHeartbeat()
+	stopStage()
+
 	//* Link
-	err = Link(gl, jr, epicLinks, issueLinks)
-	if err != nil {
-		return errors.Wrap(err, "Error linking")
+	if runsPhase(cfg, config.PhaseLinks) {
+		stopStage = stats.Default().StartStage("Links")
+		err = Link(ctx, gl, jr, epicLinks, issueLinks)
+		if err != nil {
+			return errors.Wrap(err, "Error linking")
+		}
+
+		if cfg.Jira.CustomField.Rank != "" {
+			if err := rankIssues(ctx, gl, cfg, issueLinks); err != nil {
+				return errors.Wrap(err, "Error ordering issues by Jira rank")
+			}
+		}
+
+		if err := rankEpicIssues(ctx, gl, cfg, epicLinks, issueLinks); err != nil {
+			return errors.Wrap(err, "Error ordering the issues of the epics")
+		}
+
+		if cfg.GitLab.EpicDatesFrom == config.EpicDatesChildren {
+			if err := deriveEpicDates(ctx, gl, cfg, epicLinks, issueLinks); err != nil {
+				return errors.Wrap(err, "Error deriving epic dates")
+			}
+		}
+
+		if epicMode == config.EpicModeIssue {
+			if err := addChildTaskLists(ctx, gl, cfg, issueLinks); err != nil {
+				return errors.Wrap(err, "Error adding child issues to epics")
+			}
+		}
+
+		if cfg.GitLab.InheritEpicLabels {
+			if err := inheritEpicLabels(ctx, gl, cfg, epicLinks, issueLinks); err != nil {
+				return errors.Wrap(err, "Error adding epic labels to issues")
+			}
+		}
+
+		if epicMode == config.EpicModeEpic && cfg.GitLab.EpicChildrenNote {
+			if err := addEpicChildrenNotes(ctx, gl, cfg, epicLinks, issueLinks); err != nil {
+				return errors.Wrap(err, "Error adding child issue comments to epics")
+			}
+		}
+
+		if epicMode == config.EpicModeEpic && cfg.GitLab.EpicOverview {
+			if err := mirrorEpicOverviews(ctx, gl, cfg, epicLinks, issueLinks, existingIssueLabels); err != nil {
+				return errors.Wrap(err, "Error mirroring epics to overview issues")
+			}
+		}
+
+		if cfg.Boards.Enabled {
+			if err := migrateBoards(ctx, gl, jr, cfg, gitlabProject.ID); err != nil {
+				return errors.Wrap(err, "Error migrating Jira boards")
+			}
+		}
+		stopStage()
 	}
 
 	//* Close Milestone
 	for _, milestone := range milestones {
-		if *milestone.JiraVersion.Archived || *milestone.JiraVersion.Released {
-			_, _, err := gl.Milestones.UpdateMilestone(gitlabProject.ID, milestone.ID, &gitlab.UpdateMilestoneOptions{
-				StateEvent: gitlab.String("close"),
-			})
-			if err != nil {
-				return errors.Wrap(err, fmt.Sprintf("Error closing milestone: %s", milestone.JiraVersion.Name))
+		if milestone.isClosed() && milestone.State != "closed" {
+			if err := closeMilestone(ctx, gl, gitlabProject.ID, milestone); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error closing milestone: %s", milestone.jiraName()))
 			}
 		}
 	}
 
+	if failed > 0 {
+		return errors.Wrap(ErrQuarantined, fmt.Sprintf("%d items failed and are quarantined in %s, retry them with retry-failed", failed, cfg.Quarantine))
+	}
+
 	log.Infof("You are successfully migrated %s to %s", jiraProjectID, gitlabProjectPath)
 
 	return nil
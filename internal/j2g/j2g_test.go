@@ -0,0 +1,162 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+)
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Jira.Host = "https://jira.example.com"
+	cfg.Jira.Name = "TEST"
+	cfg.GitLab.Host = "https://gitlab.example.com"
+	cfg.GitLab.Issue = "group/project"
+	cfg.GitLab.Epic = "group"
+	cfg.GitLab.LabelLevel = config.LabelLevelAuto
+	return cfg
+}
+
+// newTestEnv sets the test config for the test and returns it with a GitLab of its group and project
+func newTestEnv(t testing.TB) (*config.Config, *fake.GitLab) {
+	cfg := newTestConfig()
+	config.SetConfig(cfg)
+	t.Cleanup(func() { config.SetConfig(nil) })
+	//* The created labels are cached by project ID for the process
+	createdLabels = &labelCache{created: make(map[string]bool)}
+
+	gl := fake.NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+	return cfg, gl
+}
+
+func TestEpicDetection(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Jira.Epic.IssueTypes = []string{"에픽", "10100"}
+	cfg.Jira.Epic.Field = "customfield_10011"
+
+	localized := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "에픽"}}}
+	byID := &jira.Issue{Key: "TEST-2", Fields: &jira.IssueFields{Type: jira.IssueType{ID: "10100", Name: "Initiative"}}}
+	byField := &jira.Issue{Key: "TEST-3", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Feature"}, Unknowns: map[string]interface{}{"customfield_10011": "Login"}}}
+	notConfigured := &jira.Issue{Key: "TEST-4", Fields: &jira.IssueFields{Type: jira.IssueType{Name: "Epic"}}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, localized, byID, byField, notConfigured)
+
+	jiraEpics, jiraIssues, err := GetJiraIssues(context.Background(), jr, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []*jira.Issue{localized, byID, byField}, jiraEpics)
+	assert.Equal(t, []*jira.Issue{notConfigured}, jiraIssues)
+}
+
+func TestConvertByProjectPhases(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Jira.CustomField.ParentEpic = "customfield_10100"
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{}}}
+	issue := newTestJiraIssue()
+	issue.Key = "TEST-2"
+	issue.Fields.Attachments = nil
+	issue.Fields.Unknowns = map[string]interface{}{"customfield_10100": "TEST-1"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic, issue)
+	ctx := context.Background()
+
+	cfg.Only = config.PhaseEpics
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	assert.Len(t, gl.Epics[1], 1)
+	assert.Empty(t, gl.Issues[2])
+
+	cfg.Only = config.PhaseIssues
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	assert.Len(t, gl.Epics[1], 1)
+	assert.Len(t, gl.Issues[2], 1)
+	assert.Nil(t, gl.Issues[2][0].Epic)
+
+	cfg.Only = config.PhaseLinks
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	assert.Len(t, gl.Issues[2], 1)
+	if assert.NotNil(t, gl.Issues[2][0].Epic) {
+		assert.Equal(t, gl.Epics[1][0].ID, gl.Issues[2][0].Epic.ID)
+	}
+}
+
+func TestErrorHints(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.GitLab.EpicMode = config.EpicModeEpic
+
+	//* Epics of GitLab Free
+	gl.NoEpics = true
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue)
+
+	err := ConvertByProject(context.Background(), gl, jr)
+	var tierErr *utils.TierFeatureError
+	assert.ErrorAs(t, err, &tierErr)
+	assert.Contains(t, utils.Hint(err), "gitlab.epic_mode")
+	assert.Empty(t, gl.Issues[2])
+
+	//* Expired GitLab token
+	gl.NoEpics = false
+	gl.NoteErrors = 1
+	err = utils.ClassifyError(ConvertByProject(context.Background(), gl, jr))
+	var authErr *utils.AuthError
+	assert.ErrorAs(t, err, &authErr)
+	assert.Equal(t, "GitLab", authErr.Service)
+	assert.Contains(t, utils.Hint(err), "gitlab.token")
+	assert.Equal(t, utils.ExitAuth, utils.ExitCode(err))
+
+	//* go-jira keeps the status code in the message only
+	err = utils.ClassifyError(errors.Wrap(fmt.Errorf("request failed. Please analyze the request body for more details. Status code: %d", 404), "Error getting Jira project"))
+	var notFoundErr *utils.NotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+	assert.Contains(t, utils.Hint(err), "jira.name")
+}
+
+func BenchmarkConvertByProject(b *testing.B) {
+	cfg := newTestConfig()
+	config.SetConfig(cfg)
+	defer config.SetConfig(nil)
+	log.SetLevel(log.WarnLevel)
+	defer log.SetLevel(log.InfoLevel)
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		gl := fake.NewGitLab()
+		gl.AddGroup(1, "group")
+		gl.AddProject(2, "group/project")
+		var issues []*jira.Issue
+		for n := 1; n <= 100; n++ {
+			issue := newTestJiraIssue()
+			issue.ID = fmt.Sprintf("%d", 10000+n)
+			issue.Key = fmt.Sprintf("TEST-%d", n)
+			issue.Fields.Attachments = nil
+			issues = append(issues, issue)
+		}
+		jr := fake.NewJira(&jira.Project{Key: "TEST"}, issues...)
+		b.StartTimer()
+
+		if err := ConvertByProject(context.Background(), gl, jr); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
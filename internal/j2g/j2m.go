@@ -13,6 +13,7 @@ package j2g
 
 import (
 	"fmt"
+	"html"
 	"regexp"
 	"strconv"
 	"strings"
@@ -252,6 +253,13 @@ func JiraToMD(str string, attachments AttachmentMap, userMap UserMap) (string, [
 			repl: func(groups []string) (string, error) {
 				_, before, username, after := groups[0], groups[1], groups[2], groups[3]
 				if user, ok := userMap[username]; ok {
+					if isUnmappedUser(user) {
+						mention, err := formatUnmappedUser(user)
+						if err != nil {
+							return "", errors.Wrap(err, fmt.Sprintf("Error formatting unmapped user %s", username))
+						}
+						return before + mention + after, nil
+					}
 					if before != "" && before[len(before)-1] != ' ' {
 						before += " "
 					}
@@ -311,8 +319,12 @@ func JiraToMD(str string, attachments AttachmentMap, userMap UserMap) (string, [
 						metadataStr += fmt.Sprintf(" height=\"%s\"", heightMatch[1])
 					}
 
+					if attachment.Title != "" {
+						metadataStr += fmt.Sprintf(" title=\"%s\"", html.EscapeString(attachment.Title))
+					}
+
 					if width > 0 || height > 0 {
-						return fmt.Sprintf(`<img src="%s" alt="%s"%s>`, attachment.URL, attachment.Alt, metadataStr), nil
+						return fmt.Sprintf(`<img src="%s" alt="%s"%s>`, attachment.URL, html.EscapeString(attachment.Alt), metadataStr), nil
 					}
 
 					return attachment.Markdown, nil
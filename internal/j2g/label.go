@@ -12,64 +12,193 @@
 package j2g
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/utils"
+	"golang.org/x/sync/singleflight"
 )
 
-func convertJiraToGitLabLabels(gl *gitlab.Client, id interface{}, jiraIssue *jira.Issue, existingLabels map[string]string, isGroup bool) (*gitlab.Labels, error) {
+// Label Name -> Label Name
+func listExistingLabels(ctx context.Context, gl GitLabWriter, gid interface{}, pid interface{}) (map[string]string, map[string]string, error) {
+	existingGroupLabels := make(map[string]string)
+	existingProjectLabels := make(map[string]string)
+
+	groupLabels, err := groupLookups.labels(gid, func() ([]*gitlab.GroupLabel, error) {
+		return gl.ListGroupLabels(ctx, gid, &gitlab.ListGroupLabelsOptions{
+			IncludeAncestorGroups:    gitlab.Bool(true),
+			IncludeDescendantGrouops: gitlab.Bool(true),
+			OnlyGroupLabels:          gitlab.Bool(true),
+		})
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error getting GitLab group labels from GitLab")
+	}
+
+	for _, name := range groupLabels {
+		existingGroupLabels[name] = name
+	}
+
+	projectLabels, err := gl.ListLabels(ctx, pid, &gitlab.ListLabelsOptions{
+		IncludeAncestorGroups: gitlab.Bool(true),
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error getting GitLab project labels from GitLab")
+	}
+
+	for _, label := range projectLabels {
+		existingProjectLabels[label.Name] = label.Name
+	}
+
+	return existingGroupLabels, existingProjectLabels, nil
+}
+
+func mergeLabels(labelMaps ...map[string]string) map[string]string {
+	result := make(map[string]string)
+	for _, labelMap := range labelMaps {
+		for name, label := range labelMap {
+			result[name] = label
+		}
+	}
+	return result
+}
+
+// Where the labels of the GitLab issues are created (gitlab.label_level)
+// - auto, project: GitLab project of the issues
+// - group: GitLab group of the epics
+// Epic labels are always group labels because epics can't use project labels.
+func issueLabelTarget(cfg *config.Config) (interface{}, bool) {
+	if cfg.GitLab.LabelLevel == config.LabelLevelGroup {
+		return cfg.GitLab.Epic, true
+	}
+	return cfg.GitLab.Issue, false
+}
+
+func convertJiraToGitLabLabels(ctx context.Context, gl GitLabWriter, id interface{}, jiraIssue *jira.Issue, existingLabels map[string]string, isGroup bool) (*gitlab.Labels, error) {
 	labels := jiraIssue.Fields.Labels
 
 	//* Issue Type
 	issueType := fmt.Sprintf("type::%s", jiraIssue.Fields.Type.Name)
-	if _, ok := existingLabels[issueType]; !ok {
-		_, err := createLabel(gl, id, issueType, jiraIssue.Fields.Type.Description, isGroup)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("Error creating Issue Type label with %s", issueType))
-		}
+	if err := ensureLabel(ctx, gl, id, issueType, jiraIssue.Fields.Type.Description, existingLabels, isGroup); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating Issue Type label with %s", issueType))
 	}
 	labels = append(labels, issueType)
 
 	//* Component
 	for _, jiraComponent := range jiraIssue.Fields.Components {
 		name := fmt.Sprintf("component:%s", jiraComponent.Name)
-		if _, ok := existingLabels[name]; !ok {
-			_, err := createLabel(gl, id, name, jiraComponent.Description, isGroup)
-			if err != nil {
-				return nil, errors.Wrap(err, fmt.Sprintf("Error creating Component label with %s", name))
-			}
+		if err := ensureLabel(ctx, gl, id, name, jiraComponent.Description, existingLabels, isGroup); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating Component label with %s", name))
 		}
 		labels = append(labels, name)
 	}
 
 	//* Status
 	status := fmt.Sprintf("status::%s", jiraIssue.Fields.Status.Name)
-	if _, ok := existingLabels[status]; !ok {
-		_, err := createLabel(gl, id, status, jiraIssue.Fields.Status.Description, isGroup)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("Error creating Status label with %s", status))
-		}
+	if err := ensureLabel(ctx, gl, id, status, jiraIssue.Fields.Status.Description, existingLabels, isGroup); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating Status label with %s", status))
 	}
 	labels = append(labels, status)
 
 	//* Priority
 	priority := fmt.Sprintf("priority::%s", jiraIssue.Fields.Priority.Name)
-	if _, ok := existingLabels[priority]; !ok {
-		_, err := createLabel(gl, id, priority, jiraIssue.Fields.Priority.Description, isGroup)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("Error creating Priority label with %s", priority))
-		}
+	if err := ensureLabel(ctx, gl, id, priority, jiraIssue.Fields.Priority.Description, existingLabels, isGroup); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating Priority label with %s", priority))
 	}
 	labels = append(labels, priority)
 
+	//* Flagged (impediment)
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+	if isFlagged(cfg, jiraIssue) {
+		if err := ensureLabel(ctx, gl, id, BlockedLabel, "Flagged as an impediment in Jira", existingLabels, isGroup); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating Flagged label with %s", BlockedLabel))
+		}
+		labels = append(labels, BlockedLabel)
+	}
+
+	//* Severity
+	if severity := issueSeverity(cfg, jiraIssue); severity != "" {
+		name := severityLabel(severity)
+		if err := ensureLabel(ctx, gl, id, name, "Severity of the Jira priority and severity", existingLabels, isGroup); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating Severity label with %s", name))
+		}
+		labels = append(labels, name)
+	}
+
+	//* Environment
+	for _, name := range environmentLabels(cfg, jiraIssue) {
+		if err := ensureLabel(ctx, gl, id, name, "Jira Environment", existingLabels, isGroup); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating Environment label with %s", name))
+		}
+		labels = append(labels, name)
+	}
+
 	return (*gitlab.Labels)(&labels), nil
 }
 
-func createLabel(gl *gitlab.Client, id interface{}, name string, description string, isGroup bool) (*gitlab.Label, error) {
+// Process-wide cache of the labels created by this run
+// Concurrent creations of the same label are merged into one API call.
+type labelCache struct {
+	mutex   sync.RWMutex
+	created map[string]bool
+	group   singleflight.Group
+}
+
+var createdLabels = &labelCache{
+	created: make(map[string]bool),
+}
+
+func (c *labelCache) has(key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.created[key]
+}
+
+func (c *labelCache) add(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.created[key] = true
+}
+
+func ensureLabel(ctx context.Context, gl GitLabWriter, id interface{}, name string, description string, existingLabels map[string]string, isGroup bool) error {
+	if _, ok := existingLabels[name]; ok {
+		return nil
+	}
+
+	key := fmt.Sprintf("%v/%t/%s", id, isGroup, name)
+	if createdLabels.has(key) {
+		return nil
+	}
+
+	_, err, _ := createdLabels.group.Do(key, func() (interface{}, error) {
+		if createdLabels.has(key) {
+			return nil, nil
+		}
+
+		label, err := createLabel(ctx, gl, id, name, description, isGroup)
+		if err != nil {
+			return nil, err
+		}
+
+		createdLabels.add(key)
+		return label, nil
+	})
+
+	return err
+}
+
+func createLabel(ctx context.Context, gl GitLabWriter, id interface{}, name string, description string, isGroup bool) (*gitlab.Label, error) {
 	var label *gitlab.Label
 	var groupLabel *gitlab.GroupLabel
 	var r *gitlab.Response
@@ -83,18 +212,160 @@ func createLabel(gl *gitlab.Client, id interface{}, name string, description str
 
 	if isGroup {
 		log.Debugf("Creating group label %s to %s", name, id)
-		groupLabel, r, err = gl.GroupLabels.CreateGroupLabel(id, (*gitlab.CreateGroupLabelOptions)(gitlabCreateLabelOptions))
+		groupLabel, r, err = gl.CreateGroupLabel(ctx, id, (*gitlab.CreateGroupLabelOptions)(gitlabCreateLabelOptions))
 		label = (*gitlab.Label)(groupLabel)
 	} else {
-		label, r, err = gl.Labels.CreateLabel(id, gitlabCreateLabelOptions)
+		label, r, err = gl.CreateLabel(ctx, id, gitlabCreateLabelOptions)
 	}
-	if r.StatusCode == 409 || r.StatusCode == 400 {
+	if gitlabx.IsAlreadyExists(r, err) {
 		log.Debugf("Label %s already exists", name)
 	} else if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("Error creating label with %s", name))
 	} else {
 		log.Infof("Created label: %s", label.Name)
 	}
+	if isGroup {
+		groupLookups.addLabel(id, name)
+	}
 
 	return label, nil
 }
+
+// labelScope returns the scope of a scoped label (e.g. team of team::payments), empty for a label without a scope
+func labelScope(label string) string {
+	if i := strings.LastIndex(label, "::"); i > 0 {
+		return label[:i]
+	}
+	return ""
+}
+
+// inheritedLabels returns the scoped labels of the Jira epic inherited by its issues, the scopes owned by Jira (type, status and priority) are not
+func inheritedLabels(cfg *config.Config, epic *jira.Issue) []string {
+	var result []string
+	for _, label := range expectedLabels(cfg, epic, false) {
+		if labelScope(label) == "" {
+			continue
+		}
+		owned := false
+		for _, prefix := range jiraScopedLabels {
+			owned = owned || strings.HasPrefix(label, prefix)
+		}
+		if !owned {
+			result = append(result, label)
+		}
+	}
+	return result
+}
+
+// inheritEpicLabels adds the scoped labels of the epic to its issues (gitlab.inherit_epic_labels), a scope of the issue keeps its own label
+func inheritEpicLabels(ctx context.Context, gl GitLabWriter, cfg *config.Config, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) error {
+	for key, issueLink := range issueLinks {
+		parentKey := findParentKey(cfg, issueLink.Issue)
+
+		//* The epic is an epic or an issue (gitlab.epic_mode: issue)
+		var epic *jira.Issue
+		if epicLink, ok := epicLinks[parentKey]; ok {
+			epic = epicLink.Issue
+		} else if parent, ok := issueLinks[parentKey]; ok && isJiraEpic(cfg, parent.Issue) {
+			epic = parent.Issue
+		}
+		if epic == nil || epic.Fields == nil {
+			continue
+		}
+
+		scopes := make(map[string]bool)
+		for _, label := range expectedLabels(cfg, issueLink.Issue, false) {
+			scopes[labelScope(label)] = true
+		}
+		has := make(map[string]bool)
+		for _, label := range issueLink.gitlabIssue.Labels {
+			has[label] = true
+		}
+
+		var labels gitlab.Labels
+		for _, label := range inheritedLabels(cfg, epic) {
+			if !scopes[labelScope(label)] && !has[label] {
+				labels = append(labels, label)
+			}
+		}
+		if len(labels) == 0 {
+			continue
+		}
+
+		_, _, err := gl.UpdateIssue(ctx, cfg.GitLab.Issue, issueLink.gitlabIssue.IID, &gitlab.UpdateIssueOptions{AddLabels: &labels})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error adding labels of epic %s: issue %s", parentKey, key))
+		}
+		log.Debugf("Added labels of epic %s: issue %s %v", parentKey, key, labels)
+	}
+
+	return nil
+}
+
+// groupLookups is the cache of the group lookups of the run, a multi-project run lists the labels and milestones of a group once.
+// nil outside ConvertByProject and MigrateSite. Key: group path or ID
+var groupLookups *groupCache
+
+type groupCache struct {
+	mutex           sync.Mutex
+	groupLabels     map[string][]string
+	groupMilestones map[string][]*gitlab.Milestone
+}
+
+// startGroupCache starts the group cache of the run, call the returned function at the end of the run. A started cache is kept.
+func startGroupCache() func() {
+	if groupLookups != nil {
+		return func() {}
+	}
+	groupLookups = &groupCache{groupLabels: make(map[string][]string), groupMilestones: make(map[string][]*gitlab.Milestone)}
+	return func() {
+		groupLookups = nil
+	}
+}
+
+// labels returns the label names of the group, listed once by group
+func (c *groupCache) labels(gid interface{}, list func() ([]*gitlab.GroupLabel, error)) ([]string, error) {
+	if c == nil {
+		return listLabelNames(list)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := fmt.Sprint(gid)
+	if names, ok := c.groupLabels[key]; ok {
+		log.Debugf("Group labels of %s are cached", key)
+		return names, nil
+	}
+	names, err := listLabelNames(list)
+	if err != nil {
+		return nil, err
+	}
+	c.groupLabels[key] = names
+	return names, nil
+}
+
+// addLabel adds a label created in the group to its cached labels
+func (c *groupCache) addLabel(gid interface{}, name string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := fmt.Sprint(gid)
+	if names, ok := c.groupLabels[key]; ok {
+		c.groupLabels[key] = append(names[:len(names):len(names)], name)
+	}
+}
+
+func listLabelNames(list func() ([]*gitlab.GroupLabel, error)) ([]string, error) {
+	labels, err := list()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,68 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestInheritEpicLabels(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.ParentEpic = "customfield_10100"
+	cfg.GitLab.InheritEpicLabels = true
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Payments", Labels: []string{"team::payments", "area::api", "plain"}, Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{}}}
+	issue := newTestJiraIssue()
+	issue.Key = "TEST-2"
+	issue.Fields.Attachments = nil
+	issue.Fields.Labels = []string{"area::web"}
+	issue.Fields.Unknowns = map[string]interface{}{"customfield_10100": "TEST-1"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic, issue)
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	if assert.Len(t, gl.Issues[2], 1) {
+		labels := gl.Issues[2][0].Labels
+		assert.Contains(t, labels, "team::payments")
+		assert.Contains(t, labels, "area::web")
+		assert.NotContains(t, labels, "area::api")
+		assert.NotContains(t, labels, "plain")
+		assert.NotContains(t, labels, "type::Epic")
+	}
+}
+
+func TestGroupLabelCache(t *testing.T) {
+	defer startGroupCache()()
+
+	gl := fake.NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/app")
+	gl.AddProject(3, "group/ops")
+	_, _, err := gl.CreateGroupLabel(context.Background(), "group", &gitlab.CreateGroupLabelOptions{Name: gitlab.String("type::Bug")})
+	assert.NoError(t, err)
+
+	groupLabels, _, err := listExistingLabels(context.Background(), gl, "group", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"type::Bug": "type::Bug"}, groupLabels)
+
+	//* The label created by the first project is cached
+	_, err = createLabel(context.Background(), gl, "group", "status::Done", "", true)
+	assert.NoError(t, err)
+	groupLabels, _, err = listExistingLabels(context.Background(), gl, "group", 3)
+	assert.NoError(t, err)
+	assert.Len(t, groupLabels, 2)
+	assert.Equal(t, 1, gl.GroupLabelLists)
+}
@@ -12,6 +12,7 @@
 package j2g
 
 import (
+	"context"
 	"fmt"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
@@ -20,7 +21,6 @@ import (
 	gitlab "github.com/xanzy/go-gitlab"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
-	"golang.org/x/sync/errgroup"
 )
 
 type JiraIssueLink struct {
@@ -49,8 +49,28 @@ func convertLinkType(linkType string) (*string, error) {
 	}
 }
 
-func Link(gl *gitlab.Client, jr *jira.Client, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) error {
-	var g errgroup.Group
+// Parent Epic (custom field) or parent Issue (Sub-task) of the Jira issue
+func findParentKey(cfg *config.Config, jiraIssue *jira.Issue) string {
+	// Jira는 Epic의 부모 Epic이 없고, GitLab은 Epic이 다른 Epic의 부모가 될 수 있다.
+	parentKey := ""
+
+	if cfg.Jira.CustomField.ParentEpic != "" {
+		if parentEpic, ok := jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.ParentEpic]; ok {
+			if parentEpic != nil {
+				parentKey = parentEpic.(string)
+			}
+		}
+	}
+
+	if jiraIssue.Fields.Parent != nil {
+		parentKey = jiraIssue.Fields.Parent.Key
+	}
+
+	return parentKey
+}
+
+func Link(ctx context.Context, gl GitLabWriter, jr JiraReader, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) error {
+	var g workerGroup
 	g.SetLimit(5)
 
 	cfg, err := config.GetConfig()
@@ -58,31 +78,26 @@ func Link(gl *gitlab.Client, jr *jira.Client, epicLinks map[string]*JiraEpicLink
 		return errors.Wrap(err, "Error getting config")
 	}
 
+	//* Issues and epics of the other Jira projects (linked_state_files) are link targets
+	epicLinks, issueLinks, err = withLinkedItems(cfg, epicLinks, issueLinks)
+	if err != nil {
+		return errors.Wrap(err, "Error loading linked state files")
+	}
+
+	//* Old keys of the issues moved from another Jira project
+	moved := resolveMovedKeys(ctx, jr, cfg, epicLinks, issueLinks)
+
 	//* Find the parent Issues or Epics
 	for _, jiraIssue := range issueLinks {
 		pid := fmt.Sprintf("%d", jiraIssue.gitlabIssue.ProjectID)
 
-		// Jira는 Epic의 부모 Epic이 없고, GitLab은 Epic이 다른 Epic의 부모가 될 수 있다.
-		parentKey := ""
-
-		if cfg.Jira.CustomField.ParentEpic != "" {
-			if parentEpic, ok := jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.ParentEpic]; ok {
-				if parentEpic != nil {
-					parentKey = parentEpic.(string)
-				}
-			}
-		}
-
-		if jiraIssue.Fields.Parent != nil {
-			parentKey = jiraIssue.Fields.Parent.Key
-		}
-
+		parentKey := moved.current(findParentKey(cfg, jiraIssue.Issue))
 		if parentKey != "" {
 			g.Go(func(jiraIssue *JiraIssueLink, parentKey string) func() error {
 				return func() error {
 					//* If this Issue has a parent Epic
 					if parentEpicLink, ok := epicLinks[parentKey]; ok {
-						_, _, err := gl.Issues.UpdateIssue(pid, jiraIssue.gitlabIssue.IID, &gitlab.UpdateIssueOptions{
+						_, _, err := gl.UpdateIssue(ctx, pid, jiraIssue.gitlabIssue.IID, &gitlab.UpdateIssueOptions{
 							EpicID: &parentEpicLink.gitlabEpic.ID,
 						})
 						if err != nil {
@@ -94,13 +109,20 @@ func Link(gl *gitlab.Client, jr *jira.Client, epicLinks map[string]*JiraEpicLink
 					//* If this Issue has a parent Issue (Subtask)
 					if parentIssueLink, ok := issueLinks[parentKey]; ok {
 						parentIssueIID := fmt.Sprintf("%d", parentIssueLink.gitlabIssue.IID)
-						_, _, err := gl.IssueLinks.CreateIssueLink(pid, jiraIssue.gitlabIssue.IID, &gitlab.CreateIssueLinkOptions{
+						//* The children of an epic migrated as an issue are related to it, blocks is Premium only
+						linkType := "blocks"
+						if isJiraEpic(cfg, parentIssueLink.Issue) {
+							linkType = "relates_to"
+						}
+						_, r, err := gl.CreateIssueLink(ctx, pid, jiraIssue.gitlabIssue.IID, &gitlab.CreateIssueLinkOptions{
 							// IID: &issueLinks[innerIssueLink.OutwardIssue.Key].gitlabIssue.IID,
-							TargetProjectID: gitlab.String(pid),
+							TargetProjectID: gitlab.String(fmt.Sprintf("%d", parentIssueLink.gitlabIssue.ProjectID)),
 							TargetIssueIID:  gitlab.String(parentIssueIID),
-							LinkType:        gitlab.String("blocks"),
+							LinkType:        gitlab.String(linkType),
 						})
-						if err != nil {
+						if r != nil && r.StatusCode == 409 {
+							log.Debugf("Issue %s is already linked to parent issue %s", jiraIssue.Key, parentKey)
+						} else if err != nil {
 							return errors.Wrap(err, fmt.Sprintf("Error linking GitLab issue %s with its parent issue %s", jiraIssue.Key, parentKey))
 						}
 						log.Infof("Linked issue %s(%d) to parent issue %s(%d)", jiraIssue.Key, jiraIssue.gitlabIssue.IID, parentKey, parentIssueLink.gitlabIssue.IID)
@@ -123,33 +145,38 @@ func Link(gl *gitlab.Client, jr *jira.Client, epicLinks map[string]*JiraEpicLink
 			for _, innerIssueLink := range jiraIssue.Fields.IssueLinks {
 				outwardIssue := innerIssueLink.OutwardIssue
 				outwardType := innerIssueLink.Type.Name
-				if outwardIssue == nil || outwardIssue.Fields.Type.Name == "Epic" {
+				if outwardIssue == nil || isJiraEpic(cfg, outwardIssue) {
 					continue
 				}
 
+				outwardKey := moved.current(outwardIssue.Key)
 				if outwardIssue != nil {
-					if _, ok := issueLinks[outwardIssue.Key]; ok {
-						g.Go(func(jiraIssue *JiraIssueLink) func() error {
+					if _, ok := issueLinks[outwardKey]; ok {
+						g.Go(func(jiraIssue *JiraIssueLink, outwardKey string) func() error {
 							return func() error {
-								targetIssueIID := fmt.Sprintf("%d", issueLinks[outwardIssue.Key].gitlabIssue.IID)
+								targetProjectID := fmt.Sprintf("%d", issueLinks[outwardKey].gitlabIssue.ProjectID)
+								targetIssueIID := fmt.Sprintf("%d", issueLinks[outwardKey].gitlabIssue.IID)
 								linkType, err := convertLinkType(outwardType)
 								if err != nil {
 									return errors.Wrap(err, fmt.Sprintf("Error Converting link type: %s", outwardType))
 								}
 
-								_, _, err = gl.IssueLinks.CreateIssueLink(pid, jiraIssue.gitlabIssue.IID, &gitlab.CreateIssueLinkOptions{
-									TargetProjectID: &pid,
+								_, r, err := gl.CreateIssueLink(ctx, pid, jiraIssue.gitlabIssue.IID, &gitlab.CreateIssueLinkOptions{
+									TargetProjectID: &targetProjectID,
 									TargetIssueIID:  &targetIssueIID,
 									LinkType:        linkType,
 								})
-								if err != nil {
-									return errors.Wrap(err, fmt.Sprintf("Error Creating Issue link from %s to %s", jiraIssue.Key, outwardIssue.Key))
+								if r != nil && r.StatusCode == 409 {
+									log.Debugf("Issue %s is already linked to %s", jiraIssue.Key, outwardKey)
+									return nil
+								} else if err != nil {
+									return errors.Wrap(err, fmt.Sprintf("Error Creating Issue link from %s to %s", jiraIssue.Key, outwardKey))
 								}
 
-								log.Infof("Linked issue %s(%d) to %s(%d) with link type %s", jiraIssue.Key, jiraIssue.gitlabIssue.IID, outwardIssue.Key, issueLinks[outwardIssue.Key].gitlabIssue.IID, outwardType)
+								log.Infof("Linked issue %s(%d) to %s(%d) with link type %s", jiraIssue.Key, jiraIssue.gitlabIssue.IID, outwardKey, issueLinks[outwardKey].gitlabIssue.IID, outwardType)
 								return nil
 							}
-						}(jiraIssue))
+						}(jiraIssue, outwardKey))
 					}
 				}
 			}
@@ -160,6 +187,11 @@ func Link(gl *gitlab.Client, jr *jira.Client, epicLinks map[string]*JiraEpicLink
 		return errors.Wrap(err, "Error Link issue with other issue")
 	}
 
+	//* Resolved as Duplicate -> /duplicate of the canonical issue
+	if err := closeDuplicates(ctx, gl, issueLinks, moved); err != nil {
+		return errors.Wrap(err, "Error closing duplicate issues")
+	}
+
 	//* Link Epic with other epics
 	for _, jiraIssue := range epicLinks {
 		gid := fmt.Sprintf("%d", jiraIssue.gitlabEpic.GroupID)
@@ -174,29 +206,34 @@ func Link(gl *gitlab.Client, jr *jira.Client, epicLinks map[string]*JiraEpicLink
 					continue
 				}
 
+				outwardKey := moved.current(outwardIssue.Key)
 				if outwardIssue != nil {
-					if _, ok := issueLinks[outwardIssue.Key]; ok {
-						if _, ok := epicLinks[outwardIssue.Key]; ok {
-							g.Go(func(jiraIssue *JiraEpicLink) func() error {
+					if _, ok := issueLinks[outwardKey]; ok {
+						if _, ok := epicLinks[outwardKey]; ok {
+							g.Go(func(jiraIssue *JiraEpicLink, outwardKey string) func() error {
 								return func() error {
-									targetEpicIID := fmt.Sprintf("%d", epicLinks[outwardIssue.Key].gitlabEpic.IID)
+									targetGroupID := fmt.Sprintf("%d", epicLinks[outwardKey].gitlabEpic.GroupID)
+									targetEpicIID := fmt.Sprintf("%d", epicLinks[outwardKey].gitlabEpic.IID)
 									linkType, err := convertLinkType(outwardType)
 									if err != nil {
 										return errors.Wrap(err, "Error creating GitLab epic link")
 									}
-									_, _, err = gitlabx.CreateEpicLink(gl, gid, jiraIssue.gitlabEpic.IID, &gitlabx.CreateEpicLinkOptions{
-										TargetGroupID: &gid,
+									_, r, err := gl.CreateEpicLink(ctx, gid, jiraIssue.gitlabEpic.IID, &gitlabx.CreateEpicLinkOptions{
+										TargetGroupID: &targetGroupID,
 										TargetEpicIID: &targetEpicIID,
 										LinkType:      linkType,
 									})
-									if err != nil {
+									if r != nil && r.StatusCode == 409 {
+										log.Debugf("Epic %s is already linked to %s", jiraIssue.Key, outwardKey)
+										return nil
+									} else if err != nil {
 										return errors.Wrap(err, "Error creating GitLab epic link")
 									}
 
-									log.Infof("Linked epic %s(%d) to %s(%d) with link type %s", jiraIssue.Key, jiraIssue.gitlabEpic.IID, outwardIssue.Key, epicLinks[outwardIssue.Key].gitlabEpic.IID, outwardType)
+									log.Infof("Linked epic %s(%d) to %s(%d) with link type %s", jiraIssue.Key, jiraIssue.gitlabEpic.IID, outwardKey, epicLinks[outwardKey].gitlabEpic.IID, outwardType)
 									return nil
 								}
-							}(jiraIssue))
+							}(jiraIssue, outwardKey))
 						}
 					}
 				}
@@ -210,3 +247,50 @@ func Link(gl *gitlab.Client, jr *jira.Client, epicLinks map[string]*JiraEpicLink
 
 	return nil
 }
+
+// Jira resolution and link type of the duplicate issues, the outward issue of the link is the canonical issue
+const (
+	jiraResolutionDuplicate = "Duplicate"
+	jiraLinkTypeDuplicate   = "Duplicate"
+)
+
+// duplicateOf is the canonical issue of a Jira issue resolved as Duplicate, nil if it is not migrated
+func duplicateOf(jiraIssue *JiraIssueLink, issueLinks map[string]*JiraIssueLink, moved movedKeys) *JiraIssueLink {
+	if jiraIssue.Fields.Resolution == nil || jiraIssue.Fields.Resolution.Name != jiraResolutionDuplicate {
+		return nil
+	}
+	for _, issueLink := range jiraIssue.Fields.IssueLinks {
+		if issueLink.Type.Name != jiraLinkTypeDuplicate || issueLink.OutwardIssue == nil {
+			continue
+		}
+		if canonical, ok := issueLinks[moved.current(issueLink.OutwardIssue.Key)]; ok && canonical.gitlabIssue.ID != jiraIssue.gitlabIssue.ID {
+			return canonical
+		}
+	}
+	return nil
+}
+
+// closeDuplicates closes the duplicate issues with the /duplicate quick action, GitLab records the relation and closes the issue.
+// The note of the quick action isn't kept, running it again only adds a system note.
+func closeDuplicates(ctx context.Context, gl GitLabWriter, issueLinks map[string]*JiraIssueLink, moved movedKeys) error {
+	for _, jiraIssue := range issueLinks {
+		canonical := duplicateOf(jiraIssue, issueLinks, moved)
+		if canonical == nil {
+			continue
+		}
+
+		reference := fmt.Sprintf("#%d", canonical.gitlabIssue.IID)
+		if canonical.gitlabIssue.ProjectID != jiraIssue.gitlabIssue.ProjectID {
+			reference = canonical.gitlabIssue.WebURL
+		}
+		_, _, err := gl.CreateIssueNote(ctx, jiraIssue.gitlabIssue.ProjectID, jiraIssue.gitlabIssue.IID, &gitlabx.CreateIssueNoteOptions{
+			Body: gitlab.String("/duplicate " + reference),
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error marking issue %s as a duplicate of %s", jiraIssue.Key, canonical.Key))
+		}
+		log.Infof("Closed issue %s(%d) as a duplicate of %s(%d)", jiraIssue.Key, jiraIssue.gitlabIssue.IID, canonical.Key, canonical.gitlabIssue.IID)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+func TestCloseDuplicates(t *testing.T) {
+	ctx := context.Background()
+	gl := fake.NewGitLab()
+	gl.AddProject(2, "group/project")
+
+	issueLinks := make(map[string]*JiraIssueLink)
+	for _, key := range []string{"TEST-1", "TEST-2", "TEST-3"} {
+		key := key
+		gitlabIssue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: &key})
+		assert.NoError(t, err)
+		issueLinks[key] = &JiraIssueLink{&jira.Issue{Key: key, Fields: &jira.IssueFields{}}, gitlabIssue}
+	}
+	duplicate := &jira.IssueLink{Type: jira.IssueLinkType{Name: "Duplicate"}, OutwardIssue: &jira.Issue{Key: "TEST-1"}}
+	issueLinks["TEST-2"].Fields.Resolution = &jira.Resolution{Name: "Duplicate"}
+	issueLinks["TEST-2"].Fields.IssueLinks = []*jira.IssueLink{duplicate}
+	issueLinks["TEST-3"].Fields.Resolution = &jira.Resolution{Name: "Done"}
+	issueLinks["TEST-3"].Fields.IssueLinks = []*jira.IssueLink{duplicate}
+
+	assert.NoError(t, closeDuplicates(ctx, gl, issueLinks, nil))
+
+	if notes := gl.IssueNotes[issueLinks["TEST-2"].gitlabIssue.ID]; assert.Len(t, notes, 1) {
+		assert.Equal(t, fmt.Sprintf("/duplicate #%d", issueLinks["TEST-1"].gitlabIssue.IID), notes[0].Body)
+	}
+	assert.Empty(t, gl.IssueNotes[issueLinks["TEST-3"].gitlabIssue.ID])
+}
+
+func TestLinkAcrossProjects(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.ParentEpic = "customfield_10100"
+	cfg.LinkedStateFiles = []string{filepath.Join(t.TempDir(), "other.json")}
+
+	ctx := context.Background()
+
+	//* OTHER-1 is migrated by the migration of the other Jira project
+	otherEpic, _, err := gl.CreateEpic(ctx, "group", &gitlabx.CreateEpicOptions{Title: gitlab.String("Other")})
+	assert.NoError(t, err)
+	other := state.New(cfg.Jira.Host)
+	other.SetEpic("OTHER-1", otherEpic)
+	assert.NoError(t, other.Save(cfg.LinkedStateFiles[0]))
+
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = nil
+	issue.Fields.Unknowns = map[string]interface{}{"customfield_10100": "OTHER-1"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue)
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(ctx, gl, jr, issue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+
+	err = Link(ctx, gl, jr, map[string]*JiraEpicLink{}, map[string]*JiraIssueLink{issue.Key: {issue, gitlabIssue}})
+	assert.NoError(t, err)
+	if assert.NotNil(t, gitlabIssue.Epic) {
+		assert.Equal(t, otherEpic.ID, gitlabIssue.Epic.ID)
+	}
+}
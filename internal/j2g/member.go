@@ -0,0 +1,126 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func convertAccessLevel(accessLevel string) (gitlab.AccessLevelValue, error) {
+	accessLevelMap := map[string]gitlab.AccessLevelValue{
+		"guest":      gitlab.GuestPermissions,
+		"reporter":   gitlab.ReporterPermissions,
+		"developer":  gitlab.DeveloperPermissions,
+		"maintainer": gitlab.MaintainerPermissions,
+		"owner":      gitlab.OwnerPermissions,
+	}
+
+	if value, ok := accessLevelMap[strings.ToLower(accessLevel)]; ok {
+		return value, nil
+	} else {
+		return 0, errors.New(fmt.Sprintf("Unknown access level: %s", accessLevel))
+	}
+}
+
+// Jira Project Role -> GitLab Project/Group Member
+func migrateProjectRoles(ctx context.Context, gl GitLabWriter, jr JiraReader, jiraProject *jira.Project) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+
+	if len(cfg.ProjectRoles.AccessLevels) == 0 {
+		return nil
+	}
+
+	for roleName, roleURL := range jiraProject.Roles {
+		//* viper는 map의 key를 소문자로 바꾼다.
+		accessLevelName, ok := cfg.ProjectRoles.AccessLevels[strings.ToLower(roleName)]
+		if !ok {
+			log.Debugf("Skipping Jira project role %s which is not in project_roles", roleName)
+			continue
+		}
+
+		accessLevel, err := convertAccessLevel(accessLevelName)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error converting access level of role %s", roleName))
+		}
+
+		role, _, err := jr.GetProjectRole(ctx, jiraProject.Key, path.Base(roleURL))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting Jira project role %s", roleName))
+		}
+
+		for _, actor := range role.Actors {
+			if actor.Type != jirax.RoleActorTypeUser {
+				log.Warnf("Skipping %s %s of role %s: only users can be migrated", actor.Type, actor.Name, roleName)
+				continue
+			}
+
+			if isAnonymized(cfg, actor.Name) {
+				log.Debugf("Skipping %s of role %s: anonymized", actor.Name, roleName)
+				continue
+			}
+
+			gitlabID, ok := cfg.Users[actor.Name]
+			if !ok {
+				log.Warnf("Skipping %s of role %s: no GitLab user found", actor.Name, roleName)
+				continue
+			}
+
+			if err := addMember(ctx, gl, gitlabID, accessLevel); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error adding %s of role %s", actor.Name, roleName))
+			}
+			log.Infof("Added %s of Jira role %s as GitLab %s", actor.Name, roleName, accessLevelName)
+		}
+	}
+
+	return nil
+}
+
+func addMember(ctx context.Context, gl GitLabWriter, gitlabID int, accessLevel gitlab.AccessLevelValue) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+
+	var r *gitlab.Response
+	if cfg.ProjectRoles.Target == config.ProjectRolesTargetGroup {
+		_, r, err = gl.AddGroupMember(ctx, cfg.GitLab.Epic, &gitlab.AddGroupMemberOptions{
+			UserID:      &gitlabID,
+			AccessLevel: &accessLevel,
+		})
+	} else {
+		_, r, err = gl.AddProjectMember(ctx, cfg.GitLab.Issue, &gitlab.AddProjectMemberOptions{
+			UserID:      gitlabID,
+			AccessLevel: &accessLevel,
+		})
+	}
+
+	if r != nil && r.StatusCode == 409 {
+		log.Debugf("User %d is already a member", gitlabID)
+	} else if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error adding member %d", gitlabID))
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// DefaultMetadataTemplate is the "Jira metadata" table on top of the description (metadata_table.template)
+const DefaultMetadataTemplate = `| Jira | {{.Key}} |
+|---|---|
+| Type | {{.Type}} |
+| Priority | {{or .Priority "-"}} |
+| Original estimate | {{or .OriginalEstimate "-"}} |
+| Remaining estimate | {{or .RemainingEstimate "-"}} |
+| Sprint | {{or .Sprint "-"}} |
+| Components | {{or (join .Components ", ") "-"}} |
+| Fix versions | {{or (join .FixVersions ", ") "-"}} |
+`
+
+// Metadata is the data of the metadata template
+type Metadata struct {
+	Key               string
+	Type              string
+	Status            string
+	Priority          string
+	OriginalEstimate  string
+	RemainingEstimate string
+	Sprint            string
+	Components        []string
+	FixVersions       []string
+	Labels            []string
+}
+
+// ParseMetadataTemplate parses the template of the metadata table, the default table if it is empty
+func ParseMetadataTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultMetadataTemplate
+	}
+	return template.New("metadata").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+}
+
+// formatJiraDuration formats seconds like Jira with the default 8h working day (e.g. 1d 4h 30m)
+func formatJiraDuration(seconds int) string {
+	if seconds <= 0 {
+		return ""
+	}
+
+	minutes := seconds / 60
+	days, minutes := minutes/(8*60), minutes%(8*60)
+	hours, minutes := minutes/60, minutes%60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}
+
+func newMetadata(cfg *config.Config, issue *jira.Issue) *Metadata {
+	fields := issue.Fields
+	metadata := &Metadata{
+		Key:               issue.Key,
+		Type:              fields.Type.Name,
+		OriginalEstimate:  formatJiraDuration(fields.TimeOriginalEstimate),
+		RemainingEstimate: formatJiraDuration(fields.TimeEstimate),
+		Labels:            fields.Labels,
+	}
+	if fields.Status != nil {
+		metadata.Status = fields.Status.Name
+	}
+	if fields.Priority != nil {
+		metadata.Priority = fields.Priority.Name
+	}
+	if fields.TimeTracking != nil {
+		if fields.TimeTracking.OriginalEstimate != "" {
+			metadata.OriginalEstimate = fields.TimeTracking.OriginalEstimate
+		}
+		if fields.TimeTracking.RemainingEstimate != "" {
+			metadata.RemainingEstimate = fields.TimeTracking.RemainingEstimate
+		}
+	}
+	if cfg.Jira.CustomField.Sprint != "" {
+		if sprints := jirax.SprintNames(fields.Unknowns[cfg.Jira.CustomField.Sprint]); len(sprints) > 0 {
+			metadata.Sprint = sprints[len(sprints)-1]
+		}
+	}
+	for _, component := range fields.Components {
+		metadata.Components = append(metadata.Components, component.Name)
+	}
+	for _, version := range fields.FixVersions {
+		metadata.FixVersions = append(metadata.FixVersions, version.Name)
+	}
+
+	return metadata
+}
+
+// formatMetadataTable renders the metadata table of the Jira issue
+func formatMetadataTable(cfg *config.Config, issue *jira.Issue) (string, error) {
+	tmpl, err := ParseMetadataTemplate(cfg.MetadataTable.Template)
+	if err != nil {
+		return "", errors.Wrap(err, "Error parsing metadata template")
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, newMetadata(cfg, issue)); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error rendering metadata table: issue %s", issue.Key))
+	}
+	return result.String(), nil
+}
+
+// descriptionFuncs are the functions of the description header and footer templates
+var descriptionFuncs = template.FuncMap{
+	"join": strings.Join,
+	// date formats a Jira date or time as YYYY-MM-DD
+	"date": func(value interface{}) string {
+		var t time.Time
+		switch v := value.(type) {
+		case jira.Time:
+			t = time.Time(v)
+		case *jira.Time:
+			if v != nil {
+				t = time.Time(*v)
+			}
+		case jira.Date:
+			t = time.Time(v)
+		case *jira.Date:
+			if v != nil {
+				t = time.Time(*v)
+			}
+		case time.Time:
+			t = v
+		}
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	},
+	// field returns the value of a custom field as text (e.g. {{field . "customfield_10050"}})
+	"field": func(issue *jira.Issue, id string) string {
+		if issue.Fields == nil {
+			return ""
+		}
+		return customFieldText(issue.Fields.Unknowns[id])
+	},
+}
+
+// customFieldText formats the value of a custom field, the name or value of an option
+func customFieldText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}:
+		for _, key := range []string{"value", "name", "displayName"} {
+			if text, ok := v[key].(string); ok {
+				return text
+			}
+		}
+	case []interface{}:
+		texts := make([]string, 0, len(v))
+		for _, item := range v {
+			if text := customFieldText(item); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// ParseDescriptionTemplate parses the description header or footer, nil if it is empty
+func ParseDescriptionTemplate(name string, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New(name).Funcs(descriptionFuncs).Parse(text)
+}
+
+// formatDescriptionTemplate renders the description header or footer with the Jira issue
+func formatDescriptionTemplate(name string, text string, issue *jira.Issue) (string, error) {
+	tmpl, err := ParseDescriptionTemplate(name, text)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error parsing description %s", name))
+	}
+	if tmpl == nil {
+		return "", nil
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, issue); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error rendering description %s: issue %s", name, issue.Key))
+	}
+	return strings.TrimSpace(result.String()), nil
+}
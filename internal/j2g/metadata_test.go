@@ -0,0 +1,60 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"strings"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatJiraDuration(t *testing.T) {
+	assert.Equal(t, "", formatJiraDuration(0))
+	assert.Equal(t, "30m", formatJiraDuration(30*60))
+	//* A day has 8 working hours
+	assert.Equal(t, "1d 4h 30m", formatJiraDuration((12*60+30)*60))
+	assert.Equal(t, "2d", formatJiraDuration(16*3600))
+}
+
+func TestMetadataTable(t *testing.T) {
+	cfg, _ := newTestEnv(t)
+	cfg.MetadataTable.Enabled = true
+	cfg.Jira.CustomField.Sprint = "customfield_10020"
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.TimeOriginalEstimate = 12 * 3600
+	jiraIssue.Fields.TimeTracking = &jira.TimeTracking{RemainingEstimate: "3h"}
+	jiraIssue.Fields.Components = []*jira.Component{{Name: "API"}, {Name: "Web"}}
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10020": []interface{}{map[string]interface{}{"name": "Sprint 1"}, map[string]interface{}{"name": "Sprint 2"}}}
+
+	description, _, err := formatDescription(jiraIssue, UserMap{}, AttachmentMap{}, true)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(*description, "| Jira | TEST-1 |\n|---|---|\n| Type | Bug |\n| Priority | High |\n"))
+	assert.Contains(t, *description, "| Original estimate | 1d 4h |\n| Remaining estimate | 3h |\n| Sprint | Sprint 2 |\n| Components | API, Web |\n| Fix versions | - |\n")
+
+	//* A template of the configuration
+	cfg.MetadataTable.Template = "**{{.Status}}** {{join .Labels \" \"}}"
+	jiraIssue.Fields.Labels = []string{"backend", "login"}
+	description, _, err = formatDescription(jiraIssue, UserMap{}, AttachmentMap{}, true)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(*description, "**Done** backend login\n"))
+
+	cfg.MetadataTable.Template = "{{.Unknown}}"
+	_, _, err = formatDescription(jiraIssue, UserMap{}, AttachmentMap{}, true)
+	assert.Error(t, err)
+
+	cfg.MetadataTable.Enabled = false
+	description, _, err = formatDescription(jiraIssue, UserMap{}, AttachmentMap{}, true)
+	assert.NoError(t, err)
+	assert.NotContains(t, *description, "| Jira |")
+}
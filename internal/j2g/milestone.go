@@ -12,20 +12,199 @@
 package j2g
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
 )
 
+// Milestone is created from a Jira version or a Jira sprint
 type Milestone struct {
 	*gitlab.Milestone
 	JiraVersion *jira.Version
+	JiraSprint  *jira.Sprint
 }
 
-func createMilestoneFromJiraVersion(jr *jira.Client, gl *gitlab.Client, pid interface{}, jiraVersion *jira.Version) (*Milestone, error) {
+// Closed if the Jira version is released (archived) or the sprint is completed
+func (m *Milestone) isClosed() bool {
+	if m.JiraVersion != nil {
+		return (m.JiraVersion.Archived != nil && *m.JiraVersion.Archived) || (m.JiraVersion.Released != nil && *m.JiraVersion.Released)
+	}
+	if m.JiraSprint != nil {
+		return m.JiraSprint.State == jirax.SprintStateClosed
+	}
+	return false
+}
+
+func (m *Milestone) jiraName() string {
+	if m.JiraVersion != nil {
+		return m.JiraVersion.Name
+	}
+	return m.JiraSprint.Name
+}
+
+// milestoneTarget is the GitLab project or group of the milestones (gitlab.milestone_level)
+type milestoneTarget struct {
+	id      interface{}
+	isGroup bool
+}
+
+// versionMilestoneTarget is the target of the milestones of the Jira versions
+func versionMilestoneTarget(cfg *config.Config, pid interface{}) milestoneTarget {
+	if cfg.GitLab.MilestoneLevel == config.MilestoneLevelGroup {
+		return milestoneTarget{cfg.GitLab.Epic, true}
+	}
+	return milestoneTarget{pid, false}
+}
+
+// sprintMilestoneTarget is the target of the milestones of the Jira sprints
+func sprintMilestoneTarget(cfg *config.Config, pid interface{}) milestoneTarget {
+	if cfg.GitLab.MilestoneLevel == config.MilestoneLevelGroup || cfg.GitLab.MilestoneLevel == config.MilestoneLevelSprints {
+		return milestoneTarget{cfg.GitLab.Epic, true}
+	}
+	return milestoneTarget{pid, false}
+}
+
+// groupMilestone is the group milestone as a milestone, GroupID is set instead of ProjectID
+func groupMilestone(m *gitlab.GroupMilestone) *gitlab.Milestone {
+	return &gitlab.Milestone{
+		ID:          m.ID,
+		IID:         m.IID,
+		GroupID:     m.GroupID,
+		Title:       m.Title,
+		Description: m.Description,
+		StartDate:   m.StartDate,
+		DueDate:     m.DueDate,
+		State:       m.State,
+		UpdatedAt:   m.UpdatedAt,
+		CreatedAt:   m.CreatedAt,
+		Expired:     m.Expired,
+	}
+}
+
+// list returns the milestones of the target, the milestones of a group are listed once by run
+func (t milestoneTarget) list(ctx context.Context, gl GitLabWriter) ([]*gitlab.Milestone, error) {
+	if !t.isGroup {
+		return t.fetch(ctx, gl)
+	}
+	return groupLookups.milestones(t.id, func() ([]*gitlab.Milestone, error) {
+		return t.fetch(ctx, gl)
+	})
+}
+
+// fetch lists the milestones of the target without the cache
+func (t milestoneTarget) fetch(ctx context.Context, gl GitLabWriter) ([]*gitlab.Milestone, error) {
+	if !t.isGroup {
+		return gl.ListMilestones(ctx, t.id)
+	}
+	list, err := gl.ListGroupMilestones(ctx, t.id)
+	if err != nil {
+		return nil, err
+	}
+	milestones := make([]*gitlab.Milestone, 0, len(list))
+	for _, m := range list {
+		milestones = append(milestones, groupMilestone(m))
+	}
+	return milestones, nil
+}
+
+// create creates the milestone, the caller looked for its title in the listed milestones.
+// If the title is taken meanwhile (e.g. by another run into the group), the existing milestone is returned.
+func (t milestoneTarget) create(ctx context.Context, gl GitLabWriter, opt *gitlab.CreateMilestoneOptions) (*gitlab.Milestone, error) {
+	milestone, found, err := gitlabx.CreateOrFind(func() (*gitlab.Milestone, *gitlab.Response, error) {
+		if !t.isGroup {
+			return gl.CreateMilestone(ctx, t.id, opt)
+		}
+		created, r, err := gl.CreateGroupMilestone(ctx, t.id, &gitlab.CreateGroupMilestoneOptions{
+			Title:       opt.Title,
+			Description: opt.Description,
+			StartDate:   opt.StartDate,
+			DueDate:     opt.DueDate,
+		})
+		if err != nil {
+			return nil, r, err
+		}
+		return groupMilestone(created), r, nil
+	}, func() (*gitlab.Milestone, error) {
+		milestones, err := t.fetch(ctx, gl)
+		if err != nil {
+			return nil, err
+		}
+		return gitlabx.FindByName(milestones, milestoneTitle, *opt.Title), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		log.Infof("Milestone already exists: %s", milestone.Title)
+	}
+	if t.isGroup {
+		groupLookups.addMilestone(t.id, milestone)
+	}
+	return milestone, nil
+}
+
+func milestoneTitle(m *gitlab.Milestone) string {
+	return m.Title
+}
+
+// closeMilestone closes the project or group milestone
+func closeMilestone(ctx context.Context, gl GitLabWriter, pid interface{}, milestone *Milestone) error {
+	if milestone.GroupID != 0 {
+		_, _, err := gl.UpdateGroupMilestone(ctx, milestone.GroupID, milestone.ID, &gitlab.UpdateGroupMilestoneOptions{
+			StateEvent: gitlab.String("close"),
+		})
+		return err
+	}
+	_, _, err := gl.UpdateMilestone(ctx, pid, milestone.ID, &gitlab.UpdateMilestoneOptions{
+		StateEvent: gitlab.String("close"),
+	})
+	return err
+}
+
+// milestones returns the milestones of the group, listed once by group
+func (c *groupCache) milestones(gid interface{}, list func() ([]*gitlab.Milestone, error)) ([]*gitlab.Milestone, error) {
+	if c == nil {
+		return list()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := fmt.Sprint(gid)
+	if milestones, ok := c.groupMilestones[key]; ok {
+		log.Debugf("Group milestones of %s are cached", key)
+		return milestones, nil
+	}
+	milestones, err := list()
+	if err != nil {
+		return nil, err
+	}
+	c.groupMilestones[key] = milestones
+	return milestones, nil
+}
+
+// addMilestone adds a milestone created in the group to its cached milestones
+func (c *groupCache) addMilestone(gid interface{}, milestone *gitlab.Milestone) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := fmt.Sprint(gid)
+	if milestones, ok := c.groupMilestones[key]; ok {
+		c.groupMilestones[key] = append(milestones[:len(milestones):len(milestones)], milestone)
+	}
+}
+
+func createMilestoneFromJiraVersion(ctx context.Context, jr JiraReader, gl GitLabWriter, target milestoneTarget, jiraVersion *jira.Version) (*Milestone, error) {
 	log.Infof("Creating milestone: %s", jiraVersion.Name)
 
 	var startDate time.Time
@@ -46,14 +225,20 @@ func createMilestoneFromJiraVersion(jr *jira.Client, gl *gitlab.Client, pid inte
 		releaseDate = parsedDate
 	}
 
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+
 	option := gitlab.CreateMilestoneOptions{
 		Title:       &jiraVersion.Name,
 		Description: &jiraVersion.Description,
-		StartDate:   (*gitlab.ISOTime)(&startDate),
-		DueDate:     (*gitlab.ISOTime)(&releaseDate),
+		StartDate:   isoDate(startDate),
+		DueDate:     isoDate(releaseDate),
 	}
+	checkDateRange(cfg, fmt.Sprintf("milestone %s", jiraVersion.Name), &option.StartDate, &option.DueDate)
 
-	milestone, _, err := gl.Milestones.CreateMilestone(pid, &option)
+	milestone, err := target.create(ctx, gl, &option)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error creating milestone")
 	}
@@ -63,3 +248,34 @@ func createMilestoneFromJiraVersion(jr *jira.Client, gl *gitlab.Client, pid inte
 		JiraVersion: jiraVersion,
 	}, nil
 }
+
+// Start and end date of the sprint become the milestone dates for the burndown chart
+func createMilestoneFromJiraSprint(ctx context.Context, gl GitLabWriter, target milestoneTarget, jiraSprint *jira.Sprint) (*Milestone, error) {
+	log.Infof("Creating milestone from sprint: %s", jiraSprint.Name)
+
+	option := gitlab.CreateMilestoneOptions{
+		Title: &jiraSprint.Name,
+	}
+	if jiraSprint.StartDate != nil {
+		option.StartDate = isoDate(*jiraSprint.StartDate)
+	}
+	if jiraSprint.EndDate != nil {
+		option.DueDate = isoDate(*jiraSprint.EndDate)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+	checkDateRange(cfg, fmt.Sprintf("milestone %s", jiraSprint.Name), &option.StartDate, &option.DueDate)
+
+	milestone, err := target.create(ctx, gl, &option)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating milestone")
+	}
+
+	return &Milestone{
+		Milestone:  milestone,
+		JiraSprint: jiraSprint,
+	}, nil
+}
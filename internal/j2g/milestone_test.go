@@ -0,0 +1,99 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestGroupMilestones(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.Sprint = "customfield_10020"
+	cfg.GitLab.MilestoneLevel = config.MilestoneLevelSprints
+	cfg.AllowNonEmpty = true
+
+	gl.AddProject(3, "group/other")
+
+	done := true
+	version := jira.Version{Name: "1.0", Released: &done}
+	closed := jira.Sprint{ID: 1, Name: "Sprint 1", State: "closed"}
+	defer startGroupCache()()
+
+	//* The Jira projects of the board share the group milestone of the sprint
+	for _, target := range []string{"group/project", "group/other"} {
+		projectCfg := *cfg
+		projectCfg.GitLab.Issue = target
+		config.SetConfig(&projectCfg)
+
+		jiraIssue := newTestJiraIssue()
+		jiraIssue.Fields.Attachments = nil
+		jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10020": []interface{}{map[string]interface{}{"name": "Sprint 1"}}}
+		jr := fake.NewJira(&jira.Project{Key: "TEST", Versions: []jira.Version{version}}, jiraIssue)
+		jr.Sprints = []jira.Sprint{closed}
+		assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	}
+
+	if assert.Len(t, gl.GroupMilestones[1], 1) {
+		assert.Equal(t, "Sprint 1", gl.GroupMilestones[1][0].Title)
+		assert.Equal(t, "closed", gl.GroupMilestones[1][0].State)
+	}
+	for _, pid := range []int{2, 3} {
+		//* The versions stay project milestones
+		assert.Len(t, gl.Milestones[pid], 1)
+		if assert.Len(t, gl.Issues[pid], 1) && assert.NotNil(t, gl.Issues[pid][0].Milestone) {
+			assert.Equal(t, gl.GroupMilestones[1][0].ID, gl.Issues[pid][0].Milestone.ID)
+		}
+	}
+}
+
+func TestSprintMilestones(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.Sprint = "customfield_10020"
+
+	start := time.Date(2023, 9, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 9, 14, 18, 0, 0, 0, time.UTC)
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	//* The issue is in the milestone of its last sprint
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10020": []interface{}{
+		"com.atlassian.greenhopper.service.sprint.Sprint@1[id=1,rapidViewId=1,state=CLOSED,name=Sprint 1,startDate=2023-09-01T09:00:00.000Z]",
+		map[string]interface{}{"name": "Sprint 2"},
+	}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Sprints = []jira.Sprint{
+		{ID: 1, Name: "Sprint 1", State: "closed", StartDate: &start, EndDate: &end},
+		{ID: 2, Name: "Sprint 2", State: "active"},
+	}
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	milestones := make(map[string]*gitlab.Milestone)
+	for _, milestone := range gl.Milestones[2] {
+		milestones[milestone.Title] = milestone
+	}
+	if assert.Len(t, milestones, 2) {
+		assert.Equal(t, "2023-09-01", milestones["Sprint 1"].StartDate.String())
+		assert.Equal(t, "2023-09-14", milestones["Sprint 1"].DueDate.String())
+		assert.Equal(t, "closed", milestones["Sprint 1"].State)
+		assert.Nil(t, milestones["Sprint 2"].StartDate)
+		assert.Equal(t, "active", milestones["Sprint 2"].State)
+	}
+	if assert.Len(t, gl.Issues[2], 1) && assert.NotNil(t, gl.Issues[2][0].Milestone) {
+		assert.Equal(t, "Sprint 2", gl.Issues[2][0].Milestone.Title)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+// movedKeys is the old key of an issue moved between Jira projects -> the current key
+type movedKeys map[string]string
+
+// current returns the current key of a moved issue, the key itself otherwise
+func (m movedKeys) current(key string) string {
+	if current, ok := m[key]; ok {
+		return current
+	}
+	return key
+}
+
+// oldKeys returns the keys of the Jira issue before it was moved (the "Key" items of the changelog)
+func oldKeys(jiraIssue *jira.Issue) []string {
+	if jiraIssue.Changelog == nil {
+		return nil
+	}
+
+	var keys []string
+	for _, history := range jiraIssue.Changelog.Histories {
+		for _, item := range history.Items {
+			if item.Field == "Key" && item.FromString != "" && !strings.EqualFold(item.FromString, jiraIssue.Key) {
+				keys = append(keys, item.FromString)
+			}
+		}
+	}
+	return keys
+}
+
+// setMovedKeys keeps the old keys of the migrated issues in the state, the redirects and the mapping API find them by the old keys
+func setMovedKeys(migrationState *state.State, jiraIssues []*jira.Issue) {
+	for _, jiraIssue := range jiraIssues {
+		for _, oldKey := range oldKeys(jiraIssue) {
+			migrationState.SetMoved(oldKey, jiraIssue.Key)
+		}
+	}
+}
+
+// resolveMovedKeys looks up the parents and the link targets which aren't migrated by their key.
+// Jira finds a moved issue by its old key, so the current key of the issue is the one of the migrated item.
+func resolveMovedKeys(ctx context.Context, jr JiraReader, cfg *config.Config, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) movedKeys {
+	migrated := func(key string) bool {
+		_, isEpic := epicLinks[key]
+		_, isIssue := issueLinks[key]
+		return isEpic || isIssue
+	}
+
+	var keys []string
+	addKey := func(key string) {
+		if key != "" && !migrated(key) {
+			keys = append(keys, key)
+		}
+	}
+	addIssueLinks := func(jiraIssue *jira.Issue) {
+		for _, issueLink := range jiraIssue.Fields.IssueLinks {
+			if issueLink.OutwardIssue != nil {
+				addKey(issueLink.OutwardIssue.Key)
+			}
+		}
+	}
+	for _, issueLink := range issueLinks {
+		addKey(findParentKey(cfg, issueLink.Issue))
+		addIssueLinks(issueLink.Issue)
+	}
+	for _, epicLink := range epicLinks {
+		addIssueLinks(epicLink.Issue)
+	}
+
+	moved := make(movedKeys)
+	resolved := make(map[string]bool)
+	for _, key := range keys {
+		if resolved[key] {
+			continue
+		}
+		resolved[key] = true
+
+		current, _, err := jr.GetIssueKey(ctx, key)
+		if err != nil {
+			log.Debugf("Jira issue %s is not found: %s", key, err)
+			continue
+		}
+		if current != key && migrated(current) {
+			log.Infof("Jira issue %s is moved to %s", key, current)
+			moved[key] = current
+		}
+	}
+	return moved
+}
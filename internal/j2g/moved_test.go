@@ -0,0 +1,58 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+func TestMovedKeys(t *testing.T) {
+	cfg := newTestConfig()
+	ctx := context.Background()
+	gl := fake.NewGitLab()
+	gl.AddProject(2, "group/project")
+
+	//* TEST-2 was OLD-7 before it was moved, TEST-1 still links to OLD-7
+	moved := &jira.Issue{Key: "TEST-2", Fields: &jira.IssueFields{}, Changelog: &jira.Changelog{Histories: []jira.ChangelogHistory{
+		{Items: []jira.ChangelogItems{{Field: "Key", FromString: "OLD-7", ToString: "TEST-2"}}},
+	}}}
+	issue := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{IssueLinks: []*jira.IssueLink{
+		{Type: jira.IssueLinkType{Name: "Relates"}, OutwardIssue: &jira.Issue{Key: "OLD-7", Fields: &jira.IssueFields{}}},
+		{Type: jira.IssueLinkType{Name: "Relates"}, OutwardIssue: &jira.Issue{Key: "GONE-1", Fields: &jira.IssueFields{}}},
+	}}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue, moved)
+
+	issueLinks := make(map[string]*JiraIssueLink)
+	for _, jiraIssue := range []*jira.Issue{issue, moved} {
+		title := jiraIssue.Key
+		gitlabIssue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: &title})
+		assert.NoError(t, err)
+		issueLinks[jiraIssue.Key] = &JiraIssueLink{jiraIssue, gitlabIssue}
+	}
+
+	keys := resolveMovedKeys(ctx, jr, cfg, map[string]*JiraEpicLink{}, issueLinks)
+	assert.Equal(t, movedKeys{"OLD-7": "TEST-2"}, keys)
+
+	migrationState := state.New(cfg.Jira.Host)
+	migrationState.SetIssue("TEST-2", issueLinks["TEST-2"].gitlabIssue)
+	setMovedKeys(migrationState, []*jira.Issue{issue, moved})
+	item, ok := migrationState.Get("old-7")
+	if assert.True(t, ok) {
+		assert.Equal(t, "TEST-2", item.JiraKey)
+	}
+}
@@ -0,0 +1,295 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	PlanActionCreate = "create"
+	PlanActionSkip   = "skip" // migrated already, or left out by the reviewer
+
+	PlanTypeEpic  = "epic"
+	PlanTypeIssue = "issue"
+
+	// Fields of the plan items, for the review only
+	PlanFieldAssignee = "assignee"
+	PlanFieldState    = "state"
+	PlanFieldDueDate  = "due_date"
+)
+
+// Plan is the migration of run --plan, it is reviewed and edited before apply runs it
+type Plan struct {
+	CreatedAt time.Time `yaml:"created_at" json:"created_at"`
+	Jira      struct {
+		Host    string `yaml:"host" json:"host"`
+		Project string `yaml:"project" json:"project"`
+	} `yaml:"jira" json:"jira"`
+	GitLab struct {
+		Issue    string `yaml:"issue" json:"issue"` // project path of the issues
+		Epic     string `yaml:"epic" json:"epic"`   // group path of the epics
+		EpicMode string `yaml:"epic_mode" json:"epic_mode"`
+	} `yaml:"gitlab" json:"gitlab"`
+	Items []*PlanItem `yaml:"items" json:"items"`
+}
+
+// PlanItem is a Jira issue or epic of the plan. Action, title and labels can be edited, the other fields show the mapping.
+type PlanItem struct {
+	Key    string            `yaml:"key" json:"key"`
+	Type   string            `yaml:"type" json:"type"` // GitLab item: epic or issue
+	Action string            `yaml:"action" json:"action"`
+	Title  string            `yaml:"title" json:"title"`
+	Labels []string          `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Fields map[string]string `yaml:"fields,omitempty" json:"fields,omitempty"`
+	WebURL string            `yaml:"web_url,omitempty" json:"web_url,omitempty"` // GitLab item of a migrated Jira issue
+}
+
+// Count returns the number of items of the action
+func (p *Plan) Count(action string) int {
+	count := 0
+	for _, item := range p.Items {
+		if item.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// NewPlan fetches and converts the Jira issues without writing to GitLab, the migrated ones are skipped
+func NewPlan(ctx context.Context, gl GitLabWriter, jr JiraReader) (*Plan, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+	if len(cfg.Routes) > 0 {
+		return nil, errors.New("A plan can't be made with routes, each route has its own GitLab targets")
+	}
+
+	issues, epicMode, migrationState, err := readComparedIssues(ctx, gl, jr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if epicMode == config.EpicModeCSV {
+		return nil, errors.New("A plan can't be made with gitlab.epic_mode csv, the CSV import of the epics is done by hand")
+	}
+
+	//* Unmapped users fail the plan like the run
+	jiraIssues := make([]*jira.Issue, 0, len(issues))
+	for _, jiraIssue := range issues {
+		jiraIssues = append(jiraIssues, jiraIssue.Issue)
+	}
+	userMap, err := newUserMap(ctx, gl, jr, jiraIssues, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting GitLab users")
+	}
+
+	plan := &Plan{CreatedAt: time.Now().UTC().Truncate(time.Second), Items: make([]*PlanItem, 0, len(issues))}
+	plan.Jira.Host = cfg.Jira.Host
+	plan.Jira.Project = cfg.Jira.Name
+	plan.GitLab.Issue = cfg.GitLab.Issue
+	plan.GitLab.Epic = cfg.GitLab.Epic
+	plan.GitLab.EpicMode = epicMode
+
+	for _, jiraIssue := range issues {
+		fields := syncedFields(cfg, jiraIssue.Issue, jiraIssue.isEpic, epicMode == config.EpicModeIssue)
+		item := &PlanItem{
+			Key:    jiraIssue.Key,
+			Type:   PlanTypeIssue,
+			Action: PlanActionCreate,
+			Title:  fields[SyncFieldTitle],
+			Labels: splitLabels(fields[SyncFieldLabels]),
+			Fields: map[string]string{PlanFieldState: fields[SyncFieldState]},
+		}
+		if jiraIssue.isEpic {
+			item.Type = PlanTypeEpic
+		}
+		if dueDate := fields[SyncFieldDueDate]; dueDate != "" {
+			item.Fields[PlanFieldDueDate] = dueDate
+		}
+		if assignee := jiraIssue.Fields.Assignee; assignee != nil && !jiraIssue.isEpic {
+			item.Fields[PlanFieldAssignee] = plannedAssignee(assignee.Name, userMap[assignee.Name])
+		}
+		if migrated, ok := migrationState.Get(jiraIssue.Key); ok {
+			item.Action = PlanActionSkip
+			item.WebURL = migrated.WebURL
+		}
+		plan.Items = append(plan.Items, item)
+	}
+
+	return plan, nil
+}
+
+// plannedAssignee shows the GitLab user of the Jira assignee, e.g. jeff -> @jeff.kim
+func plannedAssignee(jiraUsername string, gitlabUser *gitlab.User) string {
+	if gitlabUser == nil || isUnmappedUser(gitlabUser) {
+		return fmt.Sprintf("%s (not assigned)", jiraUsername)
+	}
+	return fmt.Sprintf("%s -> @%s", jiraUsername, gitlabUser.Username)
+}
+
+// WriteFile writes the plan as JSON for a .json file, YAML otherwise
+func (p *Plan) WriteFile(path string) error {
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err = json.MarshalIndent(p, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(p)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error marshalling plan")
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing plan: %s", path))
+	}
+	return nil
+}
+
+// ReadPlan reads the plan of WriteFile and checks the edited actions and types
+func ReadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading plan: %s", path))
+	}
+
+	plan := new(Plan)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, plan)
+	} else {
+		err = yaml.Unmarshal(data, plan)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error parsing plan: %s", path))
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range plan.Items {
+		if item.Key == "" {
+			return nil, errors.Errorf("Plan item without a key: %s", path)
+		}
+		if seen[item.Key] {
+			return nil, errors.Errorf("Jira issue %s is planned twice", item.Key)
+		}
+		seen[item.Key] = true
+
+		switch item.Action {
+		case PlanActionCreate, PlanActionSkip:
+		default:
+			return nil, errors.Errorf("Unknown action %q of Jira issue %s (create or skip)", item.Action, item.Key)
+		}
+		switch item.Type {
+		case PlanTypeEpic, PlanTypeIssue:
+		default:
+			return nil, errors.Errorf("Unknown type %q of Jira issue %s (epic or issue)", item.Type, item.Key)
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyPlan runs the migration of the plan: its GitLab targets and epic mode, and only the items to create with their reviewed title and labels
+func ApplyPlan(ctx context.Context, gl GitLabWriter, jr JiraReader, plan *Plan) error {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "Error getting config")
+	}
+	if len(cfg.Routes) > 0 {
+		return errors.New("A plan can't be applied with routes, each route has its own GitLab targets")
+	}
+	if plan.Jira.Project != cfg.Jira.Name {
+		return errors.Errorf("The plan is made for Jira project %s, not %s", plan.Jira.Project, cfg.Jira.Name)
+	}
+
+	if plan.GitLab.Issue != "" && plan.GitLab.Issue != cfg.GitLab.Issue {
+		cfg.GitLab.Issue = plan.GitLab.Issue
+		cfg.GitLab.IssueID = 0
+	}
+	if plan.GitLab.Epic != "" && plan.GitLab.Epic != cfg.GitLab.Epic {
+		cfg.GitLab.Epic = plan.GitLab.Epic
+		cfg.GitLab.EpicID = 0
+	}
+	if plan.GitLab.EpicMode != "" {
+		cfg.GitLab.EpicMode = plan.GitLab.EpicMode
+	}
+
+	cfg.Plan = make(map[string]*config.PlannedItem)
+	for _, item := range plan.Items {
+		if item.Action == PlanActionCreate {
+			cfg.Plan[item.Key] = &config.PlannedItem{Title: item.Title, Labels: item.Labels}
+		}
+	}
+	defer func() { cfg.Plan = nil }()
+
+	return ConvertByProject(ctx, gl, jr)
+}
+
+// applyPlan keeps the Jira issues of the plan with the reviewed title and labels
+func applyPlan(cfg *config.Config, jiraIssues []*jira.Issue) []*jira.Issue {
+	var result []*jira.Issue
+	for _, jiraIssue := range jiraIssues {
+		item, ok := cfg.Plan[jiraIssue.Key]
+		if !ok {
+			continue
+		}
+
+		//* The labels of the Jira fields (type::, status::, ...) are added again by the conversion, the others are the Jira labels
+		converted := make(map[string]bool)
+		for _, label := range expectedLabels(cfg, jiraIssue, cfg.GitLab.EpicMode == config.EpicModeIssue) {
+			converted[label] = true
+		}
+		for _, label := range jiraIssue.Fields.Labels {
+			delete(converted, label)
+		}
+		planned := make(map[string]bool)
+		var labels []string
+		for _, label := range item.Labels {
+			planned[label] = true
+			if !converted[label] {
+				labels = append(labels, label)
+			}
+		}
+		for label := range converted {
+			if !planned[label] {
+				log.Warnf("Label %s of the Jira fields can't be removed by the plan: issue %s", label, jiraIssue.Key)
+			}
+		}
+		jiraIssue.Fields.Labels = labels
+
+		//* The title of an epic may be its Epic Name
+		if item.Title != "" {
+			if isJiraEpic(cfg, jiraIssue) && cfg.GitLab.EpicTitle == config.EpicTitleEpicName && epicName(cfg, jiraIssue) != "" {
+				jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.EpicName] = item.Title
+			} else {
+				jiraIssue.Fields.Summary = item.Title
+			}
+		}
+
+		result = append(result, jiraIssue)
+	}
+	return result
+}
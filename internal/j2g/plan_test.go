@@ -0,0 +1,64 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestPlan(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	first := newTestJiraIssue()
+	first.Fields.Attachments = nil
+	second := newTestJiraIssue()
+	second.Key = "TEST-2"
+	second.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, first, second)
+	ctx := context.Background()
+
+	plan, err := NewPlan(ctx, gl, jr)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, plan.Count(PlanActionCreate))
+	assert.Empty(t, gl.Issues[2])
+
+	//* Reviewed: TEST-2 is left out, TEST-1 is renamed and labelled
+	for _, item := range plan.Items {
+		if item.Key == "TEST-2" {
+			item.Action = PlanActionSkip
+			continue
+		}
+		item.Title = "Login fails with SSO"
+		item.Labels = append(item.Labels, "reviewed")
+	}
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	assert.NoError(t, plan.WriteFile(path))
+	plan, err = ReadPlan(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ApplyPlan(ctx, gl, jr, plan))
+	if assert.Len(t, gl.Issues[2], 1) {
+		assert.Equal(t, "Login fails with SSO", gl.Issues[2][0].Title)
+		assert.ElementsMatch(t, []string{"type::Bug", "status::Done", "priority::High", "reviewed"}, gl.Issues[2][0].Labels)
+	}
+
+	//* The migrated issue is skipped by the next plan
+	plan, err = NewPlan(ctx, gl, jr)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, plan.Count(PlanActionSkip))
+}
@@ -0,0 +1,455 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/scratch"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+const (
+	prefetchPending = iota
+	prefetchStarted
+	prefetchDone
+	prefetchTaken // read from Jira: converted before its turn
+)
+
+// prefetchReader downloads the Jira data of the issues to convert ahead of the conversion (prefetch.workers).
+// The other calls, and the issues which are not prefetched yet when they are converted, go to Jira.
+type prefetchReader struct {
+	JiraReader
+	cfg    *config.Config
+	dir    *scratch.Dir
+	slots  chan struct{} // prefetch.ahead
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mutex       sync.Mutex
+	issues      map[string]*prefetchedIssue
+	attachments map[string]string // attachment ID -> Jira key
+	inMemory    int
+}
+
+// prefetchedIssue is a Jira issue of the prefetch, its data is in memory or spilled over to a file
+type prefetchedIssue struct {
+	issue    *jira.Issue
+	state    int
+	done     chan struct{}
+	err      error
+	slot     bool
+	released bool // converted while it was prefetched
+	freed    bool
+
+	data        *prefetchedData
+	spill       *scratch.File
+	attachments map[string]*scratch.File
+}
+
+// prefetchedData is the Jira data of a prefetched issue, JSON when it is spilled over. A nil field is read from Jira.
+type prefetchedData struct {
+	RemoteLinks    *[]jira.RemoteLink `json:"remote_links,omitempty"`
+	Comments       *[]*jira.Comment   `json:"comments,omitempty"`
+	CommentParents *map[string]string `json:"comment_parents,omitempty"`
+}
+
+// startPrefetch prefetches the Jira issues in the order of the conversion, nil if prefetch.workers is 0
+func startPrefetch(ctx context.Context, jr JiraReader, cfg *config.Config, jiraIssues []*jira.Issue) (*prefetchReader, error) {
+	if cfg.Prefetch.Workers == 0 || len(jiraIssues) == 0 {
+		return nil, nil
+	}
+
+	//* The disk usage is bounded by prefetch.ahead, waiting for scratch.max_mb could hold up the issue being converted
+	path := cfg.Scratch.Dir
+	if path == "" {
+		path = os.TempDir()
+	}
+	dir, err := scratch.Open(path, math.MaxInt64)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error opening prefetch directory")
+	}
+
+	ahead := cfg.Prefetch.Ahead
+	if ahead <= 0 {
+		ahead = config.DefaultPrefetchAhead
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r := &prefetchReader{
+		JiraReader:  jr,
+		cfg:         cfg,
+		dir:         dir,
+		slots:       make(chan struct{}, ahead),
+		cancel:      cancel,
+		issues:      make(map[string]*prefetchedIssue),
+		attachments: make(map[string]string),
+	}
+
+	queue := make([]*prefetchedIssue, 0, len(jiraIssues))
+	for _, jiraIssue := range jiraIssues {
+		p := &prefetchedIssue{issue: jiraIssue, done: make(chan struct{})}
+		r.issues[jiraIssue.Key] = p
+		for _, attachment := range jiraIssue.Fields.Attachments {
+			r.attachments[attachment.ID] = jiraIssue.Key
+		}
+		queue = append(queue, p)
+	}
+
+	jobs := make(chan *prefetchedIssue)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer close(jobs)
+		for _, p := range queue {
+			select {
+			case r.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			if !r.claim(p) {
+				<-r.slots
+				continue
+			}
+			select {
+			case jobs <- p:
+			case <-ctx.Done():
+				r.finish(p, nil, ctx.Err())
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < cfg.Prefetch.Workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for p := range jobs {
+				data, err := r.prefetch(ctx, p)
+				r.finish(p, data, err)
+			}
+		}()
+	}
+
+	log.Infof("Prefetching %d Jira issues with %d workers", len(queue), cfg.Prefetch.Workers)
+	return r, nil
+}
+
+// prefetchedIssues are the Jira issues the run converts in its order: not migrated yet, of the phases of the run
+func prefetchedIssues(cfg *config.Config, migrationState *state.State, epicMode string, jiraEpics []*jira.Issue, jiraIssues []*jira.Issue) []*jira.Issue {
+	candidates := append(append([]*jira.Issue{}, jiraEpics...), jiraIssues...)
+	if epicMode == config.EpicModeCSV {
+		candidates = jiraIssues
+	}
+
+	var result []*jira.Issue
+	for _, jiraIssue := range candidates {
+		if _, ok := migrationState.Get(jiraIssue.Key); ok {
+			continue
+		}
+		phase := config.PhaseIssues
+		if isJiraEpic(cfg, jiraIssue) {
+			phase = config.PhaseEpics
+		}
+		if runsPhase(cfg, phase) {
+			result = append(result, jiraIssue)
+		}
+	}
+	return result
+}
+
+// claim starts the prefetch of the issue, false if it is converted already
+func (r *prefetchReader) claim(p *prefetchedIssue) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if p.state != prefetchPending {
+		return false
+	}
+	p.state = prefetchStarted
+	p.slot = true
+	return true
+}
+
+// prefetch downloads the Jira data read by the conversion of the issue
+func (r *prefetchReader) prefetch(ctx context.Context, p *prefetchedIssue) (result *prefetchedData, err error) {
+	//* A panic fails the prefetch only, the conversion reads the issue from Jira
+	defer func() {
+		if value := recover(); value != nil {
+			result, err = nil, newPanicError(value)
+		}
+	}()
+
+	jiraIssue := p.issue
+	data := &prefetchedData{}
+
+	remoteLinks, _, err := r.JiraReader.GetRemoteLinks(ctx, jiraIssue.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting remote links")
+	}
+	data.RemoteLinks = remoteLinks
+
+	if needsAllComments(r.cfg, jiraIssue) {
+		comments, err := r.JiraReader.GetComments(ctx, jiraIssue.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error getting comments")
+		}
+		data.Comments = &comments
+	}
+
+	if r.cfg.GitLab.ThreadedComments {
+		parents, err := r.JiraReader.GetCommentParents(ctx, jiraIssue.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error getting comment parents")
+		}
+		data.CommentParents = &parents
+	}
+
+	//* Attachments are always on disk
+	p.attachments = make(map[string]*scratch.File)
+	for _, attachment := range jiraIssue.Fields.Attachments {
+		fileReader, err := r.JiraReader.DownloadAttachment(ctx, attachment.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error downloading attachment: %s", attachment.Filename))
+		}
+		file, err := r.dir.Buffer(ctx, fileReader, int64(attachment.Size))
+		fileReader.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error buffering attachment: %s", attachment.Filename))
+		}
+		p.attachments[attachment.ID] = file
+	}
+
+	return data, nil
+}
+
+// finish keeps the data in memory up to prefetch.memory, spills it over to disk past it
+func (r *prefetchReader) finish(p *prefetchedIssue, data *prefetchedData, err error) {
+	var spill *scratch.File
+	if err == nil {
+		r.mutex.Lock()
+		spilled := r.inMemory >= r.cfg.Prefetch.Memory
+		if !spilled {
+			r.inMemory++
+		}
+		r.mutex.Unlock()
+
+		if spilled {
+			spill, err = r.spill(data)
+		}
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Warnf("Error prefetching Jira issue %s, it is read from Jira: %s", p.issue.Key, err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p.err = err
+	if err == nil {
+		p.spill = spill
+		if spill == nil {
+			p.data = data
+		}
+	}
+	p.state = prefetchDone
+	close(p.done)
+	if p.released || err != nil {
+		r.free(p)
+	}
+}
+
+func (r *prefetchReader) spill(data *prefetchedData) (*scratch.File, error) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error marshalling prefetched issue")
+	}
+	file, err := r.dir.Buffer(context.Background(), bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error spilling prefetched issue")
+	}
+	return file, nil
+}
+
+// take waits for the prefetch of the issue, nil if the issue is read from Jira
+func (r *prefetchReader) take(ctx context.Context, jiraKey string) *prefetchedIssue {
+	r.mutex.Lock()
+	p, ok := r.issues[jiraKey]
+	if !ok {
+		r.mutex.Unlock()
+		return nil
+	}
+	if p.state == prefetchPending {
+		p.state = prefetchTaken
+	}
+	state := p.state
+	r.mutex.Unlock()
+	if state == prefetchTaken {
+		return nil
+	}
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if p.err != nil || p.freed {
+		return nil
+	}
+	return p
+}
+
+// prefetched returns the prefetched data of the issue, nil if it is read from Jira
+func (r *prefetchReader) prefetched(ctx context.Context, jiraKey string) *prefetchedData {
+	p := r.take(ctx, jiraKey)
+	if p == nil {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if p.data != nil || p.spill == nil {
+		return p.data
+	}
+
+	//* Read again for each call, the spilled data doesn't come back to memory
+	data := &prefetchedData{}
+	if _, err := p.spill.Seek(0, io.SeekStart); err != nil {
+		log.Warnf("Error reading prefetched issue %s: %s", jiraKey, err)
+		return nil
+	}
+	if err := json.NewDecoder(p.spill).Decode(data); err != nil {
+		log.Warnf("Error reading prefetched issue %s: %s", jiraKey, err)
+		return nil
+	}
+	return data
+}
+
+func (r *prefetchReader) GetRemoteLinks(ctx context.Context, issueKey string) (*[]jira.RemoteLink, *jira.Response, error) {
+	if data := r.prefetched(ctx, issueKey); data != nil && data.RemoteLinks != nil {
+		return data.RemoteLinks, nil, nil
+	}
+	return r.JiraReader.GetRemoteLinks(ctx, issueKey)
+}
+
+func (r *prefetchReader) GetComments(ctx context.Context, issueKey string) ([]*jira.Comment, error) {
+	if data := r.prefetched(ctx, issueKey); data != nil && data.Comments != nil {
+		return *data.Comments, nil
+	}
+	return r.JiraReader.GetComments(ctx, issueKey)
+}
+
+func (r *prefetchReader) GetCommentParents(ctx context.Context, issueKey string) (map[string]string, error) {
+	if data := r.prefetched(ctx, issueKey); data != nil && data.CommentParents != nil {
+		return *data.CommentParents, nil
+	}
+	return r.JiraReader.GetCommentParents(ctx, issueKey)
+}
+
+// DownloadAttachment returns the prefetched file once, closing it removes it
+func (r *prefetchReader) DownloadAttachment(ctx context.Context, attachmentID string) (io.ReadCloser, error) {
+	r.mutex.Lock()
+	jiraKey, ok := r.attachments[attachmentID]
+	r.mutex.Unlock()
+
+	if ok {
+		if p := r.take(ctx, jiraKey); p != nil {
+			r.mutex.Lock()
+			file := p.attachments[attachmentID]
+			delete(p.attachments, attachmentID)
+			r.mutex.Unlock()
+			if file != nil {
+				return file, nil
+			}
+		}
+	}
+	return r.JiraReader.DownloadAttachment(ctx, attachmentID)
+}
+
+// Done frees the prefetched data of the converted issue and the prefetch goes on, nothing without a prefetch
+func (r *prefetchReader) Done(jiraKey string) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, ok := r.issues[jiraKey]
+	if !ok {
+		return
+	}
+	switch p.state {
+	case prefetchPending:
+		p.state = prefetchTaken
+	case prefetchStarted:
+		p.released = true
+	case prefetchDone:
+		r.free(p)
+	}
+}
+
+// free removes the data and files of the prefetched issue, the mutex is held
+func (r *prefetchReader) free(p *prefetchedIssue) {
+	if p.freed {
+		return
+	}
+	p.freed = true
+
+	if p.data != nil {
+		r.inMemory--
+		p.data = nil
+	}
+	if p.spill != nil {
+		p.spill.Close()
+		p.spill = nil
+	}
+	for _, file := range p.attachments {
+		file.Close()
+	}
+	p.attachments = nil
+	if p.slot {
+		<-r.slots
+	}
+}
+
+// Close stops the prefetch and removes the prefetched files
+func (r *prefetchReader) Close() {
+	r.cancel()
+	r.wg.Wait()
+
+	r.mutex.Lock()
+	for _, p := range r.issues {
+		if p.state == prefetchDone {
+			r.free(p)
+		}
+	}
+	r.mutex.Unlock()
+
+	if err := r.dir.Close(); err != nil {
+		log.Warnf("Error removing prefetch directory: %s", err)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestPrefetch(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Prefetch.Workers = 2
+	cfg.Scratch.Dir = t.TempDir()
+
+	jiraIssue := newTestJiraIssue()
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Attachments["1"] = []byte("log")
+	jr.RemoteLinks[jiraIssue.Key] = []jira.RemoteLink{{Object: &jira.RemoteLinkObject{URL: "https://wiki.example.com", Title: "Wiki"}}}
+	ctx := context.Background()
+
+	prefetch, err := startPrefetch(ctx, jr, cfg, []*jira.Issue{jiraIssue})
+	assert.NoError(t, err)
+	defer prefetch.Close()
+
+	//* Spilled over to disk (prefetch.memory: 0), Jira isn't read after the prefetch
+	<-prefetch.issues[jiraIssue.Key].done
+	assert.NotNil(t, prefetch.issues[jiraIssue.Key].spill)
+	remoteLinks, _, err := prefetch.GetRemoteLinks(ctx, jiraIssue.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "Wiki", (*remoteLinks)[0].Object.Title)
+	delete(jr.Attachments, "1")
+	jr.RemoteLinks[jiraIssue.Key] = nil
+
+	file, err := prefetch.DownloadAttachment(ctx, "1")
+	if assert.NoError(t, err) {
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "log", string(content))
+		file.Close()
+	}
+	remoteLinks, _, err = prefetch.GetRemoteLinks(ctx, jiraIssue.Key)
+	assert.NoError(t, err)
+	assert.Len(t, *remoteLinks, 1)
+
+	//* The converted issue is read from Jira again
+	prefetch.Done(jiraIssue.Key)
+	remoteLinks, _, err = prefetch.GetRemoteLinks(ctx, jiraIssue.Key)
+	assert.NoError(t, err)
+	assert.Empty(t, *remoteLinks)
+}
@@ -0,0 +1,86 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	log "github.com/sirupsen/logrus"
+)
+
+// gitlabMaxAttachmentSize is the default maximum attachment size of GitLab (Admin Area > Settings > General)
+const gitlabMaxAttachmentSize = 100 << 20
+
+// Preflight is the size of the migration, reported before anything is written to GitLab
+type Preflight struct {
+	Epics           int
+	Issues          int
+	Comments        int
+	Attachments     int
+	AttachmentBytes int64
+
+	Largest      *jira.Attachment
+	LargestIssue string
+	Oversized    int // attachments over gitlabMaxAttachmentSize
+}
+
+// newPreflight counts the attachments of the issues and epics with their sizes in the search result, nothing is downloaded
+func newPreflight(jiraEpics []*jira.Issue, jiraIssues []*jira.Issue) *Preflight {
+	p := &Preflight{Epics: len(jiraEpics), Issues: len(jiraIssues)}
+	for _, jiraIssue := range append(append([]*jira.Issue{}, jiraEpics...), jiraIssues...) {
+		if jiraIssue.Fields == nil {
+			continue
+		}
+		if jiraIssue.Fields.Comments != nil {
+			p.Comments += len(jiraIssue.Fields.Comments.Comments)
+		}
+		for _, attachment := range jiraIssue.Fields.Attachments {
+			p.Attachments++
+			p.AttachmentBytes += int64(attachment.Size)
+			if attachment.Size > gitlabMaxAttachmentSize {
+				p.Oversized++
+			}
+			if p.Largest == nil || attachment.Size > p.Largest.Size {
+				p.Largest = attachment
+				p.LargestIssue = jiraIssue.Key
+			}
+		}
+	}
+	return p
+}
+
+// Log writes the pre-flight report, the transfer size is the download from Jira and the upload to GitLab each
+func (p *Preflight) Log() {
+	log.Infof("Pre-flight: %d epics, %d issues, %d comments", p.Epics, p.Issues, p.Comments)
+	log.Infof("Pre-flight: %d attachments, %s to transfer", p.Attachments, formatBytes(p.AttachmentBytes))
+	if p.Largest != nil {
+		log.Infof("Pre-flight: largest attachment %s (%s) of issue %s", p.Largest.Filename, formatBytes(int64(p.Largest.Size)), p.LargestIssue)
+	}
+	if p.Oversized > 0 {
+		log.Warnf("Pre-flight: %d attachments are larger than %s, the default maximum attachment size of GitLab", p.Oversized, formatBytes(gitlabMaxAttachmentSize))
+	}
+}
+
+// formatBytes formats a size with binary units (e.g. 1.5 MiB)
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,33 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreflight(t *testing.T) {
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Attachments: []*jira.Attachment{{Filename: "plan.pdf", Size: 3 << 20}}}}
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = append(issue.Fields.Attachments, &jira.Attachment{Filename: "dump.zip", Size: 200 << 20})
+
+	p := newPreflight([]*jira.Issue{epic}, []*jira.Issue{issue})
+	assert.Equal(t, 1, p.Epics)
+	assert.Equal(t, 1, p.Issues)
+	assert.Equal(t, len(issue.Fields.Attachments)+1, p.Attachments)
+	assert.Equal(t, "dump.zip", p.Largest.Filename)
+	assert.Equal(t, 1, p.Oversized)
+	assert.Equal(t, "1.5 MiB", formatBytes(3<<19))
+	assert.Equal(t, "512 B", formatBytes(512))
+}
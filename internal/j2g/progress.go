@@ -0,0 +1,70 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/stats"
+)
+
+// startHeartbeat logs the progress of the stage at info level every progress_interval, until the returned function is called
+func startHeartbeat(cfg *config.Config, noun string, total int) func() {
+	if cfg.ProgressInterval <= 0 || total == 0 {
+		return func() {}
+	}
+
+	processed := func() int {
+		s := stats.Default()
+		return s.Items(stats.ItemCreated) + s.Items(stats.ItemSkipped) + s.Items(stats.ItemFailed)
+	}
+	base := processed()
+	start := time.Now()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(cfg.ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				log.Info(formatHeartbeat(noun, processed()-base, total, time.Since(start)))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// formatHeartbeat returns e.g. "120/800 issues migrated (40.0/min, ETA 00:17)"
+func formatHeartbeat(noun string, processed int, total int, elapsed time.Duration) string {
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(processed) / elapsed.Minutes()
+	}
+
+	eta := "--:--"
+	if rate > 0 {
+		remaining := time.Duration(float64(total-processed) / rate * float64(time.Minute))
+		eta = fmt.Sprintf("%02d:%02d", int(remaining.Hours()), int(remaining.Minutes())%60)
+	}
+	return fmt.Sprintf("%d/%d %s migrated (%.1f/min, ETA %s)", processed, total, noun, rate, eta)
+}
@@ -0,0 +1,23 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatHeartbeat(t *testing.T) {
+	assert.Equal(t, "120/800 issues migrated (40.0/min, ETA 00:17)", formatHeartbeat("issues", 120, 800, 3*time.Minute))
+	assert.Equal(t, "0/10 epics migrated (0.0/min, ETA --:--)", formatHeartbeat("epics", 0, 10, time.Minute))
+}
@@ -0,0 +1,127 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+// Jira rank is a LexoRank (e.g. 0|i0000f:), the string order is the backlog order
+func jiraRank(cfg *config.Config, jiraIssue *jira.Issue) string {
+	rank, _ := jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.Rank].(string)
+	return rank
+}
+
+func issueNumber(key string) int {
+	n, _ := strconv.Atoi(key[strings.LastIndex(key, "-")+1:])
+	return n
+}
+
+// lessRank orders by rank, the issues without a rank go last in key order
+func lessRank(cfg *config.Config, a *jira.Issue, b *jira.Issue) bool {
+	rankA, rankB := jiraRank(cfg, a), jiraRank(cfg, b)
+	if rankA == "" || rankB == "" {
+		if rankA != rankB {
+			return rankB == ""
+		}
+		return issueNumber(a.Key) < issueNumber(b.Key)
+	}
+	return rankA < rankB
+}
+
+// rankIssues reorders the issues of the project (manual sort of the boards) by the Jira rank
+func rankIssues(ctx context.Context, gl GitLabWriter, cfg *config.Config, issueLinks map[string]*JiraIssueLink) error {
+	ranked := make([]*JiraIssueLink, 0, len(issueLinks))
+	for _, issueLink := range issueLinks {
+		ranked = append(ranked, issueLink)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return lessRank(cfg, ranked[i].Issue, ranked[j].Issue)
+	})
+
+	for i := 1; i < len(ranked); i++ {
+		issue := ranked[i].gitlabIssue
+		_, _, err := gl.ReorderIssue(ctx, issue.ProjectID, issue.IID, &gitlabx.ReorderIssueOptions{
+			MoveAfterID: &ranked[i-1].gitlabIssue.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error reordering issue %s", ranked[i].Key))
+		}
+	}
+	log.Infof("Reordered %d issues by Jira rank", len(ranked))
+
+	return nil
+}
+
+// rankEpicIssues reorders the issues of each epic like the child issues of the Jira epic, by the Jira rank or the key without it.
+// The issues are added to the epics concurrently, so the epic tree and the roadmap are in random order before.
+func rankEpicIssues(ctx context.Context, gl GitLabWriter, cfg *config.Config, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) error {
+	jiraIssues := make(map[int]*jira.Issue) // GitLab issue ID -> Jira issue
+	for _, issueLink := range issueLinks {
+		jiraIssues[issueLink.gitlabIssue.ID] = issueLink.Issue
+	}
+
+	for _, epicLink := range epicLinks {
+		epicIssues, err := gl.ListEpicIssues(ctx, cfg.GitLab.Epic, epicLink.gitlabEpic.IID)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting issues of epic %s", epicLink.Key))
+		}
+
+		//* Issues of the other Jira projects stay where they are
+		var children []*gitlab.Issue
+		for _, epicIssue := range epicIssues {
+			if _, ok := jiraIssues[epicIssue.ID]; ok {
+				children = append(children, epicIssue)
+			}
+		}
+		sorted := make([]*gitlab.Issue, len(children))
+		copy(sorted, children)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return lessRank(cfg, jiraIssues[sorted[i].ID], jiraIssues[sorted[j].ID])
+		})
+		if sameOrder(children, sorted) {
+			continue
+		}
+
+		for i := 1; i < len(sorted); i++ {
+			_, _, err := gl.UpdateEpicIssueAssignment(ctx, cfg.GitLab.Epic, epicLink.gitlabEpic.IID, sorted[i].EpicIssueID, &gitlab.UpdateEpicIsssueAssignmentOptions{
+				MoveAfterID: &sorted[i-1].EpicIssueID,
+			})
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error reordering issues of epic %s", epicLink.Key))
+			}
+		}
+		log.Infof("Reordered %d issues of epic %s", len(sorted), epicLink.Key)
+	}
+
+	return nil
+}
+
+func sameOrder(a []*gitlab.Issue, b []*gitlab.Issue) bool {
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+func TestRankIssues(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Jira.CustomField.Rank = "customfield_10105"
+
+	gl := fake.NewGitLab()
+	gl.AddProject(2, "group/project")
+
+	issueLinks := make(map[string]*JiraIssueLink)
+	for i, rank := range []string{"0|i0000c:", "0|i0000a:", ""} {
+		key := fmt.Sprintf("TEST-%d", i+1)
+		gitlabIssue, _, err := gl.CreateIssue(context.Background(), 2, &gitlabx.CreateIssueOptions{Title: &key})
+		assert.NoError(t, err)
+		jiraIssue := &jira.Issue{Key: key, Fields: &jira.IssueFields{Unknowns: map[string]interface{}{}}}
+		if rank != "" {
+			jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.Rank] = rank
+		}
+		issueLinks[key] = &JiraIssueLink{jiraIssue, gitlabIssue}
+	}
+
+	assert.NoError(t, rankIssues(context.Background(), gl, cfg, issueLinks))
+
+	var titles []string
+	for _, issue := range gl.Issues[2] {
+		titles = append(titles, issue.Title)
+	}
+	assert.Equal(t, []string{"TEST-2", "TEST-1", "TEST-3"}, titles)
+}
+
+func TestRankEpicIssues(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.CustomField.Rank = "customfield_10105"
+	ctx := context.Background()
+
+	gitlabEpic, _, err := gl.CreateEpic(ctx, 1, &gitlabx.CreateEpicOptions{Title: gitlab.String("TEST-1")})
+	assert.NoError(t, err)
+
+	issueLinks := make(map[string]*JiraIssueLink)
+	for i, rank := range []string{"0|i0000c:", "", "0|i0000a:"} {
+		key := fmt.Sprintf("TEST-%d", i+2)
+		gitlabIssue, _, err := gl.CreateIssue(ctx, 2, &gitlabx.CreateIssueOptions{Title: &key})
+		assert.NoError(t, err)
+		gitlabIssue.Epic = &gitlab.Epic{ID: gitlabEpic.ID, IID: gitlabEpic.IID}
+		jiraIssue := &jira.Issue{Key: key, Fields: &jira.IssueFields{Unknowns: map[string]interface{}{}}}
+		if rank != "" {
+			jiraIssue.Fields.Unknowns[cfg.Jira.CustomField.Rank] = rank
+		}
+		issueLinks[key] = &JiraIssueLink{jiraIssue, gitlabIssue}
+	}
+	epicLinks := map[string]*JiraEpicLink{"TEST-1": {&jira.Issue{Key: "TEST-1"}, gitlabEpic}}
+
+	assert.NoError(t, rankEpicIssues(ctx, gl, cfg, epicLinks, issueLinks))
+
+	epicIssues, err := gl.ListEpicIssues(ctx, 1, gitlabEpic.IID)
+	assert.NoError(t, err)
+	var titles []string
+	for _, issue := range epicIssues {
+		titles = append(titles, issue.Title)
+	}
+	assert.Equal(t, []string{"TEST-4", "TEST-2", "TEST-3"}, titles)
+}
@@ -0,0 +1,66 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+// addRawJSON adds the original JSON of the Jira issue to the GitLab issue as a note (raw_json)
+func addRawJSON(ctx context.Context, gl GitLabWriter, cfg *config.Config, jiraIssue *jira.Issue, gitlabIssue *gitlab.Issue) error {
+	data, err := json.MarshalIndent(jiraIssue, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error marshalling Jira issue: issue %s", jiraIssue.Key))
+	}
+	redacted, err := applyRedactions(string(data))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error applying redactions: issue %s", jiraIssue.Key))
+	}
+	data = []byte(redacted)
+
+	var body string
+	if cfg.RawJSON == config.RawJSONDetails {
+		body = fmt.Sprintf("<details><summary>Original Jira issue %s</summary>\n\n```json\n%s\n```\n\n</details>", jiraIssue.Key, data)
+	}
+
+	maxSize := cfg.GitLab.MaxNoteSize
+	if maxSize <= 0 {
+		maxSize = config.DefaultMaxNoteSize
+	}
+	if body == "" || len(body) > maxSize {
+		if body != "" {
+			log.Debugf("Uploading the JSON of %s, it is longer than a note", jiraIssue.Key)
+		}
+
+		filename := jiraIssue.Key + ".json"
+		file, _, err := gl.UploadFile(ctx, cfg.GitLab.Issue, bytes.NewReader(data), filename)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error uploading %s: issue %s", filename, jiraIssue.Key))
+		}
+		body = fmt.Sprintf("Original Jira issue: %s", file.Markdown)
+	}
+
+	if _, _, err := gl.CreateIssueNote(ctx, cfg.GitLab.Issue, gitlabIssue.IID, &gitlabx.CreateIssueNoteOptions{Body: &body}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating raw JSON note: issue %s", jiraIssue.Key))
+	}
+	return nil
+}
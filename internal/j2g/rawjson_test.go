@@ -0,0 +1,47 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestRawJSON(t *testing.T) {
+	for _, mode := range []string{config.RawJSONDetails, config.RawJSONAttachment} {
+		cfg, gl := newTestEnv(t)
+		cfg.RawJSON = mode
+
+		jiraIssue := newTestJiraIssue()
+		jiraIssue.Fields.Attachments = nil
+		jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10999": "unmapped value"}
+		jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+		gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+		assert.NoError(t, err)
+		notes := gl.IssueNotes[gitlabIssue.ID]
+		last := notes[len(notes)-1].Body
+		if mode == config.RawJSONDetails {
+			assert.True(t, strings.HasPrefix(last, "<details><summary>Original Jira issue TEST-1</summary>"))
+			assert.Contains(t, last, `"customfield_10999": "unmapped value"`)
+			assert.Empty(t, gl.Uploads[2])
+		} else {
+			assert.Contains(t, last, "TEST-1.json")
+			assert.Len(t, gl.Uploads[2], 1)
+		}
+	}
+}
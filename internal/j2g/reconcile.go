@@ -0,0 +1,185 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+// jiraKeyMarker is a line of every migrated description, before the footer; it finds the GitLab item of a Jira issue
+func jiraKeyMarker(cfg *config.Config, issueKey string) string {
+	return fmt.Sprintf("Imported from Jira [%s](%s/browse/%s)", issueKey, cfg.Jira.Host, issueKey)
+}
+
+// findIssueByMarker returns the GitLab issue with the Jira key marker, nil if there is none
+func findIssueByMarker(ctx context.Context, gl GitLabWriter, cfg *config.Config, pid interface{}, issueKey string) (*gitlab.Issue, error) {
+	issues, err := gl.SearchIssues(ctx, pid, issueKey)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error searching GitLab issues: issue %s", issueKey))
+	}
+
+	//* The search also matches PROJ-12 for PROJ-1
+	marker := jiraKeyMarker(cfg, issueKey)
+	for _, issue := range issues {
+		if strings.Contains(issue.Description, marker) {
+			return issue, nil
+		}
+	}
+	return nil, nil
+}
+
+// findEpicByMarker returns the GitLab epic with the Jira key marker, nil if there is none
+func findEpicByMarker(ctx context.Context, gl GitLabWriter, cfg *config.Config, gid interface{}, issueKey string) (*gitlab.Epic, error) {
+	epics, err := gl.SearchEpics(ctx, gid, issueKey)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error searching GitLab epics: issue %s", issueKey))
+	}
+
+	marker := jiraKeyMarker(cfg, issueKey)
+	for _, epic := range epics {
+		if strings.Contains(epic.Description, marker) {
+			return epic, nil
+		}
+	}
+	return nil, nil
+}
+
+// findMigratedIssue returns the GitLab issue of the state file, or with the Jira key marker. nil if it is not migrated.
+func findMigratedIssue(ctx context.Context, gl GitLabWriter, cfg *config.Config, migrationState *state.State, issueKey string) (*gitlab.Issue, error) {
+	item, ok := migrationState.Get(issueKey)
+	if !ok || item.Type != state.ItemTypeIssue {
+		return findIssueByMarker(ctx, gl, cfg, cfg.GitLab.Issue, issueKey)
+	}
+
+	issue, r, err := gl.GetIssue(ctx, item.ProjectID, item.IID)
+	if r != nil && r.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting GitLab issue %d: issue %s", item.IID, issueKey))
+	}
+	return issue, nil
+}
+
+// findMigratedEpic is findMigratedIssue for an epic
+func findMigratedEpic(ctx context.Context, gl GitLabWriter, cfg *config.Config, migrationState *state.State, issueKey string) (*gitlab.Epic, error) {
+	item, ok := migrationState.Get(issueKey)
+	if !ok || item.Type != state.ItemTypeEpic {
+		return findEpicByMarker(ctx, gl, cfg, cfg.GitLab.Epic, issueKey)
+	}
+
+	epic, r, err := gl.GetEpic(ctx, item.GroupID, item.IID)
+	if r != nil && r.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting GitLab epic %d: issue %s", item.IID, issueKey))
+	}
+	return epic, nil
+}
+
+// isCreateTimeout is true if the create may have succeeded on the server without an answer:
+// a client timeout or a gateway timeout of a proxy in front of GitLab
+func isCreateTimeout(r *gitlab.Response, err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return r != nil && (r.StatusCode == http.StatusBadGateway || r.StatusCode == http.StatusGatewayTimeout)
+}
+
+// createIssue creates the GitLab issue of the Jira issue.
+// After a timeout it looks for the issue with the Jira key marker, and creates it once more only if it isn't there.
+func createIssue(ctx context.Context, gl GitLabWriter, cfg *config.Config, pid interface{}, issueKey string, opt *gitlabx.CreateIssueOptions) (*gitlab.Issue, error) {
+	gitlabIssue, r, err := gl.CreateIssue(ctx, pid, opt)
+	if err == nil {
+		return gitlabIssue, nil
+	}
+	if ctx.Err() != nil || !isCreateTimeout(r, err) {
+		return nil, err
+	}
+
+	log.Warnf("Creating GitLab issue timed out, looking for it before retrying: issue %s (%s)", issueKey, err)
+	issue, searchErr := findIssueByMarker(ctx, gl, cfg, pid, issueKey)
+	if searchErr != nil {
+		return nil, errors.Wrap(searchErr, "Error after a timeout")
+	}
+	if issue != nil {
+		log.Infof("GitLab issue %d was created before the timeout: issue %s", issue.IID, issueKey)
+		return issue, nil
+	}
+
+	gitlabIssue, _, err = gl.CreateIssue(ctx, pid, opt)
+	return gitlabIssue, err
+}
+
+// createEpic is createIssue for an epic
+func createEpic(ctx context.Context, gl GitLabWriter, cfg *config.Config, gid interface{}, issueKey string, opt *gitlabx.CreateEpicOptions) (*gitlab.Epic, error) {
+	gitlabEpic, r, err := gl.CreateEpic(ctx, gid, opt)
+	if err == nil {
+		return gitlabEpic, nil
+	}
+	if ctx.Err() != nil || !isCreateTimeout(r, err) {
+		return nil, err
+	}
+
+	log.Warnf("Creating GitLab epic timed out, looking for it before retrying: issue %s (%s)", issueKey, err)
+	epic, searchErr := findEpicByMarker(ctx, gl, cfg, gid, issueKey)
+	if searchErr != nil {
+		return nil, errors.Wrap(searchErr, "Error after a timeout")
+	}
+	if epic != nil {
+		log.Infof("GitLab epic %d was created before the timeout: issue %s", epic.IID, issueKey)
+		return epic, nil
+	}
+
+	gitlabEpic, _, err = gl.CreateEpic(ctx, gid, opt)
+	return gitlabEpic, err
+}
+
+// createEpicNote creates the note of the epic.
+// After a timeout it looks for the note with the same body, and creates it once more only if it isn't there.
+func createEpicNote(ctx context.Context, gl GitLabWriter, gid interface{}, epicID int, opt *gitlabx.CreateEpicNoteOptions) (*gitlab.Note, error) {
+	note, r, err := gl.CreateEpicNote(ctx, gid, epicID, opt)
+	if err == nil {
+		return note, nil
+	}
+	if ctx.Err() != nil || !isCreateTimeout(r, err) {
+		return nil, err
+	}
+
+	log.Warnf("Creating GitLab epic note timed out, looking for it before retrying: epic ID %d (%s)", epicID, err)
+	notes, listErr := gl.ListEpicNotes(ctx, gid, epicID)
+	if listErr != nil {
+		return nil, errors.Wrap(listErr, "Error after a timeout")
+	}
+	for _, note := range notes {
+		if opt.Body != nil && note.Body == *opt.Body {
+			log.Infof("GitLab epic note %d was created before the timeout: epic ID %d", note.ID, epicID)
+			return note, nil
+		}
+	}
+
+	note, _, err = gl.CreateEpicNote(ctx, gid, epicID, opt)
+	return note, err
+}
@@ -0,0 +1,58 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestCreateIssueAfterTimeout(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	gl.CreateTimeouts = 1
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+
+	// The issue created before the timeout is found by the Jira key marker, not created twice
+	assert.Len(t, gl.Issues[2], 1)
+	assert.Equal(t, gl.Issues[2][0].ID, gitlabIssue.ID)
+}
+
+func TestRerunFindsEpic(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Only = config.PhaseEpics
+
+	epic := &jira.Issue{Key: "TEST-1", Fields: &jira.IssueFields{Summary: "Auth", Type: jira.IssueType{Name: "Epic"}, Status: &jira.Status{Name: "To Do"}, Priority: &jira.Priority{Name: "High"}, Comments: &jira.Comments{}}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, epic)
+	ctx := context.Background()
+
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	assert.Len(t, gl.Epics[1], 1)
+
+	//* The state file is lost, the epic is found by the Jira key marker
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.AllowNonEmpty = true
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	assert.Len(t, gl.Epics[1], 1)
+}
@@ -0,0 +1,84 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+// loadRegistry merges the state files of the migrations of the other Jira projects (linked_state_files).
+// Jira keys are unique across the projects, so one registry resolves every project.
+// A state file is replaced atomically, so the file of a migration running in parallel can be read.
+func loadRegistry(cfg *config.Config) (*state.State, error) {
+	registry := state.New(cfg.Jira.Host)
+	for _, path := range cfg.LinkedStateFiles {
+		linked, err := state.Load(path)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error loading linked state file: %s", path))
+		}
+		for key, item := range linked.Items {
+			registry.Items[key] = item
+		}
+		for oldKey, key := range linked.Moved {
+			registry.SetMoved(oldKey, key)
+		}
+		log.Debugf("Loaded %d items of linked state file %s", len(linked.Items), path)
+	}
+	return registry, nil
+}
+
+// withLinkedItems adds the items of the registry which are not migrated by this run.
+// They have no fields, so they are only the targets of the parents and links.
+func withLinkedItems(cfg *config.Config, epicLinks map[string]*JiraEpicLink, issueLinks map[string]*JiraIssueLink) (map[string]*JiraEpicLink, map[string]*JiraIssueLink, error) {
+	if len(cfg.LinkedStateFiles) == 0 {
+		return epicLinks, issueLinks, nil
+	}
+
+	registry, err := loadRegistry(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allEpicLinks := make(map[string]*JiraEpicLink, len(epicLinks))
+	for key, link := range epicLinks {
+		allEpicLinks[key] = link
+	}
+	allIssueLinks := make(map[string]*JiraIssueLink, len(issueLinks))
+	for key, link := range issueLinks {
+		allIssueLinks[key] = link
+	}
+
+	for key, item := range registry.Items {
+		if _, ok := allEpicLinks[key]; ok {
+			continue
+		}
+		if _, ok := allIssueLinks[key]; ok {
+			continue
+		}
+
+		stub := &jira.Issue{Key: key, Fields: &jira.IssueFields{}}
+		switch item.Type {
+		case state.ItemTypeEpic:
+			allEpicLinks[key] = &JiraEpicLink{stub, item.GitLabEpic()}
+		case state.ItemTypeIssue:
+			allIssueLinks[key] = &JiraIssueLink{stub, item.GitLabIssue()}
+		}
+	}
+
+	return allEpicLinks, allIssueLinks, nil
+}
@@ -0,0 +1,62 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// Jira Remote Link (Confluence, Web Link) -> "Links" section of the description
+func formatRemoteLinks(ctx context.Context, jr JiraReader, jiraIssue *jira.Issue) (string, error) {
+	remoteLinks, _, err := jr.GetRemoteLinks(ctx, jiraIssue.Key)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error getting remote links: issue %s", jiraIssue.Key))
+	}
+
+	if remoteLinks == nil || len(*remoteLinks) == 0 {
+		return "", nil
+	}
+
+	lines := []string{}
+	for _, remoteLink := range *remoteLinks {
+		if remoteLink.Object == nil || remoteLink.Object.URL == "" {
+			continue
+		}
+
+		title := remoteLink.Object.Title
+		if title == "" {
+			title = remoteLink.Object.URL
+		}
+
+		line := fmt.Sprintf("* [%s](%s)", title, remoteLink.Object.URL)
+		if remoteLink.Relationship != "" {
+			line += fmt.Sprintf(" (%s)", remoteLink.Relationship)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	result, err := applyRewriteRules(fmt.Sprintf("\n\n### Links\n\n%s", strings.Join(lines, "\n")))
+	if err != nil {
+		return "", errors.Wrap(err, "Error applying rewrite rules")
+	}
+
+	return result, nil
+}
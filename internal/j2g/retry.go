@@ -0,0 +1,127 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/quarantine"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+// RetryFailed runs the migration of the quarantined items only and returns the items which are still failing.
+// The items of the state file are the targets of their parents and links.
+func RetryFailed(ctx context.Context, gl GitLabWriter, jr JiraReader) ([]*quarantine.Item, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+	if cfg.Quarantine == "" {
+		return nil, errors.New("retry-failed requires the quarantine file (quarantine)")
+	}
+	if cfg.StateFile == "" {
+		return nil, errors.New("retry-failed requires the state file (state_file)")
+	}
+
+	quarantined, err := quarantine.Load(cfg.Quarantine)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error loading quarantine file: %s", cfg.Quarantine))
+	}
+	items := quarantined.List()
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	migrationState, err := state.Load(cfg.StateFile)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", cfg.StateFile))
+	}
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.JiraKey)
+
+		//* Migrated since it failed (e.g. migrate issue), the run releases it
+		if _, ok := migrationState.Get(item.JiraKey); ok {
+			continue
+		}
+		if err := deleteFailedItem(ctx, gl, cfg, item); err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("Retrying %d quarantined items: %s", len(keys), strings.Join(keys, ", "))
+
+	//* Only the quarantined issues are searched, the others are resolved through the state file
+	jql := fmt.Sprintf("key in (%s)", strings.Join(keys, ", "))
+	if cfg.Jira.Jql != "" {
+		jql = fmt.Sprintf("(%s) AND %s", cfg.Jira.Jql, jql)
+	}
+	cfg.Jira.Jql = jql
+
+	linked := false
+	for _, path := range cfg.LinkedStateFiles {
+		if path == cfg.StateFile {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		cfg.LinkedStateFiles = append(cfg.LinkedStateFiles, cfg.StateFile)
+	}
+
+	runErr := ConvertByProject(ctx, gl, jr)
+
+	quarantined, err = quarantine.Load(cfg.Quarantine)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error loading quarantine file: %s", cfg.Quarantine))
+	}
+	return quarantined.List(), runErr
+}
+
+// deleteFailedItem deletes what the failed attempt left in GitLab, e.g. an issue without the comments which failed
+// The item is searched in its target, the targets of the config are used for a quarantine file without it.
+func deleteFailedItem(ctx context.Context, gl GitLabWriter, cfg *config.Config, item *quarantine.Item) error {
+	switch item.Type {
+	case state.ItemTypeEpic:
+		target := item.Target
+		if target == "" {
+			target = cfg.GitLab.Epic
+		}
+		epic, err := findEpicByMarker(ctx, gl, cfg, target, item.JiraKey)
+		if err != nil || epic == nil {
+			return err
+		}
+		log.Infof("Deleting GitLab epic %d of the failed attempt: issue %s", epic.IID, item.JiraKey)
+		if _, err := gl.DeleteEpic(ctx, epic.GroupID, epic.IID); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error deleting GitLab epic %d: issue %s", epic.IID, item.JiraKey))
+		}
+	default:
+		target := item.Target
+		if target == "" {
+			target = cfg.GitLab.Issue
+		}
+		issue, err := findIssueByMarker(ctx, gl, cfg, target, item.JiraKey)
+		if err != nil || issue == nil {
+			return err
+		}
+		log.Infof("Deleting GitLab issue %d of the failed attempt: issue %s", issue.IID, item.JiraKey)
+		if _, err := gl.DeleteIssue(ctx, issue.ProjectID, issue.IID); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error deleting GitLab issue %d: issue %s", issue.IID, item.JiraKey))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/quarantine"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+func TestQuarantine(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Quarantine = filepath.Join(t.TempDir(), "quarantine.json")
+
+	gl.NoteErrors = 1
+
+	first := newTestJiraIssue()
+	first.Fields.Attachments = nil
+	second := newTestJiraIssue()
+	second.Key = "TEST-2"
+	second.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, first, second)
+
+	//* The comment of one issue fails, the other one is migrated
+	assert.ErrorIs(t, ConvertByProject(context.Background(), gl, jr), ErrQuarantined)
+	quarantined, err := quarantine.Load(cfg.Quarantine)
+	assert.NoError(t, err)
+	items := quarantined.List()
+	if !assert.Len(t, items, 1) {
+		return
+	}
+	assert.Equal(t, state.ItemTypeIssue, items[0].Type)
+	assert.Contains(t, items[0].Error, "401")
+
+	//* The issue left by the failed attempt is replaced
+	items, err = RetryFailed(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Len(t, gl.Issues[2], 2)
+	for _, issue := range gl.Issues[2] {
+		assert.Len(t, gl.IssueNotes[issue.ID], 1)
+	}
+	assert.NoFileExists(t, cfg.Quarantine)
+}
+
+func TestQuarantineRoute(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Quarantine = filepath.Join(t.TempDir(), "quarantine.json")
+	cfg.Routes = []config.Route{{Components: []string{"backend"}, Project: "group/backend"}}
+
+	gl.AddProject(3, "group/backend")
+	gl.NoteErrors = 1
+
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = nil
+	issue.Fields.Components = []*jira.Component{{Name: "Backend"}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue)
+
+	assert.ErrorIs(t, ConvertByProject(context.Background(), gl, jr), ErrQuarantined)
+	quarantined, err := quarantine.Load(cfg.Quarantine)
+	assert.NoError(t, err)
+	items := quarantined.List()
+	if !assert.Len(t, items, 1) {
+		return
+	}
+	assert.Equal(t, "group/backend", items[0].Target)
+
+	//* The issue left in the project of the route is replaced, not duplicated
+	items, err = RetryFailed(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	if assert.Len(t, gl.Issues[3], 1) {
+		assert.Len(t, gl.IssueNotes[gl.Issues[3][0].ID], 1)
+	}
+	assert.Empty(t, gl.Issues[2])
+}
@@ -0,0 +1,161 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// routeIndex returns the route of the Jira issue: index + 1 of the first matching route, len(routes) + 1 if no route matches
+func routeIndex(cfg *config.Config, jiraIssue *jira.Issue) int {
+	for i, route := range cfg.Routes {
+		if matchesRoute(&route, jiraIssue) {
+			return i + 1
+		}
+	}
+	return len(cfg.Routes) + 1
+}
+
+func matchesRoute(route *config.Route, jiraIssue *jira.Issue) bool {
+	if jiraIssue.Fields == nil {
+		return false
+	}
+	fields := jiraIssue.Fields
+
+	if len(route.IssueTypes) > 0 && !containsAny(route.IssueTypes, fields.Type.Name, fields.Type.ID) {
+		return false
+	}
+	if len(route.Components) > 0 {
+		var components []string
+		for _, component := range fields.Components {
+			components = append(components, component.Name)
+		}
+		if !containsAny(route.Components, components...) {
+			return false
+		}
+	}
+	if len(route.Labels) > 0 && !containsAny(route.Labels, fields.Labels...) {
+		return false
+	}
+	return true
+}
+
+// containsAny is true if one of the values is in the list, ignoring empty values
+func containsAny(list []string, values ...string) bool {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, item := range list {
+			if strings.EqualFold(item, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterRoute keeps the Jira issues of the route of the run (route_index)
+func filterRoute(cfg *config.Config, jiraIssues []*jira.Issue) []*jira.Issue {
+	var result []*jira.Issue
+	for _, jiraIssue := range jiraIssues {
+		if routeIndex(cfg, jiraIssue) == cfg.RouteIndex {
+			result = append(result, jiraIssue)
+		}
+	}
+	return result
+}
+
+// routeStateFile is the state file of the route, <state_file>.route<N>.json for state.json. The issues of no route keep the state file.
+func routeStateFile(cfg *config.Config, index int) string {
+	if cfg.StateFile == "" || index > len(cfg.Routes) {
+		return cfg.StateFile
+	}
+	ext := filepath.Ext(cfg.StateFile)
+	return fmt.Sprintf("%s.route%d%s", strings.TrimSuffix(cfg.StateFile, ext), index, ext)
+}
+
+// migrateRoutes runs the migration once per route with its GitLab project and group, the issues of no route last.
+// Like the site migration, each phase runs for every route before the next one, so the links across the routes are resolved through their state files.
+func migrateRoutes(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config) error {
+	defer config.SetConfig(cfg)
+	defer startGroupCache()()
+
+	if cfg.StateFile == "" {
+		log.Warn("Routes without a state file: the links across the routes are not migrated")
+	}
+
+	routeCfgs := make([]*config.Config, 0, len(cfg.Routes)+1)
+	for index := 1; index <= len(cfg.Routes)+1; index++ {
+		routeCfg := *cfg
+		routeCfg.RouteIndex = index
+		routeCfg.StateFile = routeStateFile(cfg, index)
+		if index <= len(cfg.Routes) {
+			route := cfg.Routes[index-1]
+			routeCfg.GitLab.Issue = route.Project
+			routeCfg.GitLab.IssueID = 0
+			if route.Epic != "" {
+				routeCfg.GitLab.Epic = route.Epic
+				routeCfg.GitLab.EpicID = 0
+			}
+		}
+		routeCfgs = append(routeCfgs, &routeCfg)
+	}
+	for _, routeCfg := range routeCfgs {
+		routeCfg.LinkedStateFiles = append([]string{}, cfg.LinkedStateFiles...)
+		for _, other := range routeCfgs {
+			if other != routeCfg && other.StateFile != "" && other.StateFile != routeCfg.StateFile {
+				routeCfg.LinkedStateFiles = append(routeCfg.LinkedStateFiles, other.StateFile)
+			}
+		}
+	}
+
+	//* Nothing migrated yet: the projects must not be in use, the group of the epics may be shared by the routes
+	if err := checkEmptyRunTargets(ctx, gl, routeCfgs); err != nil {
+		return err
+	}
+
+	phases := []string{config.PhaseEpics, config.PhaseIssues, config.PhaseLinks}
+	if cfg.Only != "" {
+		phases = []string{cfg.Only}
+	}
+	for _, phase := range phases {
+		for _, routeCfg := range routeCfgs {
+			if err := ctx.Err(); err != nil {
+				return ErrInterrupted
+			}
+
+			phaseCfg := *routeCfg
+			phaseCfg.Only = phase
+			//* The targets are checked before the phases
+			phaseCfg.AllowNonEmpty = true
+			config.SetConfig(&phaseCfg)
+
+			log.Infof("Route %d: %s to %s", phaseCfg.RouteIndex, phase, phaseCfg.GitLab.Issue)
+			if err := ConvertByProject(ctx, gl, jr); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error migrating route %d to %s", phaseCfg.RouteIndex, phaseCfg.GitLab.Issue))
+			}
+			//* The targets resolved by the run are kept for the next phases
+			routeCfg.GitLab = phaseCfg.GitLab
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+func TestRoutes(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Routes = []config.Route{{Components: []string{"backend"}, Project: "group/backend"}}
+
+	gl.AddProject(3, "group/backend")
+
+	frontend := newTestJiraIssue()
+	frontend.Fields.Attachments = nil
+	backend := newTestJiraIssue()
+	backend.Key = "TEST-2"
+	backend.Fields.Attachments = nil
+	backend.Fields.Components = []*jira.Component{{Name: "Backend"}}
+	backend.Fields.IssueLinks = []*jira.IssueLink{{Type: jira.IssueLinkType{Name: "Relates"}, OutwardIssue: &jira.Issue{Key: "TEST-1"}}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, frontend, backend)
+
+	assert.NoError(t, ConvertByProject(context.Background(), gl, jr))
+	if assert.Len(t, gl.Issues[2], 1) && assert.Len(t, gl.Issues[3], 1) {
+		assert.Contains(t, gl.Issues[2][0].Description, "TEST-1")
+		assert.Contains(t, gl.Issues[3][0].Description, "TEST-2")
+	}
+	assert.FileExists(t, filepath.Join(filepath.Dir(cfg.StateFile), "state.route1.json"))
+
+	//* The link across the routes is resolved through the state files
+	assert.Len(t, gl.IssueLinks, 1)
+}
+
+func TestRoutesNonEmptyTarget(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Routes = []config.Route{{Components: []string{"backend"}, Project: "group/backend"}}
+
+	gl.AddProject(3, "group/backend")
+	ctx := context.Background()
+
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue)
+
+	//* The project of a route is in use
+	_, _, err := gl.CreateIssue(ctx, "group/backend", &gitlabx.CreateIssueOptions{Title: gitlab.String("Real work")})
+	assert.NoError(t, err)
+	err = ConvertByProject(ctx, gl, jr)
+	assert.ErrorIs(t, err, ErrNonEmptyTarget)
+	assert.Empty(t, gl.Issues[2])
+}
+
+func TestRoutesCompared(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+	cfg.Routes = []config.Route{{Components: []string{"backend"}, Project: "group/backend"}}
+
+	gl.AddProject(3, "group/backend")
+	ctx := context.Background()
+
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = nil
+	issue.Fields.Components = []*jira.Component{{Name: "Backend"}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue)
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+
+	//* The routed issues are not in the default targets and state file, they are rejected instead of missing or migrated again
+	_, _, err := Verify(ctx, gl, jr)
+	assert.Error(t, err)
+	_, _, err = Sync(ctx, gl, jr)
+	assert.Error(t, err)
+	_, err = Diff(ctx, gl, jr)
+	assert.Error(t, err)
+	_, err = MigrateIssue(ctx, gl, jr, issue.Key, false)
+	assert.Error(t, err)
+	assert.Len(t, gl.Issues[3], 1)
+}
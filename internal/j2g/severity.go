@@ -0,0 +1,90 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+// incidentIssueType is the GitLab issue type of the incidents (Monitor > Incidents)
+const incidentIssueType = "incident"
+
+// S1-S4 -> GraphQL severity
+var gitlabSeverities = map[string]string{
+	"S1": gitlabx.SeverityCritical,
+	"S2": gitlabx.SeverityHigh,
+	"S3": gitlabx.SeverityMedium,
+	"S4": gitlabx.SeverityLow,
+}
+
+// isJiraIncident is true for an issue of an incident issue type, name or ID (severity.issue_types)
+func isJiraIncident(cfg *config.Config, jiraIssue *jira.Issue) bool {
+	for _, issueType := range cfg.Severity.IssueTypes {
+		if jiraIssue.Fields.Type.Name == issueType || (jiraIssue.Fields.Type.ID != "" && jiraIssue.Fields.Type.ID == issueType) {
+			return true
+		}
+	}
+	return false
+}
+
+// issueSeverity returns the severity (S1-S4) of the first rule of the matrix matching the Jira issue, empty if none matches
+func issueSeverity(cfg *config.Config, jiraIssue *jira.Issue) string {
+	var priority, severity string
+	if jiraIssue.Fields.Priority != nil {
+		priority = jiraIssue.Fields.Priority.Name
+	}
+	if cfg.Severity.Field != "" {
+		severity = customFieldText(jiraIssue.Fields.Unknowns[cfg.Severity.Field])
+	}
+
+	for _, rule := range cfg.Severity.Matrix {
+		if len(rule.Priorities) > 0 && !containsAny(rule.Priorities, priority) {
+			continue
+		}
+		if len(rule.Severities) > 0 && !containsAny(rule.Severities, severity) {
+			continue
+		}
+		return rule.Severity
+	}
+	return ""
+}
+
+// severityLabel is the scoped label of the severity, e.g. severity::S1
+func severityLabel(severity string) string {
+	return fmt.Sprintf("severity::%s", severity)
+}
+
+// setIssueSeverity sets the severity of the GitLab incident of the Jira issue, the other issues only have the label
+func setIssueSeverity(ctx context.Context, gl GitLabWriter, cfg *config.Config, jiraIssue *jira.Issue, gitlabIssue *gitlab.Issue) error {
+	if !isJiraIncident(cfg, jiraIssue) || isJiraTestCase(cfg, jiraIssue) {
+		return nil
+	}
+
+	severity := issueSeverity(cfg, jiraIssue)
+	if severity == "" {
+		log.Debugf("No severity rule matches: issue %s", jiraIssue.Key)
+		return nil
+	}
+
+	if err := gl.SetIssueSeverity(ctx, cfg.GitLab.Issue, gitlabIssue.IID, gitlabSeverities[severity]); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error setting severity %s: issue %s", severity, jiraIssue.Key))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestSeverityMatrix(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Severity.Field = "customfield_10080"
+	cfg.Severity.IssueTypes = []string{"Incident"}
+	cfg.Severity.Matrix = []config.SeverityRule{
+		{Priorities: []string{"Highest"}, Severities: []string{"Critical"}, Severity: "S1"},
+		{Priorities: []string{"Highest", "High"}, Severity: "S2"},
+		{Severity: "S3"},
+	}
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Type = jira.IssueType{Name: "Incident"}
+	jiraIssue.Fields.Priority = &jira.Priority{Name: "Highest"}
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10080": map[string]interface{}{"value": "Critical"}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	ctx := context.Background()
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(ctx, gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Equal(t, "incident", *gitlabIssue.IssueType)
+	assert.Contains(t, gitlabIssue.Labels, "severity::S1")
+	assert.NoError(t, setIssueSeverity(ctx, gl, cfg, jiraIssue, gitlabIssue))
+	assert.Equal(t, "CRITICAL", gl.IssueSeverities[gitlabIssue.ID])
+
+	//* Only the priority matches, the other issue types get the label without the severity
+	jiraIssue.Fields.Unknowns["customfield_10080"] = map[string]interface{}{"value": "Minor"}
+	assert.Equal(t, "S2", issueSeverity(cfg, jiraIssue))
+	jiraIssue.Fields.Priority = &jira.Priority{Name: "Low"}
+	jiraIssue.Fields.Type = jira.IssueType{Name: "Bug"}
+	assert.Equal(t, "S3", issueSeverity(cfg, jiraIssue))
+	assert.False(t, isJiraIncident(cfg, jiraIssue))
+}
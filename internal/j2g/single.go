@@ -0,0 +1,237 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+// MigrateIssue (re)migrates one Jira issue without the rest of the project.
+// The previous GitLab issue is deleted and created again with force, the links to the migrated issues are restored.
+func MigrateIssue(ctx context.Context, gl GitLabWriter, jr JiraReader, issueKey string, force bool) (*gitlab.Issue, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+	if len(cfg.Routes) > 0 {
+		return nil, errors.New("An issue can't be migrated alone with routes, each route has its own GitLab targets and state file")
+	}
+	//* Scratch directory of the attachments
+	closeScratch, err := openScratch(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error opening scratch directory")
+	}
+	defer closeScratch()
+	defer startUploadCache()()
+
+	issueKey = strings.ToUpper(issueKey)
+	if err := resolveTargets(ctx, gl, cfg); err != nil {
+		return nil, errors.Wrap(err, "Error resolving GitLab targets")
+	}
+	gateFeatures(ctx, gl, cfg)
+
+	//* Jira Issue
+	jiraIssues, err := jr.SearchIssues(ctx, fmt.Sprintf("project = %s AND key = %s", cfg.Jira.Name, issueKey))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira issue: %s", issueKey))
+	}
+	var jiraIssue *jira.Issue
+	for _, issue := range jiraIssues {
+		if issue.Key == issueKey {
+			jiraIssue = issue
+		}
+	}
+	if jiraIssue == nil {
+		return nil, errors.Errorf("Jira issue %s is not found in project %s", issueKey, cfg.Jira.Name)
+	}
+
+	epicMode, err := resolveEpicMode(ctx, gl, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error resolving epic mode")
+	}
+	if isJiraEpic(cfg, jiraIssue) && epicMode != config.EpicModeIssue {
+		return nil, errors.Errorf("%s is an epic, only issues can be migrated one by one", issueKey)
+	}
+
+	//* State
+	migrationState := state.New(cfg.Jira.Host)
+	if cfg.StateFile != "" {
+		migrationState, err = state.Load(cfg.StateFile)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", cfg.StateFile))
+		}
+		migrationState.JiraHost = cfg.Jira.Host
+	}
+
+	//* Previous GitLab Issue
+	previous, err := findMigratedIssue(ctx, gl, cfg, migrationState, issueKey)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		if !force {
+			return nil, errors.Errorf("%s is already migrated to %s, use --force to replace it", issueKey, previous.WebURL)
+		}
+
+		log.Infof("Deleting GitLab issue %s migrated from %s", previous.WebURL, issueKey)
+		if _, err := gl.DeleteIssue(ctx, previous.ProjectID, previous.IID); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error deleting GitLab issue %d: issue %s", previous.IID, issueKey))
+		}
+	}
+
+	//* Users, Labels, Milestones of the issue
+	userMap, err := newUserMap(ctx, gl, jr, []*jira.Issue{jiraIssue}, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating user map")
+	}
+
+	existingGroupLabels, existingProjectLabels, err := listExistingLabels(ctx, gl, cfg.GitLab.Epic, cfg.GitLab.Issue)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting GitLab labels")
+	}
+	existingIssueLabels := existingProjectLabels
+	if cfg.GitLab.LabelLevel != config.LabelLevelProject {
+		existingIssueLabels = mergeLabels(existingProjectLabels, existingGroupLabels)
+	}
+
+	//* The sprints may be group milestones (gitlab.milestone_level), the versions win like in the project migration
+	milestones := make(map[string]*Milestone)
+	for _, target := range []milestoneTarget{sprintMilestoneTarget(cfg, cfg.GitLab.Issue), versionMilestoneTarget(cfg, cfg.GitLab.Issue)} {
+		existingMilestones, err := target.list(ctx, gl)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error getting GitLab milestones")
+		}
+		for _, milestone := range existingMilestones {
+			milestones[milestone.Title] = &Milestone{Milestone: milestone}
+		}
+	}
+
+	iterations := make(map[string]*gitlabx.Iteration)
+	if cfg.GitLab.Iterations.Enabled {
+		iterations, err = migrateSprintsToIterations(ctx, gl, jr, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating GitLab iterations")
+		}
+	}
+
+	crmContacts := &CRMContacts{}
+	if cfg.CRM.Field != "" {
+		crmContacts, err = listCRMContacts(ctx, gl, cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error getting GitLab CRM contacts")
+		}
+	}
+
+	//* Issue
+	log.Infof("Converting issue: %s", issueKey)
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(ctx, gl, jr, jiraIssue, userMap, existingIssueLabels, milestones)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", issueKey))
+	}
+
+	if cfg.GitLab.Iterations.Enabled {
+		if err := setIssueIteration(ctx, gl, cfg, jiraIssue, gitlabIssue, iterations); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", issueKey))
+		}
+	}
+
+	if len(cfg.Severity.Matrix) > 0 {
+		if err := setIssueSeverity(ctx, gl, cfg, jiraIssue, gitlabIssue); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", issueKey))
+		}
+	}
+
+	if cfg.CRM.Field != "" {
+		if err := setIssueCRMContacts(ctx, gl, cfg, jiraIssue, gitlabIssue, crmContacts); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error converting issue: %s", issueKey))
+		}
+	}
+
+	migrationState.SetIssue(issueKey, gitlabIssue)
+	migrationState.SetSynced(issueKey, syncedFields(cfg, jiraIssue, false, epicMode == config.EpicModeIssue))
+	if cfg.StateFile != "" {
+		if err := migrationState.Save(cfg.StateFile); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error saving state file: %s", cfg.StateFile))
+		}
+	}
+
+	//* Link
+	epicLinks, issueLinks := singleIssueLinks(cfg, migrationState, jiraIssue, gitlabIssue)
+	if err := Link(ctx, gl, jr, epicLinks, issueLinks); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error linking issue: %s", issueKey))
+	}
+
+	return gitlabIssue, nil
+}
+
+// singleIssueLinks returns the links of Link for one issue.
+// The migrated issues of the state file only link back to the issue: inward links and sub-tasks.
+func singleIssueLinks(cfg *config.Config, migrationState *state.State, jiraIssue *jira.Issue, gitlabIssue *gitlab.Issue) (map[string]*JiraEpicLink, map[string]*JiraIssueLink) {
+	epicLinks := make(map[string]*JiraEpicLink)
+	issueLinks := map[string]*JiraIssueLink{jiraIssue.Key: {jiraIssue, gitlabIssue}}
+
+	stub := func(key string) *JiraIssueLink {
+		if link, ok := issueLinks[key]; ok {
+			return link
+		}
+		item, ok := migrationState.Get(key)
+		if !ok || item.Type != state.ItemTypeIssue {
+			return nil
+		}
+		link := &JiraIssueLink{&jira.Issue{Key: key, Fields: &jira.IssueFields{}}, item.GitLabIssue()}
+		issueLinks[key] = link
+		return link
+	}
+
+	//* Parent
+	if parentKey := findParentKey(cfg, jiraIssue); parentKey != "" {
+		if item, ok := migrationState.Get(parentKey); ok && item.Type == state.ItemTypeEpic {
+			epicLinks[parentKey] = &JiraEpicLink{&jira.Issue{Key: parentKey, Fields: &jira.IssueFields{}}, item.GitLabEpic()}
+		} else {
+			stub(parentKey)
+		}
+	}
+
+	//* Sub-tasks
+	for _, subtask := range jiraIssue.Fields.Subtasks {
+		if link := stub(subtask.Key); link != nil {
+			link.Fields.Parent = &jira.Parent{Key: jiraIssue.Key}
+		}
+	}
+
+	//* Issue links, outward links are created by the issue itself
+	for _, issueLink := range jiraIssue.Fields.IssueLinks {
+		if issueLink.OutwardIssue != nil {
+			stub(issueLink.OutwardIssue.Key)
+		}
+		if issueLink.InwardIssue != nil {
+			if link := stub(issueLink.InwardIssue.Key); link != nil {
+				link.Fields.IssueLinks = append(link.Fields.IssueLinks, &jira.IssueLink{
+					Type:         issueLink.Type,
+					OutwardIssue: &jira.Issue{Key: jiraIssue.Key, Fields: &jira.IssueFields{Type: jiraIssue.Fields.Type}},
+				})
+			}
+		}
+	}
+
+	return epicLinks, issueLinks
+}
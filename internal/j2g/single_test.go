@@ -0,0 +1,39 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestMigrateIssue(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	jiraIssue := newTestJiraIssue()
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Attachments["1"] = []byte("log")
+
+	first, err := MigrateIssue(context.Background(), gl, jr, "test-1", false)
+	assert.NoError(t, err)
+
+	_, err = MigrateIssue(context.Background(), gl, jr, "TEST-1", false)
+	assert.ErrorContains(t, err, "--force")
+
+	second, err := MigrateIssue(context.Background(), gl, jr, "TEST-1", true)
+	assert.NoError(t, err)
+	assert.Len(t, gl.Issues[2], 1)
+	assert.NotEqual(t, first.ID, second.ID)
+}
@@ -0,0 +1,237 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// SiteProject is a Jira project of the site migration and its GitLab project
+type SiteProject struct {
+	Key      string
+	Name     string
+	Category string
+
+	Path      string // full path of the GitLab project
+	Group     string // full path of the group of the epics, the namespace of the project
+	StateFile string
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// siteFuncs are the functions of site.project_path
+var siteFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"slug": func(s string) string {
+		return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	},
+}
+
+// PlanSite returns the Jira projects of the site migration (site.category, site.exclude) and their GitLab projects
+func PlanSite(ctx context.Context, jr JiraReader) ([]*SiteProject, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+
+	text := cfg.Site.ProjectPath
+	if text == "" {
+		text = config.DefaultSiteProjectPath
+	}
+	tmpl, err := template.New("project_path").Funcs(siteFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing site.project_path")
+	}
+
+	stateDir := cfg.Site.StateDir
+	if stateDir == "" {
+		stateDir = config.DefaultSiteStateDir
+	}
+
+	jiraProjects, err := jr.ListProjects(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error listing Jira projects")
+	}
+
+	exclude := make(map[string]bool)
+	for _, key := range cfg.Site.Exclude {
+		exclude[strings.ToUpper(key)] = true
+	}
+
+	var projects []*SiteProject
+	for _, jiraProject := range *jiraProjects {
+		if exclude[jiraProject.Key] {
+			continue
+		}
+		if cfg.Site.Category != "" && !strings.EqualFold(jiraProject.ProjectCategory.Name, cfg.Site.Category) {
+			continue
+		}
+
+		project := &SiteProject{
+			Key:       jiraProject.Key,
+			Name:      jiraProject.Name,
+			Category:  jiraProject.ProjectCategory.Name,
+			StateFile: filepath.Join(stateDir, jiraProject.Key+".json"),
+		}
+
+		var path strings.Builder
+		if err := tmpl.Execute(&path, project); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error rendering site.project_path: project %s", jiraProject.Key))
+		}
+		project.Path = strings.Trim(cfg.GitLab.Epic, "/") + "/" + strings.Trim(path.String(), "/")
+		project.Group = project.Path[:strings.LastIndex(project.Path, "/")]
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
+
+// MigrateSite migrates every Jira project of the site to its GitLab project, the groups and projects are created if they don't exist.
+// Each phase runs for every project before the next one, so the links across the projects are resolved through their state files.
+// The clients and their caches are shared by the projects.
+func MigrateSite(ctx context.Context, gl GitLabWriter, jr JiraReader) ([]*SiteProject, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting config")
+	}
+	if len(cfg.Routes) > 0 {
+		return nil, errors.New("routes can't be used with the site migration, the projects have their own targets")
+	}
+	defer config.SetConfig(cfg)
+	//* The projects of a category share the group of the epics
+	defer startGroupCache()()
+
+	projects, err := PlanSite(ctx, jr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, project := range projects {
+		if err := provisionSiteProject(ctx, gl, project); err != nil {
+			return nil, err
+		}
+	}
+
+	projectCfgs := make([]*config.Config, 0, len(projects))
+	for _, project := range projects {
+		projectCfg := *cfg
+		projectCfg.Jira.Name = project.Key
+		projectCfg.GitLab.Issue = project.Path
+		projectCfg.GitLab.Epic = project.Group
+		projectCfg.GitLab.IssueID = 0
+		projectCfg.GitLab.EpicID = 0
+		projectCfg.StateFile = project.StateFile
+		projectCfg.LinkedStateFiles = append([]string{}, cfg.LinkedStateFiles...)
+		for _, other := range projects {
+			if other != project {
+				projectCfg.LinkedStateFiles = append(projectCfg.LinkedStateFiles, other.StateFile)
+			}
+		}
+		projectCfgs = append(projectCfgs, &projectCfg)
+	}
+
+	//* Nothing migrated yet: the projects must not be in use, the group of the epics is shared by the projects of a category
+	if err := checkEmptyRunTargets(ctx, gl, projectCfgs); err != nil {
+		return projects, err
+	}
+
+	for _, phase := range []string{config.PhaseEpics, config.PhaseIssues, config.PhaseLinks} {
+		for i, project := range projects {
+			if err := ctx.Err(); err != nil {
+				return projects, ErrInterrupted
+			}
+
+			phaseCfg := *projectCfgs[i]
+			phaseCfg.Only = phase
+			//* The targets are checked before the phases
+			phaseCfg.AllowNonEmpty = true
+			config.SetConfig(&phaseCfg)
+
+			log.Infof("Site migration: %s of %s to %s", phase, project.Key, project.Path)
+			if err := ConvertByProject(ctx, gl, jr); err != nil {
+				return projects, errors.Wrap(err, fmt.Sprintf("Error migrating Jira project %s", project.Key))
+			}
+		}
+	}
+
+	return projects, nil
+}
+
+// provisionSiteProject creates the GitLab project of the Jira project and its groups if they don't exist
+func provisionSiteProject(ctx context.Context, gl GitLabWriter, project *SiteProject) error {
+	_, r, err := gl.GetProject(ctx, project.Path)
+	if err == nil {
+		return nil
+	}
+	if r == nil || r.StatusCode != http.StatusNotFound {
+		return errors.Wrap(err, fmt.Sprintf("Error getting GitLab project: %s", project.Path))
+	}
+
+	namespaceID, err := ensureGroupPath(ctx, gl, project.Group)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Creating GitLab project %s of Jira project %s", project.Path, project.Key)
+	_, _, err = gl.CreateProject(ctx, &gitlab.CreateProjectOptions{
+		Name:        gitlab.String(project.Name),
+		Path:        gitlab.String(project.Path[strings.LastIndex(project.Path, "/")+1:]),
+		NamespaceID: gitlab.Int(namespaceID),
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating GitLab project: %s", project.Path))
+	}
+	return nil
+}
+
+// ensureGroupPath returns the ID of the group, the missing subgroups are created. The root group must exist.
+func ensureGroupPath(ctx context.Context, gl GitLabWriter, fullPath string) (int, error) {
+	group, r, err := gl.GetGroup(ctx, fullPath)
+	if err == nil {
+		return group.ID, nil
+	}
+	if r == nil || r.StatusCode != http.StatusNotFound {
+		return 0, errors.Wrap(err, fmt.Sprintf("Error getting GitLab group: %s", fullPath))
+	}
+
+	i := strings.LastIndex(fullPath, "/")
+	if i < 0 {
+		return 0, errors.Errorf("GitLab group %s is not found, the root group of the site must exist", fullPath)
+	}
+	parentID, err := ensureGroupPath(ctx, gl, fullPath[:i])
+	if err != nil {
+		return 0, err
+	}
+
+	name := fullPath[i+1:]
+	log.Infof("Creating GitLab group %s", fullPath)
+	group, _, err = gl.CreateGroup(ctx, &gitlab.CreateGroupOptions{
+		Name:     gitlab.String(name),
+		Path:     gitlab.String(name),
+		ParentID: gitlab.Int(parentID),
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("Error creating GitLab group: %s", fullPath))
+	}
+	return group.ID, nil
+}
@@ -0,0 +1,64 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestMigrateSite(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Site.StateDir = t.TempDir()
+	cfg.Site.Exclude = []string{"old"}
+	config.SetConfig(cfg)
+	defer config.SetConfig(nil)
+
+	gl := fake.NewGitLab()
+	gl.AddGroup(1, "group")
+
+	app := newTestJiraIssue()
+	app.Key = "APP-1"
+	app.Fields.Attachments = nil
+	app.Fields.IssueLinks = []*jira.IssueLink{{Type: jira.IssueLinkType{Name: "Relates"}, OutwardIssue: &jira.Issue{Key: "OPS-1", Fields: &jira.IssueFields{}}}}
+	ops := newTestJiraIssue()
+	ops.Key = "OPS-1"
+	ops.Fields.Attachments = nil
+	jr := fake.NewJira(nil, app, ops)
+	jr.Projects = jira.ProjectList{
+		{Key: "APP", Name: "App", ProjectCategory: jira.ProjectCategory{Name: "Product Teams"}},
+		{Key: "OPS", Name: "Ops"},
+		{Key: "OLD", Name: "Old"},
+	}
+
+	projects, err := MigrateSite(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	if !assert.Len(t, projects, 2) {
+		return
+	}
+	assert.Equal(t, "group/product-teams/app", projects[0].Path)
+	assert.Equal(t, "group/product-teams", projects[0].Group)
+	assert.Equal(t, "group/ops", projects[1].Path)
+
+	appProject, _, err := gl.GetProject(context.Background(), "group/product-teams/app")
+	assert.NoError(t, err)
+	opsProject, _, err := gl.GetProject(context.Background(), "group/ops")
+	assert.NoError(t, err)
+	if assert.Len(t, gl.Issues[appProject.ID], 1) && assert.Len(t, gl.Issues[opsProject.ID], 1) {
+		//* The link to the project migrated after it
+		assert.Len(t, gl.IssueLinks[gl.Issues[appProject.ID][0].ID], 1)
+	}
+}
@@ -0,0 +1,240 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+// Conflict is a field changed in both Jira and GitLab since the last sync, it is not synced (sync.conflict_policy: skip-and-report)
+type Conflict struct {
+	JiraKey string
+	WebURL  string
+	Field   string
+	Base    string // at the migration or the last sync, empty if the state file doesn't have it
+	Jira    string
+	GitLab  string
+}
+
+// Sync writes the Jira fields changed since the last sync to the migrated GitLab issues and epics.
+// A field changed in GitLab only is kept, a field changed in both is resolved by sync.conflict_policy.
+// The Jira issues without a GitLab item are migrated by run, not by the sync.
+func Sync(ctx context.Context, gl GitLabWriter, jr JiraReader) (changes []*Change, conflicts []*Conflict, err error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error getting config")
+	}
+	if len(cfg.Routes) > 0 {
+		return nil, nil, errors.New("The issues can't be synced with routes, each route has its own GitLab targets and state file")
+	}
+
+	issues, epicMode, migrationState, err := readComparedIssues(ctx, gl, jr, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//* The items synced before an error are saved, the next sync doesn't apply them again
+	if cfg.StateFile != "" {
+		defer func() {
+			saveErr := migrationState.Save(cfg.StateFile)
+			if saveErr == nil {
+				return
+			}
+			if err != nil {
+				log.Errorf("Error saving state file %s: %s", cfg.StateFile, saveErr)
+				return
+			}
+			err = errors.Wrap(saveErr, fmt.Sprintf("Error saving state file: %s", cfg.StateFile))
+		}()
+	}
+
+	for _, jiraIssue := range issues {
+		item, err := getMigratedItem(ctx, gl, cfg, migrationState, jiraIssue.Key, jiraIssue.isEpic)
+		if err != nil {
+			return nil, nil, err
+		}
+		if item == nil {
+			continue
+		}
+
+		epicAsIssue := epicMode == config.EpicModeIssue
+		fields := syncedFields(cfg, jiraIssue.Issue, jiraIssue.isEpic, epicAsIssue)
+		base := map[string]string{}
+		if stateItem, ok := migrationState.Get(jiraIssue.Key); ok && stateItem.Synced != nil {
+			base = stateItem.Synced
+		}
+
+		//* The fields in sync and the applied fields are the base of the next sync
+		synced := make(map[string]string)
+		for name, value := range fields {
+			synced[name] = value
+		}
+
+		change := &Change{JiraKey: jiraIssue.Key, Action: ChangeUpdate, WebURL: item.webURL, IsEpic: jiraIssue.isEpic}
+		if diff := diffMigratedItem(cfg, jiraIssue.Issue, item, jiraIssue.isEpic, epicAsIssue); diff != nil {
+			for _, field := range diff.Fields {
+				baseValue, hasBase := base[field.Field]
+
+				//* Changed in GitLab only, the GitLab value is kept
+				if hasBase && baseValue == fields[field.Field] {
+					continue
+				}
+
+				//* Without a base, the field may have changed on both sides
+				if !hasBase || gitlabFieldChanged(field.Field, baseValue, item) {
+					switch cfg.Sync.ConflictPolicy {
+					case config.ConflictPolicyJiraWins:
+						log.Warnf("Overwriting %s changed in GitLab with Jira: issue %s", field.Field, jiraIssue.Key)
+					case config.ConflictPolicyGitLabWins:
+						log.Infof("Keeping %s changed in GitLab: issue %s", field.Field, jiraIssue.Key)
+						continue
+					default:
+						conflicts = append(conflicts, &Conflict{jiraIssue.Key, item.webURL, field.Field, baseValue, fields[field.Field], field.From})
+						if hasBase {
+							synced[field.Field] = baseValue
+						} else {
+							delete(synced, field.Field)
+						}
+						continue
+					}
+				}
+
+				change.Fields = append(change.Fields, field)
+			}
+		}
+
+		if len(change.Fields) > 0 {
+			log.Infof("Syncing %s: %s", jiraIssue.Key, item.webURL)
+			if err := applyFieldChanges(ctx, gl, item, change, fields); err != nil {
+				return changes, conflicts, err
+			}
+			changes = append(changes, change)
+		}
+		migrationState.SetSynced(jiraIssue.Key, synced)
+	}
+
+	//* The overview issues follow the synced epics and their children
+	if epicMode == config.EpicModeEpic && cfg.GitLab.EpicOverview {
+		epicLinks := make(map[string]*JiraEpicLink)
+		issueLinks := make(map[string]*JiraIssueLink)
+		for _, jiraIssue := range issues {
+			item, ok := migrationState.Get(jiraIssue.Key)
+			if !ok {
+				continue
+			}
+			if jiraIssue.isEpic && item.Type == state.ItemTypeEpic {
+				epicLinks[jiraIssue.Key] = &JiraEpicLink{jiraIssue.Issue, item.GitLabEpic()}
+			} else if !jiraIssue.isEpic && item.Type == state.ItemTypeIssue {
+				issueLinks[jiraIssue.Key] = &JiraIssueLink{jiraIssue.Issue, item.GitLabIssue()}
+			}
+		}
+		if err := mirrorEpicOverviews(ctx, gl, cfg, epicLinks, issueLinks, map[string]string{}); err != nil {
+			return changes, conflicts, errors.Wrap(err, "Error mirroring epics to overview issues")
+		}
+	}
+
+	return changes, conflicts, nil
+}
+
+// gitlabFieldChanged is true if the GitLab value of the field differs from the base
+func gitlabFieldChanged(field string, base string, item *migratedItem) bool {
+	switch field {
+	case SyncFieldTitle:
+		return item.title != base
+	case SyncFieldState:
+		return item.state != base
+	case SyncFieldDueDate:
+		return item.dueDate != base
+	case SyncFieldLabels:
+		added, removed := diffLabels(splitLabels(base), item.labels)
+		return len(added) > 0 || len(removed) > 0
+	}
+	return false
+}
+
+// applyFieldChanges writes the Jira values of the changed fields to the GitLab issue or epic
+func applyFieldChanges(ctx context.Context, gl GitLabWriter, item *migratedItem, change *Change, fields map[string]string) error {
+	var title, stateEvent *string
+	var dueDate *gitlab.ISOTime
+	var added, removed []string
+	for _, field := range change.Fields {
+		switch field.Field {
+		case SyncFieldTitle:
+			title = gitlab.String(fields[SyncFieldTitle])
+		case SyncFieldState:
+			stateEvent = gitlab.String("reopen")
+			if fields[SyncFieldState] == "closed" {
+				stateEvent = gitlab.String("close")
+			}
+		case SyncFieldDueDate:
+			//* The API can't remove a due date, it is left to the user
+			date, err := time.Parse("2006-01-02", fields[SyncFieldDueDate])
+			if err != nil {
+				log.Warnf("Skipping due date removed in Jira: issue %s", change.JiraKey)
+				continue
+			}
+			dueDate = isoDate(date)
+		case SyncFieldLabels:
+			added, removed = diffLabels(splitLabels(fields[SyncFieldLabels]), item.labels)
+		}
+	}
+
+	if change.IsEpic {
+		opt := &gitlab.UpdateEpicOptions{Title: title, StateEvent: stateEvent}
+		if len(added) > 0 || len(removed) > 0 {
+			labels := gitlab.Labels(applyLabelChanges(item.labels, added, removed))
+			opt.Labels = &labels
+		}
+		if _, _, err := gl.UpdateEpic(ctx, item.parentID, item.iid, opt); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error updating GitLab epic %d: issue %s", item.iid, change.JiraKey))
+		}
+		return nil
+	}
+
+	opt := &gitlab.UpdateIssueOptions{Title: title, StateEvent: stateEvent, DueDate: dueDate}
+	if len(added) > 0 {
+		addLabels := gitlab.Labels(added)
+		opt.AddLabels = &addLabels
+	}
+	if len(removed) > 0 {
+		removeLabels := gitlab.Labels(removed)
+		opt.RemoveLabels = &removeLabels
+	}
+	if _, _, err := gl.UpdateIssue(ctx, item.parentID, item.iid, opt); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error updating GitLab issue %d: issue %s", item.iid, change.JiraKey))
+	}
+	return nil
+}
+
+// applyLabelChanges returns the labels without the removed labels and with the added labels
+func applyLabelChanges(labels []string, added []string, removed []string) []string {
+	drop := make(map[string]bool)
+	for _, label := range removed {
+		drop[label] = true
+	}
+
+	var result []string
+	for _, label := range labels {
+		if !drop[label] {
+			result = append(result, label)
+		}
+	}
+	return append(result, added...)
+}
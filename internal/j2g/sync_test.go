@@ -0,0 +1,116 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+func TestSync(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	jiraIssue := newTestJiraIssue()
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Attachments["1"] = []byte("log")
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	migrationState := state.New(cfg.Jira.Host)
+	migrationState.SetIssue(jiraIssue.Key, gitlabIssue)
+	migrationState.SetSynced(jiraIssue.Key, syncedFields(cfg, jiraIssue, false, false))
+	assert.NoError(t, migrationState.Save(cfg.StateFile))
+
+	//* Jira: the summary and the priority, GitLab: the priority and the state
+	jiraIssue.Fields.Summary = "New summary"
+	jiraIssue.Fields.Priority.Name = "Low"
+	gitlabIssue.Labels = append(gitlab.Labels{"priority::Medium"}, gitlabIssue.Labels[1:]...)
+	gitlabIssue.State = "opened"
+
+	changes, conflicts, err := Sync(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "New summary", gitlabIssue.Title)
+	assert.Equal(t, "opened", gitlabIssue.State)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, SyncFieldLabels, conflicts[0].Field)
+
+	//* The conflict is reported again, Jira wins with jira-wins
+	_, conflicts, err = Sync(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Len(t, conflicts, 1)
+
+	cfg.Sync.ConflictPolicy = config.ConflictPolicyJiraWins
+	_, conflicts, err = Sync(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Contains(t, gitlabIssue.Labels, "priority::Low")
+	assert.NotContains(t, gitlabIssue.Labels, "priority::Medium")
+}
+
+// failingUpdateGitLab fails the update of an issue
+type failingUpdateGitLab struct {
+	*fake.GitLab
+	iid int
+}
+
+func (g *failingUpdateGitLab) UpdateIssue(ctx context.Context, pid interface{}, iid int, opt *gitlab.UpdateIssueOptions) (*gitlab.Issue, *gitlab.Response, error) {
+	if iid == g.iid {
+		return nil, nil, errors.New("update failed")
+	}
+	return g.GitLab.UpdateIssue(ctx, pid, iid, opt)
+}
+
+func TestSyncSavesStateOnError(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	first := newTestJiraIssue()
+	first.Fields.Attachments = nil
+	second := newTestJiraIssue()
+	second.Key = "TEST-2"
+	second.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, first, second)
+
+	migrationState := state.New(cfg.Jira.Host)
+	var secondIID int
+	for _, jiraIssue := range []*jira.Issue{first, second} {
+		gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+		assert.NoError(t, err)
+		migrationState.SetIssue(jiraIssue.Key, gitlabIssue)
+		migrationState.SetSynced(jiraIssue.Key, syncedFields(cfg, jiraIssue, false, false))
+		secondIID = gitlabIssue.IID
+	}
+	assert.NoError(t, migrationState.Save(cfg.StateFile))
+
+	//* The first issue is synced before the update of the second one fails
+	first.Fields.Summary = "New summary"
+	second.Fields.Summary = "Other summary"
+	_, _, err := Sync(context.Background(), &failingUpdateGitLab{gl, secondIID}, jr)
+	assert.Error(t, err)
+
+	saved, err := state.Load(cfg.StateFile)
+	assert.NoError(t, err)
+	item, _ := saved.Get(first.Key)
+	assert.Equal(t, "New summary", item.Synced[SyncFieldTitle])
+	item, _ = saved.Get(second.Key)
+	assert.NotEqual(t, "Other summary", item.Synced[SyncFieldTitle])
+}
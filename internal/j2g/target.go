@@ -0,0 +1,140 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+// resolveTargets looks up the GitLab project, group and users of the config once per run.
+// gitlab.issue and gitlab.epic become full paths (GraphQL needs them) and their IDs are kept in the config,
+// users given by username in user.csv get their IDs.
+func resolveTargets(ctx context.Context, gl GitLabWriter, cfg *config.Config) error {
+	if cfg.GitLab.IssueID == 0 {
+		project, _, err := gl.GetProject(ctx, cfg.GitLab.Issue)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting GitLab project: %s", cfg.GitLab.Issue))
+		}
+		if project.PathWithNamespace != "" && project.PathWithNamespace != cfg.GitLab.Issue {
+			log.Debugf("GitLab project %s: %s", cfg.GitLab.Issue, project.PathWithNamespace)
+			cfg.GitLab.Issue = project.PathWithNamespace
+		}
+		cfg.GitLab.IssueID = project.ID
+	}
+
+	if cfg.GitLab.EpicID == 0 {
+		group, _, err := gl.GetGroup(ctx, cfg.GitLab.Epic)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting GitLab group: %s", cfg.GitLab.Epic))
+		}
+		if group.FullPath != "" && group.FullPath != cfg.GitLab.Epic {
+			log.Debugf("GitLab group %s: %s", cfg.GitLab.Epic, group.FullPath)
+			cfg.GitLab.Epic = group.FullPath
+		}
+		cfg.GitLab.EpicID = group.ID
+	}
+
+	for jiraUsername, gitlabUsername := range cfg.UserNames {
+		users, _, err := gl.ListUsers(ctx, &gitlab.ListUsersOptions{Username: gitlab.String(gitlabUsername)})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting GitLab user: %s", gitlabUsername))
+		}
+		if len(users) == 0 {
+			return errors.Errorf("GitLab user %s of Jira user %s is not found", gitlabUsername, jiraUsername)
+		}
+		if cfg.Users == nil {
+			cfg.Users = make(map[string]int)
+		}
+		cfg.Users[jiraUsername] = users[0].ID
+	}
+	cfg.UserNames = nil
+
+	return nil
+}
+
+// ErrNonEmptyTarget is returned when the GitLab project or group already has issues or epics (run --allow-non-empty)
+var ErrNonEmptyTarget = errors.New("GitLab target is not empty")
+
+// checkEmptyTargets refuses a new migration into a project or group in use, the migrated issues would be mixed into the real work.
+// A resumed migration (the state file has items) and --allow-non-empty skip the check.
+func checkEmptyTargets(ctx context.Context, gl GitLabWriter, cfg *config.Config, epics bool, migrationState *state.State) error {
+	if cfg.AllowNonEmpty || len(migrationState.Items) > 0 {
+		return nil
+	}
+
+	issues, err := gl.CountIssues(ctx, cfg.GitLab.Issue)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error counting GitLab issues: %s", cfg.GitLab.Issue))
+	}
+	if issues > 0 {
+		return errors.Wrap(ErrNonEmptyTarget, fmt.Sprintf("GitLab project %s has %d issues, run with --allow-non-empty to migrate into it anyway", cfg.GitLab.Issue, issues))
+	}
+
+	if !epics {
+		return nil
+	}
+	count, err := gl.CountEpics(ctx, cfg.GitLab.Epic)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error counting GitLab epics: %s", cfg.GitLab.Epic))
+	}
+	if count > 0 {
+		return errors.Wrap(ErrNonEmptyTarget, fmt.Sprintf("GitLab group %s has %d epics, run with --allow-non-empty to migrate into it anyway", cfg.GitLab.Epic, count))
+	}
+	return nil
+}
+
+// checkEmptyRunTargets runs checkEmptyTargets once per project of the site or route before their phases.
+// The group of the epics may be shared by them: it is counted once, and not at all when one of them is resumed.
+// The run of each phase then skips the check (the targets fill up with the earlier phases).
+func checkEmptyRunTargets(ctx context.Context, gl GitLabWriter, cfgs []*config.Config) error {
+	if len(cfgs) == 0 || cfgs[0].AllowNonEmpty {
+		return nil
+	}
+
+	states := make([]*state.State, len(cfgs))
+	inUse := make(map[string]bool)
+	for i, cfg := range cfgs {
+		states[i] = state.New(cfg.Jira.Host)
+		if cfg.StateFile != "" {
+			migrationState, err := state.Load(cfg.StateFile)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", cfg.StateFile))
+			}
+			states[i] = migrationState
+		}
+		if len(states[i].Items) > 0 {
+			inUse[cfg.GitLab.Epic] = true
+		}
+	}
+
+	for i, cfg := range cfgs {
+		epicMode, err := resolveEpicMode(ctx, gl, cfg)
+		if err != nil {
+			return errors.Wrap(err, "Error resolving epic mode")
+		}
+		epics := epicMode == config.EpicModeEpic && !inUse[cfg.GitLab.Epic]
+		if err := checkEmptyTargets(ctx, gl, cfg, epics, states[i]); err != nil {
+			return err
+		}
+		if epics {
+			inUse[cfg.GitLab.Epic] = true
+		}
+	}
+	return nil
+}
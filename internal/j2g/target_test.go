@@ -0,0 +1,58 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestResolveTargets(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.GitLab.Issue = "2"
+	cfg.GitLab.Epic = "1"
+	cfg.UserNames = map[string]string{"jeff": "jeff.gitlab"}
+
+	gl.Users[10] = &gitlab.User{ID: 10, Username: "jeff.gitlab"}
+
+	assert.NoError(t, resolveTargets(context.Background(), gl, cfg))
+	assert.Equal(t, "group/project", cfg.GitLab.Issue)
+	assert.Equal(t, 2, cfg.GitLab.IssueID)
+	assert.Equal(t, "group", cfg.GitLab.Epic)
+	assert.Equal(t, 1, cfg.GitLab.EpicID)
+	assert.Equal(t, 10, cfg.Users["jeff"])
+
+	cfg.UserNames = map[string]string{"kim": "nobody"}
+	assert.Error(t, resolveTargets(context.Background(), gl, cfg))
+}
+
+func TestNonEmptyTarget(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+
+	gl.Issues[2] = []*gitlab.Issue{{ID: 100, IID: 1, ProjectID: 2, Title: "Real work"}}
+	issue := newTestJiraIssue()
+	issue.Fields.Attachments = nil
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, issue)
+	ctx := context.Background()
+
+	err := ConvertByProject(ctx, gl, jr)
+	assert.ErrorIs(t, err, ErrNonEmptyTarget)
+	assert.Len(t, gl.Issues[2], 1)
+
+	cfg.AllowNonEmpty = true
+	assert.NoError(t, ConvertByProject(ctx, gl, jr))
+	assert.Len(t, gl.Issues[2], 2)
+}
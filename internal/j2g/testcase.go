@@ -0,0 +1,172 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// testCaseIssueType is the GitLab issue type of the test cases (Quality > Test cases)
+const testCaseIssueType = "test_case"
+
+// isJiraTestCase is true for an issue of a test issue type, name or ID (jira.test_case.issue_types)
+func isJiraTestCase(cfg *config.Config, jiraIssue *jira.Issue) bool {
+	for _, issueType := range cfg.Jira.TestCase.IssueTypes {
+		if jiraIssue.Fields.Type.Name == issueType || (jiraIssue.Fields.Type.ID != "" && jiraIssue.Fields.Type.ID == issueType) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestStep is a step of a manual test
+type TestStep struct {
+	Action         string
+	Data           string
+	ExpectedResult string
+}
+
+// testSteps parses the steps field, the steps of Xray Server/DC with the fields of each step or the step, data and result of the Xray REST API
+// e.g. {"steps": [{"index": 1, "fields": {"Action": "Login", "Data": "", "Expected Result": "Home"}}]}
+func testSteps(value interface{}) ([]*TestStep, bool) {
+	if field, ok := value.(map[string]interface{}); ok {
+		value = field["steps"]
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	// raw returns the text of a step value, a text or {"raw": text, "rendered": html}
+	raw := func(value interface{}) string {
+		if field, ok := value.(map[string]interface{}); ok {
+			value = field["raw"]
+		}
+		text, _ := value.(string)
+		return text
+	}
+
+	var steps []*TestStep
+	for _, v := range values {
+		step, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if fields, ok := step["fields"].(map[string]interface{}); ok {
+			steps = append(steps, &TestStep{Action: raw(fields["Action"]), Data: raw(fields["Data"]), ExpectedResult: raw(fields["Expected Result"])})
+		} else {
+			steps = append(steps, &TestStep{Action: raw(step["step"]), Data: raw(step["data"]), ExpectedResult: raw(step["result"])})
+		}
+	}
+	return steps, true
+}
+
+// formatTestSteps formats the steps of the test as a checklist (jira.test_case.steps), a text field as it is
+func formatTestSteps(cfg *config.Config, jiraIssue *jira.Issue, userMap UserMap) (string, error) {
+	if cfg.Jira.TestCase.Steps == "" {
+		return "", nil
+	}
+	value := jiraIssue.Fields.Unknowns[cfg.Jira.TestCase.Steps]
+
+	// markdown converts the Jira text of a step, the lines after the first one are indented in the list item
+	markdown := func(text string) (string, error) {
+		result, _, err := textToGitLabMarkdown(text, userMap, AttachmentMap{}, true)
+		if err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(strings.TrimSpace(result), "\n", "\n    "), nil
+	}
+
+	steps, ok := testSteps(value)
+	if !ok {
+		text, _ := value.(string)
+		if strings.TrimSpace(text) == "" {
+			return "", nil
+		}
+		result, _, err := textToGitLabMarkdown(text, userMap, AttachmentMap{}, true)
+		if err != nil {
+			return "", err
+		}
+		return "\n\n### Test steps\n\n" + strings.TrimSpace(result), nil
+	}
+	if len(steps) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n### Test steps\n\n")
+	for i, step := range steps {
+		action, err := markdown(step.Action)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "- [ ] **%d.** %s\n", i+1, action)
+		if step.Data != "" {
+			data, err := markdown(step.Data)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "  - Data: %s\n", data)
+		}
+		if step.ExpectedResult != "" {
+			result, err := markdown(step.ExpectedResult)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "  - Expected result: %s\n", result)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// formatTestRuns formats the table of the test runs of the test (jira.test_case.results)
+func formatTestRuns(ctx context.Context, jr JiraReader, cfg *config.Config, jiraIssue *jira.Issue, userMap UserMap) (string, error) {
+	if cfg.Jira.TestCase.Results == "" {
+		return "", nil
+	}
+
+	runs, r, err := jr.GetTestRuns(ctx, cfg.Jira.TestCase.Results, jiraIssue)
+	if err != nil {
+		//* The add-on is not installed or the issue is not a test of it
+		if r != nil && r.StatusCode == http.StatusNotFound {
+			log.Warnf("Test runs of %s are not found: issue %s", cfg.Jira.TestCase.Results, jiraIssue.Key)
+			return "", nil
+		}
+		return "", errors.Wrap(err, fmt.Sprintf("Error getting test runs: issue %s", jiraIssue.Key))
+	}
+	if len(runs) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n### Test runs\n\n| Run date | Status | Executed by | Execution |\n|---|---|---|---|\n")
+	for _, run := range runs {
+		executedBy := ""
+		if run.ExecutedBy != "" {
+			author, err := formatWorklogAuthor(&jira.User{Name: run.ExecutedBy}, userMap)
+			if err != nil {
+				return "", err
+			}
+			executedBy = tableCell(author)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", orDash(tableCell(run.Executed)), orDash(tableCell(run.Status)), orDash(executedBy), orDash(tableCell(run.Execution)))
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,47 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func TestTestCase(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Jira.TestCase.IssueTypes = []string{"Test"}
+	cfg.Jira.TestCase.Steps = "customfield_10200"
+	cfg.Jira.TestCase.Results = config.TestResultsXray
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Type = jira.IssueType{Name: "Test"}
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10200": map[string]interface{}{"steps": []interface{}{
+		map[string]interface{}{"index": 1.0, "fields": map[string]interface{}{"Action": "Open the *login* page", "Data": "", "Expected Result": "The form"}},
+		map[string]interface{}{"index": 2.0, "step": map[string]interface{}{"raw": "Submit"}, "data": "user", "result": map[string]interface{}{"raw": "Home"}},
+	}}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.TestRuns["TEST-1"] = []*jirax.TestRun{{Execution: "TEST-9", Status: "PASS", ExecutedBy: "jeff", Executed: "2023-09-07T09:00:00+09:00"}}
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Equal(t, "test_case", *gitlabIssue.IssueType)
+	assert.Contains(t, gitlabIssue.Description, "### Test runs\n\n| Run date | Status | Executed by | Execution |\n|---|---|---|---|\n| 2023-09-07T09:00:00+09:00 | PASS | jeff | TEST-9 |")
+	assert.Contains(t, gitlabIssue.Description, "### Test steps\n\n"+
+		"- [ ] **1.** Open the **login** page\n  - Expected result: The form\n"+
+		"- [ ] **2.** Submit\n  - Data: user\n  - Expected result: Home")
+}
@@ -12,23 +12,90 @@
 package j2g
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
 )
 
 func textToGitLabMarkdown(text string, userMap UserMap, attachments AttachmentMap, isProject bool) (string, []string, error) {
+	text, err := applyRedactions(text)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Error applying redactions")
+	}
+
 	result, usedAttachments, err := JiraToMD(text, attachments, userMap)
 	if err != nil {
 		return "", nil, errors.Wrap(err, "Error converting Jira to GitLab Markdown")
 	}
 
+	result, err = applyRewriteRules(result)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Error applying rewrite rules")
+	}
+
+	//* Again on the result, a rewrite rule or the markup may reveal a match
+	result, err = applyRedactions(result)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Error applying redactions")
+	}
+
 	return result, usedAttachments, nil
 }
 
+// Rewrite Rules (regex -> replacement) on the migrated bodies
+func applyRewriteRules(text string) (string, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "Error getting config")
+	}
+
+	for _, rule := range cfg.RewriteRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("Error compiling rewrite rule: %s", rule.Pattern))
+		}
+		text = re.ReplaceAllString(text, rule.Replacement)
+	}
+
+	return text, nil
+}
+
+// Redactions (regex -> replacement) of secrets, internal hostnames or PII
+func applyRedactions(text string) (string, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "Error getting config")
+	}
+
+	for _, rule := range cfg.Redactions {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("Error compiling redaction rule: %s", rule.Pattern))
+		}
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = config.DefaultRedaction
+		}
+		text = re.ReplaceAllString(text, replacement)
+	}
+
+	if cfg.Anonymize.Enabled {
+		text = emailPattern.ReplaceAllString(text, config.DefaultRedaction)
+	}
+
+	return text, nil
+}
+
 // comment -> comments : GitLab 작성자는 API owner이지만, 텍스트로 Jira 작성자를 표현
 func formatNote(issueKey string, jiraComment *jira.Comment, userMap UserMap, attachments AttachmentMap, isProject bool) (*string, *time.Time, []string, error) {
 	created, err := time.Parse("2006-01-02T15:04:05.000-0700", jiraComment.Created)
@@ -50,10 +117,137 @@ func formatNote(issueKey string, jiraComment *jira.Comment, userMap UserMap, att
 	}
 
 	result := fmt.Sprintf("%s\n\n%s by %s [[Original](%s)]",
-		markdownBody, dateFormat, jiraComment.Author.DisplayName, commentLink)
+		markdownBody, dateFormat, displayName(cfg, &jiraComment.Author), commentLink)
 	return &result, &created, usedAttachments, nil
 }
 
+// loadComments replaces the comments of the issue with every page of its comments with their properties.
+// The search returns the first page of the comments without their properties (jira.service_desk).
+func loadComments(ctx context.Context, jr JiraReader, cfg *config.Config, jiraIssue *jira.Issue) error {
+	//* An issue without comments has nothing to load
+	if jiraIssue.Fields.Comments == nil {
+		jiraIssue.Fields.Comments = &jira.Comments{}
+	}
+	if !needsAllComments(cfg, jiraIssue) {
+		return nil
+	}
+
+	comments, err := jr.GetComments(ctx, jiraIssue.Key)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error getting comments: issue %s", jiraIssue.Key))
+	}
+	jiraIssue.Fields.Comments = &jira.Comments{Comments: comments}
+	return nil
+}
+
+// needsAllComments is true if the comments of the search are not complete: a page of them, or without their properties
+func needsAllComments(cfg *config.Config, jiraIssue *jira.Issue) bool {
+	if jiraIssue.Fields.Comments == nil || len(jiraIssue.Fields.Comments.Comments) == 0 {
+		return false
+	}
+	return cfg.Jira.ServiceDesk || len(jiraIssue.Fields.Comments.Comments) >= jirax.SearchCommentLimit
+}
+
+// notePartMarkerSize is reserved in every part of a split note for its marker and code fences
+const notePartMarkerSize = 64
+
+// splitNoteBody splits a body longer than gitlab.max_note_size into parts with "(part 2/3)" markers.
+// The parts end at a line if possible, a code block split across parts is closed and opened again.
+func splitNoteBody(body string, maxSize int) []string {
+	if maxSize <= 0 {
+		maxSize = config.DefaultMaxNoteSize
+	}
+	if len(body) <= maxSize {
+		return []string{body}
+	}
+
+	size := maxSize - notePartMarkerSize
+	var parts []string
+	inCode := false
+	for len(body) > 0 {
+		n := len(body)
+		if n > size {
+			n = strings.LastIndex(body[:size], "\n") + 1
+			if n == 0 {
+				n = size
+				for n > 0 && !utf8.RuneStart(body[n]) {
+					n--
+				}
+			}
+		}
+		part := body[:n]
+		body = body[n:]
+
+		opened := inCode
+		if strings.Count(part, "```")%2 == 1 {
+			inCode = !inCode
+		}
+		if opened {
+			part = "```\n" + part
+		}
+		if inCode {
+			part = strings.TrimSuffix(part, "\n") + "\n```"
+		}
+		parts = append(parts, part)
+	}
+
+	for i, part := range parts {
+		parts[i] = fmt.Sprintf("(part %d/%d)\n\n%s", i+1, len(parts), part)
+	}
+	return parts
+}
+
+// sortCommentsByCreated returns the comments oldest first, the comments with an unknown date are last
+func sortCommentsByCreated(comments []*jira.Comment) []*jira.Comment {
+	created := make(map[*jira.Comment]time.Time, len(comments))
+	for _, comment := range comments {
+		if t, err := time.Parse("2006-01-02T15:04:05.000-0700", comment.Created); err == nil {
+			created[comment] = t
+		}
+	}
+
+	sorted := append([]*jira.Comment{}, comments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, okA := created[sorted[i]]
+		b, okB := created[sorted[j]]
+		if !okA || !okB {
+			return okA && !okB
+		}
+		return a.Before(b)
+	})
+	return sorted
+}
+
+// convertCommentVisibility applies restricted_comments to a comment restricted to a Jira role or group, or a JSM internal comment.
+// It returns the note body and if the note is internal, or nil if the comment is skipped.
+func convertCommentVisibility(cfg *config.Config, issueKey string, jiraComment *jira.Comment, body *string) (*string, bool) {
+	visibility := jiraComment.Visibility
+
+	//* JSM internal comment is restricted to the agents, it is never public
+	serviceDeskInternal := jirax.IsInternalComment(jiraComment)
+	if serviceDeskInternal {
+		visibility = jira.CommentVisibility{Type: "Service Management", Value: "agents"}
+	}
+
+	if visibility.Value == "" {
+		return body, false
+	}
+
+	switch cfg.RestrictedComments {
+	case config.RestrictedCommentsSkip:
+		log.Debugf("Skipping comment %s of issue %s restricted to %s %s", jiraComment.ID, issueKey, visibility.Type, visibility.Value)
+		return nil, false
+	case config.RestrictedCommentsPlaceholder:
+		commentLink := fmt.Sprintf("%s/browse/%s?focusedCommentId=%s", cfg.Jira.Host, issueKey, jiraComment.ID)
+		placeholder := fmt.Sprintf("_A comment restricted to the Jira %s %s is not migrated._ [[Original](%s)]", visibility.Type, visibility.Value, commentLink)
+		return &placeholder, false
+	case config.RestrictedCommentsPublic:
+		return body, serviceDeskInternal
+	default:
+		return body, true
+	}
+}
+
 func formatDescription(issue *jira.Issue, userMap UserMap, attachments AttachmentMap, isProject bool) (*string, []string, error) {
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -64,6 +258,62 @@ func formatDescription(issue *jira.Issue, userMap UserMap, attachments Attachmen
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Error converting Text to GitLab Markdown")
 	}
-	result := fmt.Sprintf("%s\n\nImported from Jira [%s](%s/browse/%s)", markdownDescription, issue.Key, cfg.Jira.Host, issue.Key)
+
+	//* Environment
+	if environmentSection(cfg) && strings.TrimSpace(issue.Fields.Environment) != "" {
+		environment, environmentAttachments, err := textToGitLabMarkdown(issue.Fields.Environment, userMap, attachments, isProject)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Error converting Environment to GitLab Markdown")
+		}
+		markdownDescription = fmt.Sprintf("%s\n\n### Environment\n\n%s", markdownDescription, environment)
+		usedAttachments = append(usedAttachments, environmentAttachments...)
+	}
+
+	result := markdownDescription
+
+	//* Metadata Table
+	if cfg.MetadataTable.Enabled {
+		table, err := formatMetadataTable(cfg, issue)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Error formatting metadata table")
+		}
+		result = table + "\n" + result
+	}
+
+	//* The Epic Name or summary which is not the title
+	if isJiraEpic(cfg, issue) {
+		if line := epicTitleHeader(cfg, issue); line != "" {
+			result = line + "\n\n" + result
+		}
+	}
+
+	//* Header and Footer
+	header, err := formatDescriptionTemplate("header", cfg.Description.Header, issue)
+	if err != nil {
+		return nil, nil, err
+	}
+	footer, err := formatDescriptionTemplate("footer", cfg.Description.Footer, issue)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header != "" {
+		result = header + "\n\n" + result
+	}
+
+	//* The templates may print any field of the issue, the marker is added after so a redaction can't break it
+	result, err = applyRedactions(result)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error applying redactions")
+	}
+	footer, err = applyRedactions(footer)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error applying redactions")
+	}
+
+	result = fmt.Sprintf("%s\n\n%s", result, jiraKeyMarker(cfg, issue.Key))
+	if footer != "" {
+		result = result + "\n\n" + footer
+	}
+
 	return &result, usedAttachments, nil
 }
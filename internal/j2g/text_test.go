@@ -0,0 +1,82 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestRedactions(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.RawJSON = config.RawJSONDetails
+	cfg.Redactions = []config.RedactionRule{
+		{Pattern: `(?i)password\s*=\s*\S+`, Replacement: "password=***"},
+		{Pattern: `db01\.corp\.example\.com`},
+	}
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Description = "Connect to *db01.corp.example.com* with PASSWORD=hunter2"
+	jiraIssue.Fields.Comments.Comments[0].Body = "password = hunter2 still works"
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Contains(t, gitlabIssue.Description, "Connect to **[REDACTED]** with password=***")
+
+	notes := gl.IssueNotes[gitlabIssue.ID]
+	if assert.Len(t, notes, 2) {
+		assert.True(t, strings.HasPrefix(notes[0].Body, "password=*** still works"))
+		assert.NotContains(t, notes[1].Body, "hunter2")
+		assert.NotContains(t, notes[1].Body, "db01")
+	}
+}
+
+func TestRedactionsKeepMarker(t *testing.T) {
+	cfg, _ := newTestEnv(t)
+	cfg.Redactions = []config.RedactionRule{{Pattern: `jira\.example\.com`}}
+	cfg.Description.Footer = `See {{.Key}} on jira.example.com`
+
+	//* The marker finds the issue on the next run, a redaction of the Jira host doesn't change it
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Description = "Moved from jira.example.com"
+	description, _, err := formatDescription(jiraIssue, UserMap{}, AttachmentMap{}, true)
+	assert.NoError(t, err)
+	assert.Contains(t, *description, jiraKeyMarker(cfg, jiraIssue.Key))
+	assert.Contains(t, *description, "Moved from [REDACTED]")
+	assert.True(t, strings.HasSuffix(*description, "See TEST-1 on [REDACTED]"))
+}
+
+func TestDescriptionTemplates(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Description.Header = `Reported by {{with .Fields.Reporter}}{{.DisplayName}}{{end}} on {{date .Fields.Created}}`
+	cfg.Description.Footer = `Team: {{field . "customfield_10050"}}`
+	config.SetConfig(cfg)
+	defer config.SetConfig(nil)
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Reporter = &jira.User{DisplayName: "Jeff"}
+	jiraIssue.Fields.Created = jira.Time(time.Date(2023, 9, 6, 10, 0, 0, 0, time.UTC))
+	jiraIssue.Fields.Unknowns = map[string]interface{}{"customfield_10050": map[string]interface{}{"value": "Platform"}}
+
+	description, _, err := formatDescription(jiraIssue, UserMap{}, AttachmentMap{}, true)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(*description, "Reported by Jeff on 2023-09-06\n\n"))
+	assert.True(t, strings.HasSuffix(*description, "\n\nTeam: Platform"))
+}
@@ -0,0 +1,80 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+// createThreadedNotes creates the Jira comments as discussions (gitlab.threaded_comments), a reply is added to the discussion of its parent.
+// GitLab discussions can't be internal, an internal comment is a note of its own. The parts of a long comment are in its discussion.
+func createThreadedNotes(ctx context.Context, gl GitLabWriter, pid interface{}, issue int, comments []*jira.Comment, parents map[string]string, maxNoteSize int, formatComment func(*jira.Comment) (*gitlabx.CreateIssueNoteOptions, error)) error {
+	discussions := make(map[string]string) // Jira comment ID -> GitLab discussion ID
+	for _, jiraComment := range comments {
+		options, err := formatComment(jiraComment)
+		if err != nil {
+			return err
+		}
+		if options == nil {
+			continue
+		}
+		parts := splitNoteBody(*options.Body, maxNoteSize)
+
+		if options.Internal != nil && *options.Internal {
+			for _, body := range parts {
+				part := *options
+				part.Body = gitlab.String(body)
+				if _, _, err := gl.CreateIssueNote(ctx, pid, issue, &part); err != nil {
+					return errors.Wrap(err, fmt.Sprintf("Error creating note of comment %s", jiraComment.ID))
+				}
+			}
+			continue
+		}
+
+		//* A reply of a reply is in the discussion of the first comment
+		discussionID, ok := discussions[parents[jiraComment.ID]]
+		if !ok {
+			if parent, ok := parents[jiraComment.ID]; ok {
+				log.Debugf("Parent comment %s of reply %s is not migrated, starting a discussion", parent, jiraComment.ID)
+			}
+
+			discussion, _, err := gl.CreateIssueDiscussion(ctx, pid, issue, &gitlab.CreateIssueDiscussionOptions{
+				Body:      gitlab.String(parts[0]),
+				CreatedAt: options.CreatedAt,
+			})
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error creating discussion of comment %s", jiraComment.ID))
+			}
+			discussionID = discussion.ID
+			parts = parts[1:]
+		}
+
+		for _, body := range parts {
+			_, _, err := gl.AddIssueDiscussionNote(ctx, pid, issue, discussionID, &gitlab.AddIssueDiscussionNoteOptions{
+				Body:      gitlab.String(body),
+				CreatedAt: options.CreatedAt,
+			})
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error adding reply of comment %s", jiraComment.ID))
+			}
+		}
+		discussions[jiraComment.ID] = discussionID
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestThreadedComments(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.GitLab.ThreadedComments = true
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	author := jira.User{DisplayName: "Jane Doe"}
+	jiraIssue.Fields.Comments.Comments = []*jira.Comment{
+		{ID: "101", Body: "question", Author: author, Created: "2023-09-01T12:00:00.000+0900"},
+		{ID: "102", Body: "answer", Author: author, Created: "2023-09-02T12:00:00.000+0900"},
+		{ID: "103", Body: "thanks", Author: author, Created: "2023-09-03T12:00:00.000+0900"},
+		{ID: "104", Body: "other topic", Author: author, Created: "2023-09-04T12:00:00.000+0900"},
+	}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.CommentParents[jiraIssue.Key] = map[string]string{"102": "101", "103": "102"}
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	discussions := gl.IssueDiscussions[gitlabIssue.ID]
+	if assert.Len(t, discussions, 2) {
+		assert.Len(t, discussions[0].Notes, 3)
+		assert.True(t, strings.HasPrefix(discussions[0].Notes[2].Body, "thanks"))
+		assert.Len(t, discussions[1].Notes, 1)
+	}
+}
@@ -12,21 +12,25 @@
 package j2g
 
 import (
+	"context"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
+	"text/template"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	gitlab "github.com/xanzy/go-gitlab"
-	"golang.org/x/sync/errgroup"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
 )
 
 // Jira Username -> GitLab ID
 type UserMap map[string]*gitlab.User
 
-func newUserMap(gl *gitlab.Client, jiraIssues []*jira.Issue, users map[string]int) (UserMap, error) {
-	var g errgroup.Group
+func newUserMap(ctx context.Context, gl GitLabWriter, jr JiraReader, jiraIssues []*jira.Issue, cfg *config.Config) (UserMap, error) {
+	var g workerGroup
 	g.SetLimit(10)
 	mutex := sync.RWMutex{}
 
@@ -36,26 +40,65 @@ func newUserMap(gl *gitlab.Client, jiraIssues []*jira.Issue, users map[string]in
 	}
 
 	userMap := make(UserMap)
+	getUser := func(gitlabID int, jiraUsername string) func() error {
+		return func() error {
+			gitlabUser, _, err := gl.GetUser(ctx, gitlabID)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error getting GitLab user %d", gitlabID))
+			}
+
+			mutex.Lock()
+			userMap[jiraUsername] = gitlabUser
+			mutex.Unlock()
+
+			return nil
+		}
+	}
+
 	for _, jiraUsername := range jiraUsernames {
-		gitlabID, ok := users[jiraUsername]
+		//* Anonymized users are placeholders even if they are in users
+		if isAnonymized(cfg, jiraUsername) {
+			mutex.Lock()
+			userMap[jiraUsername] = newAnonymousUser(jiraUsername)
+			mutex.Unlock()
+			continue
+		}
+
+		gitlabID, ok := cfg.Users[jiraUsername]
+		if !ok && cfg.UnmappedUsers.Allow {
+			log.Warnf("No GitLab user found for Jira account ID %s, it is mentioned by the Jira name", jiraUsername)
+			unmappedUser := newUnmappedUser(ctx, jr, jiraIssues, jiraUsername)
+			mutex.Lock()
+			userMap[jiraUsername] = unmappedUser
+			mutex.Unlock()
+			continue
+		}
 		if !ok {
 			return nil, errors.New(fmt.Sprintf("No GitLab user found for Jira account ID %s", jiraUsername))
 		}
 
-		g.Go(func(gitlabID int, jiraUsername string) func() error {
-			return func() error {
-				gitlabUser, _, err := gl.Users.GetUser(gitlabID, gitlab.GetUsersOptions{})
-				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("Error getting GitLab user %d", gitlabID))
-				}
+		g.Go(getUser(gitlabID, jiraUsername))
+	}
 
+	//* Worklog authors are mentioned if they are in users, by their display name otherwise
+	if cfg.Worklogs.Mode == config.WorklogsSpend || cfg.Worklogs.Mode == config.WorklogsSummary {
+		known := make(map[string]bool)
+		for _, jiraUsername := range jiraUsernames {
+			known[jiraUsername] = true
+		}
+		for _, jiraUsername := range worklogAuthors(jiraIssues) {
+			if isAnonymized(cfg, jiraUsername) && !known[jiraUsername] {
+				known[jiraUsername] = true
 				mutex.Lock()
-				userMap[jiraUsername] = gitlabUser
+				userMap[jiraUsername] = newAnonymousUser(jiraUsername)
 				mutex.Unlock()
-
-				return nil
+				continue
+			}
+			if gitlabID, ok := cfg.Users[jiraUsername]; ok && !known[jiraUsername] {
+				known[jiraUsername] = true
+				g.Go(getUser(gitlabID, jiraUsername))
 			}
-		}(gitlabID, jiraUsername))
+		}
 	}
 
 	if err := g.Wait(); err != nil {
@@ -65,6 +108,80 @@ func newUserMap(gl *gitlab.Client, jiraIssues []*jira.Issue, users map[string]in
 	return userMap, nil
 }
 
+// DefaultUnmappedUserTemplate is the mention of a Jira user without a GitLab user (unmapped_users.template)
+const DefaultUnmappedUserTemplate = "**{{.DisplayName}} (Jira)**"
+
+// newUnmappedUser keeps the Jira user in a GitLab user without ID (Username: Jira username, Name: display name).
+// The display name is looked up on the issues or in Jira.
+func newUnmappedUser(ctx context.Context, jr JiraReader, jiraIssues []*jira.Issue, jiraUsername string) *gitlab.User {
+	jiraUser := findJiraUser(jiraIssues, jiraUsername)
+	if jiraUser == nil {
+		user, _, err := jr.GetUser(ctx, jiraUsername)
+		if err != nil {
+			log.Warnf("Error getting Jira user %s: %s", jiraUsername, err)
+			user = &jira.User{Name: jiraUsername}
+		}
+		jiraUser = user
+	}
+
+	name := jiraUser.DisplayName
+	if name == "" {
+		name = jiraUsername
+	}
+	return &gitlab.User{Username: jiraUsername, Name: name, Email: jiraUser.EmailAddress}
+}
+
+// findJiraUser returns the assignee, reporter or comment author of the issues with the username
+func findJiraUser(jiraIssues []*jira.Issue, jiraUsername string) *jira.User {
+	for _, issue := range jiraIssues {
+		if issue.Fields == nil {
+			continue
+		}
+		for _, user := range []*jira.User{issue.Fields.Assignee, issue.Fields.Reporter} {
+			if user != nil && user.Name == jiraUsername {
+				return user
+			}
+		}
+		if issue.Fields.Comments == nil {
+			continue
+		}
+		for _, comment := range issue.Fields.Comments.Comments {
+			if comment.Author.Name == jiraUsername {
+				return &comment.Author
+			}
+		}
+	}
+	return nil
+}
+
+// isUnmappedUser is true for a Jira user without a GitLab user (unmapped_users.allow)
+func isUnmappedUser(user *gitlab.User) bool {
+	return user.ID == 0
+}
+
+// formatUnmappedUser renders the mention of a Jira user without a GitLab user
+func formatUnmappedUser(user *gitlab.User) (string, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "Error getting config")
+	}
+
+	text := cfg.UnmappedUsers.Template
+	if text == "" {
+		text = DefaultUnmappedUserTemplate
+	}
+	tmpl, err := template.New("unmapped_user").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "Error parsing unmapped_users.template")
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, &jira.User{Name: user.Username, DisplayName: user.Name, EmailAddress: user.Email}); err != nil {
+		return "", errors.Wrap(err, "Error rendering unmapped_users.template")
+	}
+	return result.String(), nil
+}
+
 // @Ouput: Jira User List
 func GetJiraUsernamesFromIssues(issues []*jira.Issue) ([]string, error) {
 	usernameArray := make([]string, 0)
@@ -0,0 +1,40 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestUnmappedUser(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.UnmappedUsers.Allow = true
+
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Description = "Asked [~hong]\n\nand [~kim]"
+	jiraIssue.Fields.Assignee = &jira.User{Name: "hong", DisplayName: "홍길동"}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Users["kim"] = &jira.User{Name: "kim", DisplayName: "김철수"}
+
+	userMap, err := newUserMap(context.Background(), gl, jr, []*jira.Issue{jiraIssue}, cfg)
+	assert.NoError(t, err)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, userMap, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	assert.Contains(t, gitlabIssue.Description, "Asked **홍길동 (Jira)**\n\nand **김철수 (Jira)**")
+	assert.Empty(t, gitlabIssue.Assignees)
+}
@@ -0,0 +1,258 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+const (
+	MismatchMissing    = "missing"    // No GitLab issue or epic for the Jira issue
+	MismatchComments   = "comments"   // Jira comments without a note
+	MismatchAttachment = "attachment" // Jira attachment not in the description or the notes
+	MismatchLabels     = "labels"     // Labels of the Jira issue not on the GitLab item
+)
+
+// Mismatch is a difference between a Jira issue and its GitLab issue or epic
+type Mismatch struct {
+	JiraKey string
+	Kind    string
+	Detail  string
+}
+
+// migratedItem is the GitLab issue or epic of a Jira issue re-read from GitLab
+type migratedItem struct {
+	iid         int
+	parentID    int // project of an issue, group of an epic
+	webURL      string
+	title       string
+	description string
+	state       string
+	dueDate     string // YYYY-MM-DD, empty without a due date
+	labels      []string
+	notes       []*gitlab.Note
+}
+
+// Verify re-reads the Jira issues and their GitLab issues and epics and returns the differences.
+// The GitLab item is found in the state file, or by the Jira key marker of the description.
+func Verify(ctx context.Context, gl GitLabWriter, jr JiraReader) (int, []*Mismatch, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "Error getting config")
+	}
+	if len(cfg.Routes) > 0 {
+		return 0, nil, errors.New("The migration can't be verified with routes, each route has its own GitLab targets and state file")
+	}
+
+	issues, epicMode, migrationState, err := readComparedIssues(ctx, gl, jr, cfg)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var mismatches []*Mismatch
+	verified := 0
+	for _, jiraIssue := range issues {
+		item, err := getMigratedItem(ctx, gl, cfg, migrationState, jiraIssue.Key, jiraIssue.isEpic)
+		if err != nil {
+			return verified, nil, err
+		}
+
+		verified++
+		if item == nil {
+			mismatches = append(mismatches, &Mismatch{jiraIssue.Key, MismatchMissing, "no GitLab item"})
+			continue
+		}
+
+		mismatches = append(mismatches, compareMigratedItem(cfg, jiraIssue.Issue, item, epicMode == config.EpicModeIssue)...)
+	}
+
+	return verified, mismatches, nil
+}
+
+// comparedIssue is a Jira issue to compare with GitLab, isEpic if it is a GitLab epic
+type comparedIssue struct {
+	*jira.Issue
+	isEpic bool
+}
+
+// readComparedIssues reads the Jira epics and issues with the epic mode and the state of the migration
+func readComparedIssues(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config) ([]*comparedIssue, string, *state.State, error) {
+	if err := resolveTargets(ctx, gl, cfg); err != nil {
+		return nil, "", nil, errors.Wrap(err, "Error resolving GitLab targets")
+	}
+
+	jiraEpics, jiraIssues, err := GetJiraIssues(ctx, jr, cfg)
+	if err != nil {
+		return nil, "", nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira issues: %s", cfg.Jira.Name))
+	}
+
+	epicMode, err := resolveEpicMode(ctx, gl, cfg)
+	if err != nil {
+		return nil, "", nil, errors.Wrap(err, "Error resolving epic mode")
+	}
+
+	migrationState := state.New(cfg.Jira.Host)
+	if cfg.StateFile != "" {
+		migrationState, err = state.Load(cfg.StateFile)
+		if err != nil {
+			return nil, "", nil, errors.Wrap(err, fmt.Sprintf("Error loading state file: %s", cfg.StateFile))
+		}
+	}
+
+	switch epicMode {
+	case config.EpicModeIssue:
+		jiraIssues = append(jiraEpics, jiraIssues...)
+		jiraEpics = nil
+	case config.EpicModeCSV:
+		//* The CSV import is done by hand, nothing to compare
+		jiraEpics = nil
+	}
+
+	var issues []*comparedIssue
+	for _, jiraIssue := range append(jiraEpics, jiraIssues...) {
+		issues = append(issues, &comparedIssue{jiraIssue, isJiraEpic(cfg, jiraIssue) && epicMode == config.EpicModeEpic})
+	}
+	return issues, epicMode, migrationState, nil
+}
+
+func getMigratedItem(ctx context.Context, gl GitLabWriter, cfg *config.Config, migrationState *state.State, issueKey string, isEpic bool) (*migratedItem, error) {
+	if isEpic {
+		epic, err := findMigratedEpic(ctx, gl, cfg, migrationState, issueKey)
+		if err != nil || epic == nil {
+			return nil, err
+		}
+
+		notes, err := gl.ListEpicNotes(ctx, epic.GroupID, epic.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting notes of GitLab epic %d: issue %s", epic.IID, issueKey))
+		}
+		return &migratedItem{epic.IID, epic.GroupID, epic.WebURL, epic.Title, epic.Description, epic.State, isoTimeText(epic.DueDate), epic.Labels, notes}, nil
+	}
+
+	issue, err := findMigratedIssue(ctx, gl, cfg, migrationState, issueKey)
+	if err != nil || issue == nil {
+		return nil, err
+	}
+
+	notes, err := gl.ListIssueNotes(ctx, issue.ProjectID, issue.IID)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting notes of GitLab issue %d: issue %s", issue.IID, issueKey))
+	}
+	return &migratedItem{issue.IID, issue.ProjectID, issue.WebURL, issue.Title, issue.Description, issue.State, isoTimeText(issue.DueDate), issue.Labels, notes}, nil
+}
+
+func compareMigratedItem(cfg *config.Config, jiraIssue *jira.Issue, item *migratedItem, epicAsIssue bool) []*Mismatch {
+	var mismatches []*Mismatch
+	log.Debugf("Verifying %s: %s", jiraIssue.Key, item.webURL)
+
+	texts := []string{item.description}
+	for _, note := range item.notes {
+		texts = append(texts, note.Body)
+	}
+	contains := func(s string) bool {
+		for _, text := range texts {
+			if strings.Contains(text, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	//* Comments, every migrated note links to its Jira comment
+	if jiraIssue.Fields.Comments != nil {
+		expected, missing := 0, 0
+		for _, jiraComment := range jiraIssue.Fields.Comments.Comments {
+			if body, _ := convertCommentVisibility(cfg, jiraIssue.Key, jiraComment, &jiraComment.Body); body == nil {
+				continue
+			}
+			expected++
+			if !contains(fmt.Sprintf("focusedCommentId=%s)", jiraComment.ID)) {
+				missing++
+			}
+		}
+		if missing > 0 {
+			mismatches = append(mismatches, &Mismatch{jiraIssue.Key, MismatchComments, fmt.Sprintf("%d of %d comments are not migrated", missing, expected)})
+		}
+	}
+
+	//* Attachments
+	for _, attachment := range jiraIssue.Fields.Attachments {
+		if !contains(attachment.Filename) && !contains(url.PathEscape(attachment.Filename)) && !contains(normalizeAttachmentFilename(attachment.Filename)) {
+			mismatches = append(mismatches, &Mismatch{jiraIssue.Key, MismatchAttachment, attachment.Filename})
+		}
+	}
+
+	//* Labels, labels added in GitLab after the migration are not a difference
+	expected := expectedLabels(cfg, jiraIssue, epicAsIssue)
+
+	var missingLabels []string
+	for _, label := range expected {
+		found := false
+		for _, gitlabLabel := range item.labels {
+			if gitlabLabel == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missingLabels = append(missingLabels, label)
+		}
+	}
+	if len(missingLabels) > 0 {
+		mismatches = append(mismatches, &Mismatch{jiraIssue.Key, MismatchLabels, fmt.Sprintf("missing %s", strings.Join(missingLabels, ", "))})
+	}
+
+	return mismatches
+}
+
+// expectedLabels are the labels of the migrated item of the Jira issue
+func expectedLabels(cfg *config.Config, jiraIssue *jira.Issue, epicAsIssue bool) []string {
+	expected := append([]string{}, jiraIssue.Fields.Labels...)
+	expected = append(expected, fmt.Sprintf("type::%s", jiraIssue.Fields.Type.Name))
+	for _, component := range jiraIssue.Fields.Components {
+		expected = append(expected, fmt.Sprintf("component:%s", component.Name))
+	}
+	if jiraIssue.Fields.Status != nil {
+		expected = append(expected, fmt.Sprintf("status::%s", jiraIssue.Fields.Status.Name))
+	}
+	if jiraIssue.Fields.Priority != nil {
+		expected = append(expected, fmt.Sprintf("priority::%s", jiraIssue.Fields.Priority.Name))
+	}
+	if isFlagged(cfg, jiraIssue) {
+		expected = append(expected, BlockedLabel)
+	}
+	if severity := issueSeverity(cfg, jiraIssue); severity != "" {
+		expected = append(expected, severityLabel(severity))
+	}
+	expected = append(expected, environmentLabels(cfg, jiraIssue)...)
+	if epicAsIssue && isJiraEpic(cfg, jiraIssue) {
+		expected = append(expected, EpicLabel)
+	}
+	return expected
+}
+
+func isoTimeText(t *gitlab.ISOTime) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
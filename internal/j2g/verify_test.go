@@ -0,0 +1,49 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestVerify(t *testing.T) {
+	_, gl := newTestEnv(t)
+
+	jiraIssue := newTestJiraIssue()
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+	jr.Attachments["1"] = []byte("log")
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, UserMap{}, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+
+	verified, mismatches, err := Verify(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, verified)
+	assert.Empty(t, mismatches)
+
+	//* The comment and the label are lost
+	gl.IssueNotes[gitlabIssue.ID] = nil
+	gitlabIssue.Labels = gitlab.Labels{"type::Bug"}
+
+	_, mismatches, err = Verify(context.Background(), gl, jr)
+	assert.NoError(t, err)
+	kinds := []string{}
+	for _, mismatch := range mismatches {
+		kinds = append(kinds, mismatch.Kind)
+	}
+	assert.Equal(t, []string{MismatchComments, MismatchAttachment, MismatchLabels}, kinds)
+}
@@ -0,0 +1,81 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+)
+
+// GitLab versions of the features used by the migration
+var (
+	internalNotesVersion = gitlabVersion{15, 0}
+	iterationsVersion    = gitlabVersion{14, 1}
+)
+
+type gitlabVersion struct {
+	Major int
+	Minor int
+}
+
+func (v gitlabVersion) Before(other gitlabVersion) bool {
+	return v.Major < other.Major || (v.Major == other.Major && v.Minor < other.Minor)
+}
+
+func (v gitlabVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+var gitlabVersionPattern = regexp.MustCompile(`^(\d+)\.(\d+)`)
+
+// parseGitLabVersion parses the version of /version (e.g. 15.11.3-ee)
+func parseGitLabVersion(version string) (gitlabVersion, bool) {
+	match := gitlabVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return gitlabVersion{}, false
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	return gitlabVersion{major, minor}, true
+}
+
+// gateFeatures turns off the features the GitLab instance is too old for, instead of failing mid-run with 400 errors
+func gateFeatures(ctx context.Context, gl GitLabWriter, cfg *config.Config) {
+	answer, err := gl.GetVersion(ctx)
+	if err != nil {
+		log.Warnf("Error getting GitLab version, features are not checked: %s", err)
+		return
+	}
+
+	version, ok := parseGitLabVersion(answer.Version)
+	if !ok {
+		log.Debugf("Unknown GitLab version %q, features are not checked", answer.Version)
+		return
+	}
+	log.Infof("GitLab version: %s", answer.Version)
+
+	if cfg.RestrictedComments == config.RestrictedCommentsInternal && version.Before(internalNotesVersion) {
+		log.Warnf("GitLab %s doesn't have internal notes (>= %s), restricted comments become placeholders", version, internalNotesVersion)
+		cfg.RestrictedComments = config.RestrictedCommentsPlaceholder
+	}
+
+	if cfg.GitLab.Iterations.Enabled && version.Before(iterationsVersion) {
+		log.Warnf("GitLab %s doesn't have iteration cadences (>= %s), sprints become milestones", version, iterationsVersion)
+		cfg.GitLab.Iterations.Enabled = false
+	}
+}
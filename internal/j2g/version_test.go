@@ -0,0 +1,39 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestGateFeatures(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.RestrictedComments = config.RestrictedCommentsInternal
+	cfg.GitLab.Iterations.Enabled = true
+
+	gl := fake.NewGitLab()
+	gl.Version = "14.0.5-ee"
+	gateFeatures(context.Background(), gl, cfg)
+	assert.Equal(t, config.RestrictedCommentsPlaceholder, cfg.RestrictedComments)
+	assert.False(t, cfg.GitLab.Iterations.Enabled)
+
+	cfg.RestrictedComments = config.RestrictedCommentsInternal
+	cfg.GitLab.Iterations.Enabled = true
+	gl.Version = "15.11.3-ee"
+	gateFeatures(context.Background(), gl, cfg)
+	assert.Equal(t, config.RestrictedCommentsInternal, cfg.RestrictedComments)
+	assert.True(t, cfg.GitLab.Iterations.Enabled)
+}
@@ -0,0 +1,142 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+// writeProjectWiki creates or updates the wiki page summarizing the Jira project
+func writeProjectWiki(ctx context.Context, gl GitLabWriter, jr JiraReader, cfg *config.Config, jiraProject *jira.Project, gitlabProjectPath string) error {
+	title := cfg.Wiki.Title
+	if title == "" {
+		title = fmt.Sprintf("Jira project %s", jiraProject.Key)
+	}
+
+	//* Workflows, the diagrams are not available on the REST API
+	statuses, _, err := jr.ListProjectStatuses(ctx, jiraProject.Key)
+	if err != nil {
+		log.Warnf("Skipping workflows of the wiki page: %s", err)
+	}
+	content := formatProjectWiki(cfg, jiraProject, statuses)
+
+	//* The slug of a page is its title with dashes
+	slug := strings.ReplaceAll(title, " ", "-")
+	_, r, err := gl.GetWikiPage(ctx, gitlabProjectPath, slug)
+	if err != nil && (r == nil || r.StatusCode != http.StatusNotFound) {
+		return errors.Wrap(err, fmt.Sprintf("Error getting wiki page: %s", title))
+	}
+
+	if err == nil {
+		log.Infof("Updating wiki page: %s", title)
+		_, _, err = gl.EditWikiPage(ctx, gitlabProjectPath, slug, &gitlab.EditWikiPageOptions{
+			Content: gitlab.String(content),
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error updating wiki page: %s", title))
+		}
+		return nil
+	}
+
+	log.Infof("Creating wiki page: %s", title)
+	_, _, err = gl.CreateWikiPage(ctx, gitlabProjectPath, &gitlab.CreateWikiPageOptions{
+		Title:   gitlab.String(title),
+		Content: gitlab.String(content),
+		Format:  gitlab.WikiFormat(gitlab.WikiFormatMarkdown),
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating wiki page: %s", title))
+	}
+	return nil
+}
+
+// tableCell escapes the pipes and line breaks of a Markdown table cell
+func tableCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", " ")
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+func formatProjectWiki(cfg *config.Config, jiraProject *jira.Project, statuses []*jirax.IssueTypeStatuses) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s (%s)\n\n", jiraProject.Name, jiraProject.Key)
+	fmt.Fprintf(&b, "Archived from Jira [%s](%s/browse/%s)", jiraProject.Key, cfg.Jira.Host, jiraProject.Key)
+	if lead := displayName(cfg, &jiraProject.Lead); lead != "" {
+		fmt.Fprintf(&b, ", lead: %s", lead)
+	}
+	b.WriteString("\n\n")
+	if jiraProject.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", jiraProject.Description)
+	}
+
+	//* Components
+	if len(jiraProject.Components) > 0 {
+		b.WriteString("## Components\n\n| Component | Lead | Description |\n|---|---|---|\n")
+		for _, component := range jiraProject.Components {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", tableCell(component.Name), tableCell(orDash(displayName(cfg, &component.Lead))), tableCell(orDash(component.Description)))
+		}
+		b.WriteString("\n")
+	}
+
+	//* Versions
+	if len(jiraProject.Versions) > 0 {
+		b.WriteString("## Versions\n\n| Version | Status | Release date | Description |\n|---|---|---|---|\n")
+		for _, version := range jiraProject.Versions {
+			status := "Unreleased"
+			if version.Released != nil && *version.Released {
+				status = "Released"
+			}
+			if version.Archived != nil && *version.Archived {
+				status = "Archived"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", tableCell(version.Name), status, orDash(version.ReleaseDate), tableCell(orDash(version.Description)))
+		}
+		b.WriteString("\n")
+	}
+
+	//* Workflows
+	if len(statuses) > 0 {
+		b.WriteString("## Workflows\n\n| Issue type | Statuses |\n|---|---|\n")
+		for _, issueType := range statuses {
+			names := make([]string, 0, len(issueType.Statuses))
+			for _, status := range issueType.Statuses {
+				if status.StatusCategory.Name == "" {
+					names = append(names, status.Name)
+					continue
+				}
+				names = append(names, fmt.Sprintf("%s (%s)", status.Name, status.StatusCategory.Name))
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", tableCell(issueType.Name), tableCell(strings.Join(names, ", ")))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func orDash(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return text
+}
@@ -0,0 +1,49 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/jirax"
+)
+
+func TestProjectWiki(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Wiki.Enabled = true
+
+	project := &jira.Project{
+		Key:        "TEST",
+		Name:       "Test",
+		Components: []jira.ProjectComponent{{Name: "API", Lead: jira.User{DisplayName: "Jeff"}}},
+		Versions:   []jira.Version{{Name: "1.0", Released: gitlab.Bool(true), ReleaseDate: "2023-09-06"}},
+	}
+	jr := fake.NewJira(project)
+	jr.Statuses = []*jirax.IssueTypeStatuses{{Name: "Bug", Statuses: []jira.Status{{Name: "Open"}, {Name: "Done", StatusCategory: jira.StatusCategory{Name: "Done"}}}}}
+
+	assert.NoError(t, writeProjectWiki(context.Background(), gl, jr, cfg, project, "group/project"))
+	assert.Len(t, gl.Wikis[2], 1)
+	assert.Equal(t, "Jira-project-TEST", gl.Wikis[2][0].Slug)
+	assert.Contains(t, gl.Wikis[2][0].Content, "| API | Jeff | - |")
+	assert.Contains(t, gl.Wikis[2][0].Content, "| 1.0 | Released | 2023-09-06 | - |")
+	assert.Contains(t, gl.Wikis[2][0].Content, "| Bug | Open, Done (Done) |")
+
+	//* Updated on the next run
+	project.Description = "Archived"
+	assert.NoError(t, writeProjectWiki(context.Background(), gl, jr, cfg, project, "group/project"))
+	assert.Len(t, gl.Wikis[2], 1)
+	assert.Contains(t, gl.Wikis[2][0].Content, "Archived\n")
+}
@@ -0,0 +1,64 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// PanicError is a panic of a worker, returned as its error so the run stops or quarantines the item like any other failure
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func newPanicError(value interface{}) *PanicError {
+	err := &PanicError{Value: value, Stack: debug.Stack()}
+	log.Errorf("Recovered %s\n%s", err, err.Stack)
+	return err
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Format prints the stack trace of the panic with %+v
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	io.WriteString(s, e.Error())
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "\n%s", e.Stack)
+	}
+}
+
+// workerGroup is an errgroup.Group whose workers return their panics as a PanicError
+type workerGroup struct {
+	errgroup.Group
+}
+
+func (g *workerGroup) Go(fn func() error) {
+	g.Group.Go(func() (err error) {
+		defer recoverPanic(&err)
+		return fn()
+	})
+}
+
+// recoverPanic sets the error of the deferring function to the panic, call it with defer
+func recoverPanic(err *error) {
+	if value := recover(); value != nil {
+		*err = newPanicError(value)
+	}
+}
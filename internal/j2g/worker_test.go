@@ -0,0 +1,73 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/quarantine"
+)
+
+// panickingJira panics on the download of the attachments
+type panickingJira struct {
+	*fake.Jira
+}
+
+func (j *panickingJira) DownloadAttachment(ctx context.Context, attachmentID string) (io.ReadCloser, error) {
+	var attachments map[string][]byte
+	attachments[attachmentID] = nil
+	return nil, nil
+}
+
+func TestPanicIsQuarantined(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Quarantine = filepath.Join(t.TempDir(), "quarantine.json")
+
+	withAttachment := newTestJiraIssue()
+	withoutAttachment := newTestJiraIssue()
+	withoutAttachment.Key = "TEST-2"
+	withoutAttachment.Fields.Attachments = nil
+	jr := &panickingJira{fake.NewJira(&jira.Project{Key: "TEST"}, withAttachment, withoutAttachment)}
+
+	//* The panic of the attachment worker fails its issue only
+	err := ConvertByProject(context.Background(), gl, jr)
+	assert.ErrorContains(t, err, "1 items failed")
+	assert.Len(t, gl.Issues[2], 1)
+
+	quarantined, err := quarantine.Load(cfg.Quarantine)
+	assert.NoError(t, err)
+	items := quarantined.List()
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "TEST-1", items[0].JiraKey)
+		assert.Contains(t, items[0].Error, "panic: assignment to entry in nil map")
+	}
+
+	//* Without the quarantine the run stops with the panic as its error
+	cfg.Quarantine = ""
+	err = ConvertByProject(context.Background(), fake.NewGitLab(), jr)
+	assert.Error(t, err)
+
+	var panicErr *PanicError
+	gl = fake.NewGitLab()
+	gl.AddGroup(1, "group")
+	gl.AddProject(2, "group/project")
+	err = ConvertByProject(context.Background(), gl, jr)
+	if assert.ErrorAs(t, err, &panicErr) {
+		assert.Contains(t, fmt.Sprintf("%+v", panicErr), "DownloadAttachment")
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package j2g
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/gitlabx"
+)
+
+// worklogAuthors returns the Jira usernames of the worklog authors of the issues
+func worklogAuthors(jiraIssues []*jira.Issue) []string {
+	var result []string
+	for _, issue := range jiraIssues {
+		if issue.Fields == nil || issue.Fields.Worklog == nil {
+			continue
+		}
+		for _, worklog := range issue.Fields.Worklog.Worklogs {
+			if worklog.Author != nil && worklog.Author.Name != "" {
+				result = append(result, worklog.Author.Name)
+			}
+		}
+	}
+	return result
+}
+
+// formatWorklogAuthor mentions the GitLab user of the worklog author, or the Jira display name if the author is not in users
+func formatWorklogAuthor(author *jira.User, userMap UserMap) (string, error) {
+	if author == nil {
+		return "Anonymous", nil
+	}
+	if user, ok := userMap[author.Name]; ok {
+		if isUnmappedUser(user) {
+			return formatUnmappedUser(user)
+		}
+		return "@" + user.Username, nil
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "Error getting config")
+	}
+	if name := displayName(cfg, author); name != "" {
+		return name, nil
+	}
+	return author.Name, nil
+}
+
+// worklogStarted returns the start time of the worklog, the creation time if it has none
+func worklogStarted(worklog *jira.WorklogRecord) time.Time {
+	if worklog.Started != nil {
+		return time.Time(*worklog.Started)
+	}
+	if worklog.Created != nil {
+		return time.Time(*worklog.Created)
+	}
+	return time.Time{}
+}
+
+// migrateWorklogs creates the notes of the Jira worklogs with the /spend quick action (worklogs.mode)
+func migrateWorklogs(ctx context.Context, gl GitLabWriter, cfg *config.Config, jiraIssue *jira.Issue, gitlabIssue *gitlab.Issue, userMap UserMap) error {
+	if cfg.Worklogs.Mode != config.WorklogsSpend && cfg.Worklogs.Mode != config.WorklogsSummary {
+		return nil
+	}
+	if jiraIssue.Fields.Worklog == nil || len(jiraIssue.Fields.Worklog.Worklogs) == 0 {
+		return nil
+	}
+
+	worklogs := make([]*jira.WorklogRecord, 0, len(jiraIssue.Fields.Worklog.Worklogs))
+	for i := range jiraIssue.Fields.Worklog.Worklogs {
+		if jiraIssue.Fields.Worklog.Worklogs[i].TimeSpentSeconds > 0 {
+			worklogs = append(worklogs, &jiraIssue.Fields.Worklog.Worklogs[i])
+		}
+	}
+	if len(worklogs) == 0 {
+		return nil
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool {
+		return worklogStarted(worklogs[i]).Before(worklogStarted(worklogs[j]))
+	})
+
+	pid := cfg.GitLab.Issue
+	createNote := func(body string, createdAt time.Time) error {
+		options := &gitlabx.CreateIssueNoteOptions{Body: &body}
+		if !createdAt.IsZero() {
+			options.CreatedAt = &createdAt
+		}
+		if _, _, err := gl.CreateIssueNote(ctx, pid, gitlabIssue.IID, options); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error creating worklog note: issue %s", jiraIssue.Key))
+		}
+		return nil
+	}
+
+	//* Spend, a note per worklog in order
+	if cfg.Worklogs.Mode == config.WorklogsSpend {
+		for _, worklog := range worklogs {
+			author, err := formatWorklogAuthor(worklog.Author, userMap)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error formatting worklog author: issue %s", jiraIssue.Key))
+			}
+			comment, _, err := textToGitLabMarkdown(worklog.Comment, userMap, AttachmentMap{}, true)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Error converting worklog comment: issue %s", jiraIssue.Key))
+			}
+
+			started := worklogStarted(worklog)
+			duration := formatJiraDuration(worklog.TimeSpentSeconds)
+			body := fmt.Sprintf("%s logged %s on %s", author, duration, started.Format("January 02, 2006"))
+			if comment = strings.TrimSpace(comment); comment != "" {
+				body += "\n\n" + comment
+			}
+			body += fmt.Sprintf("\n\n/spend %s %s", duration, started.Format("2006-01-02"))
+
+			if err := createNote(body, started); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	//* Summary, a table of the worklogs and the total
+	var summary strings.Builder
+	fmt.Fprint(&summary, "**Jira worklogs**\n\n| Date | Author | Time spent | Comment |\n|---|---|---|---|\n")
+	total := 0
+	for _, worklog := range worklogs {
+		author, err := formatWorklogAuthor(worklog.Author, userMap)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error formatting worklog author: issue %s", jiraIssue.Key))
+		}
+		fmt.Fprintf(&summary, "| %s | %s | %s | %s |\n",
+			worklogStarted(worklog).Format("2006-01-02"), tableCell(author), formatJiraDuration(worklog.TimeSpentSeconds), orDash(tableCell(worklog.Comment)))
+		total += worklog.TimeSpentSeconds
+	}
+	fmt.Fprintf(&summary, "\n**Total:** %s\n\n/spend %s", formatJiraDuration(total), formatJiraDuration(total))
+
+	return createNote(summary.String(), worklogStarted(worklogs[len(worklogs)-1]))
+}
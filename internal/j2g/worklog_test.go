@@ -0,0 +1,53 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package j2g
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/config"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/fake"
+)
+
+func TestWorklogs(t *testing.T) {
+	cfg, gl := newTestEnv(t)
+	cfg.Worklogs.Mode = config.WorklogsSpend
+	cfg.Users = map[string]int{"jeff": 10}
+
+	gl.Users[10] = &gitlab.User{ID: 10, Username: "jeff"}
+
+	started := jira.Time(time.Date(2023, 9, 6, 10, 0, 0, 0, time.UTC))
+	earlier := jira.Time(time.Date(2023, 9, 5, 10, 0, 0, 0, time.UTC))
+	jiraIssue := newTestJiraIssue()
+	jiraIssue.Fields.Attachments = nil
+	jiraIssue.Fields.Comments = &jira.Comments{}
+	jiraIssue.Fields.Worklog = &jira.Worklog{Worklogs: []jira.WorklogRecord{
+		{Author: &jira.User{Name: "jeff"}, Started: &started, TimeSpentSeconds: 5400, Comment: "Debugging"},
+		{Author: &jira.User{Name: "kim", DisplayName: "Kim"}, Started: &earlier, TimeSpentSeconds: 8 * 3600},
+	}}
+	jr := fake.NewJira(&jira.Project{Key: "TEST"}, jiraIssue)
+
+	userMap, err := newUserMap(context.Background(), gl, jr, []*jira.Issue{jiraIssue}, cfg)
+	assert.NoError(t, err)
+
+	gitlabIssue, err := ConvertJiraIssueToGitLabIssue(context.Background(), gl, jr, jiraIssue, userMap, map[string]string{}, map[string]*Milestone{})
+	assert.NoError(t, err)
+	notes := gl.IssueNotes[gitlabIssue.ID]
+	if assert.Len(t, notes, 2) {
+		assert.Equal(t, "Kim logged 1d on September 05, 2023\n\n/spend 1d 2023-09-05", notes[0].Body)
+		assert.Equal(t, "@jeff logged 1h 30m on September 06, 2023\n\nDebugging\n\n/spend 1h 30m 2023-09-06", notes[1].Body)
+	}
+}
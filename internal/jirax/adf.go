@@ -0,0 +1,281 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ADF is the Atlassian Document Format of the text fields of the API version 3.
+// The documents are converted to wiki markup, the text of the API version 2, so the issues are converted the same way.
+
+// isADF reports if the JSON value is an ADF document
+func isADF(value interface{}) bool {
+	node, ok := value.(map[string]interface{})
+	return ok && node["type"] == "doc" && node["content"] != nil
+}
+
+// ReplaceADF replaces the ADF documents of the decoded JSON with their wiki markup, e.g. the description and the comment bodies of an issue
+func ReplaceADF(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if isADF(v) {
+			return ADFToWiki(v)
+		}
+		for key, item := range v {
+			v[key] = ReplaceADF(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = ReplaceADF(item)
+		}
+	}
+	return value
+}
+
+// ADFToWiki converts an ADF document to Jira wiki markup
+func ADFToWiki(doc map[string]interface{}) string {
+	var b strings.Builder
+	writeADFBlocks(&b, adfContent(doc))
+	return strings.TrimSpace(b.String())
+}
+
+func adfContent(node map[string]interface{}) []map[string]interface{} {
+	items, _ := node["content"].([]interface{})
+	result := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if child, ok := item.(map[string]interface{}); ok {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+func adfAttr(node map[string]interface{}, name string) string {
+	attrs, _ := node["attrs"].(map[string]interface{})
+	switch v := attrs[name].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// writeADFBlocks writes the block nodes separated by blank lines
+func writeADFBlocks(b *strings.Builder, nodes []map[string]interface{}) {
+	for i, node := range nodes {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		writeADFBlock(b, node, "")
+	}
+}
+
+// writeADFBlock writes the block node, listPrefix is the bullets of the enclosing lists (e.g. *#)
+func writeADFBlock(b *strings.Builder, node map[string]interface{}, listPrefix string) {
+	switch node["type"] {
+	case "paragraph":
+		b.WriteString(adfInline(adfContent(node)))
+	case "heading":
+		level := adfAttr(node, "level")
+		if level == "" {
+			level = "1"
+		}
+		fmt.Fprintf(b, "h%s. %s", level, adfInline(adfContent(node)))
+	case "bulletList", "orderedList", "taskList":
+		bullet := "*"
+		if node["type"] == "orderedList" {
+			bullet = "#"
+		}
+		for i, item := range adfContent(node) {
+			if i > 0 || listPrefix != "" {
+				b.WriteString("\n")
+			}
+			writeADFListItem(b, item, listPrefix+bullet)
+		}
+	case "codeBlock":
+		if language := adfAttr(node, "language"); language != "" {
+			fmt.Fprintf(b, "{code:%s}\n", language)
+		} else {
+			b.WriteString("{code}\n")
+		}
+		b.WriteString(adfText(adfContent(node)))
+		b.WriteString("\n{code}")
+	case "blockquote":
+		b.WriteString("{quote}\n")
+		writeADFBlocks(b, adfContent(node))
+		b.WriteString("\n{quote}")
+	case "panel":
+		b.WriteString("{panel}\n")
+		writeADFBlocks(b, adfContent(node))
+		b.WriteString("\n{panel}")
+	case "expand", "nestedExpand":
+		if title := adfAttr(node, "title"); title != "" {
+			fmt.Fprintf(b, "*%s*\n\n", title)
+		}
+		writeADFBlocks(b, adfContent(node))
+	case "rule":
+		b.WriteString("----")
+	case "table":
+		for i, row := range adfContent(node) {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			for _, cell := range adfContent(row) {
+				separator := "|"
+				if cell["type"] == "tableHeader" {
+					separator = "||"
+				}
+				var content strings.Builder
+				writeADFBlocks(&content, adfContent(cell))
+				fmt.Fprintf(b, "%s%s", separator, strings.ReplaceAll(content.String(), "\n\n", "\n"))
+			}
+			if cells := adfContent(row); len(cells) > 0 && cells[len(cells)-1]["type"] == "tableHeader" {
+				b.WriteString("||")
+			} else {
+				b.WriteString("|")
+			}
+		}
+	case "mediaSingle", "mediaGroup":
+		for i, media := range adfContent(node) {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString(adfMedia(media))
+		}
+	default:
+		//* The text of unknown nodes is kept
+		if content := adfContent(node); len(content) > 0 && content[0]["type"] == "text" {
+			b.WriteString(adfInline(content))
+		} else {
+			writeADFBlocks(b, content)
+		}
+	}
+}
+
+func writeADFListItem(b *strings.Builder, item map[string]interface{}, prefix string) {
+	b.WriteString(prefix + " ")
+	if item["type"] == "taskItem" {
+		if adfAttr(item, "state") == "DONE" {
+			b.WriteString("[x] ")
+		} else {
+			b.WriteString("[ ] ")
+		}
+		b.WriteString(adfInline(adfContent(item)))
+		return
+	}
+
+	first := true
+	for _, child := range adfContent(item) {
+		switch child["type"] {
+		case "bulletList", "orderedList", "taskList":
+			writeADFBlock(b, child, prefix)
+		default:
+			if !first {
+				b.WriteString("\n")
+			}
+			writeADFBlock(b, child, "")
+		}
+		first = false
+	}
+}
+
+// adfText is the plain text of the nodes, e.g. of a code block
+func adfText(nodes []map[string]interface{}) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		text, _ := node["text"].(string)
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+func adfInline(nodes []map[string]interface{}) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		switch node["type"] {
+		case "text":
+			text, _ := node["text"].(string)
+			b.WriteString(adfMarks(text, node))
+		case "hardBreak":
+			b.WriteString("\n")
+		case "mention":
+			//* Jira Cloud has no usernames, the mention is its text (e.g. @Jane Doe)
+			b.WriteString(adfAttr(node, "text"))
+		case "emoji":
+			if text := adfAttr(node, "text"); text != "" {
+				b.WriteString(text)
+			} else {
+				b.WriteString(adfAttr(node, "shortName"))
+			}
+		case "inlineCard":
+			fmt.Fprintf(&b, "[%s]", adfAttr(node, "url"))
+		case "status":
+			b.WriteString(adfAttr(node, "text"))
+		case "date":
+			var milliseconds int64
+			if _, err := fmt.Sscan(adfAttr(node, "timestamp"), &milliseconds); err == nil {
+				b.WriteString(time.UnixMilli(milliseconds).UTC().Format("2006-01-02"))
+			}
+		case "media", "mediaInline":
+			b.WriteString(adfMedia(node))
+		}
+	}
+	return b.String()
+}
+
+func adfMarks(text string, node map[string]interface{}) string {
+	marks, _ := node["marks"].([]interface{})
+	for _, item := range marks {
+		mark, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch mark["type"] {
+		case "strong":
+			text = "*" + text + "*"
+		case "em":
+			text = "_" + text + "_"
+		case "code":
+			text = "{{" + text + "}}"
+		case "strike":
+			text = "-" + text + "-"
+		case "underline":
+			text = "+" + text + "+"
+		case "subsup":
+			if adfAttr(mark, "type") == "sub" {
+				text = "~" + text + "~"
+			} else {
+				text = "^" + text + "^"
+			}
+		case "textColor":
+			text = fmt.Sprintf("{color:%s}%s{color}", adfAttr(mark, "color"), text)
+		case "link":
+			text = fmt.Sprintf("[%s|%s]", text, adfAttr(mark, "href"))
+		}
+	}
+	return text
+}
+
+// adfMedia is the wiki markup of an attachment, the alt text of a file is its file name
+func adfMedia(media map[string]interface{}) string {
+	if adfAttr(media, "type") == "external" {
+		return fmt.Sprintf("!%s!", adfAttr(media, "url"))
+	}
+	if alt := adfAttr(media, "alt"); alt != "" {
+		return fmt.Sprintf("!%s!", alt)
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// ServiceDeskDate is a date of the Jira Service Management API
+type ServiceDeskDate struct {
+	ISO8601     string `json:"iso8601"`
+	EpochMillis int64  `json:"epochMillis"`
+	Friendly    string `json:"friendly"`
+}
+
+// Time is zero if the date is absent
+func (d *ServiceDeskDate) Time() time.Time {
+	if d == nil || d.EpochMillis == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(d.EpochMillis)
+}
+
+type Approver struct {
+	Approver         *jira.User `json:"approver"`
+	ApproverDecision string     `json:"approverDecision"` // approved, declined or pending
+}
+
+// Approval is an approval step of a Jira Service Management request
+type Approval struct {
+	ID            string           `json:"id"`
+	Name          string           `json:"name"`
+	FinalDecision string           `json:"finalDecision"` // approved, declined or pending
+	Approvers     []*Approver      `json:"approvers"`
+	CreatedDate   *ServiceDeskDate `json:"createdDate"`
+	CompletedDate *ServiceDeskDate `json:"completedDate"`
+}
+
+type approvalPage struct {
+	Size       int         `json:"size"`
+	IsLastPage bool        `json:"isLastPage"`
+	Values     []*Approval `json:"values"`
+}
+
+// GetApprovals returns the approvals of the request, 404 if the issue is not a request of a service desk
+func GetApprovals(ctx context.Context, jr *jira.Client, issueKey string) ([]*Approval, *jira.Response, error) {
+	var result []*Approval
+	start := 0
+
+	for {
+		u := fmt.Sprintf("rest/servicedeskapi/request/%s/approval?start=%d&limit=50", issueKey, start)
+		req, err := jr.NewRequest(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Error creating request")
+		}
+
+		page := new(approvalPage)
+		resp, err := jr.Do(req, page)
+		if err != nil {
+			return nil, resp, errors.Wrap(err, fmt.Sprintf("Error getting approvals: issue %s", issueKey))
+		}
+
+		result = append(result, page.Values...)
+		start += len(page.Values)
+		if page.IsLastPage || len(page.Values) == 0 {
+			return result, resp, nil
+		}
+	}
+}
@@ -0,0 +1,42 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// DownloadProjectAvatar downloads the largest avatar of the project, the content type tells the image format
+func DownloadProjectAvatar(ctx context.Context, jr *jira.Client, project *jira.Project) (io.ReadCloser, string, error) {
+	avatarURL := project.AvatarUrls.Four8X48
+	if avatarURL == "" {
+		return nil, "", errors.Errorf("Project %s has no avatar", project.Key)
+	}
+
+	req, err := jr.NewRequest(ctx, http.MethodGet, avatarURL, nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Error creating request")
+	}
+
+	resp, err := jr.Do(req, nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, fmt.Sprintf("Error downloading avatar: project %s", project.Key))
+	}
+
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,108 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// Column constraint of a board, the min and max of the columns count the issues
+const BoardConstraintIssueCount = "issueCount"
+
+type BoardColumn struct {
+	Name     string              `json:"name"`
+	Statuses []BoardColumnStatus `json:"statuses"`
+	Min      int                 `json:"min"`
+	Max      int                 `json:"max"` // WIP limit, 0 if there is no limit
+}
+
+type BoardColumnStatus struct {
+	ID string `json:"id"`
+}
+
+// BoardConfiguration is the column configuration of a board
+type BoardConfiguration struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	ColumnConfig struct {
+		Columns        []BoardColumn `json:"columns"`
+		ConstraintType string        `json:"constraintType"` // none, issueCount, issueCountExclSubs
+	} `json:"columnConfig"`
+	Filter struct {
+		ID string `json:"id"`
+	} `json:"filter"` // saved filter of the issues of the board
+}
+
+// UnpaginateBoards returns the boards of the project, of every type if boardType is empty (scrum, kanban)
+func UnpaginateBoards(ctx context.Context, jr *jira.Client, projectKey string, boardType string) ([]jira.Board, error) {
+	var boards []jira.Board
+	boardOptions := &jira.BoardListOptions{
+		BoardType:      boardType,
+		ProjectKeyOrID: projectKey,
+		SearchOptions:  jira.SearchOptions{MaxResults: 50},
+	}
+	for {
+		list, _, err := jr.Board.GetAllBoards(ctx, boardOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira boards: %s", projectKey))
+		}
+		boards = append(boards, list.Values...)
+		if list.IsLast || len(list.Values) == 0 {
+			break
+		}
+		boardOptions.StartAt += len(list.Values)
+	}
+
+	return boards, nil
+}
+
+func GetBoardConfiguration(ctx context.Context, jr *jira.Client, boardID int) (*BoardConfiguration, *jira.Response, error) {
+	u := fmt.Sprintf("rest/agile/1.0/board/%d/configuration", boardID)
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	configuration := new(BoardConfiguration)
+	resp, err := jr.Do(req, configuration)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, fmt.Sprintf("Error getting board configuration: board %d", boardID))
+	}
+
+	return configuration, resp, nil
+}
+
+// ListBoardConfigurations returns the configuration of every board of the project
+func ListBoardConfigurations(ctx context.Context, jr *jira.Client, projectKey string) ([]*BoardConfiguration, error) {
+	boards, err := UnpaginateBoards(ctx, jr, projectKey, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*BoardConfiguration, 0, len(boards))
+	for _, board := range boards {
+		configuration, _, err := GetBoardConfiguration(ctx, jr, board.ID)
+		if err != nil {
+			return nil, err
+		}
+		configuration.Type = board.Type
+		result = append(result, configuration)
+	}
+
+	return result, nil
+}
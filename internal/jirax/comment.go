@@ -0,0 +1,115 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// Comment property of Jira Service Management, {"internal": true} for an internal comment
+const ServiceDeskCommentProperty = "sd.public.comment"
+
+// SearchCommentLimit is the number of comments of an issue in the search result, the first page of the comments
+const SearchCommentLimit = 50
+
+type commentPage struct {
+	StartAt    int             `json:"startAt"`
+	MaxResults int             `json:"maxResults"`
+	Total      int             `json:"total"`
+	Comments   []*jira.Comment `json:"comments"`
+}
+
+// GetComments returns every comment of the issue with the comment properties, the search doesn't return them
+func GetComments(ctx context.Context, jr *jira.Client, issueKey string) ([]*jira.Comment, error) {
+	var result []*jira.Comment
+	startAt := 0
+
+	for {
+		u := fmt.Sprintf("rest/api/2/issue/%s/comment?expand=properties&startAt=%d&maxResults=100", issueKey, startAt)
+		req, err := jr.NewRequest(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating request")
+		}
+
+		page := new(commentPage)
+		if _, err := jr.Do(req, page); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting comments: issue %s", issueKey))
+		}
+
+		result = append(result, page.Comments...)
+		startAt += len(page.Comments)
+		if len(page.Comments) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+type commentParentPage struct {
+	StartAt  int `json:"startAt"`
+	Total    int `json:"total"`
+	Comments []struct {
+		ID       string      `json:"id"`
+		ParentID interface{} `json:"parentId"` // Jira Cloud only, a number
+	} `json:"comments"`
+}
+
+// GetCommentParents returns the parent comment ID of every reply of the issue (comment ID -> parent ID).
+// Jira Cloud threads the replies, Jira Server has none.
+func GetCommentParents(ctx context.Context, jr *jira.Client, issueKey string) (map[string]string, error) {
+	result := make(map[string]string)
+	startAt := 0
+
+	for {
+		u := fmt.Sprintf("rest/api/2/issue/%s/comment?startAt=%d&maxResults=100", issueKey, startAt)
+		req, err := jr.NewRequest(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating request")
+		}
+
+		page := new(commentParentPage)
+		if _, err := jr.Do(req, page); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting comments: issue %s", issueKey))
+		}
+
+		for _, comment := range page.Comments {
+			if comment.ParentID != nil {
+				result[comment.ID] = fmt.Sprint(comment.ParentID)
+			}
+		}
+		startAt += len(page.Comments)
+		if len(page.Comments) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// IsInternalComment is true for an internal comment of Jira Service Management (not shared with the customer)
+func IsInternalComment(comment *jira.Comment) bool {
+	for _, property := range comment.Properties {
+		if property.Key != ServiceDeskCommentProperty {
+			continue
+		}
+		if value, ok := property.Value.(map[string]interface{}); ok {
+			internal, _ := value["internal"].(bool)
+			return internal
+		}
+	}
+	return false
+}
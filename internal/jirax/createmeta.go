@@ -0,0 +1,76 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// CreateMetaField is a field of the create screen of an issue type
+type CreateMetaField struct {
+	Required bool   `json:"required"`
+	Name     string `json:"name"`
+	Schema   struct {
+		Type   string `json:"type"`
+		System string `json:"system"`
+		Custom string `json:"custom"`
+	} `json:"schema"`
+	AllowedValues []CreateMetaValue `json:"allowedValues"`
+}
+
+// CreateMetaValue is an option of a select field (value) or a version, component, priority, ... (name)
+type CreateMetaValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CreateMetaIssueType is an issue type with the fields of its create screen. Key: field ID
+type CreateMetaIssueType struct {
+	ID          string                      `json:"id"`
+	Name        string                      `json:"name"`
+	Description string                      `json:"description"`
+	Subtask     bool                        `json:"subtask"`
+	Fields      map[string]*CreateMetaField `json:"fields"`
+}
+
+// GetCreateMeta returns the issue types of the project with the fields of their create screens
+func GetCreateMeta(ctx context.Context, jr *jira.Client, projectKey string) ([]*CreateMetaIssueType, *jira.Response, error) {
+	u := fmt.Sprintf("rest/api/2/issue/createmeta?projectKeys=%s&expand=projects.issuetypes.fields", url.QueryEscape(projectKey))
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	var meta struct {
+		Projects []struct {
+			Key        string                 `json:"key"`
+			IssueTypes []*CreateMetaIssueType `json:"issuetypes"`
+		} `json:"projects"`
+	}
+	resp, err := jr.Do(req, &meta)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, "Error getting create meta")
+	}
+
+	for _, project := range meta.Projects {
+		if project.Key == projectKey {
+			return project.IssueTypes, resp, nil
+		}
+	}
+	return nil, resp, errors.Errorf("Project %s is not found in the create meta", projectKey)
+}
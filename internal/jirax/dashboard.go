@@ -0,0 +1,188 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+type Dashboard struct {
+	ID      string             `json:"id"`
+	Name    string             `json:"name"`
+	View    string             `json:"view"` // URL of the dashboard
+	Gadgets []*DashboardGadget `json:"-"`
+}
+
+// DashboardGadget is a gadget of a dashboard, the gadget type is its module key or the URI of a legacy gadget
+type DashboardGadget struct {
+	ID        int    `json:"id"`
+	ModuleKey string `json:"moduleKey"`
+	URI       string `json:"uri"`
+	Title     string `json:"title"`
+	// Filter and project of the gadget configuration (e.g. projectOrFilterId: filter-10000)
+	Filter    *jira.Filter `json:"-"`
+	ProjectID string       `json:"-"`
+}
+
+// e.g. filter-10000 or project-10000
+var gadgetReferencePattern = regexp.MustCompile(`^(filter|project)-(\d+)$`)
+
+// ListDashboards returns the dashboards visible to the user with their gadgets.
+// Jira Server before 9 has no gadget API, the dashboards are returned without gadgets.
+func ListDashboards(ctx context.Context, jr *jira.Client) ([]*Dashboard, error) {
+	var result []*Dashboard
+	startAt := 0
+	for {
+		req, err := jr.NewRequest(ctx, "GET", fmt.Sprintf("rest/api/2/dashboard?startAt=%d&maxResults=50", startAt), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating request")
+		}
+
+		var list struct {
+			Dashboards []*Dashboard `json:"dashboards"`
+			Total      int          `json:"total"`
+		}
+		if _, err := jr.Do(req, &list); err != nil {
+			return nil, errors.Wrap(err, "Error getting Jira dashboards")
+		}
+		result = append(result, list.Dashboards...)
+
+		startAt += len(list.Dashboards)
+		if len(list.Dashboards) == 0 || startAt >= list.Total {
+			break
+		}
+	}
+
+	filters := make(map[string]*jira.Filter)
+	for _, dashboard := range result {
+		gadgets, resp, err := listDashboardGadgets(ctx, jr, dashboard.ID)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, gadget := range gadgets {
+			references, err := getGadgetReferences(ctx, jr, dashboard.ID, gadget.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, reference := range references {
+				match := gadgetReferencePattern.FindStringSubmatch(reference)
+				if match[1] == "project" {
+					gadget.ProjectID = match[2]
+					continue
+				}
+
+				filter, ok := filters[match[2]]
+				if !ok {
+					id, _ := strconv.Atoi(match[2])
+					filter, _, err = jr.Filter.Get(ctx, id)
+					if err != nil {
+						return nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira filter %s: dashboard %s", match[2], dashboard.ID))
+					}
+					filters[match[2]] = filter
+				}
+				gadget.Filter = filter
+			}
+		}
+		dashboard.Gadgets = gadgets
+	}
+
+	return result, nil
+}
+
+func listDashboardGadgets(ctx context.Context, jr *jira.Client, dashboardID string) ([]*DashboardGadget, *jira.Response, error) {
+	u := fmt.Sprintf("rest/api/2/dashboard/%s/gadget", dashboardID)
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	var result struct {
+		Gadgets []*DashboardGadget `json:"gadgets"`
+	}
+	resp, err := jr.Do(req, &result)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, fmt.Sprintf("Error getting gadgets: dashboard %s", dashboardID))
+	}
+
+	return result.Gadgets, resp, nil
+}
+
+// getGadgetReferences returns the filters and projects in the properties of the gadget (e.g. filter-10000)
+func getGadgetReferences(ctx context.Context, jr *jira.Client, dashboardID string, gadgetID int) ([]string, error) {
+	u := fmt.Sprintf("rest/api/2/dashboard/%s/items/%d/properties", dashboardID, gadgetID)
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating request")
+	}
+
+	var keys struct {
+		Keys []struct {
+			Key string `json:"key"`
+		} `json:"keys"`
+	}
+	if _, err := jr.Do(req, &keys); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error getting gadget properties: dashboard %s, gadget %d", dashboardID, gadgetID))
+	}
+
+	var references []string
+	for _, key := range keys.Keys {
+		req, err := jr.NewRequest(ctx, "GET", fmt.Sprintf("%s/%s", u, key.Key), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating request")
+		}
+
+		var property struct {
+			Value interface{} `json:"value"`
+		}
+		if _, err := jr.Do(req, &property); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting gadget property %s: dashboard %s, gadget %d", key.Key, dashboardID, gadgetID))
+		}
+		references = appendGadgetReferences(references, "", property.Value)
+	}
+
+	return references, nil
+}
+
+// appendGadgetReferences finds the references in the property value, a filterId is the ID alone
+func appendGadgetReferences(references []string, name string, value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if gadgetReferencePattern.MatchString(v) {
+			return append(references, v)
+		}
+		if _, err := strconv.Atoi(v); err == nil && name == "filterId" {
+			return append(references, "filter-"+v)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			references = appendGadgetReferences(references, key, item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			references = appendGadgetReferences(references, name, item)
+		}
+	}
+	return references
+}
@@ -0,0 +1,127 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+//* Jira Development Panel (dev-status) API는 라이브러리에서 지원하지 않는다.
+
+type DevStatusCommit struct {
+	ID        string `json:"id"`
+	DisplayID string `json:"displayId"`
+	Message   string `json:"message"`
+	URL       string `json:"url"`
+}
+
+type DevStatusRepository struct {
+	Name    string             `json:"name"`
+	URL     string             `json:"url"`
+	Commits []*DevStatusCommit `json:"commits"`
+}
+
+type DevStatusBranch struct {
+	Name       string               `json:"name"`
+	URL        string               `json:"url"`
+	Repository *DevStatusRepository `json:"repository"`
+}
+
+type DevStatusPullRequest struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Status string `json:"status"`
+}
+
+type DevStatusDetail struct {
+	Repositories []*DevStatusRepository  `json:"repositories"`
+	Branches     []*DevStatusBranch      `json:"branches"`
+	PullRequests []*DevStatusPullRequest `json:"pullRequests"`
+}
+
+type DevStatus struct {
+	Errors []interface{}      `json:"errors"`
+	Detail []*DevStatusDetail `json:"detail"`
+}
+
+type DevStatusCount struct {
+	Count int `json:"count"`
+}
+
+type DevStatusSummaryItem struct {
+	Overall        DevStatusCount            `json:"overall"`
+	ByInstanceType map[string]DevStatusCount `json:"byInstanceType"` // Key: application type
+}
+
+type DevStatusSummary struct {
+	Summary map[string]*DevStatusSummaryItem `json:"summary"` // Key: repository, branch, pullrequest
+}
+
+// Count returns the number of items of the data type in the application type
+func (s *DevStatusSummary) Count(applicationType string, dataType string) int {
+	item, ok := s.Summary[dataType]
+	if !ok || item == nil {
+		return 0
+	}
+	return item.ByInstanceType[applicationType].Count
+}
+
+// GetDevStatusSummary returns the number of commits, branches and pull requests of the issue by application type.
+// It is one request for every application type, the details are requested only if there is something.
+func GetDevStatusSummary(ctx context.Context, jr *jira.Client, issueID string) (*DevStatusSummary, *jira.Response, error) {
+	q := url.Values{}
+	q.Set("issueId", issueID)
+	u := fmt.Sprintf("rest/dev-status/1.0/issue/summary?%s", q.Encode())
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	summary := new(DevStatusSummary)
+	resp, err := jr.Do(req, summary)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, "Error getting dev status summary")
+	}
+
+	return summary, resp, nil
+}
+
+// GetDevStatus returns the development information of the issue
+// - applicationType: stash, bitbucket, github, gitlab, ...
+// - dataType: repository (commits), branch, pullrequest
+func GetDevStatus(ctx context.Context, jr *jira.Client, issueID string, applicationType string, dataType string) (*DevStatus, *jira.Response, error) {
+	q := url.Values{}
+	q.Set("issueId", issueID)
+	q.Set("applicationType", applicationType)
+	q.Set("dataType", dataType)
+	u := fmt.Sprintf("rest/dev-status/1.0/issue/detail?%s", q.Encode())
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	devStatus := new(DevStatus)
+	resp, err := jr.Do(req, devStatus)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, "Error getting dev status")
+	}
+
+	return devStatus, resp, nil
+}
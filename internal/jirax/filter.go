@@ -0,0 +1,60 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// ListProjectFilters returns the favourite filters of the user and the saved filters of the boards of the project. A filter is returned once.
+func ListProjectFilters(ctx context.Context, jr *jira.Client, projectKey string) ([]*jira.Filter, error) {
+	favourites, _, err := jr.Filter.GetFavouriteList(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting favourite Jira filters")
+	}
+
+	result := make([]*jira.Filter, 0, len(favourites))
+	seen := make(map[string]bool)
+	for _, filter := range favourites {
+		if !seen[filter.ID] {
+			seen[filter.ID] = true
+			result = append(result, filter)
+		}
+	}
+
+	boards, err := ListBoardConfigurations(ctx, jr, projectKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, board := range boards {
+		if board.Filter.ID == "" || seen[board.Filter.ID] {
+			continue
+		}
+		id, err := strconv.Atoi(board.Filter.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error parsing filter ID: board %d", board.ID))
+		}
+		filter, _, err := jr.Filter.Get(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira filter: board %d", board.ID))
+		}
+		seen[board.Filter.ID] = true
+		result = append(result, filter)
+	}
+
+	return result, nil
+}
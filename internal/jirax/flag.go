@@ -0,0 +1,42 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+)
+
+// Jira Software starts the comment of "Add flag" with the flag emoticon, e.g. "(flag) Flag added\n\nWaiting for the API key"
+const FlagCommentPrefix = "(flag)"
+
+// IsFlagged is true if the Flagged custom field has a value, e.g. [{"value": "Impediment"}]
+func IsFlagged(value interface{}) bool {
+	values, ok := value.([]interface{})
+	return ok && len(values) > 0
+}
+
+// FlagComment returns the last comment of "Add flag", nil if the flag was added without a comment
+func FlagComment(issue *jira.Issue) *jira.Comment {
+	if issue.Fields.Comments == nil {
+		return nil
+	}
+
+	var result *jira.Comment
+	for _, comment := range issue.Fields.Comments.Comments {
+		if strings.HasPrefix(strings.TrimSpace(comment.Body), FlagCommentPrefix) {
+			result = comment
+		}
+	}
+	return result
+}
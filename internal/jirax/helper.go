@@ -13,13 +13,22 @@ package jirax
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
 
 	jira "github.com/andygrunwald/go-jira/v2/onpremise"
 	"github.com/pkg/errors"
 )
 
-func UnpaginateIssue(
+// SearchExpand is expanded on the search, so the issues don't need another request for them
+const SearchExpand = "changelog"
+
+// UnpaginateIssue returns every issue of the JQL with all the fields (comments, attachments, worklogs, ...) and the changelog.
+// The search returns only the first 20 worklogs of an issue, the other worklogs are requested for those issues only.
+func UnpaginateIssue(ctx context.Context,
 	jr *jira.Client,
+	api API,
 	jql string,
 ) ([]*jira.Issue, error) {
 
@@ -29,10 +38,25 @@ func UnpaginateIssue(
 		StartAt:    0,
 		MaxResults: 100,
 		Fields:     []string{"*all"},
+		Expand:     SearchExpand,
+	}
+
+	pageToken := ""
+	for api != APIv2 {
+		items, next, err := searchIssues(ctx, jr, api, jql, searchOptions, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+		if next == "" {
+			break
+		}
+		searchOptions.StartAt += len(items)
+		pageToken = next
 	}
 
-	for {
-		itemsV2, r, err := jr.Issue.Search(context.Background(), jql, searchOptions)
+	for api == APIv2 {
+		itemsV2, r, err := jr.Issue.Search(ctx, jql, searchOptions)
 		if err != nil {
 			return nil, errors.Wrap(err, "Error getting Jira issues V2")
 		}
@@ -54,5 +78,92 @@ func UnpaginateIssue(
 		searchOptions.StartAt += len(itemsV2)
 	}
 
+	//* Worklogs
+	for _, issue := range result {
+		if issue.Fields == nil || issue.Fields.Worklog == nil || issue.Fields.Worklog.Total <= len(issue.Fields.Worklog.Worklogs) {
+			continue
+		}
+
+		worklog, err := GetWorklogs(ctx, jr, issue.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting worklogs: issue %s", issue.Key))
+		}
+		issue.Fields.Worklog = worklog
+	}
+
 	return result, nil
 }
+
+// SampleIssues returns the first issues of the JQL with all the fields, a single page of the search
+func SampleIssues(ctx context.Context, jr *jira.Client, api API, jql string, maxResults int) ([]*jira.Issue, error) {
+	if api != APIv2 {
+		items, _, err := searchIssues(ctx, jr, api, jql, &jira.SearchOptions{MaxResults: maxResults, Fields: []string{"*all"}}, "")
+		return items, err
+	}
+
+	items, _, err := jr.Issue.Search(ctx, jql, &jira.SearchOptions{MaxResults: maxResults, Fields: []string{"*all"}})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error getting Jira issues")
+	}
+
+	result := make([]*jira.Issue, 0, len(items))
+	for i := range items {
+		result = append(result, &items[i])
+	}
+	return result, nil
+}
+
+// searchIssues returns a page of the search of the API and the token of the next page, empty on the last page.
+// The enhanced search pages with the token, the other searches with options.StartAt. The ADF documents of the API version 3 are converted to wiki markup.
+func searchIssues(ctx context.Context, jr *jira.Client, api API, jql string, options *jira.SearchOptions, pageToken string) ([]*jira.Issue, string, error) {
+	query := url.Values{}
+	query.Set("jql", jql)
+	query.Set("maxResults", fmt.Sprintf("%d", options.MaxResults))
+	query.Set("fields", "*all")
+	if options.Expand != "" {
+		query.Set("expand", options.Expand)
+	}
+
+	u := fmt.Sprintf("rest/api/%s/search", api.Version)
+	if api.EnhancedSearch {
+		u += "/jql"
+		if pageToken != "" {
+			query.Set("nextPageToken", pageToken)
+		}
+	} else {
+		query.Set("startAt", fmt.Sprintf("%d", options.StartAt))
+	}
+
+	req, err := jr.NewRequest(ctx, "GET", u+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Error creating request")
+	}
+
+	var page struct {
+		Issues        []interface{} `json:"issues"`
+		NextPageToken string        `json:"nextPageToken"` // enhanced search
+		Total         int           `json:"total"`
+	}
+	if _, err := jr.Do(req, &page); err != nil {
+		return nil, "", errors.Wrap(err, fmt.Sprintf("Error getting Jira issues V%s", api.Version))
+	}
+
+	result := make([]*jira.Issue, 0, len(page.Issues))
+	for _, raw := range page.Issues {
+		data, err := json.Marshal(ReplaceADF(raw))
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Error encoding Jira issue")
+		}
+		issue := new(jira.Issue)
+		if err := json.Unmarshal(data, issue); err != nil {
+			return nil, "", errors.Wrap(err, "Error decoding Jira issue")
+		}
+		result = append(result, issue)
+	}
+
+	next := page.NextPageToken
+	if !api.EnhancedSearch && len(page.Issues) > 0 && options.StartAt+len(page.Issues) < page.Total {
+		next = fmt.Sprintf("%d", options.StartAt+len(page.Issues))
+	}
+	return result, next, nil
+}
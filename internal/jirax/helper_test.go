@@ -0,0 +1,67 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package jirax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnpaginateIssue(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			assert.Equal(t, SearchExpand, r.URL.Query().Get("expand"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"startAt": 0, "maxResults": 100, "total": 2,
+				"issues": []map[string]interface{}{
+					{"id": "10001", "key": "TEST-1", "fields": map[string]interface{}{
+						"worklog": map[string]interface{}{"total": 1, "worklogs": []map[string]interface{}{{"id": "1"}}},
+					}},
+					{"id": "10002", "key": "TEST-2", "fields": map[string]interface{}{
+						"worklog": map[string]interface{}{"total": 2, "worklogs": []map[string]interface{}{{"id": "2"}}},
+					}},
+				},
+			})
+		case "/rest/api/2/issue/10002/worklog":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"startAt": 0, "maxResults": 100, "total": 2,
+				"worklogs": []map[string]interface{}{{"id": "2"}, {"id": "3"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	jr, err := jira.NewClient(server.URL, server.Client())
+	assert.NoError(t, err)
+
+	issues, err := UnpaginateIssue(context.Background(), jr, APIv2, "project = TEST")
+	assert.NoError(t, err)
+	if assert.Len(t, issues, 2) {
+		assert.Len(t, issues[0].Fields.Worklog.Worklogs, 1)
+		assert.Len(t, issues[1].Fields.Worklog.Worklogs, 2)
+	}
+
+	//* Only the issue with more worklogs than the search returns requests them
+	assert.Equal(t, []string{"/rest/api/2/search", "/rest/api/2/issue/10002/worklog"}, requests)
+}
@@ -0,0 +1,43 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+const (
+	RoleActorTypeUser  = "atlassian-user-role-actor"
+	RoleActorTypeGroup = "atlassian-group-role-actor"
+)
+
+// GetProjectRole returns the project role with its actors (users and groups)
+func GetProjectRole(ctx context.Context, jr *jira.Client, projectKey string, roleID string) (*jira.Role, *jira.Response, error) {
+	u := fmt.Sprintf("rest/api/2/project/%s/role/%s", projectKey, roleID)
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	role := new(jira.Role)
+	resp, err := jr.Do(req, role)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, "Error getting project role")
+	}
+
+	return role, resp, nil
+}
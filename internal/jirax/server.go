@@ -0,0 +1,76 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// Deployment types of the server info
+const (
+	DeploymentCloud      = "Cloud"
+	DeploymentServer     = "Server"
+	DeploymentDataCenter = "DataCenter"
+)
+
+type ServerInfo struct {
+	BaseURL        string `json:"baseUrl"`
+	Version        string `json:"version"`
+	VersionNumbers []int  `json:"versionNumbers"`
+	DeploymentType string `json:"deploymentType"`
+	BuildNumber    int    `json:"buildNumber"`
+}
+
+// API is the REST API of a Jira
+type API struct {
+	// 2 returns the text fields as wiki markup, 3 as Atlassian Document Format (Jira Cloud only)
+	Version string
+	// Jira Cloud removed /search for /search/jql, which pages with a token
+	EnhancedSearch bool
+}
+
+var APIv2 = API{Version: "2"}
+
+func GetServerInfo(ctx context.Context, jr *jira.Client) (*ServerInfo, error) {
+	req, err := jr.NewRequest(ctx, "GET", "rest/api/2/serverInfo", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating request")
+	}
+
+	info := new(ServerInfo)
+	if _, err := jr.Do(req, info); err != nil {
+		return nil, errors.Wrap(err, "Error getting Jira server info")
+	}
+
+	return info, nil
+}
+
+// IsCloud reports if the Jira is Jira Cloud, Jira Server and Data Center return Server or DataCenter
+func (s *ServerInfo) IsCloud() bool {
+	return s.DeploymentType == DeploymentCloud
+}
+
+// DetectAPI returns the API of the Jira, version 3 on Jira Cloud and 2 on Jira Server and Data Center.
+// version overrides the detected version if it is not empty.
+func DetectAPI(info *ServerInfo, version string) API {
+	api := APIv2
+	if info != nil && info.IsCloud() {
+		api = API{Version: "3", EnhancedSearch: true}
+	}
+	if version != "" {
+		api.Version = version
+	}
+	return api
+}
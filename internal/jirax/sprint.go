@@ -0,0 +1,83 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+const SprintStateClosed = "closed"
+
+// UnpaginateSprints returns the sprints of every scrum board of the project. A sprint shared by boards is returned once.
+func UnpaginateSprints(ctx context.Context, jr *jira.Client, projectKey string) ([]jira.Sprint, error) {
+	boards, err := UnpaginateBoards(ctx, jr, projectKey, "scrum")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []jira.Sprint
+	seen := make(map[int]bool)
+	for _, board := range boards {
+		sprintOptions := &jira.GetAllSprintsOptions{SearchOptions: jira.SearchOptions{MaxResults: 50}}
+		for {
+			list, _, err := jr.Board.GetAllSprints(ctx, board.ID, sprintOptions)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("Error getting Jira sprints: board %d", board.ID))
+			}
+			for _, sprint := range list.Values {
+				if !seen[sprint.ID] {
+					seen[sprint.ID] = true
+					result = append(result, sprint)
+				}
+			}
+			if list.IsLast || len(list.Values) == 0 {
+				break
+			}
+			sprintOptions.StartAt += len(list.Values)
+		}
+	}
+
+	return result, nil
+}
+
+// Jira Server before 8 returns the sprint field as text
+// e.g. com.atlassian.greenhopper.service.sprint.Sprint@1a2b[id=1,rapidViewId=1,state=CLOSED,name=Sprint 1,...]
+var sprintNamePattern = regexp.MustCompile(`[\[,]name=([^,\]]*)`)
+
+// SprintNames parses the value of the sprint custom field of an issue, the last one is the current sprint
+func SprintNames(value interface{}) []string {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, v := range values {
+		switch sprint := v.(type) {
+		case string:
+			if match := sprintNamePattern.FindStringSubmatch(sprint); match != nil {
+				names = append(names, match[1])
+			}
+		case map[string]interface{}:
+			if name, ok := sprint["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
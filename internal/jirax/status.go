@@ -0,0 +1,46 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// IssueTypeStatuses are the statuses of the workflow of an issue type
+type IssueTypeStatuses struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Subtask  bool          `json:"subtask"`
+	Statuses []jira.Status `json:"statuses"`
+}
+
+// ListProjectStatuses returns the statuses of each issue type of the project
+func ListProjectStatuses(ctx context.Context, jr *jira.Client, projectKey string) ([]*IssueTypeStatuses, *jira.Response, error) {
+	u := fmt.Sprintf("rest/api/2/project/%s/statuses", projectKey)
+
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	var result []*IssueTypeStatuses
+	resp, err := jr.Do(req, &result)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, "Error getting project statuses")
+	}
+
+	return result, resp, nil
+}
@@ -0,0 +1,99 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// TestRun is an execution of a test by Xray or Zephyr Squad
+type TestRun struct {
+	Execution  string // test execution issue (Xray) or test cycle (Zephyr)
+	Status     string // e.g. PASS, FAIL
+	ExecutedBy string // Jira username
+	Executed   string // as the add-on formats it
+}
+
+type xrayTestRun struct {
+	Status      string `json:"status"`
+	TestExecKey string `json:"testExecKey"`
+	ExecutedBy  string `json:"executedBy"`
+	StartedOn   string `json:"startedOn"`
+	FinishedOn  string `json:"finishedOn"`
+}
+
+// GetXrayTestRuns returns the test runs of the Xray test
+func GetXrayTestRuns(ctx context.Context, jr *jira.Client, testKey string) ([]*TestRun, *jira.Response, error) {
+	u := fmt.Sprintf("rest/raven/1.0/api/test/%s/testruns", testKey)
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	var runs []*xrayTestRun
+	resp, err := jr.Do(req, &runs)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, fmt.Sprintf("Error getting Xray test runs: issue %s", testKey))
+	}
+
+	result := make([]*TestRun, 0, len(runs))
+	for _, run := range runs {
+		executed := run.FinishedOn
+		if executed == "" {
+			executed = run.StartedOn
+		}
+		result = append(result, &TestRun{Execution: run.TestExecKey, Status: run.Status, ExecutedBy: run.ExecutedBy, Executed: executed})
+	}
+	return result, resp, nil
+}
+
+type zephyrExecutions struct {
+	// Key: status ID
+	Status map[string]struct {
+		Name string `json:"name"`
+	} `json:"status"`
+	Executions []struct {
+		ExecutionStatus string `json:"executionStatus"`
+		ExecutedOn      string `json:"executedOn"`
+		ExecutedBy      string `json:"executedBy"`
+		CycleName       string `json:"cycleName"`
+	} `json:"executions"`
+}
+
+// GetZephyrExecutions returns the executions of the Zephyr Squad test, the unexecuted ones too
+func GetZephyrExecutions(ctx context.Context, jr *jira.Client, issueID string) ([]*TestRun, *jira.Response, error) {
+	u := fmt.Sprintf("rest/zapi/latest/execution?issueId=%s", issueID)
+	req, err := jr.NewRequest(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error creating request")
+	}
+
+	data := new(zephyrExecutions)
+	resp, err := jr.Do(req, data)
+	if err != nil {
+		return nil, resp, errors.Wrap(err, fmt.Sprintf("Error getting Zephyr executions: issue %s", issueID))
+	}
+
+	result := make([]*TestRun, 0, len(data.Executions))
+	for _, execution := range data.Executions {
+		status := execution.ExecutionStatus
+		if s, ok := data.Status[status]; ok {
+			status = s.Name
+		}
+		result = append(result, &TestRun{Execution: execution.CycleName, Status: status, ExecutedBy: execution.ExecutedBy, Executed: execution.ExecutedOn})
+	}
+	return result, resp, nil
+}
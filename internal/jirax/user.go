@@ -32,7 +32,7 @@ type UserQueryOptions struct {
 	Expand string
 }
 
-func GetUser(jr *jira.Client, options *UserQueryOptions) (*jira.User, *jira.Response, error) {
+func GetUser(ctx context.Context, jr *jira.Client, options *UserQueryOptions) (*jira.User, *jira.Response, error) {
 	u, err := url.Parse(jr.BaseURL.Host)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, fmt.Sprintf("Error parsing Jira URL: %s", jr.BaseURL))
@@ -56,7 +56,7 @@ func GetUser(jr *jira.Client, options *UserQueryOptions) (*jira.User, *jira.Resp
 	u.RawQuery = q.Encode()
 
 	user := new(jira.User)
-	req, err := jr.NewRequest(context.Background(), "GET", u.String(), nil)
+	req, err := jr.NewRequest(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "Error creating request")
 	}
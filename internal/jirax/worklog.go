@@ -0,0 +1,49 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package jirax
+
+import (
+	"context"
+	"fmt"
+
+	jira "github.com/andygrunwald/go-jira/v2/onpremise"
+	"github.com/pkg/errors"
+)
+
+// GetWorklogs returns every worklog of the issue, page by page
+func GetWorklogs(ctx context.Context, jr *jira.Client, issueID string) (*jira.Worklog, error) {
+	result := &jira.Worklog{}
+	startAt := 0
+
+	for {
+		u := fmt.Sprintf("rest/api/2/issue/%s/worklog?startAt=%d&maxResults=100", issueID, startAt)
+		req, err := jr.NewRequest(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating request")
+		}
+
+		page := new(jira.Worklog)
+		if _, err := jr.Do(req, page); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error getting worklogs: issue %s", issueID))
+		}
+
+		result.Worklogs = append(result.Worklogs, page.Worklogs...)
+		startAt += len(page.Worklogs)
+		if len(page.Worklogs) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+
+	result.MaxResults = len(result.Worklogs)
+	result.Total = len(result.Worklogs)
+	return result, nil
+}
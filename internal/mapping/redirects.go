@@ -0,0 +1,86 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package mapping
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+const (
+	FormatNginx = "nginx"
+	FormatCaddy = "caddy"
+)
+
+// WriteRedirects writes the redirect rules from https://jira/browse/{key} to the GitLab URL
+func WriteRedirects(w io.Writer, s *state.State, format string) error {
+	keys := make([]string, 0, len(s.Items)+len(s.Moved))
+	for key := range s.Items {
+		keys = append(keys, key)
+	}
+	//* Old keys of the moved issues
+	for key := range s.Moved {
+		if _, ok := s.Get(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	switch format {
+	case FormatNginx:
+		return writeNginx(w, s, keys)
+	case FormatCaddy:
+		return writeCaddy(w, s, keys)
+	default:
+		return errors.Errorf("Unknown redirect format: %s (must be %s or %s)", format, FormatNginx, FormatCaddy)
+	}
+}
+
+// Usage in the server block of Jira:
+//
+//	if ($j2lab_redirect) { return 301 $j2lab_redirect; }
+func writeNginx(w io.Writer, s *state.State, keys []string) error {
+	if _, err := fmt.Fprintf(w, "map $uri $j2lab_redirect {\n    default \"\";\n"); err != nil {
+		return errors.Wrap(err, "Error writing redirects")
+	}
+
+	for _, key := range keys {
+		item, _ := s.Get(key)
+		if _, err := fmt.Fprintf(w, "    /browse/%s %s;\n", key, item.WebURL); err != nil {
+			return errors.Wrap(err, "Error writing redirects")
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "}\n"); err != nil {
+		return errors.Wrap(err, "Error writing redirects")
+	}
+
+	return nil
+}
+
+// Usage in the site block of Jira:
+//
+//	import j2lab-redirects.caddy
+func writeCaddy(w io.Writer, s *state.State, keys []string) error {
+	for _, key := range keys {
+		item, _ := s.Get(key)
+		if _, err := fmt.Fprintf(w, "redir /browse/%s %s permanent\n", key, item.WebURL); err != nil {
+			return errors.Wrap(err, "Error writing redirects")
+		}
+	}
+
+	return nil
+}
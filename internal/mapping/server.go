@@ -0,0 +1,88 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package mapping
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+type Response struct {
+	JiraKey   string `json:"jira_key"`
+	JiraURL   string `json:"jira_url"`
+	Type      string `json:"type"`
+	GitLabIID int    `json:"gitlab_iid"`
+	GitLabURL string `json:"gitlab_url"`
+}
+
+// NewHandler returns the mapping API backed by the state
+// - GET /jira/{key}        : Jira Key -> GitLab URL
+// - GET /gitlab?url={url}  : GitLab URL -> Jira Key
+// Add ?redirect=true to get a 302 redirect instead of JSON.
+func NewHandler(s *state.State) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/jira/", func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/jira/")
+		item, ok := s.Get(key)
+		if !ok {
+			http.Error(w, "jira key not found: "+key, http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Query().Get("redirect") == "true" {
+			http.Redirect(w, r, item.WebURL, http.StatusFound)
+			return
+		}
+
+		writeJSON(w, newResponse(s, item))
+	})
+
+	mux.HandleFunc("/gitlab", func(w http.ResponseWriter, r *http.Request) {
+		webURL := r.URL.Query().Get("url")
+		item, ok := s.GetByWebURL(webURL)
+		if !ok {
+			http.Error(w, "gitlab url not found: "+webURL, http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Query().Get("redirect") == "true" {
+			http.Redirect(w, r, s.JiraURL(item.JiraKey), http.StatusFound)
+			return
+		}
+
+		writeJSON(w, newResponse(s, item))
+	})
+
+	return mux
+}
+
+func newResponse(s *state.State, item *state.Item) *Response {
+	return &Response{
+		JiraKey:   item.JiraKey,
+		JiraURL:   s.JiraURL(item.JiraKey),
+		Type:      item.Type,
+		GitLabIID: item.IID,
+		GitLabURL: item.WebURL,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Error writing response: %s", err)
+	}
+}
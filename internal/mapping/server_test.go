@@ -0,0 +1,62 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package mapping
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/state"
+)
+
+func newTestState() *state.State {
+	s := state.New("https://jira.example.com")
+	s.SetIssue("PROJ-1", &gitlab.Issue{ID: 100, IID: 1, ProjectID: 10, WebURL: "https://gitlab.example.com/group/project/-/issues/1"})
+	s.SetEpic("PROJ-2", &gitlab.Epic{ID: 200, IID: 2, GroupID: 20, WebURL: "https://gitlab.example.com/groups/group/-/epics/2"})
+	return s
+}
+
+func TestJiraToGitLab(t *testing.T) {
+	handler := NewHandler(newTestState())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jira/proj-1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "PROJ-1", resp.JiraKey)
+	assert.Equal(t, "https://jira.example.com/browse/PROJ-1", resp.JiraURL)
+	assert.Equal(t, "https://gitlab.example.com/group/project/-/issues/1", resp.GitLabURL)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jira/PROJ-2?redirect=true", nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://gitlab.example.com/groups/group/-/epics/2", rec.Header().Get("Location"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jira/PROJ-3", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGitLabToJira(t *testing.T) {
+	handler := NewHandler(newTestState())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gitlab?url=https://gitlab.example.com/group/project/-/issues/1&redirect=true", nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://jira.example.com/browse/PROJ-1", rec.Header().Get("Location"))
+}
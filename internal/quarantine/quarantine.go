@@ -0,0 +1,136 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package quarantine
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Item is a Jira issue which failed to migrate
+type Item struct {
+	JiraKey  string    `json:"jira_key"`
+	Type     string    `json:"type"`             // epic or issue, as in the state file
+	Target   string    `json:"target,omitempty"` // GitLab project of the issue or group of the epic of the failed attempt
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+	Attempts int       `json:"attempts"`
+}
+
+// Quarantine is the queue of the failed items, they are retried by retry-failed after the cause is fixed (e.g. an expired token)
+type Quarantine struct {
+	mutex sync.RWMutex
+
+	Items map[string]*Item `json:"items"` // Jira Key -> Item
+}
+
+func New() *Quarantine {
+	return &Quarantine{Items: make(map[string]*Item)}
+}
+
+// Load reads the quarantine file. If the file does not exist, an empty quarantine is returned.
+func Load(path string) (*Quarantine, error) {
+	q := New()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "Error reading quarantine file")
+	}
+
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, errors.Wrap(err, "Error parsing quarantine file")
+	}
+
+	if q.Items == nil {
+		q.Items = make(map[string]*Item)
+	}
+
+	return q, nil
+}
+
+// Save writes the quarantine file atomically, the file is removed when nothing is left
+func (q *Quarantine) Save(path string) error {
+	q.mutex.RLock()
+	empty := len(q.Items) == 0
+	data, err := json.MarshalIndent(q, "", "  ")
+	q.mutex.RUnlock()
+	if err != nil {
+		return errors.Wrap(err, "Error marshalling quarantine")
+	}
+
+	if empty {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "Error removing quarantine file")
+		}
+		return nil
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return errors.Wrap(err, "Error writing quarantine file")
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "Error renaming quarantine file")
+	}
+
+	return nil
+}
+
+// Add quarantines the item with its GitLab target and its error, the attempts are counted
+func (q *Quarantine) Add(jiraKey string, itemType string, target string, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	attempts := 1
+	if item, ok := q.Items[jiraKey]; ok {
+		attempts = item.Attempts + 1
+	}
+	q.Items[jiraKey] = &Item{
+		JiraKey:  jiraKey,
+		Type:     itemType,
+		Target:   target,
+		Error:    err.Error(),
+		FailedAt: time.Now(),
+		Attempts: attempts,
+	}
+}
+
+// Remove releases the item after it is migrated
+func (q *Quarantine) Remove(jiraKey string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.Items, jiraKey)
+}
+
+// List returns the items sorted by the Jira key
+func (q *Quarantine) List() []*Item {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	items := make([]*Item, 0, len(q.Items))
+	for _, item := range q.Items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].JiraKey < items[j].JiraKey
+	})
+	return items
+}
@@ -0,0 +1,67 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+
+	q, err := Load(path)
+	assert.NoError(t, err)
+	assert.Empty(t, q.List())
+
+	q.Add("TEST-2", "issue", "group/project", errors.New("401 Unauthorized"))
+	q.Add("TEST-1", "epic", "group", errors.New("500 Internal Server Error"))
+	q.Add("TEST-2", "issue", "group/project", errors.New("401 Unauthorized"))
+	assert.NoError(t, q.Save(path))
+
+	q, err = Load(path)
+	assert.NoError(t, err)
+	items := q.List()
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, "TEST-1", items[0].JiraKey)
+		assert.Equal(t, "epic", items[0].Type)
+		assert.Equal(t, "group", items[0].Target)
+		assert.Equal(t, 1, items[0].Attempts)
+		assert.Equal(t, "TEST-2", items[1].JiraKey)
+		assert.Equal(t, "401 Unauthorized", items[1].Error)
+		assert.Equal(t, 2, items[1].Attempts)
+	}
+
+	//* The file is removed when nothing is left
+	q.Remove("TEST-1")
+	q.Remove("TEST-2")
+	assert.NoError(t, q.Save(path))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, q.Save(path))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	_, err := Load(path)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{}"), 0600))
+	q, err := Load(path)
+	assert.NoError(t, err)
+	q.Add("TEST-1", "issue", "group/project", errors.New("failed"))
+	assert.Len(t, q.List(), 1)
+}
@@ -0,0 +1,159 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package scratch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
+)
+
+const runPrefix = "j2lab-"
+
+// Dir is the scratch directory of a run (<dir>/j2lab-<pid>), the buffered files count against its maximum usage
+type Dir struct {
+	path  string
+	max   int64
+	usage *semaphore.Weighted
+}
+
+// Open creates the directory of the run in dir. The directories of the runs which are not running anymore
+// (e.g. killed or crashed) are removed first, so a crash doesn't leave the attachments behind for long.
+func Open(dir string, maxBytes int64) (*Dir, error) {
+	if maxBytes <= 0 {
+		return nil, errors.Errorf("Maximum usage of scratch directory %s must be positive", dir)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating scratch directory: %s", dir))
+	}
+	removeStale(dir)
+
+	path, err := os.MkdirTemp(dir, fmt.Sprintf("%s%d-", runPrefix, os.Getpid()))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating scratch directory: %s", dir))
+	}
+	return &Dir{path: path, max: maxBytes, usage: semaphore.NewWeighted(maxBytes)}, nil
+}
+
+// Path is the directory of the run
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// Close removes the directory of the run with the files which are still open
+func (d *Dir) Close() error {
+	if err := os.RemoveAll(d.path); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error removing scratch directory: %s", d.path))
+	}
+	return nil
+}
+
+// File is a file of the scratch directory, Close removes it and frees its space
+type File struct {
+	*os.File
+	dir      *Dir
+	reserved int64
+	once     sync.Once
+}
+
+// Buffer copies r to a new file, rewound for reading. The expected size is reserved first and
+// Buffer waits while the maximum usage is reached; a file larger than the maximum waits for the whole directory.
+func (d *Dir) Buffer(ctx context.Context, r io.Reader, size int64) (*File, error) {
+	reserved := size
+	if reserved < 1 {
+		reserved = 1
+	}
+	if reserved > d.max {
+		reserved = d.max
+	}
+	if err := d.usage.Acquire(ctx, reserved); err != nil {
+		return nil, errors.Wrap(err, "Error waiting for scratch space")
+	}
+
+	file, err := os.CreateTemp(d.path, "buffer-*")
+	if err != nil {
+		d.usage.Release(reserved)
+		return nil, errors.Wrap(err, "Error creating scratch file")
+	}
+	f := &File{File: file, dir: d, reserved: reserved}
+
+	if _, err := io.Copy(file, r); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "Error writing scratch file")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "Error rewinding scratch file")
+	}
+	return f, nil
+}
+
+func (f *File) Close() error {
+	var err error
+	f.once.Do(func() {
+		err = f.File.Close()
+		if removeErr := os.Remove(f.Name()); err == nil && !os.IsNotExist(removeErr) {
+			err = removeErr
+		}
+		f.dir.usage.Release(f.reserved)
+	})
+	return err
+}
+
+// removeStale removes the run directories of the processes which don't exist anymore
+func removeStale(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), runPrefix) {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(entry.Name(), runPrefix), "-", 2)[0])
+		if err != nil || pid == os.Getpid() || processExists(pid) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			log.Warnf("Error removing scratch directory of a previous run %s: %s", path, err)
+			continue
+		}
+		log.Infof("Removed scratch directory of a previous run: %s", path)
+	}
+}
+
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	//* Windows finds only the running processes, the signal 0 is not supported there
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM) // a process of another user
+}
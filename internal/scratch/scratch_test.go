@@ -0,0 +1,82 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package scratch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	dir, err := Open(t.TempDir(), 10)
+	assert.NoError(t, err)
+	defer dir.Close()
+
+	file, err := dir.Buffer(context.Background(), strings.NewReader("attachment"), 10)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "attachment", string(content))
+
+	//* The directory is full until the file is closed
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = dir.Buffer(ctx, strings.NewReader("log"), 3)
+	assert.Error(t, err)
+
+	assert.NoError(t, file.Close())
+	assert.NoError(t, file.Close())
+	_, err = os.Stat(file.Name())
+	assert.True(t, os.IsNotExist(err))
+
+	//* A file over the maximum waits for the whole directory
+	large, err := dir.Buffer(context.Background(), strings.NewReader("larger than the maximum"), 100)
+	assert.NoError(t, err)
+	assert.NoError(t, large.Close())
+
+	assert.NoError(t, dir.Close())
+	_, err = os.Stat(dir.Path())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOpen(t *testing.T) {
+	parent := t.TempDir()
+	_, err := Open(parent, 0)
+	assert.Error(t, err)
+
+	//* The directories of the stopped runs are removed, of the running ones kept
+	stale := filepath.Join(parent, runPrefix+"4194304-1")
+	running := filepath.Join(parent, fmt.Sprintf("%s%d-1", runPrefix, os.Getppid()))
+	other := filepath.Join(parent, "other")
+	for _, path := range []string{stale, running, other} {
+		assert.NoError(t, os.MkdirAll(path, 0o700))
+	}
+
+	dir, err := Open(parent, 10)
+	assert.NoError(t, err)
+	defer dir.Close()
+	assert.True(t, strings.HasPrefix(filepath.Base(dir.Path()), fmt.Sprintf("%s%d-", runPrefix, os.Getpid())))
+
+	_, err = os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+	for _, path := range []string{running, other} {
+		_, err = os.Stat(path)
+		assert.NoError(t, err)
+	}
+}
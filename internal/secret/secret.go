@@ -0,0 +1,194 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package secret
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// PassphraseEnv is the environment variable of the passphrase, it is never read from the config file
+const PassphraseEnv = "J2LAB_PASSPHRASE"
+
+// Prefix of an encrypted config value (e.g. gitlab.token: enc:...)
+const Prefix = "enc:"
+
+// Encrypted data: magic, salt, nonce, AES-256-GCM ciphertext. The key is PBKDF2-HMAC-SHA256 of the passphrase and the salt.
+var magic = []byte("J2LABENC1")
+
+const (
+	saltSize   = 16
+	iterations = 600000
+	keySize    = 32
+)
+
+// The key derivation is slow on purpose, the state file is saved often.
+// The salt of Encrypt and the derived keys are kept for the process.
+var (
+	salts sync.Map // passphrase -> salt
+	keys  sync.Map // passphrase + salt -> key
+)
+
+var ErrNoPassphrase = errors.Errorf("%s is required for the encrypted data", PassphraseEnv)
+
+// Passphrase returns the passphrase of the environment, empty if encryption is not used
+func Passphrase() string {
+	return os.Getenv(PassphraseEnv)
+}
+
+// IsEncrypted is true for data of Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+
+	salt, err := processSalt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "Error generating nonce")
+	}
+
+	result := append(append(append([]byte{}, magic...), salt...), nonce...)
+	return gcm.Seal(result, nonce, plaintext, magic), nil
+}
+
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+	if !IsEncrypted(data) {
+		return nil, errors.New("Data is not encrypted")
+	}
+
+	data = data[len(magic):]
+	if len(data) < saltSize {
+		return nil, errors.New("Encrypted data is truncated")
+	}
+	salt, data := data[:saltSize], data[saltSize:]
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("Encrypted data is truncated")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, magic)
+	if err != nil {
+		return nil, errors.New("Error decrypting: wrong passphrase or corrupted data")
+	}
+	return plaintext, nil
+}
+
+// EncryptString returns the config value of the text, enc:<base64>
+func EncryptString(text string, passphrase string) (string, error) {
+	data, err := Encrypt([]byte(text), passphrase)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptString decrypts a config value of EncryptString, other values are returned as they are
+func DecryptString(value string, passphrase string) (string, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return value, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return "", errors.Wrap(err, "Error decoding encrypted value")
+	}
+	plaintext, err := Decrypt(data, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func processSalt(passphrase string) ([]byte, error) {
+	if salt, ok := salts.Load(passphrase); ok {
+		return salt.([]byte), nil
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "Error generating salt")
+	}
+	actual, _ := salts.LoadOrStore(passphrase, salt)
+	return actual.([]byte), nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	id := passphrase + "\x00" + string(salt)
+	key, ok := keys.Load(id)
+	if !ok {
+		key, _ = keys.LoadOrStore(id, pbkdf2([]byte(passphrase), salt, iterations, keySize))
+	}
+
+	block, err := aes.NewCipher(key.([]byte))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating cipher")
+	}
+	return gcm, nil
+}
+
+// pbkdf2 derives a key of keyLen bytes with PBKDF2-HMAC-SHA256, RFC 8018
+func pbkdf2(password []byte, salt []byte, iter int, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	key := make([]byte, 0, keyLen+prf.Size())
+	var index [4]byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(index[:], block)
+		prf.Write(index[:])
+		u := prf.Sum(nil)
+
+		t := append([]byte{}, u...)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
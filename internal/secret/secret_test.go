@@ -0,0 +1,92 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package secret
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPBKDF2(t *testing.T) {
+	//* PBKDF2-HMAC-SHA256 test vectors of RFC 7914, section 11
+	tests := []struct {
+		password string
+		salt     string
+		iter     int
+		key      string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+
+	for _, test := range tests {
+		key := pbkdf2([]byte(test.password), []byte(test.salt), test.iter, 64)
+		assert.Equal(t, test.key, hex.EncodeToString(key))
+		assert.Equal(t, test.key[:2*keySize], hex.EncodeToString(pbkdf2([]byte(test.password), []byte(test.salt), test.iter, keySize)))
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	data, err := Encrypt([]byte("state"), "passphrase")
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(data))
+	assert.NotContains(t, string(data), "state")
+
+	plaintext, err := Decrypt(data, "passphrase")
+	assert.NoError(t, err)
+	assert.Equal(t, "state", string(plaintext))
+
+	_, err = Decrypt(data, "wrong")
+	assert.EqualError(t, err, "Error decrypting: wrong passphrase or corrupted data")
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 1
+	_, err = Decrypt(corrupted, "passphrase")
+	assert.EqualError(t, err, "Error decrypting: wrong passphrase or corrupted data")
+
+	//* Truncated in the salt, in the nonce and in the ciphertext
+	for _, size := range []int{len(magic) + saltSize/2, len(magic) + saltSize + 4, len(data) - 1} {
+		_, err = Decrypt(data[:size], "passphrase")
+		assert.Error(t, err)
+	}
+
+	_, err = Encrypt([]byte("state"), "")
+	assert.Equal(t, ErrNoPassphrase, err)
+	_, err = Decrypt(data, "")
+	assert.Equal(t, ErrNoPassphrase, err)
+	_, err = Decrypt([]byte(`{"issues":{}}`), "passphrase")
+	assert.EqualError(t, err, "Data is not encrypted")
+}
+
+func TestEncryptString(t *testing.T) {
+	value, err := EncryptString("glpat-token", "passphrase")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, Prefix))
+
+	text, err := DecryptString(value, "passphrase")
+	assert.NoError(t, err)
+	assert.Equal(t, "glpat-token", text)
+
+	_, err = DecryptString(value, "wrong")
+	assert.Error(t, err)
+	_, err = DecryptString(value[:len(value)-8], "passphrase")
+	assert.Error(t, err)
+	_, err = DecryptString(Prefix+"not base64!", "passphrase")
+	assert.Error(t, err)
+
+	//* A plain value is returned as it is
+	text, err = DecryptString("glpat-plain", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "glpat-plain", text)
+}
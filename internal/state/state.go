@@ -0,0 +1,216 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/secret"
+)
+
+const DefaultPath = "state.json"
+
+const (
+	ItemTypeIssue = "issue"
+	ItemTypeEpic  = "epic"
+)
+
+// Item is a migrated GitLab item for a Jira issue
+type Item struct {
+	JiraKey   string `json:"jira_key"`
+	Type      string `json:"type"`
+	ID        int    `json:"id"`
+	IID       int    `json:"iid"`
+	ProjectID int    `json:"project_id,omitempty"`
+	GroupID   int    `json:"group_id,omitempty"`
+	WebURL    string `json:"web_url"`
+
+	// Synced are the values of the Jira fields at the migration or the last sync, the base of the next sync
+	Synced map[string]string `json:"synced,omitempty"`
+}
+
+// State is the mapping between Jira issues and GitLab issues/epics
+// It is persisted as a JSON file so that it can be used after the migration (e.g. redirects)
+type State struct {
+	mutex sync.RWMutex
+
+	JiraHost string            `json:"jira_host"`
+	Items    map[string]*Item  `json:"items"`           // Jira Key -> Item
+	Moved    map[string]string `json:"moved,omitempty"` // old Jira Key of a moved issue -> Jira Key
+}
+
+func New(jiraHost string) *State {
+	return &State{
+		JiraHost: jiraHost,
+		Items:    make(map[string]*Item),
+	}
+}
+
+// Load reads the state file. If the file does not exist, an empty state is returned.
+// An encrypted state file is decrypted with the passphrase of J2LAB_PASSPHRASE.
+func Load(path string) (*State, error) {
+	s := New("")
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "Error reading state file")
+	}
+
+	if secret.IsEncrypted(data) {
+		data, err = secret.Decrypt(data, secret.Passphrase())
+		if err != nil {
+			return nil, errors.Wrap(err, "Error decrypting state file")
+		}
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, errors.Wrap(err, "Error parsing state file")
+	}
+
+	if s.Items == nil {
+		s.Items = make(map[string]*Item)
+	}
+
+	return s, nil
+}
+
+// Save writes the state file atomically, encrypted with AES-GCM if J2LAB_PASSPHRASE is set
+func (s *State) Save(path string) error {
+	s.mutex.RLock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mutex.RUnlock()
+	if err != nil {
+		return errors.Wrap(err, "Error marshalling state")
+	}
+
+	if passphrase := secret.Passphrase(); passphrase != "" {
+		data, err = secret.Encrypt(data, passphrase)
+		if err != nil {
+			return errors.Wrap(err, "Error encrypting state")
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return errors.Wrap(err, "Error writing state file")
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "Error renaming state file")
+	}
+
+	return nil
+}
+
+func (s *State) SetIssue(jiraKey string, issue *gitlab.Issue) {
+	s.set(&Item{
+		JiraKey:   jiraKey,
+		Type:      ItemTypeIssue,
+		ID:        issue.ID,
+		IID:       issue.IID,
+		ProjectID: issue.ProjectID,
+		WebURL:    issue.WebURL,
+	})
+}
+
+func (s *State) SetEpic(jiraKey string, epic *gitlab.Epic) {
+	s.set(&Item{
+		JiraKey: jiraKey,
+		Type:    ItemTypeEpic,
+		ID:      epic.ID,
+		IID:     epic.IID,
+		GroupID: epic.GroupID,
+		WebURL:  epic.WebURL,
+	})
+}
+
+func (s *State) set(item *Item) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Items[item.JiraKey] = item
+}
+
+// SetMoved keeps the old key of an issue moved from another Jira project, the old links find the GitLab item too
+func (s *State) SetMoved(oldKey string, jiraKey string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.Moved == nil {
+		s.Moved = make(map[string]string)
+	}
+	s.Moved[strings.ToUpper(oldKey)] = jiraKey
+}
+
+// SetSynced keeps the values of the Jira fields written to the GitLab item of the Jira key
+func (s *State) SetSynced(jiraKey string, fields map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if item, ok := s.Items[jiraKey]; ok {
+		item.Synced = fields
+	}
+}
+
+// Get returns the GitLab item for the Jira key, or for the old key of a moved issue
+func (s *State) Get(jiraKey string) (*Item, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	jiraKey = strings.ToUpper(jiraKey)
+	if item, ok := s.Items[jiraKey]; ok {
+		return item, true
+	}
+	item, ok := s.Items[s.Moved[jiraKey]]
+	return item, ok
+}
+
+// GetByWebURL returns the GitLab item for the GitLab web URL
+func (s *State) GetByWebURL(webURL string) (*Item, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	webURL = strings.TrimSuffix(webURL, "/")
+	for _, item := range s.Items {
+		if item.WebURL == webURL {
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// JiraURL returns the browse URL of the Jira issue
+func (s *State) JiraURL(jiraKey string) string {
+	return strings.TrimSuffix(s.JiraHost, "/") + "/browse/" + jiraKey
+}
+
+// GitLabIssue returns the GitLab issue with the fields kept in the state
+func (i *Item) GitLabIssue() *gitlab.Issue {
+	return &gitlab.Issue{
+		ID:        i.ID,
+		IID:       i.IID,
+		ProjectID: i.ProjectID,
+		WebURL:    i.WebURL,
+	}
+}
+
+// GitLabEpic returns the GitLab epic with the fields kept in the state
+func (i *Item) GitLabEpic() *gitlab.Epic {
+	return &gitlab.Epic{
+		ID:      i.ID,
+		IID:     i.IID,
+		GroupID: i.GroupID,
+		WebURL:  i.WebURL,
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gitlab "github.com/xanzy/go-gitlab"
+	"gitlab.com/infograb/team/devops/toy/j2lab/internal/secret"
+)
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s, err := Load(path)
+	assert.NoError(t, err)
+	assert.Empty(t, s.Items)
+
+	s = New("https://jira.example.com/")
+	s.SetIssue("TEST-2", &gitlab.Issue{ID: 100, IID: 1, ProjectID: 2, WebURL: "https://gitlab.example.com/group/project/-/issues/1"})
+	s.SetEpic("TEST-1", &gitlab.Epic{ID: 200, IID: 1, GroupID: 1, WebURL: "https://gitlab.example.com/groups/group/-/epics/1"})
+	assert.NoError(t, s.Save(path))
+
+	s, err = Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, s.Items, 2)
+	assert.Equal(t, "https://jira.example.com/browse/TEST-2", s.JiraURL("TEST-2"))
+
+	//* By key and by web URL
+	item, ok := s.Get("test-2")
+	assert.True(t, ok)
+	assert.Equal(t, &gitlab.Issue{ID: 100, IID: 1, ProjectID: 2, WebURL: "https://gitlab.example.com/group/project/-/issues/1"}, item.GitLabIssue())
+	epic, ok := s.GetByWebURL("https://gitlab.example.com/groups/group/-/epics/1/")
+	assert.True(t, ok)
+	assert.Equal(t, ItemTypeEpic, epic.Type)
+	assert.Equal(t, 1, epic.GitLabEpic().GroupID)
+
+	_, ok = s.Get("TEST-3")
+	assert.False(t, ok)
+}
+
+func TestStateMoved(t *testing.T) {
+	s := New("https://jira.example.com")
+	s.SetIssue("TEST-2", &gitlab.Issue{ID: 100, IID: 1, ProjectID: 2})
+	s.SetMoved("old-7", "TEST-2")
+
+	//* The old key of a moved issue finds its item
+	item, ok := s.Get("TEST-2")
+	assert.True(t, ok)
+	moved, ok := s.Get("OLD-7")
+	assert.True(t, ok)
+	assert.Equal(t, item, moved)
+}
+
+func TestStateSynced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s := New("https://jira.example.com")
+	s.SetIssue("TEST-2", &gitlab.Issue{ID: 100, IID: 1, ProjectID: 2})
+	s.SetSynced("TEST-2", map[string]string{"summary": "Login fails"})
+	s.SetSynced("TEST-3", map[string]string{"summary": "Unknown"})
+	assert.NoError(t, s.Save(path))
+
+	//* The synced fields of an unknown issue are not kept
+	s, err := Load(path)
+	assert.NoError(t, err)
+	item, ok := s.Get("TEST-2")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"summary": "Login fails"}, item.Synced)
+	_, ok = s.Get("TEST-3")
+	assert.False(t, ok)
+}
+
+func TestEncryptedState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	t.Setenv(secret.PassphraseEnv, "passphrase")
+
+	s := New("https://jira.example.com")
+	s.SetIssue("TEST-1", &gitlab.Issue{ID: 100, IID: 1, ProjectID: 2})
+	assert.NoError(t, s.Save(path))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, secret.IsEncrypted(data))
+
+	s, err = Load(path)
+	assert.NoError(t, err)
+	_, ok := s.Get("TEST-1")
+	assert.True(t, ok)
+
+	t.Setenv(secret.PassphraseEnv, "wrong")
+	_, err = Load(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package stats
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats is the API call statistics and the stage timing of a run
+type Stats struct {
+	mutex sync.Mutex
+
+	calls         map[string]map[string]int // Client -> Endpoint -> Count
+	retries       map[string]int            // Client -> Count
+	bytesUploaded map[string]int64          // Client -> Bytes
+	items         map[string]int            // Outcome -> Count of Jira issues and epics
+	warnings      []string                  // corrected data of the report, e.g. reversed dates
+	stages        []*Stage
+}
+
+// Outcomes of the migration of a Jira issue or epic
+const (
+	ItemCreated = "created"
+	ItemSkipped = "skipped" // already migrated
+	ItemFailed  = "failed"
+)
+
+type Stage struct {
+	Name     string
+	Duration time.Duration
+}
+
+var defaultStats = New()
+
+func New() *Stats {
+	return &Stats{
+		calls:         make(map[string]map[string]int),
+		retries:       make(map[string]int),
+		bytesUploaded: make(map[string]int64),
+		items:         make(map[string]int),
+	}
+}
+
+func Default() *Stats {
+	return defaultStats
+}
+
+func (s *Stats) AddCall(client string, endpoint string, bytesUploaded int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.calls[client]; !ok {
+		s.calls[client] = make(map[string]int)
+	}
+	s.calls[client][endpoint]++
+
+	if bytesUploaded > 0 {
+		s.bytesUploaded[client] += bytesUploaded
+	}
+}
+
+func (s *Stats) AddRetry(client string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retries[client]++
+}
+
+// AddItem counts a Jira issue or epic of the outcome
+func (s *Stats) AddItem(outcome string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.items[outcome]++
+}
+
+// Items returns the number of Jira issues and epics of the outcome
+func (s *Stats) Items(outcome string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.items[outcome]
+}
+
+// AddWarning adds a warning to the report of the run, e.g. a Jira value corrected for GitLab
+func (s *Stats) AddWarning(format string, args ...interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.warnings = append(s.warnings, fmt.Sprintf(format, args...))
+}
+
+// Warnings returns the warnings of the report in the order they are added
+func (s *Stats) Warnings() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.warnings...)
+}
+
+// StartStage starts the timer of the stage. Call the returned function at the end of the stage.
+func (s *Stats) StartStage(name string) func() {
+	start := time.Now()
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.stages = append(s.stages, &Stage{Name: name, Duration: time.Since(start)})
+	}
+}
+
+func (s *Stats) Print(w io.Writer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fmt.Fprintf(w, "\nStages:\n")
+	for _, stage := range s.stages {
+		fmt.Fprintf(w, "  %-20s %s\n", stage.Name, stage.Duration.Round(time.Millisecond))
+	}
+
+	if len(s.items) > 0 {
+		fmt.Fprintf(w, "\nItems: %d created, %d skipped, %d failed\n", s.items[ItemCreated], s.items[ItemSkipped], s.items[ItemFailed])
+	}
+
+	if len(s.warnings) > 0 {
+		fmt.Fprintf(w, "\nWarnings: %d\n", len(s.warnings))
+		for _, warning := range s.warnings {
+			fmt.Fprintf(w, "  %s\n", warning)
+		}
+	}
+
+	clients := make([]string, 0, len(s.calls))
+	for client := range s.calls {
+		clients = append(clients, client)
+	}
+	sort.Strings(clients)
+
+	for _, client := range clients {
+		total := 0
+		endpoints := make([]string, 0, len(s.calls[client]))
+		for endpoint, count := range s.calls[client] {
+			endpoints = append(endpoints, endpoint)
+			total += count
+		}
+		sort.Strings(endpoints)
+
+		fmt.Fprintf(w, "\n%s API calls: %d (retries: %d, uploaded: %d bytes)\n", client, total, s.retries[client], s.bytesUploaded[client])
+		for _, endpoint := range endpoints {
+			fmt.Fprintf(w, "  %6d %s\n", s.calls[client][endpoint], endpoint)
+		}
+	}
+}
+
+type transport struct {
+	client string
+	base   http.RoundTripper
+	stats  *Stats
+}
+
+// NewTransport returns a RoundTripper counting the API calls of the client
+func NewTransport(client string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{client: client, base: base, stats: defaultStats}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var bytesUploaded int64
+	if req.Method != http.MethodGet && req.ContentLength > 0 {
+		bytesUploaded = req.ContentLength
+	}
+	t.stats.AddCall(t.client, req.Method+" "+normalizePath(req.URL.EscapedPath()), bytesUploaded)
+	return t.base.RoundTrip(req)
+}
+
+var (
+	reNumber  = regexp.MustCompile(`^\d+$`)
+	reJiraKey = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-\d+$`)
+)
+
+// /api/v4/projects/group%2Fproject/issues/12/notes -> /api/v4/projects/:id/issues/:id/notes
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if reNumber.MatchString(segment) || reJiraKey.MatchString(segment) {
+			segments[i] = ":id"
+		} else if i > 0 && (segments[i-1] == "projects" || segments[i-1] == "groups" || segments[i-1] == "project") && segment != "" {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,134 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package throttle
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Controller limits the requests in flight with AIMD (additive increase, multiplicative decrease).
+// A 429 or 5xx halves the limit, every success adds 1/limit, so the limit grows by one per limit successes.
+// One controller is shared by the Jira and GitLab clients, the migration slows down as a whole.
+type Controller struct {
+	mutex sync.Mutex
+
+	min      int
+	max      int
+	limit    float64
+	inFlight int
+	wait     chan struct{} // closed when a request is done
+
+	// One decrease per cooldown, the requests in flight answer the same overload
+	cooldown     time.Duration
+	lastDecrease time.Time
+}
+
+func New(min int, max int) *Controller {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &Controller{
+		min:      min,
+		max:      max,
+		limit:    float64(max),
+		wait:     make(chan struct{}),
+		cooldown: time.Second,
+	}
+}
+
+// Limit returns the current number of requests in flight allowed
+func (c *Controller) Limit() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return int(c.limit)
+}
+
+func (c *Controller) acquire(ctx context.Context) error {
+	for {
+		c.mutex.Lock()
+		if c.inFlight < int(c.limit) {
+			c.inFlight++
+			c.mutex.Unlock()
+			return nil
+		}
+		wait := c.wait
+		c.mutex.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Controller) release(client string, throttled bool, succeeded bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.inFlight--
+	switch {
+	case throttled:
+		if time.Since(c.lastDecrease) >= c.cooldown && int(c.limit) > c.min {
+			c.limit = math.Max(float64(c.min), math.Floor(c.limit/2))
+			c.lastDecrease = time.Now()
+			log.Warnf("%s is throttling, requests in flight are limited to %d", client, int(c.limit))
+		}
+	case succeeded:
+		c.limit = math.Min(float64(c.max), c.limit+1/c.limit)
+	}
+
+	close(c.wait)
+	c.wait = make(chan struct{})
+}
+
+type transport struct {
+	client     string
+	base       http.RoundTripper
+	controller *Controller
+}
+
+// NewTransport returns a RoundTripper waiting for the controller before each request
+func NewTransport(client string, controller *Controller, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{client: client, base: base, controller: controller}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.controller.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		var netErr net.Error
+		t.controller.release(t.client, errors.As(err, &netErr) && netErr.Timeout(), false)
+		return resp, err
+	}
+
+	throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	t.controller.release(t.client, throttled, !throttled)
+	return resp, nil
+}
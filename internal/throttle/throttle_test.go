@@ -0,0 +1,102 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package throttle
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestController(t *testing.T) {
+	c := New(2, 8)
+	assert.Equal(t, 8, c.Limit())
+
+	//* A 429 halves the limit once per cooldown
+	c.inFlight = 2
+	c.release("GitLab", true, false)
+	c.release("GitLab", true, false)
+	assert.Equal(t, 4, c.Limit())
+
+	c.lastDecrease = time.Time{}
+	c.inFlight = 1
+	c.release("GitLab", true, false)
+	assert.Equal(t, 2, c.Limit())
+
+	//* Never below min
+	c.lastDecrease = time.Time{}
+	c.inFlight = 1
+	c.release("GitLab", true, false)
+	assert.Equal(t, 2, c.Limit())
+
+	//* About one more per limit successes, never above max
+	c.inFlight = 3
+	c.release("GitLab", false, true)
+	c.release("GitLab", false, true)
+	assert.Equal(t, 2, c.Limit())
+	c.release("GitLab", false, true)
+	assert.Equal(t, 3, c.Limit())
+	for i := 0; i < 100; i++ {
+		c.inFlight = 1
+		c.release("GitLab", false, true)
+	}
+	assert.Equal(t, 8, c.Limit())
+
+	c = New(0, 0)
+	assert.Equal(t, 1, c.Limit())
+}
+
+func TestControllerAcquire(t *testing.T) {
+	c := New(1, 1)
+	assert.NoError(t, c.acquire(context.Background()))
+
+	//* The second request waits for the first one
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.acquire(ctx), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() { done <- c.acquire(context.Background()) }()
+	c.release("Jira", false, true)
+	assert.NoError(t, <-done)
+}
+
+func TestTransport(t *testing.T) {
+	status := http.StatusTooManyRequests
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	c := New(1, 4)
+	client := &http.Client{Transport: NewTransport("GitLab", c, base)}
+
+	resp, err := client.Get("https://gitlab.example.com/api/v4/version")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, c.Limit())
+	assert.Equal(t, 0, c.inFlight)
+
+	status = http.StatusOK
+	resp, err = client.Get("https://gitlab.example.com/api/v4/version")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2.5, c.limit)
+}
@@ -0,0 +1,158 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// Header of the GitLab personal access token
+const Header = "PRIVATE-TOKEN"
+
+type token struct {
+	value   string
+	limiter *rate.Limiter
+	// A 429 of the token skips it until Retry-After
+	blockedUntil time.Time
+}
+
+// Pool rotates the tokens round robin, each token has its own rate budget.
+// A token over its budget or throttled by the server is skipped until it is available again.
+type Pool struct {
+	mutex  sync.Mutex
+	tokens []*token
+	next   int
+}
+
+// NewPool returns the pool of the tokens with perSecond requests per token, unlimited if it is 0
+func NewPool(values []string, perSecond float64) *Pool {
+	limit := rate.Inf
+	burst := 1
+	if perSecond > 0 {
+		limit = rate.Limit(perSecond)
+		if perSecond > 1 {
+			burst = int(perSecond)
+		}
+	}
+
+	p := &Pool{}
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		p.tokens = append(p.tokens, &token{value: value, limiter: rate.NewLimiter(limit, burst)})
+	}
+	return p
+}
+
+// Len returns the number of tokens
+func (p *Pool) Len() int {
+	return len(p.tokens)
+}
+
+// take returns the next token with budget left, or waits for the next token in turn
+func (p *Pool) take(ctx context.Context) (*token, error) {
+	p.mutex.Lock()
+	now := time.Now()
+	for i := 0; i < len(p.tokens); i++ {
+		t := p.tokens[(p.next+i)%len(p.tokens)]
+		if now.Before(t.blockedUntil) || !t.limiter.Allow() {
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.tokens)
+		p.mutex.Unlock()
+		return t, nil
+	}
+
+	t := p.tokens[p.next]
+	p.next = (p.next + 1) % len(p.tokens)
+	wait := time.Until(t.blockedUntil)
+	p.mutex.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := t.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (p *Pool) block(t *token, until time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if until.After(t.blockedUntil) {
+		t.blockedUntil = until
+	}
+}
+
+// index returns the position of the token in the pool, not the token itself for the logs
+func (p *Pool) index(t *token) int {
+	for i, tok := range p.tokens {
+		if tok == t {
+			return i
+		}
+	}
+	return -1
+}
+
+type transport struct {
+	pool *Pool
+	base http.RoundTripper
+}
+
+// NewTransport returns a RoundTripper sending each request with the next token of the pool
+func NewTransport(pool *Pool, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{pool: pool, base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.pool.Len() == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	tok, err := t.pool.take(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, tok.value)
+
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := time.Minute
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		log.Debugf("GitLab token %d is throttled for %s", t.pool.index(tok), retryAfter)
+		t.pool.block(tok, time.Now().Add(retryAfter))
+	}
+	return resp, err
+}
@@ -0,0 +1,100 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+package tokens
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestPool(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool([]string{"a", "", "b"}, 0)
+	assert.Equal(t, 2, p.Len())
+
+	//* Round robin
+	var values []string
+	for i := 0; i < 4; i++ {
+		tok, err := p.take(ctx)
+		assert.NoError(t, err)
+		values = append(values, tok.value)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "b"}, values)
+
+	//* A blocked token is skipped
+	p.block(p.tokens[0], time.Now().Add(time.Hour))
+	for i := 0; i < 2; i++ {
+		tok, err := p.take(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "b", tok.value)
+	}
+	assert.Equal(t, 1, p.index(p.tokens[1]))
+}
+
+func TestPoolBudget(t *testing.T) {
+	p := NewPool([]string{"a", "b"}, 1)
+	ctx := context.Background()
+	for _, value := range []string{"a", "b"} {
+		tok, err := p.take(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, value, tok.value)
+	}
+
+	//* Every token is over its budget, the next one in turn is waited for
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err := p.take(ctx)
+	assert.Error(t, err)
+}
+
+func TestTransport(t *testing.T) {
+	var sent []string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get(Header))
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+		if req.Header.Get(Header) == "a" {
+			resp.StatusCode = http.StatusTooManyRequests
+			resp.Header.Set("Retry-After", "3600")
+		}
+		return resp, nil
+	})
+	p := NewPool([]string{"a", "b"}, 0)
+	client := &http.Client{Transport: NewTransport(p, base)}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("https://gitlab.example.com/api/v4/version")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	//* a is throttled for an hour after its 429
+	assert.Equal(t, []string{"a", "b", "b"}, sent)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), p.tokens[0].blockedUntil, time.Minute)
+
+	//* Without tokens the request is sent as it is
+	sent = nil
+	client = &http.Client{Transport: NewTransport(NewPool(nil, 0), base)}
+	resp, err := client.Get("https://gitlab.example.com/api/v4/version")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{""}, sent)
+}
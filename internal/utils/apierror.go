@@ -0,0 +1,154 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// The API failures are classified by ClassifyError, the hint of the error tells the user how to fix it
+
+// AuthError is a token rejected (401) or a user without the permission (403)
+type AuthError struct {
+	Service    string // Jira or GitLab
+	StatusCode int
+	Err        error
+}
+
+func (e *AuthError) Error() string { return e.Err.Error() }
+func (e *AuthError) Unwrap() error { return e.Err }
+func (e *AuthError) Cause() error  { return e.Err }
+
+func (e *AuthError) Hint() string {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return fmt.Sprintf("The %s token is invalid or expired, create a new token (%s.token)", e.Service, strings.ToLower(e.Service))
+	case e.Service == "GitLab" && strings.Contains(e.Err.Error(), "insufficient_scope"):
+		return "Your GitLab token lacks the api scope, create a token with the api scope"
+	case e.Service == "GitLab":
+		return "The GitLab user lacks the permission, it needs the Maintainer role of the project and the group"
+	}
+	return "The Jira user lacks the permission, it needs to browse the project and its issues"
+}
+
+// NotFoundError is a project, group, issue or endpoint missing (404)
+type NotFoundError struct {
+	Service string
+	Err     error
+}
+
+func (e *NotFoundError) Error() string { return e.Err.Error() }
+func (e *NotFoundError) Unwrap() error { return e.Err }
+func (e *NotFoundError) Cause() error  { return e.Err }
+
+func (e *NotFoundError) Hint() string {
+	if e.Service == "GitLab" {
+		return "Check gitlab.issue and gitlab.epic, GitLab answers 404 to a user who can't see the project or group"
+	}
+	return "Check jira.name and jira.host, Jira answers 404 to a user who can't browse the project"
+}
+
+// RateLimitError is a request rejected by the rate limit (429)
+type RateLimitError struct {
+	Service    string
+	RetryAfter time.Duration // 0 if it is unknown
+	Err        error
+}
+
+func (e *RateLimitError) Error() string { return e.Err.Error() }
+func (e *RateLimitError) Unwrap() error { return e.Err }
+func (e *RateLimitError) Cause() error  { return e.Err }
+
+func (e *RateLimitError) Hint() string {
+	hint := "Lower concurrency.max"
+	if e.Service == "GitLab" {
+		hint += ", or set gitlab.token_rate and add gitlab.tokens"
+	}
+	if e.RetryAfter > 0 {
+		hint += fmt.Sprintf(". %s asks to retry after %s", e.Service, e.RetryAfter)
+	}
+	return hint
+}
+
+// TierFeatureError is a GitLab feature missing in the tier of the GitLab (e.g. epics of GitLab Free)
+type TierFeatureError struct {
+	Feature string // e.g. Epics
+	Tier    string // e.g. Premium
+	Config  string // how to migrate without the feature
+	Err     error
+}
+
+func (e *TierFeatureError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s require GitLab %s", e.Feature, e.Tier)
+	}
+	return fmt.Sprintf("%s require GitLab %s: %s", e.Feature, e.Tier, e.Err)
+}
+func (e *TierFeatureError) Unwrap() error { return e.Err }
+
+func (e *TierFeatureError) Hint() string {
+	return fmt.Sprintf("%s require GitLab %s or Ultimate, %s", e.Feature, e.Tier, e.Config)
+}
+
+// Status code of the errors of go-jira, which keep only the message
+var jiraStatusPattern = regexp.MustCompile(`Status code: (\d+)`)
+
+// ClassifyError wraps an API failure of the error chain into AuthError, NotFoundError or RateLimitError, other errors are returned as they are
+func ClassifyError(err error) error {
+	if err == nil || Hint(err) != "" {
+		return err
+	}
+
+	var gitlabErr *gitlab.ErrorResponse
+	if errors.As(err, &gitlabErr) && gitlabErr.Response != nil {
+		return classifyStatus("GitLab", gitlabErr.Response.StatusCode, gitlabErr.Response.Header, err)
+	}
+
+	if match := jiraStatusPattern.FindStringSubmatch(err.Error()); match != nil {
+		status, _ := strconv.Atoi(match[1])
+		return classifyStatus("Jira", status, nil, err)
+	}
+	return err
+}
+
+func classifyStatus(service string, status int, header http.Header, err error) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &AuthError{Service: service, StatusCode: status, Err: err}
+	case http.StatusNotFound:
+		return &NotFoundError{Service: service, Err: err}
+	case http.StatusTooManyRequests:
+		rateLimitErr := &RateLimitError{Service: service, Err: err}
+		if seconds, convErr := strconv.Atoi(header.Get("Retry-After")); convErr == nil {
+			rateLimitErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return rateLimitErr
+	}
+	return err
+}
+
+// Hint returns the remediation hint of the first classified error of the chain, empty if there is none
+func Hint(err error) string {
+	var hinter interface{ Hint() string }
+	if errors.As(err, &hinter) {
+		return hinter.Hint()
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package utils
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Exit codes of the commands, e.g. for a CI pipeline
+const (
+	ExitOK       = 0
+	ExitError    = 1 // any other error
+	ExitConfig   = 2 // invalid or missing config, user.csv or flags
+	ExitAuth     = 3 // a Jira or GitLab token is rejected
+	ExitPartial  = 4 // the migration stopped after migrating some items or quarantined the failed ones, the state file resumes it
+	ExitMismatch = 5 // verify found a difference between Jira and GitLab
+)
+
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+func (e *exitError) Cause() error  { return e.err }
+
+// WithExitCode sets the exit code of the error, nil if err is nil
+func WithExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code, err}
+}
+
+// ExitCode returns the exit code of the error: its exit code, ExitAuth for a Jira or GitLab 401 or 403, or ExitError
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	var authErr *AuthError
+	if errors.As(ClassifyError(err), &authErr) {
+		return ExitAuth
+	}
+	return ExitError
+}
@@ -14,21 +14,28 @@ package utils
 import (
 	"fmt"
 	"math/rand"
+	"os"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// CheckErr prints the error to stderr and exits with its exit code
 func CheckErr(err error) {
+	if err == nil {
+		return
+	}
+
+	err = ClassifyError(err)
 	if logrus.GetLevel() >= logrus.DebugLevel {
-		if err != nil {
-			fmt.Printf("Error: %+v\n", err)
-		}
+		fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
 	} else {
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	if hint := Hint(err); hint != "" {
+		fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
 	}
+	os.Exit(ExitCode(err))
 }
 
 func RandomColor() *string {
@@ -0,0 +1,75 @@
+/*
+ * This file is part of the InfoGrab project.
+ *
+ * Copyright (C) 2023 InfoGrab
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * it is available under the terms of the GNU Lesser General Public License
+ * by the Free Software Foundation, either version 3 of the License or by the Free Software Foundation
+ * (at your option) any later version.
+ */
+
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type stoppingKey struct{}
+
+// WithGracefulShutdown returns a context which
+// 1. is marked as stopping on SIGINT/SIGTERM so that no new work is accepted (see IsStopping)
+// 2. is canceled when the in-flight work doesn't finish within the timeout or on the second signal
+func WithGracefulShutdown(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	stopping := make(chan struct{})
+	ctx = context.WithValue(ctx, stoppingKey{}, stopping)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Warnf("Received %s: waiting up to %s for in-flight work (send again to abort)", sig, timeout)
+			close(stopping)
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case <-signals:
+			log.Warnf("Aborting in-flight work")
+		case <-time.After(timeout):
+			log.Warnf("In-flight work did not finish within %s, aborting", timeout)
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// IsStopping reports whether a shutdown was requested and no new work should be started
+func IsStopping(ctx context.Context) bool {
+	stopping, ok := ctx.Value(stoppingKey{}).(chan struct{})
+	if !ok {
+		return false
+	}
+
+	select {
+	case <-stopping:
+		return true
+	default:
+		return false
+	}
+}